@@ -5,12 +5,15 @@ import (
 	golog "log"
 	"redditclone/internal/pkg/apperror"
 	"redditclone/internal/pkg/config"
+	"time"
 
 	"github.com/minipkg/log"
 	"github.com/pkg/errors"
 
 	"redditclone/internal/pkg/auth"
 	"redditclone/internal/pkg/jwt"
+	"redditclone/internal/pkg/mail"
+	"redditclone/internal/pkg/password"
 
 	pg "github.com/minipkg/db/gorm"
 	"github.com/minipkg/db/mongo"
@@ -18,9 +21,15 @@ import (
 	"github.com/minipkg/db/redis/cache"
 
 	"redditclone/internal/domain/comment"
+	"redditclone/internal/domain/community"
+	"redditclone/internal/domain/feed"
+	"redditclone/internal/domain/modlog"
 	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/report"
+	"redditclone/internal/domain/search"
 	"redditclone/internal/domain/user"
 	"redditclone/internal/domain/vote"
+	inmemoryrep "redditclone/internal/infrastructure/repository/inmemory"
 	mongorep "redditclone/internal/infrastructure/repository/mongo"
 	pgrep "redditclone/internal/infrastructure/repository/pg"
 	redisrep "redditclone/internal/infrastructure/repository/redis"
@@ -36,20 +45,32 @@ type App struct {
 	Domain  Domain
 	Auth    Auth
 	Cache   cache.Service
+	Mail    mail.Sender
+
+	// MongoAggregationDB runs the aggregation pipelines which MongoDB does not support
+	MongoAggregationDB *mongorep.AggregationDB
 }
 
 type Auth struct {
-	SessionRepository auth.SessionRepository
-	TokenRepository   auth.TokenRepository
-	Service           auth.Service
+	SessionRepository       auth.SessionRepository
+	LoginAttemptRepository  auth.LoginAttemptRepository
+	PasswordResetRepository auth.PasswordResetRepository
+	TokenRepository         auth.TokenRepository
+	Service                 auth.Service
+	KeySet                  *jwt.KeySet
 }
 
 // Domain is a Domain Layer Entry Point
 type Domain struct {
-	User    DomainUser
-	Post    DomainPost
-	Vote    DomainVote
-	Comment DomainComment
+	User      DomainUser
+	Post      DomainPost
+	Vote      DomainVote
+	Comment   DomainComment
+	Community DomainCommunity
+	Feed      DomainFeed
+	Search    DomainSearch
+	Report    DomainReport
+	ModLog    DomainModLog
 }
 
 type DomainUser struct {
@@ -72,6 +93,32 @@ type DomainComment struct {
 	Service    comment.IService
 }
 
+type DomainCommunity struct {
+	Repository community.Repository
+	Service    community.IService
+}
+
+type DomainFeed struct {
+	Repository         feed.Repository
+	TimelineRepository feed.TimelineRepository
+	Service            feed.IService
+}
+
+type DomainSearch struct {
+	Repository search.Repository
+	Service    search.IService
+}
+
+type DomainReport struct {
+	Repository report.Repository
+	Service    report.IService
+}
+
+type DomainModLog struct {
+	Repository modlog.Repository
+	Service    modlog.IService
+}
+
 // New func is a constructor for the App
 func New(cfg config.Configuration) *App {
 	logger, err := log.New(cfg.Log)
@@ -89,17 +136,30 @@ func New(cfg config.Configuration) *App {
 		golog.Fatal(err)
 	}
 
+	mAggregationDB, err := mongorep.NewAggregationDB(cfg.DB.Mongo)
+	if err != nil {
+		golog.Fatal(err)
+	}
+
 	rDB, err := redis.New(cfg.DB.Redis)
 	if err != nil {
 		golog.Fatal(err)
 	}
 
+	mailSender, err := mail.NewSender(cfg.Mail, logger)
+	if err != nil {
+		golog.Fatal(err)
+	}
+
 	app := &App{
 		Cfg:     cfg,
 		Logger:  logger,
 		DB:      pgDB,
 		MongoDB: mDB,
 		Redis:   rDB,
+		Mail:    mailSender,
+
+		MongoAggregationDB: mAggregationDB,
 	}
 
 	err = app.Init()
@@ -115,6 +175,10 @@ func (app *App) Init() (err error) {
 		return err
 	}
 	app.SetupServices()
+
+	if _, err := app.Domain.Community.Service.Seed(context.Background()); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -141,22 +205,108 @@ func (app *App) SetupRepositories() (err error) {
 		return errors.Errorf("Can not cast DB repository for entity %q to %vRepository. Repo: %v", comment.EntityName, comment.EntityName, app.getMongoRepo(post.EntityName))
 	}
 
+	app.Domain.Community.Repository, ok = app.getMongoRepo(community.EntityName).(community.Repository)
+	if !ok {
+		return errors.Errorf("Can not cast DB repository for entity %q to %vRepository. Repo: %v", community.EntityName, community.EntityName, app.getMongoRepo(community.EntityName))
+	}
+
+	app.Domain.Feed.Repository, ok = app.getMongoRepo(feed.EntityName).(feed.Repository)
+	if !ok {
+		return errors.Errorf("Can not cast DB repository for entity %q to %vRepository. Repo: %v", feed.EntityName, feed.EntityName, app.getMongoRepo(feed.EntityName))
+	}
+
+	app.Domain.Report.Repository, ok = app.getMongoRepo(report.EntityName).(report.Repository)
+	if !ok {
+		return errors.Errorf("Can not cast DB repository for entity %q to %vRepository. Repo: %v", report.EntityName, report.EntityName, app.getMongoRepo(report.EntityName))
+	}
+
+	app.Domain.ModLog.Repository, ok = app.getMongoRepo(modlog.EntityName).(modlog.Repository)
+	if !ok {
+		return errors.Errorf("Can not cast DB repository for entity %q to %vRepository. Repo: %v", modlog.EntityName, modlog.EntityName, app.getMongoRepo(modlog.EntityName))
+	}
+
+	if app.Domain.Search.Repository, err = app.searchRepository(); err != nil {
+		return err
+	}
+
 	if app.Auth.SessionRepository, err = redisrep.NewSessionRepository(app.Redis, app.Cfg.SessionLifeTime, app.Domain.User.Repository); err != nil {
 		return errors.Errorf("Can not get new SessionRepository err: %v", err)
 	}
-	app.Auth.TokenRepository = jwt.NewRepository()
+	if app.Auth.LoginAttemptRepository, err = redisrep.NewLoginAttemptRepository(app.Redis); err != nil {
+		return errors.Errorf("Can not get new LoginAttemptRepository err: %v", err)
+	}
+	if app.Auth.PasswordResetRepository, err = redisrep.NewPasswordResetRepository(app.Redis); err != nil {
+		return errors.Errorf("Can not get new PasswordResetRepository err: %v", err)
+	}
+	if app.Domain.Feed.TimelineRepository, err = redisrep.NewTimelineRepository(app.Redis); err != nil {
+		return errors.Errorf("Can not get new TimelineRepository err: %v", err)
+	}
+	if app.Auth.KeySet, err = app.keySet(); err != nil {
+		return errors.Errorf("Can not get the JWT keyset err: %v", err)
+	}
+	app.Auth.TokenRepository = jwt.NewRepository(app.Auth.KeySet)
 
 	app.Cache = cache.NewService(app.Redis, app.Cfg.CacheLifeTime)
 
 	return nil
 }
 
+// defaultSearchIndexRefresh is the interval of the rebuilding of the in-process search index if it is not set in the config
+const defaultSearchIndexRefresh = 60 * time.Second
+
+// searchRepository returns the search repository of the engine of the config
+func (app *App) searchRepository() (search.Repository, error) {
+	switch app.Cfg.Search.Engine {
+	case "", search.EngineMongo:
+		repo, ok := app.getMongoRepo(search.EntityName).(search.Repository)
+		if !ok {
+			return nil, errors.Errorf("Can not cast DB repository for entity %q to %vRepository. Repo: %v", search.EntityName, search.EntityName, app.getMongoRepo(search.EntityName))
+		}
+		return repo, nil
+	case search.EngineIndex:
+		refresh := time.Duration(app.Cfg.Search.IndexRefresh) * time.Second
+		if refresh == 0 {
+			refresh = defaultSearchIndexRefresh
+		}
+		return inmemoryrep.NewSearchRepository(app.Domain.Post.Repository, refresh), nil
+	}
+	return nil, errors.Errorf("Unknown search engine %q", app.Cfg.Search.Engine)
+}
+
+// keySet returns the JWT keyset from the file of the config, or the keyset of the single HS256 key if the file is not set.
+// A missing file gives an empty keyset, so the CLI can generate the keys, but no tokens can be issued until then.
+func (app *App) keySet() (*jwt.KeySet, error) {
+	if app.Cfg.JWTKeySetPath == "" {
+		return jwt.NewHMACKeySet(app.Cfg.JWTSigningKey), nil
+	}
+
+	ks, err := jwt.LoadKeySet(app.Cfg.JWTKeySetPath)
+	if err != nil {
+		if errors.Cause(err) == apperror.ErrNotFound {
+			app.Logger.Errorf("%v, generate the keys by the \"keys\" CLI command", err)
+			return &jwt.KeySet{}, nil
+		}
+		return nil, err
+	}
+	return ks, nil
+}
+
 func (app *App) SetupServices() {
+	passwordHasher, err := password.NewHasher(app.Cfg.Password)
+	if err != nil {
+		golog.Fatalf("Can not get the password hasher, error happened: %v", err)
+	}
+
+	app.Domain.ModLog.Service = modlog.NewService(app.Logger, app.Domain.ModLog.Repository)
 	app.Domain.User.Service = user.NewService(app.Logger, app.Domain.User.Repository)
-	app.Domain.Post.Service = post.NewService(app.Logger, app.Domain.Post.Repository, app.Domain.Comment.Repository, app.Domain.Vote.Repository)
+	app.Domain.Post.Service = post.NewService(app.Logger, app.Domain.Post.Repository, app.Domain.Comment.Repository, app.Domain.Vote.Repository, app.Domain.Community.Repository, app.Domain.ModLog.Service)
 	app.Domain.Vote.Service = vote.NewService(app.Logger, app.Domain.Vote.Repository)
-	app.Domain.Comment.Service = comment.NewService(app.Logger, app.Domain.Comment.Repository)
-	app.Auth.Service = auth.NewService(app.Cfg.JWTSigningKey, app.Cfg.JWTExpiration, app.Domain.User.Service, app.Logger, app.Auth.SessionRepository, app.Auth.TokenRepository)
+	app.Domain.Comment.Service = comment.NewService(app.Logger, app.Domain.Comment.Repository, app.Domain.Post.Service, app.Domain.ModLog.Service)
+	app.Domain.Community.Service = community.NewService(app.Logger, app.Domain.Community.Repository, app.Domain.ModLog.Service)
+	app.Domain.Report.Service = report.NewService(app.Logger, app.Domain.Report.Repository, app.Domain.Post.Service, app.Domain.Comment.Service, app.Domain.ModLog.Service)
+	app.Domain.Search.Service = search.NewService(app.Logger, app.Domain.Search.Repository, app.Domain.Post.Repository, app.Domain.Comment.Repository)
+	app.Domain.Feed.Service = feed.NewService(app.Logger, app.Cfg.Feed, app.Domain.Feed.Repository, app.Domain.Feed.TimelineRepository, app.Domain.Post.Service, app.Domain.Community.Repository)
+	app.Auth.Service = auth.NewService(app.Cfg.AccessTokenLifeTime, passwordHasher, app.Domain.User.Service, app.Logger, app.Auth.SessionRepository, app.Auth.TokenRepository, app.Cfg.LoginThrottle, app.Auth.LoginAttemptRepository, app.Mail, app.Cfg.PasswordReset, app.Auth.PasswordResetRepository, app.Domain.ModLog.Service)
 }
 
 // Run is func to run the App
@@ -176,7 +326,7 @@ func (app *App) getPgRepo(entityName string) (repo pgrep.IRepository) {
 func (app *App) getMongoRepo(entityName string) (repo mongorep.IRepository) {
 	var err error
 
-	if repo, err = mongorep.GetRepository(app.Logger, app.MongoDB, entityName); err != nil {
+	if repo, err = mongorep.GetRepository(app.Logger, app.MongoDB, app.MongoAggregationDB, entityName); err != nil {
 		golog.Fatalf("Can not get mongodb repository for entity %q, error happened: %v", entityName, err)
 	}
 	return repo
@@ -186,6 +336,9 @@ func (app *App) Stop() error {
 	errRedis := app.Redis.Close()
 	errPg := app.DB.DB().Close()
 	errMongo := app.MongoDB.Close(context.Background())
+	if err := app.MongoAggregationDB.Close(context.Background()); err != nil && errMongo == nil {
+		errMongo = err
+	}
 
 	switch {
 	case errPg != nil:
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/jwt"
+)
+
+// defaultKeepKeys is the number of the previous keys which are kept by a rotation by default
+const defaultKeepKeys = 2
+
+var (
+	keysPath string
+	keysAlg  string
+	keysKeep int
+)
+
+// keysCmd represents the keys command
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manages the JWT keyset",
+	Long: `Manages the JSON file of the keys which sign and verify the JWT tokens.
+The file is set by the "jwtkeysetpath" option of the config, the servers read it at start, so restart them after a change.`,
+}
+
+// keysGenerateCmd represents the keys generate command
+var keysGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generates a new key",
+	Long: `Generates a new key and adds it to the keyset, the file is created if it does not exist.
+The key becomes the signing key only in a new keyset, otherwise it is only published until the next rotation.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ks, err := loadOrNewKeySet(keysPath)
+		if err != nil {
+			return err
+		}
+
+		key, err := jwt.NewKey(keysAlg)
+		if err != nil {
+			return err
+		}
+		ks.Add(*key)
+
+		if err = ks.Save(keysPath); err != nil {
+			return err
+		}
+		fmt.Printf("key %v (%v) generated, signing key: %v\n", key.ID, key.Algorithm, ks.SigningKeyID)
+		return nil
+	},
+}
+
+// keysRotateCmd represents the keys rotate command
+var keysRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Rotates the signing key",
+	Long: `Generates a new signing key. The previous keys still verify the tokens signed by them,
+the keys older than the last --keep ones are removed, so keep them at least as long as an access token lives.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ks, err := loadOrNewKeySet(keysPath)
+		if err != nil {
+			return err
+		}
+
+		key, err := ks.Rotate(keysAlg, keysKeep)
+		if err != nil {
+			return err
+		}
+
+		if err = ks.Save(keysPath); err != nil {
+			return err
+		}
+		fmt.Printf("signing key %v (%v), verification keys: %v\n", key.ID, key.Algorithm, len(ks.Keys)-1)
+		return nil
+	},
+}
+
+// loadOrNewKeySet reads the keyset from the file or returns an empty keyset if the file does not exist.
+func loadOrNewKeySet(path string) (*jwt.KeySet, error) {
+	if path == "" {
+		return nil, errors.New("the path to the keyset file is not set, use --path or the jwtkeysetpath option of the config")
+	}
+	ks, err := jwt.LoadKeySet(path)
+	if err != nil {
+		if errors.Cause(err) == apperror.ErrNotFound {
+			return &jwt.KeySet{}, nil
+		}
+		return nil, err
+	}
+	return ks, nil
+}
+
+func init() {
+	keysCmd.PersistentFlags().StringVar(&keysPath, "path", "", "path to the keyset file, the jwtkeysetpath option of the config by default")
+	keysCmd.PersistentFlags().StringVar(&keysAlg, "alg", jwt.AlgEdDSA, "signing algorithm of the key: HS256, RS256 or EdDSA")
+	keysRotateCmd.Flags().IntVar(&keysKeep, "keep", defaultKeepKeys, "number of the previous keys which are kept for verification")
+
+	keysCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		if keysPath == "" {
+			keysPath = app.Cfg.JWTKeySetPath
+		}
+	}
+
+	keysCmd.AddCommand(keysGenerateCmd, keysRotateCmd)
+	app.rootCmd.AddCommand(keysCmd)
+}
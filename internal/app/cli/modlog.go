@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"redditclone/internal/domain/modlog"
+)
+
+var modLogOutput string
+var modLogFrom string
+var modLogTo string
+var modLogFilter modlog.Filter
+var modLogCategory string
+
+// modLogCmd represents the modlog command
+var modLogCmd = &cobra.Command{
+	Use:   "modlog",
+	Short: "Works with the moderation log",
+}
+
+// modLogExportCmd represents the modlog export command
+var modLogExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports the moderation log as JSON lines",
+	Long: `Writes the entries of the moderation log in the chronological order, one JSON object per line, to the standard output or to the file of --output.
+The entries are filtered by the flags, all the entries are exported by default.`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := context.Background()
+		filter := modLogFilter
+		if modLogCategory != "" {
+			filter.Categories = []string{modLogCategory}
+		}
+		if filter.From, err = parseTime(modLogFrom); err != nil {
+			return errors.Wrap(err, "--from")
+		}
+		if filter.To, err = parseTime(modLogTo); err != nil {
+			return errors.Wrap(err, "--to")
+		}
+		if err = filter.Validate(); err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+		if modLogOutput != "" {
+			f, err := os.Create(modLogOutput)
+			if err != nil {
+				return errors.Wrapf(err, "Can not create the file %q", modLogOutput)
+			}
+			defer f.Close()
+			out = f
+		}
+		w := bufio.NewWriter(out)
+
+		count, err := app.Domain.ModLog.Service.Export(ctx, filter, w)
+		if err != nil {
+			app.Logger.With(ctx).Error(err)
+			return err
+		}
+		if err = w.Flush(); err != nil {
+			return errors.Wrap(err, "Can not write the entries")
+		}
+		fmt.Fprintf(os.Stderr, "entries exported: %v\n", count)
+		return nil
+	},
+}
+
+// parseTime parses the time of a flag in the RFC3339 format, an empty value is the zero time
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func init() {
+	modLogExportCmd.Flags().StringVarP(&modLogOutput, "output", "o", "", "file to write the entries to, the standard output by default")
+	modLogExportCmd.Flags().StringVar(&modLogFrom, "from", "", "time of the first entry in the RFC3339 format")
+	modLogExportCmd.Flags().StringVar(&modLogTo, "to", "", "time of the last entry in the RFC3339 format")
+	modLogExportCmd.Flags().StringVar(&modLogFilter.Action, "action", "", "action of the entries")
+	modLogExportCmd.Flags().StringVar(&modLogFilter.TargetType, "type", "", "type of the targets: post, comment or user")
+	modLogExportCmd.Flags().StringVar(&modLogFilter.TargetID, "target", "", "ID of the target")
+	modLogExportCmd.Flags().UintVar(&modLogFilter.ActorID, "actor", 0, "user ID of the moderator or the admin")
+	modLogExportCmd.Flags().StringVar(&modLogCategory, "category", "", "category of the entries")
+
+	modLogCmd.AddCommand(modLogExportCmd)
+	app.rootCmd.AddCommand(modLogCmd)
+}
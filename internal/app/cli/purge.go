@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultDeletedRetention is the retention of the deleted posts and comments in days if it is not set in the config
+const defaultDeletedRetention = 30
+
+var purgeRetention uint
+
+// purgeDeletedCmd represents the purge-deleted command
+var purgeDeletedCmd = &cobra.Command{
+	Use:   "purge-deleted",
+	Short: "Removes deleted posts and comments for good",
+	Long: `Removes the posts and the comments deleted earlier than the retention period ago together with their votes, the comments, the revisions and the marks of a removed post are removed with it.
+A deleted comment with replies keeps its place in the tree, only its body and author are erased. It is meant to be run periodically, e.g. daily by cron, and it is safe to run it repeatedly.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		retention := purgeRetention
+		if retention == 0 {
+			retention = app.Cfg.DeletedRetention
+		}
+		if retention == 0 {
+			retention = defaultDeletedRetention
+		}
+
+		before := time.Now().AddDate(0, 0, -int(retention))
+		posts, comments, err := app.Domain.Post.Service.Purge(ctx, before)
+		if err != nil {
+			app.Logger.With(ctx).Error(err)
+			return err
+		}
+		fmt.Printf("deleted before %v purged, posts: %v, comments: %v\n", before.Format(time.RFC3339), posts, comments)
+		return nil
+	},
+}
+
+func init() {
+	purgeDeletedCmd.Flags().UintVar(&purgeRetention, "retention", 0, "retention period in days, the one of the config by default")
+
+	app.rootCmd.AddCommand(purgeDeletedCmd)
+}
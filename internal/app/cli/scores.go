@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// recountScoresCmd represents the recount-scores command
+var recountScoresCmd = &cobra.Command{
+	Use:   "recount-scores",
+	Short: "Recounts scores of posts and comments",
+	Long:  `Recalculates scores and rankings of all posts and comments from the votes and fixes the ones which differ. It is safe to run it repeatedly.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		fixed, err := app.Domain.Post.Service.RecountScores(ctx)
+		if err != nil {
+			app.Logger.With(ctx).Error(err)
+			return err
+		}
+		fmt.Printf("scores recounted, fixed items: %v\n", fixed)
+		return nil
+	},
+}
+
+func init() {
+	app.rootCmd.AddCommand(recountScoresCmd)
+}
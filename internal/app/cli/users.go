@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"redditclone/internal/domain/user"
+)
+
+var usersDryRun bool
+var usersBanStatus string
+var usersBanUntil string
+var usersBanReason string
+
+// usersCmd represents the users command
+var usersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "Manages the users",
+}
+
+// usersNormalizeNamesCmd represents the users normalize-names command
+var usersNormalizeNamesCmd = &cobra.Command{
+	Use:   "normalize-names",
+	Short: "Saves the normalised names of the users",
+	Long: `Saves the normalised names of the users registered before the names were normalised, so they are found case-insensitively.
+The users whose names have the same normalised form are listed and not changed, rename them and run the command again.
+It is safe to run it repeatedly, it fails while there are collisions.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		updated, collisions, err := app.Domain.User.Service.NormalizeNames(ctx, usersDryRun)
+		if err != nil {
+			app.Logger.With(ctx).Error(err)
+			return err
+		}
+
+		names := make([]string, 0, len(collisions))
+		for name := range collisions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("collision %q:", name)
+			for _, u := range collisions[name] {
+				deleted := ""
+				if u.DeletedAt != nil {
+					deleted = ", deleted"
+				}
+				fmt.Printf(" %q (id %v%v)", u.Name, u.ID, deleted)
+			}
+			fmt.Println()
+		}
+
+		if usersDryRun {
+			fmt.Printf("names to normalise: %v, collisions: %v\n", updated, len(collisions))
+		} else {
+			fmt.Printf("names normalised: %v, collisions: %v\n", updated, len(collisions))
+		}
+		if len(collisions) > 0 {
+			return errors.Errorf("%v names collide, resolve them manually", len(collisions))
+		}
+		return nil
+	},
+}
+
+// usersBanCmd represents the users ban command
+var usersBanCmd = &cobra.Command{
+	Use:   "ban <user id>",
+	Short: "Suspends, bans or shadowbans the account of the user",
+	Long: `Suspends, bans or shadowbans the account of the user, the active sessions of the user get the new state at once.
+A suspension requires --until, the reason is shown to the suspended or the banned user.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		state := user.AccountState{
+			Status: usersBanStatus,
+			Reason: usersBanReason,
+		}
+		if usersBanUntil != "" {
+			until, err := time.Parse(time.RFC3339, usersBanUntil)
+			if err != nil {
+				return errors.Wrap(err, "--until is required to be a time in the RFC3339 format")
+			}
+			state.Until = &until
+		}
+		if state.Status == user.StatusActive {
+			return errors.New("use the unban command to activate the account")
+		}
+		return setAccountState(args[0], state)
+	},
+}
+
+// usersUnbanCmd represents the users unban command
+var usersUnbanCmd = &cobra.Command{
+	Use:   "unban <user id>",
+	Short: "Lifts the suspension, the ban or the shadowban of the account of the user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setAccountState(args[0], user.AccountState{Status: user.StatusActive})
+	},
+}
+
+func setAccountState(arg string, state user.AccountState) error {
+	ctx := context.Background()
+	id, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "user id is required to be uint: %q", arg)
+	}
+
+	entity, err := app.Auth.Service.SetAccountState(ctx, uint(id), state)
+	if err != nil {
+		app.Logger.With(ctx).Error(err)
+		return err
+	}
+	fmt.Printf("user %q (id %v): %v\n", entity.Name, entity.ID, entity.State.Status)
+	return nil
+}
+
+func init() {
+	usersNormalizeNamesCmd.Flags().BoolVar(&usersDryRun, "dry-run", false, "only report the names to normalise and the collisions")
+
+	usersBanCmd.Flags().StringVar(&usersBanStatus, "status", user.StatusBanned, "state of the account: suspended, banned or shadowbanned")
+	usersBanCmd.Flags().StringVar(&usersBanUntil, "until", "", "end of the suspension in the RFC3339 format")
+	usersBanCmd.Flags().StringVar(&usersBanReason, "reason", "", "reason of the suspension or the ban")
+
+	usersCmd.AddCommand(usersNormalizeNamesCmd)
+	usersCmd.AddCommand(usersBanCmd)
+	usersCmd.AddCommand(usersUnbanCmd)
+	app.rootCmd.AddCommand(usersCmd)
+}
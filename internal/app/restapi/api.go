@@ -14,6 +14,7 @@ import (
 
 	"redditclone/internal/pkg/config"
 	"redditclone/internal/pkg/errorshandler"
+	"redditclone/internal/pkg/jwt"
 
 	"github.com/minipkg/log/accesslog"
 
@@ -71,6 +72,8 @@ func (app *App) buildHandler() *routing.Router {
 		"/static/": "/website/static/",
 	}))
 
+	router.Get("/.well-known/jwks.json", jwt.JWKSHandler(app.Auth.KeySet))
+
 	rg := router.Group("/api")
 
 	authMiddleware := auth.Middleware(app.Logger, app.Auth.Service)
@@ -78,9 +81,10 @@ func (app *App) buildHandler() *routing.Router {
 	auth.RegisterHandlers(rg.Group(""),
 		app.Auth.Service,
 		app.Logger,
+		authMiddleware,
 	)
 
-	app.RegisterHandlers(rg, authMiddleware)
+	app.RegisterHandlers(rg, authMiddleware, auth.OptionalMiddleware(app.Logger, app.Auth.Service))
 
 	return router
 }
@@ -109,11 +113,19 @@ func (app *App) Run() error {
 }
 
 // RegisterHandlers sets up the routing of the HTTP handlers.
-func (app *App) RegisterHandlers(rg *routing.RouteGroup, authMiddleware routing.Handler) {
-
-	//controller.RegisterUserHandlers(rg, app.Domain.User.Service, app.Logger, authMiddleware)
-	controller.RegisterPostHandlers(rg, app.Domain.Post.Service, app.Domain.User.Service, app.Logger, authMiddleware)
-	controller.RegisterCommentHandlers(rg, app.Domain.Comment.Service, app.Domain.Post.Service, app.Logger, authMiddleware)
-	controller.RegisterVoteHandlers(rg, app.Domain.Vote.Service, app.Domain.Post.Service, app.Logger, authMiddleware)
+// The viewerMiddleware authenticates the viewer of the public handlers, if there is a token.
+func (app *App) RegisterHandlers(rg *routing.RouteGroup, authMiddleware routing.Handler, viewerMiddleware routing.Handler) {
+
+	controller.RegisterUserHandlers(rg.Group(""), app.Domain.User.Service, app.Domain.Post.Service, app.Logger, authMiddleware)
+	controller.RegisterPostHandlers(rg.Group(""), app.Domain.Post.Service, app.Domain.User.Service, app.Domain.Report.Service, app.Logger, authMiddleware, viewerMiddleware)
+	controller.RegisterCommentHandlers(rg.Group(""), app.Domain.Comment.Service, app.Domain.Post.Service, app.Logger, authMiddleware)
+	controller.RegisterVoteHandlers(rg.Group(""), app.Domain.Vote.Service, app.Domain.Post.Service, app.Logger, authMiddleware)
+	controller.RegisterCommunityHandlers(rg.Group(""), app.Domain.Community.Service, app.Logger, authMiddleware)
+	controller.RegisterReportHandlers(rg.Group(""), app.Domain.Report.Service, app.Logger, authMiddleware)
+	controller.RegisterModLogHandlers(rg.Group(""), app.Domain.ModLog.Service, app.Logger, authMiddleware)
+	controller.RegisterSearchHandlers(rg.Group(""), app.Domain.Search.Service, app.Domain.User.Service, app.Logger, viewerMiddleware)
+	controller.RegisterFeedHandlers(rg.Group(""), app.Domain.Feed.Service, app.Domain.User.Service, app.Logger, authMiddleware)
+	controller.RegisterAccountHandlers(rg.Group(""), app.Auth.Service, app.Domain.User.Service, app.Domain.Post.Service, app.Logger, authMiddleware)
+	controller.RegisterAdminHandlers(rg.Group(""), app.Auth.Service, app.Domain.Post.Service, app.Domain.Comment.Service, app.Logger, authMiddleware)
 
 }
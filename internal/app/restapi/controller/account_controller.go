@@ -0,0 +1,89 @@
+package controller
+
+import (
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/minipkg/log"
+
+	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/auth"
+	"redditclone/internal/pkg/errorshandler"
+)
+
+type accountController struct {
+	AuthService auth.Service
+	UserService user.IService
+	PostService post.IService
+	Logger      log.ILogger
+}
+
+type deleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+func (r deleteAccountRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Password, validation.Required, validation.Length(4, 100)),
+	)
+}
+
+//	DELETE /api/me - удаление аккаунта по паролю, посты и комментарии пользователя остаются с автором "[deleted]"
+func RegisterAccountHandlers(r *routing.RouteGroup, authService auth.Service, userService user.IService, postService post.IService, logger log.ILogger, authHandler routing.Handler) {
+	c := accountController{
+		AuthService: authService,
+		UserService: userService,
+		PostService: postService,
+		Logger:      logger,
+	}
+
+	r.Use(authHandler)
+
+	r.Delete(`/me`, c.delete)
+}
+
+// delete method is for the deletion of the account of the current user.
+// The content of the user is anonymized before the user is deleted, so a failure leaves the account working.
+func (c *accountController) delete(ctx *routing.Context) error {
+	var req deleteAccountRequest
+	rctx := ctx.Request.Context()
+
+	if err := ctx.Read(&req); err != nil {
+		c.Logger.With(rctx).Info(err)
+		return errorshandler.BadRequest("")
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	entity, err := c.AuthService.Reauthenticate(rctx, req.Password, c.AuthService.RequestClient(ctx.Request))
+	if err != nil {
+		if er, ok := err.(auth.ThrottledError); ok {
+			return auth.ThrottledResponse(ctx, er)
+		}
+		if er, ok := err.(errorshandler.Response); ok {
+			return er
+		}
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+
+	if err = c.PostService.AnonymizeAuthor(rctx, entity.ID); err != nil {
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+
+	if err = c.UserService.Delete(rctx, entity.ID); err != nil {
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+
+	if err = c.AuthService.LogoutAll(rctx); err != nil {
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+
+	c.Logger.With(rctx, "user", entity.Name).Infof("the account has been deleted")
+	return ctx.Write(errorshandler.SuccessMessage())
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/minipkg/log"
+	ozzo_routing "github.com/minipkg/ozzo_routing"
+	"github.com/pkg/errors"
+
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+
+	"redditclone/internal/domain/comment"
+	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/auth"
+	"redditclone/internal/pkg/errorshandler"
+)
+
+type adminController struct {
+	AuthService    auth.Service
+	PostService    post.IService
+	CommentService comment.IService
+	Logger         log.ILogger
+}
+
+// RegisterHandlers sets up the routing of the HTTP handlers, the handlers are for the admins only.
+//	POST /api/admin/users/{USER_ID}/ban - блокировка аккаунта: {"status": "suspended|banned|shadowbanned", "until": "2020-01-01T00:00:00Z", "reason": "..."}
+//		until обязателен только для suspended, причина показывается пользователю, кроме shadowbanned
+//	DELETE /api/admin/users/{USER_ID}/ban - снятие блокировки
+//	POST /api/admin/post/{POST_ID}/restore - восстановление удалённого поста
+//	POST /api/admin/comment/{COMMENT_ID}/restore - восстановление удалённого комментария
+func RegisterAdminHandlers(r *routing.RouteGroup, authService auth.Service, postService post.IService, commentService comment.IService, logger log.ILogger, authHandler routing.Handler) {
+	c := adminController{
+		AuthService:    authService,
+		PostService:    postService,
+		CommentService: commentService,
+		Logger:         logger,
+	}
+
+	r.Use(authHandler, auth.AdminMiddleware())
+
+	r.Post(`/admin/users/<id:\d+>/ban`, c.ban)
+	r.Delete(`/admin/users/<id:\d+>/ban`, c.unban)
+	r.Post(`/admin/post/<id>/restore`, c.restore(postService.Restore))
+	r.Post(`/admin/comment/<id>/restore`, c.restore(commentService.Restore))
+}
+
+// ban method sets the suspension, the ban or the shadowban of the account of the user
+func (c adminController) ban(ctx *routing.Context) error {
+	state := user.AccountState{}
+	if err := ctx.Read(&state); err != nil {
+		c.Logger.With(ctx.Request.Context()).Info(err)
+		return errorshandler.BadRequest(err.Error())
+	}
+	if state.Status == user.StatusActive {
+		return errorshandler.BadRequest("status: must be a valid value.")
+	}
+	return c.setState(ctx, state)
+}
+
+// unban method lifts the suspension, the ban or the shadowban of the account of the user
+func (c adminController) unban(ctx *routing.Context) error {
+	return c.setState(ctx, user.AccountState{Status: user.StatusActive})
+}
+
+func (c adminController) setState(ctx *routing.Context, state user.AccountState) error {
+	rctx := ctx.Request.Context()
+
+	id, err := ozzo_routing.ParseUintParam(ctx, "id")
+	if err != nil {
+		return errorshandler.BadRequest("ID is required to be uint")
+	}
+
+	entity, err := c.AuthService.SetAccountState(rctx, uint(id), state)
+	if err != nil {
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			c.Logger.With(rctx).Info(err)
+			return errorshandler.NotFound("")
+		case errors.Is(err, apperror.ErrBadRequest):
+			c.Logger.With(rctx).Info(err)
+			return errorshandler.BadRequest(err.Error())
+		}
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+	return ctx.Write(entity.State)
+}
+
+// restore method returns the handler which restores the deleted entity by the function
+func (c adminController) restore(restoreFunc func(ctx context.Context, id string) error) routing.Handler {
+	return func(ctx *routing.Context) error {
+		rctx := ctx.Request.Context()
+
+		if err := restoreFunc(rctx, ctx.Param("id")); err != nil {
+			if er, ok := err.(errorshandler.Response); ok {
+				c.Logger.With(rctx).Info(err)
+				return er
+			}
+			if errors.Is(err, apperror.ErrNotFound) {
+				c.Logger.With(rctx).Info(err)
+				return errorshandler.NotFound("Can not find a deleted entity")
+			}
+			c.Logger.With(rctx).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+
+		ctx.Response.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		return ctx.WriteWithStatus(errorshandler.SuccessMessage(), http.StatusOK)
+	}
+}
@@ -5,9 +5,11 @@ import (
 
 	routing "github.com/go-ozzo/ozzo-routing/v2"
 	"github.com/minipkg/log"
+	"github.com/pkg/errors"
 
 	"redditclone/internal/domain/comment"
 	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/vote"
 	"redditclone/internal/pkg/apperror"
 	"redditclone/internal/pkg/auth"
 	"redditclone/internal/pkg/errorshandler"
@@ -19,8 +21,14 @@ type commentController struct {
 	Logger      log.ILogger
 }
 
+//	GET /api/post/{POST_ID}/comments - список комментов поста по порядку добавления
+//		?after={CURSOR}|before={CURSOR}&limit={N} - постраничный вывод, ответ: {"items": [...], "next": "...", "prev": "..."}
 //	POST /api/post/{POST_ID} - добавление коммента
 //	DELETE /api/post/{POST_ID}/{COMMENT_ID} - удаление коммента
+//	GET /api/post/{POST_ID}/{COMMENT_ID}/replies - ветка ответов на коммент (продолжение дерева)
+//	GET /api/post/{POST_ID}/{COMMENT_ID}/upvote - рейтинг коммента вверх
+//	GET /api/post/{POST_ID}/{COMMENT_ID}/downvote - рейтинг коммента вниз
+//	GET /api/post/{POST_ID}/{COMMENT_ID}/unvote - отмена голоса за коммент
 func RegisterCommentHandlers(r *routing.RouteGroup, service comment.IService, postService post.IService, logger log.ILogger, authHandler routing.Handler) {
 	c := commentController{
 		Service:     service,
@@ -28,10 +36,39 @@ func RegisterCommentHandlers(r *routing.RouteGroup, service comment.IService, po
 		Logger:      logger,
 	}
 
+	r.Get(`/post/<postId>/comments`, c.list)
+	r.Get(`/post/<postId>/<id>/replies`, c.thread)
+
 	r.Use(authHandler)
 
 	r.Post(`/post/<postId>`, c.create)
 	r.Delete(`/post/<postId>/<id>`, c.delete)
+
+	r.Get(`/post/<postId>/<id>/upvote`, c.upvote)
+	r.Get(`/post/<postId>/<id>/downvote`, c.downvote)
+	r.Get(`/post/<postId>/<id>/unvote`, c.unvote)
+}
+
+// list method is for getting a page of the comments of the post
+func (c *commentController) list(ctx *routing.Context) error {
+	params, err := pageParams(ctx)
+	if err != nil {
+		c.Logger.With(ctx.Request.Context()).Info(err)
+		return errorshandler.BadRequest("")
+	}
+
+	page, err := c.Service.Page(ctx.Request.Context(), ctx.Param("postId"), params)
+	if err != nil {
+		if errors.Cause(err) == apperror.ErrBadRequest {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return errorshandler.BadRequest(err.Error())
+		}
+		c.Logger.With(ctx.Request.Context()).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+
+	ctx.Response.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	return ctx.Write(page)
 }
 
 func (c *commentController) create(ctx *routing.Context) error {
@@ -54,6 +91,9 @@ func (c *commentController) create(ctx *routing.Context) error {
 
 	if err := c.Service.Create(ctx.Request.Context(), entity); err != nil {
 		c.Logger.With(ctx.Request.Context()).Info(err)
+		if er, ok := err.(errorshandler.Response); ok {
+			return er
+		}
 		return errorshandler.BadRequest(err.Error())
 	}
 
@@ -71,11 +111,41 @@ func (c *commentController) create(ctx *routing.Context) error {
 	return ctx.WriteWithStatus(post, http.StatusCreated)
 }
 
+// thread method is for getting a comment with the tree of its replies
+func (c *commentController) thread(ctx *routing.Context) error {
+	entity, err := c.Service.Thread(ctx.Request.Context(), ctx.Param("postId"), ctx.Param("id"))
+	if err != nil {
+		if err == apperror.ErrNotFound {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return errorshandler.NotFound("")
+		}
+		c.Logger.With(ctx.Request.Context()).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+
+	ctx.Response.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	return ctx.Write(entity)
+}
+
 func (c *commentController) delete(ctx *routing.Context) error {
 	postId := ctx.Param("postId")
 	id := ctx.Param("id")
 
 	if err := c.Service.Delete(ctx.Request.Context(), id); err != nil {
+		if er, ok := err.(errorshandler.Response); ok {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return er
+		}
+		if err == apperror.ErrNotFound {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return errorshandler.NotFound("")
+		}
+		c.Logger.With(ctx.Request.Context()).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+
+	post, err := c.PostService.Get(ctx.Request.Context(), postId)
+	if err != nil {
 		if err == apperror.ErrNotFound {
 			c.Logger.With(ctx.Request.Context()).Info(err)
 			return errorshandler.NotFound("")
@@ -84,6 +154,67 @@ func (c *commentController) delete(ctx *routing.Context) error {
 		return errorshandler.InternalServerError("")
 	}
 
+	ctx.Response.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	return ctx.WriteWithStatus(post, http.StatusOK)
+}
+
+func (c *commentController) upvote(ctx *routing.Context) error {
+	return c.vote(ctx, ctx.Param("postId"), ctx.Param("id"), 1)
+}
+
+func (c *commentController) downvote(ctx *routing.Context) error {
+	return c.vote(ctx, ctx.Param("postId"), ctx.Param("id"), -1)
+}
+
+func (c *commentController) vote(ctx *routing.Context, postId string, id string, val int) error {
+	session := auth.CurrentSession(ctx.Request.Context())
+	entity := c.PostService.NewVoteEntity(session.UserID, postId, val)
+	entity.CommentID = id
+	entity.User = session.User
+
+	if err := c.PostService.Vote(ctx.Request.Context(), entity); err != nil {
+		if er, ok := err.(errorshandler.Response); ok {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return er
+		}
+		if errors.Cause(err) == apperror.ErrNotFound {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return errorshandler.NotFound("")
+		}
+		c.Logger.With(ctx.Request.Context()).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+
+	return c.writePost(ctx, postId)
+}
+
+func (c *commentController) unvote(ctx *routing.Context) error {
+	postId := ctx.Param("postId")
+	session := auth.CurrentSession(ctx.Request.Context())
+	entity := &vote.Vote{
+		PostID:    postId,
+		CommentID: ctx.Param("id"),
+		UserID:    session.UserID,
+		User:      session.User,
+	}
+
+	if err := c.PostService.Unvote(ctx.Request.Context(), entity); err != nil {
+		if er, ok := err.(errorshandler.Response); ok {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return er
+		}
+		if errors.Cause(err) == apperror.ErrNotFound {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return errorshandler.NotFound("")
+		}
+		c.Logger.With(ctx.Request.Context()).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+
+	return c.writePost(ctx, postId)
+}
+
+func (c *commentController) writePost(ctx *routing.Context, postId string) error {
 	post, err := c.PostService.Get(ctx.Request.Context(), postId)
 	if err != nil {
 		if err == apperror.ErrNotFound {
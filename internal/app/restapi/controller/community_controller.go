@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/minipkg/log"
+	ozzo_routing "github.com/minipkg/ozzo_routing"
+	"github.com/pkg/errors"
+
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+
+	"redditclone/internal/domain/community"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/errorshandler"
+)
+
+type communityController struct {
+	Logger  log.ILogger
+	Service community.IService
+}
+
+// RegisterHandlers sets up the routing of the HTTP handlers.
+//	GET /api/communities - список сообществ, самые популярные первыми
+//		?q={TEXT} - поиск по названию и описанию, ?offset={N}&limit={N} - постраничный вывод
+//	GET /api/community/{COMMUNITY_NAME} - описание и правила сообщества
+//	POST /api/communities - создание сообщества: {"name": "golang", "description": "...", "rules": ["..."]}
+//	POST /api/community/{COMMUNITY_NAME}/subscribe - подписка на сообщество
+//	POST /api/community/{COMMUNITY_NAME}/unsubscribe - отписка от сообщества
+//	GET /api/me/subscriptions - сообщества, на которые подписан пользователь
+//	GET /api/community/{COMMUNITY_NAME}/bans - баны в сообществе, только модератору сообщества или админу
+//	POST /api/community/{COMMUNITY_NAME}/bans - бан пользователя в сообществе: {"userId": 2, "reason": "...", "until": "2020-01-01T00:00:00Z"}
+//		until не обязателен, без него бан бессрочный, причина показывается забаненному пользователю
+//	DELETE /api/community/{COMMUNITY_NAME}/bans/{USER_ID} - снятие бана
+func RegisterCommunityHandlers(r *routing.RouteGroup, service community.IService, logger log.ILogger, authHandler routing.Handler) {
+	c := communityController{
+		Logger:  logger,
+		Service: service,
+	}
+
+	r.Get("/communities", c.list)
+	r.Get(`/community/<name:\w+>`, c.get)
+
+	r.Use(authHandler)
+
+	r.Post("/communities", c.create)
+	r.Post(`/community/<name:\w+>/subscribe`, c.subscribe)
+	r.Post(`/community/<name:\w+>/unsubscribe`, c.unsubscribe)
+	r.Get("/me/subscriptions", c.subscriptions)
+
+	r.Get(`/community/<name:\w+>/bans`, c.bans)
+	r.Post(`/community/<name:\w+>/bans`, c.ban)
+	r.Delete(`/community/<name:\w+>/bans/<userId:\d+>`, c.unban)
+}
+
+// get method is for getting a one entity by the name
+func (c communityController) get(ctx *routing.Context) error {
+	rctx := ctx.Request.Context()
+
+	entity, err := c.Service.Get(rctx, ctx.Param("name"))
+	if err != nil {
+		return c.error(ctx, err)
+	}
+	return ctx.Write(entity)
+}
+
+// list method is for a getting a list of the entities, the list is filtered by ?q= if it is given
+func (c communityController) list(ctx *routing.Context) error {
+	rctx := ctx.Request.Context()
+
+	offset, limit, err := offsetParams(ctx)
+	if err != nil {
+		c.Logger.With(rctx).Info(err)
+		return errorshandler.BadRequest("")
+	}
+
+	var items []community.Community
+	if q := ctx.Query("q"); q != "" {
+		items, err = c.Service.Search(rctx, q, offset, limit)
+	} else {
+		items, err = c.Service.List(rctx, offset, limit)
+	}
+	if err != nil {
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+	return ctx.Write(items)
+}
+
+// create method is for a creating a new community owned by the current user
+func (c communityController) create(ctx *routing.Context) error {
+	rctx := ctx.Request.Context()
+
+	entity := c.Service.NewEntity()
+	if err := ctx.Read(entity); err != nil {
+		c.Logger.With(rctx).Info(err)
+		return errorshandler.BadRequest(err.Error())
+	}
+
+	if err := entity.Validate(); err != nil {
+		return errorshandler.BadRequest(err.Error())
+	}
+
+	if err := c.Service.Create(rctx, entity); err != nil {
+		return c.error(ctx, err)
+	}
+	return ctx.WriteWithStatus(entity, http.StatusCreated)
+}
+
+// subscribe method subscribes the current user to the community
+func (c communityController) subscribe(ctx *routing.Context) error {
+	entity, err := c.Service.Subscribe(ctx.Request.Context(), ctx.Param("name"))
+	if err != nil {
+		return c.error(ctx, err)
+	}
+	return ctx.Write(entity)
+}
+
+// unsubscribe method unsubscribes the current user from the community
+func (c communityController) unsubscribe(ctx *routing.Context) error {
+	entity, err := c.Service.Unsubscribe(ctx.Request.Context(), ctx.Param("name"))
+	if err != nil {
+		return c.error(ctx, err)
+	}
+	return ctx.Write(entity)
+}
+
+// subscriptions method is for a getting the communities which the current user is subscribed to
+func (c communityController) subscriptions(ctx *routing.Context) error {
+	rctx := ctx.Request.Context()
+
+	items, err := c.Service.Subscriptions(rctx)
+	if err != nil {
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+	return ctx.Write(items)
+}
+
+// bans method is for a getting the bans in the community
+func (c communityController) bans(ctx *routing.Context) error {
+	items, err := c.Service.Bans(ctx.Request.Context(), ctx.Param("name"))
+	if err != nil {
+		return c.error(ctx, err)
+	}
+	return ctx.Write(items)
+}
+
+// ban method bans the user in the community
+func (c communityController) ban(ctx *routing.Context) error {
+	rctx := ctx.Request.Context()
+
+	entity := c.Service.NewBanEntity()
+	if err := ctx.Read(entity); err != nil {
+		c.Logger.With(rctx).Info(err)
+		return errorshandler.BadRequest(err.Error())
+	}
+
+	if err := c.Service.Ban(rctx, ctx.Param("name"), entity); err != nil {
+		if errors.Is(err, apperror.ErrConflict) {
+			c.Logger.With(rctx).Info(err)
+			return errorshandler.Conflict("User is already banned")
+		}
+		return c.error(ctx, err)
+	}
+	return ctx.WriteWithStatus(entity, http.StatusCreated)
+}
+
+// unban method lifts the ban of the user in the community
+func (c communityController) unban(ctx *routing.Context) error {
+	userID, err := ozzo_routing.ParseUintParam(ctx, "userId")
+	if err != nil {
+		return errorshandler.BadRequest("User ID is required to be uint")
+	}
+
+	if err = c.Service.Unban(ctx.Request.Context(), ctx.Param("name"), uint(userID)); err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return errorshandler.NotFound("Can not find ban")
+		}
+		return c.error(ctx, err)
+	}
+	return ctx.Write(errorshandler.SuccessMessage())
+}
+
+// error converts an error of the service to a response
+func (c communityController) error(ctx *routing.Context, err error) error {
+	rctx := ctx.Request.Context()
+
+	if er, ok := err.(errorshandler.Response); ok {
+		c.Logger.With(rctx).Info(err)
+		return er
+	}
+	switch {
+	case errors.Is(err, apperror.ErrNotFound):
+		c.Logger.With(rctx).Info(err)
+		return errorshandler.NotFound("Can not find community")
+	case errors.Is(err, apperror.ErrBadRequest):
+		c.Logger.With(rctx).Info(err)
+		return errorshandler.BadRequest(err.Error())
+	case errors.Is(err, apperror.ErrConflict):
+		c.Logger.With(rctx).Info(err)
+		return errorshandler.Conflict("Community already exists")
+	}
+	c.Logger.With(rctx).Error(err)
+	return errorshandler.InternalServerError("")
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"github.com/minipkg/log"
+	"github.com/pkg/errors"
+
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+
+	"redditclone/internal/domain/feed"
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/errorshandler"
+)
+
+type feedController struct {
+	Logger      log.ILogger
+	Service     feed.IService
+	UserService user.IService
+}
+
+// RegisterHandlers sets up the routing of the HTTP handlers.
+//	GET /api/feed - лента из постов сообществ, на которые подписан пользователь, и постов пользователей, за которыми он следит
+//		?sort=hot|top|new|rising|controversial&t=day|week|month|all, ?after={CURSOR}|before={CURSOR}&limit={N} - как у списка постов
+//	POST /api/u/{USER_LOGIN}/follow - подписка на посты пользователя
+//	POST /api/u/{USER_LOGIN}/unfollow - отписка от постов пользователя
+//	GET /api/me/following - пользователи, за которыми следит пользователь
+func RegisterFeedHandlers(r *routing.RouteGroup, service feed.IService, userService user.IService, logger log.ILogger, authHandler routing.Handler) {
+	c := feedController{
+		Logger:      logger,
+		Service:     service,
+		UserService: userService,
+	}
+
+	r.Use(authHandler)
+
+	r.Get("/feed", c.feed)
+	r.Post(`/u/<userName:[\p{L}\p{N}]+>/follow`, c.follow)
+	r.Post(`/u/<userName:[\p{L}\p{N}]+>/unfollow`, c.unfollow)
+	r.Get("/me/following", c.following)
+}
+
+// feed method is for a getting a page of the feed of the current user
+func (c feedController) feed(ctx *routing.Context) error {
+	rctx := ctx.Request.Context()
+
+	params, err := pageParams(ctx)
+	if err != nil {
+		c.Logger.With(rctx).Info(err)
+		return errorshandler.BadRequest("")
+	}
+
+	page, err := c.Service.Page(rctx, ctx.Query("sort"), ctx.Query("t"), params)
+	if err != nil {
+		if errors.Is(err, apperror.ErrBadRequest) {
+			c.Logger.With(rctx).Info(err)
+			return errorshandler.BadRequest(err.Error())
+		}
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+	ctx.Response.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	return ctx.Write(page)
+}
+
+// follow method makes the current user a follower of the user
+func (c feedController) follow(ctx *routing.Context) error {
+	rctx := ctx.Request.Context()
+
+	followed, err := c.user(ctx)
+	if err != nil {
+		return err
+	}
+
+	entity, err := c.Service.Follow(rctx, followed)
+	if err != nil {
+		if errors.Is(err, apperror.ErrBadRequest) {
+			c.Logger.With(rctx).Info(err)
+			return errorshandler.BadRequest(err.Error())
+		}
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+	return ctx.Write(entity)
+}
+
+// unfollow method stops the following of the user by the current user
+func (c feedController) unfollow(ctx *routing.Context) error {
+	rctx := ctx.Request.Context()
+
+	followed, err := c.user(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = c.Service.Unfollow(rctx, followed); err != nil {
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+	return ctx.Write(errorshandler.SuccessMessage())
+}
+
+// following method is for a getting the follows of the current user
+func (c feedController) following(ctx *routing.Context) error {
+	rctx := ctx.Request.Context()
+
+	items, err := c.Service.Following(rctx)
+	if err != nil {
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+	return ctx.Write(items)
+}
+
+// user returns the user by the name from the path or the error response
+func (c feedController) user(ctx *routing.Context) (*user.User, error) {
+	rctx := ctx.Request.Context()
+	userName := ctx.Param("userName")
+
+	entity, err := c.UserService.First(rctx, &user.User{
+		Name: userName,
+	})
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			c.Logger.With(rctx).Info(errors.Wrapf(err, "Can not find user with name: %q", userName))
+			return nil, errorshandler.NotFound("Can not find user")
+		}
+		c.Logger.With(rctx).Error(err)
+		return nil, errorshandler.InternalServerError("")
+	}
+	return entity, nil
+}
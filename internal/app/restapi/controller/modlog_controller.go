@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"github.com/minipkg/log"
+	ozzo_routing "github.com/minipkg/ozzo_routing"
+
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+
+	"redditclone/internal/domain/modlog"
+	"redditclone/internal/pkg/errorshandler"
+)
+
+type modLogController struct {
+	Logger  log.ILogger
+	Service modlog.IService
+}
+
+// RegisterHandlers sets up the routing of the HTTP handlers.
+//	GET /api/mod/log - журнал действий модераторов и админов, самые новые первыми, модератору - только его категории
+//		?action=delete_post|delete_comment|approve_report|remove_report|dismiss_report|ban_user|unban_user|set_account_state|restore_post|restore_comment - фильтр по действию
+//		?type=post|comment|user&target={TARGET_ID}&category={CATEGORY_NAME}&actor={USER_ID} - фильтры по объекту, категории и автору действия
+//		?from={DATE}&to={DATE} - время действия, дата 2006-01-02 или время RFC 3339, ?offset={N}&limit={N} - постраничный вывод
+func RegisterModLogHandlers(r *routing.RouteGroup, service modlog.IService, logger log.ILogger, authHandler routing.Handler) {
+	c := modLogController{
+		Logger:  logger,
+		Service: service,
+	}
+
+	r.Use(authHandler)
+
+	r.Get("/mod/log", c.list)
+}
+
+// list method is for a getting a list of the entries of the moderation log
+func (c modLogController) list(ctx *routing.Context) error {
+	rctx := ctx.Request.Context()
+
+	offset, limit, err := offsetParams(ctx)
+	if err != nil {
+		c.Logger.With(rctx).Info(err)
+		return errorshandler.BadRequest("")
+	}
+
+	filter := modlog.Filter{
+		Action:     ctx.Query("action"),
+		TargetType: ctx.Query("type"),
+		TargetID:   ctx.Query("target"),
+	}
+	if category := ctx.Query("category"); category != "" {
+		filter.Categories = []string{category}
+	}
+	if ctx.Query("actor") != "" {
+		actorID, err := ozzo_routing.ParseUintQueryParam(ctx, "actor")
+		if err != nil {
+			return errorshandler.BadRequest("actor: ID is required to be uint")
+		}
+		filter.ActorID = actorID
+	}
+	if filter.From, err = parseDate(ctx.Query("from"), false); err != nil {
+		return errorshandler.BadRequest("from: " + err.Error())
+	}
+	if filter.To, err = parseDate(ctx.Query("to"), true); err != nil {
+		return errorshandler.BadRequest("to: " + err.Error())
+	}
+	if err := filter.Validate(); err != nil {
+		return errorshandler.BadRequest(err.Error())
+	}
+
+	items, err := c.Service.Query(rctx, filter, offset, limit)
+	if err != nil {
+		if er, ok := err.(errorshandler.Response); ok {
+			c.Logger.With(rctx).Info(err)
+			return er
+		}
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+	return ctx.Write(items)
+}
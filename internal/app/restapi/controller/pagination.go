@@ -0,0 +1,40 @@
+package controller
+
+import (
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/minipkg/ozzo_routing"
+
+	"redditclone/internal/pkg/pagination"
+)
+
+// pageParams reads the pagination params ?after=&before=&limit= of a request
+func pageParams(ctx *routing.Context) (pagination.Params, error) {
+	params := pagination.Params{
+		After:  ctx.Query("after"),
+		Before: ctx.Query("before"),
+	}
+
+	if ctx.Query("limit") != "" {
+		limit, err := ozzo_routing.ParseUintQueryParam(ctx, "limit")
+		if err != nil {
+			return params, err
+		}
+		params.Limit = limit
+	}
+	return params, nil
+}
+
+// offsetParams reads the pagination params ?offset=&limit= of a request, zero means the param is not given
+func offsetParams(ctx *routing.Context) (offset, limit uint, err error) {
+	if ctx.Query("offset") != "" {
+		if offset, err = ozzo_routing.ParseUintQueryParam(ctx, "offset"); err != nil {
+			return 0, 0, err
+		}
+	}
+	if ctx.Query("limit") != "" {
+		if limit, err = ozzo_routing.ParseUintQueryParam(ctx, "limit"); err != nil {
+			return 0, 0, err
+		}
+	}
+	return offset, limit, nil
+}
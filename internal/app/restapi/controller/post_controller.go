@@ -15,46 +15,67 @@ import (
 	"redditclone/internal/pkg/errorshandler"
 
 	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/report"
 	"redditclone/internal/domain/user"
 	"redditclone/internal/domain/vote"
 )
 
 type postController struct {
-	Service     post.IService
-	UserService user.IService
-	Logger      log.ILogger
+	Service       post.IService
+	UserService   user.IService
+	ReportService report.IService
+	Logger        log.ILogger
 }
 
 // RegisterHandlers sets up the routing of the HTTP handlers.
 //	GET /api/posts/ - список всех постов
+//		?sort=hot|top|new|rising|controversial - сортировка списков постов, для top период ?t=day|week|month|all
+//		?after={CURSOR}|before={CURSOR}&limit={N} - постраничный вывод, ответ: {"items": [...], "next": "...", "prev": "..."}
 //	GET /api/post/{POST_ID} - детали поста с комментами
+//		модераторам категории в постах показывается число жалоб "reports"
 //	GET /api/posts/{CATEGORY_NAME} - список постов конкретной категории
 //	GET /api/user/{USER_LOGIN} - получение всех постов конкртеного пользователя
 //	POST /api/posts/ - добавление поста - обратите внимание - есть с урлом, а есть с текстом
+//	PUT /api/post/{POST_ID} - редактирование поста автором
+//	PATCH /api/post/{POST_ID} - частичное редактирование поста автором
+//	GET /api/post/{POST_ID}/revisions - история изменений поста
 //	DELETE /api/post/{POST_ID} - удаление поста
 //	GET /api/post/{POST_ID}/upvote - рейтинг поста вверх
 //	GET /api/post/{POST_ID}/downvote - рейтинг поста вниз
 //	GET /api/post/{POST_ID}/unvote - рейтинг постп вверх
-func RegisterPostHandlers(r *routing.RouteGroup, service post.IService, userService user.IService, logger log.ILogger, authHandler routing.Handler) {
+//	POST /api/post/{POST_ID}/save - сохранение поста, DELETE - удаление из сохранённых
+//	POST /api/post/{POST_ID}/hide - скрытие поста из списков постов пользователя, DELETE - отмена скрытия
+//	GET /api/me/saved - сохранённые посты, ?after={CURSOR}|before={CURSOR}&limit={N} - постраничный вывод
+func RegisterPostHandlers(r *routing.RouteGroup, service post.IService, userService user.IService, reportService report.IService, logger log.ILogger, authHandler routing.Handler, viewerHandler routing.Handler) {
 	c := postController{
-		Service:     service,
-		UserService: userService,
-		Logger:      logger,
+		Service:       service,
+		UserService:   userService,
+		ReportService: reportService,
+		Logger:        logger,
 	}
 
-	r.Get("/posts", c.list)
-	r.Get(`/post/<id>`, c.get)
-	r.Get(`/posts/<category:\w+>`, c.list)
-	r.Get(`/user/<userName:\w+>`, c.list)
+	r.Get("/posts", viewerHandler, c.list)
+	r.Get(`/post/<id>`, viewerHandler, c.get)
+	r.Get(`/posts/<category:\w+>`, viewerHandler, c.list)
+	r.Get(`/user/<userName:[\p{L}\p{N}]+>`, viewerHandler, c.list)
+	r.Get(`/post/<id>/revisions`, viewerHandler, c.revisions)
 
 	r.Use(authHandler)
 
 	r.Post("/posts", c.create)
+	r.Put(`/post/<id>`, c.update)
+	r.Patch(`/post/<id>`, c.patch)
 	r.Delete(`/post/<id>`, c.delete)
 
 	r.Get(`/post/<postId>/upvote`, c.upvote)
 	r.Get(`/post/<postId>/downvote`, c.downvote)
 	r.Get(`/post/<postId>/unvote`, c.unvote)
+
+	r.Post(`/post/<id>/save`, c.mark(post.MarkSave))
+	r.Delete(`/post/<id>/save`, c.unmark(post.MarkSave))
+	r.Post(`/post/<id>/hide`, c.mark(post.MarkHide))
+	r.Delete(`/post/<id>/hide`, c.unmark(post.MarkHide))
+	r.Get("/me/saved", c.saved)
 }
 
 // get method is for getting a one entity by ID
@@ -76,6 +97,13 @@ func (c *postController) get(ctx *routing.Context) error {
 		return errorshandler.InternalServerError("")
 	}
 
+	items := []post.Post{*entity}
+	if err = c.ReportService.WithCounts(ctx.Request.Context(), items); err != nil {
+		c.Logger.With(ctx.Request.Context()).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+	entity = &items[0]
+
 	ctx.Response.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	return ctx.Write(entity)
 }
@@ -98,7 +126,6 @@ func (c *postController) list(ctx *routing.Context) error {
 	}
 
 	if userName := ctx.Param("userName"); userName != "" {
-		// @todo: make case insensitive
 		user, err := c.UserService.First(rctx, &user.User{
 			Name: userName,
 		})
@@ -112,12 +139,45 @@ func (c *postController) list(ctx *routing.Context) error {
 		}
 		where.UserID = user.ID
 	}
-	cond := selection_condition.SelectionCondition{
-		Where: where,
+	params, err := pageParams(ctx)
+	if err != nil {
+		c.Logger.With(ctx.Request.Context()).Info(err)
+		return errorshandler.BadRequest("")
+	}
+
+	if !params.IsEmpty() {
+		page, err := c.Service.Page(rctx, where, ctx.Query("sort"), ctx.Query("t"), params)
+		if err != nil {
+			if errors.Cause(err) == apperror.ErrBadRequest {
+				c.Logger.With(ctx.Request.Context()).Info(err)
+				return errorshandler.BadRequest(err.Error())
+			}
+			c.Logger.With(ctx.Request.Context()).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+		if items, ok := page.Items.([]post.Post); ok {
+			if err = c.ReportService.WithCounts(rctx, items); err != nil {
+				c.Logger.With(ctx.Request.Context()).Error(err)
+				return errorshandler.InternalServerError("")
+			}
+		}
+		ctx.Response.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		return ctx.Write(page)
 	}
 
-	items, err := c.Service.Query(rctx, cond)
+	var items []post.Post
+	if sort := ctx.Query("sort"); sort != "" {
+		items, err = c.Service.Ranked(rctx, where, sort, ctx.Query("t"))
+	} else {
+		items, err = c.Service.Query(rctx, selection_condition.SelectionCondition{
+			Where: &post.Filter{Post: *where},
+		})
+	}
 	if err != nil {
+		if errors.Cause(err) == apperror.ErrBadRequest {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return errorshandler.BadRequest(err.Error())
+		}
 		if err == apperror.ErrNotFound {
 			c.Logger.With(ctx.Request.Context()).Info(err)
 			return errorshandler.NotFound("")
@@ -125,10 +185,63 @@ func (c *postController) list(ctx *routing.Context) error {
 		c.Logger.With(ctx.Request.Context()).Error(err)
 		return errorshandler.InternalServerError("")
 	}
+	if err = c.ReportService.WithCounts(rctx, items); err != nil {
+		c.Logger.With(ctx.Request.Context()).Error(err)
+		return errorshandler.InternalServerError("")
+	}
 	ctx.Response.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	return ctx.Write(items)
 }
 
+// mark returns the handler which marks the entity by the current user with the kind
+func (c *postController) mark(kind string) routing.Handler {
+	return func(ctx *routing.Context) error {
+		if err := c.Service.Mark(ctx.Request.Context(), ctx.Param("id"), kind); err != nil {
+			if errors.Is(err, apperror.ErrNotFound) {
+				c.Logger.With(ctx.Request.Context()).Info(err)
+				return errorshandler.NotFound("")
+			}
+			c.Logger.With(ctx.Request.Context()).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+		return ctx.Write(errorshandler.SuccessMessage())
+	}
+}
+
+// unmark returns the handler which removes the mark of the kind of the entity by the current user
+func (c *postController) unmark(kind string) routing.Handler {
+	return func(ctx *routing.Context) error {
+		if err := c.Service.Unmark(ctx.Request.Context(), ctx.Param("id"), kind); err != nil {
+			c.Logger.With(ctx.Request.Context()).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+		return ctx.Write(errorshandler.SuccessMessage())
+	}
+}
+
+// saved method is for a getting a page of the entities saved by the current user
+func (c *postController) saved(ctx *routing.Context) error {
+	rctx := ctx.Request.Context()
+
+	params, err := pageParams(ctx)
+	if err != nil {
+		c.Logger.With(rctx).Info(err)
+		return errorshandler.BadRequest("")
+	}
+
+	page, err := c.Service.Saved(rctx, params)
+	if err != nil {
+		if errors.Is(err, apperror.ErrBadRequest) {
+			c.Logger.With(rctx).Info(err)
+			return errorshandler.BadRequest(err.Error())
+		}
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+	ctx.Response.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	return ctx.Write(page)
+}
+
 func (c *postController) create(ctx *routing.Context) error {
 	entity := c.Service.NewEntity()
 	if err := ctx.Read(entity); err != nil {
@@ -146,6 +259,9 @@ func (c *postController) create(ctx *routing.Context) error {
 
 	if err := c.Service.Create(ctx.Request.Context(), entity); err != nil {
 		c.Logger.With(ctx.Request.Context()).Info(err)
+		if er, ok := err.(errorshandler.Response); ok {
+			return er
+		}
 		return errorshandler.BadRequest(err.Error())
 	}
 
@@ -153,10 +269,85 @@ func (c *postController) create(ctx *routing.Context) error {
 	return ctx.WriteWithStatus(entity, http.StatusCreated)
 }
 
+// update method replaces the title and the content of the entity
+func (c *postController) update(ctx *routing.Context) error {
+	input := c.Service.NewEntity()
+	if err := ctx.Read(input); err != nil {
+		c.Logger.With(ctx.Request.Context()).Info(err)
+		return errorshandler.BadRequest(err.Error())
+	}
+
+	return c.save(ctx, ctx.Param("id"), input)
+}
+
+// patch method changes only the given fields of the title and the content of the entity,
+// the other fields are taken from the stored entity rather than from the one masked for showing
+func (c *postController) patch(ctx *routing.Context) error {
+	id := ctx.Param("id")
+
+	entity, err := c.Service.GetActive(ctx.Request.Context(), id)
+	if err != nil {
+		if err == apperror.ErrNotFound {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return errorshandler.NotFound("")
+		}
+		c.Logger.With(ctx.Request.Context()).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+
+	//	the input is a copy, so the stored entity is not changed before it is compared with the input
+	input := *entity
+	if err := ctx.Read(&input); err != nil {
+		c.Logger.With(ctx.Request.Context()).Info(err)
+		return errorshandler.BadRequest(err.Error())
+	}
+
+	return c.save(ctx, id, &input)
+}
+
+func (c *postController) save(ctx *routing.Context, id string, input *post.Post) error {
+	entity, err := c.Service.Update(ctx.Request.Context(), id, input)
+	if err != nil {
+		if er, ok := err.(errorshandler.Response); ok {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return er
+		}
+		if err == apperror.ErrNotFound {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return errorshandler.NotFound("")
+		}
+		c.Logger.With(ctx.Request.Context()).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+
+	ctx.Response.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	return ctx.Write(entity)
+}
+
+// revisions method is for getting a list of previous versions of the entity
+func (c *postController) revisions(ctx *routing.Context) error {
+	items, err := c.Service.Revisions(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		if err == apperror.ErrNotFound {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return errorshandler.NotFound("")
+		}
+		c.Logger.With(ctx.Request.Context()).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+
+	ctx.Response.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	return ctx.Write(items)
+}
+
 func (c *postController) delete(ctx *routing.Context) error {
 	id := ctx.Param("id")
 
 	if err := c.Service.Delete(ctx.Request.Context(), id); err != nil {
+		if er, ok := err.(errorshandler.Response); ok {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return er
+		}
 		if err == apperror.ErrNotFound {
 			c.Logger.With(ctx.Request.Context()).Info(err)
 			return errorshandler.NotFound("")
@@ -183,6 +374,10 @@ func (c *postController) vote(ctx *routing.Context, postId string, val int) erro
 	entity.User = session.User
 
 	if err := c.Service.Vote(ctx.Request.Context(), entity); err != nil {
+		if er, ok := err.(errorshandler.Response); ok {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return er
+		}
 		c.Logger.With(ctx.Request.Context()).Error(err)
 		return errorshandler.InternalServerError(err.Error())
 	}
@@ -211,6 +406,14 @@ func (c *postController) unvote(ctx *routing.Context) error {
 	}
 
 	if err := c.Service.Unvote(ctx.Request.Context(), entity); err != nil {
+		if er, ok := err.(errorshandler.Response); ok {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return er
+		}
+		if errors.Cause(err) == apperror.ErrNotFound {
+			c.Logger.With(ctx.Request.Context()).Info(err)
+			return errorshandler.NotFound("")
+		}
 		c.Logger.With(ctx.Request.Context()).Error(err)
 		return errorshandler.InternalServerError(err.Error())
 	}
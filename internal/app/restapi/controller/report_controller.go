@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"github.com/minipkg/log"
+	"github.com/pkg/errors"
+
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+
+	"redditclone/internal/domain/report"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/errorshandler"
+)
+
+type reportController struct {
+	Logger  log.ILogger
+	Service report.IService
+}
+
+// removal is the body of the removal of a queue item
+type removal struct {
+	Reason string `json:"reason"`
+}
+
+// RegisterHandlers sets up the routing of the HTTP handlers.
+//	POST /api/post/{POST_ID}/report - жалоба на пост: {"reason": "spam|harassment|hate|violence|sexual|misinformation|other", "details": "..."}
+//		details обязательны для reason=other, повторная жалоба пользователя не учитывается
+//	POST /api/post/{POST_ID}/{COMMENT_ID}/report - жалоба на коммент, как на пост
+//	GET /api/mod/queue - очередь модерации, модератору - только его категории, с наибольшим числом жалоб первыми
+//		?status=open|approved|removed|dismissed&type=post|comment&category={CATEGORY_NAME}, ?offset={N}&limit={N} - постраничный вывод
+//	POST /api/mod/queue/{ITEM_ID}/approve - оставить пост или коммент как есть
+//	POST /api/mod/queue/{ITEM_ID}/remove - удалить пост или коммент: {"reason": "..."}
+//	POST /api/mod/queue/{ITEM_ID}/dismiss - отклонить жалобы
+func RegisterReportHandlers(r *routing.RouteGroup, service report.IService, logger log.ILogger, authHandler routing.Handler) {
+	c := reportController{
+		Logger:  logger,
+		Service: service,
+	}
+
+	r.Use(authHandler)
+
+	r.Post(`/post/<id>/report`, c.reportPost)
+	r.Post(`/post/<postId>/<id>/report`, c.reportComment)
+
+	r.Get("/mod/queue", c.queue)
+	r.Post(`/mod/queue/<id>/approve`, c.approve)
+	r.Post(`/mod/queue/<id>/remove`, c.remove)
+	r.Post(`/mod/queue/<id>/dismiss`, c.dismiss)
+}
+
+// reportPost method is for a reporting of the post by the current user
+func (c reportController) reportPost(ctx *routing.Context) error {
+	entity, err := c.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = c.Service.ReportPost(ctx.Request.Context(), ctx.Param("id"), entity); err != nil {
+		return c.error(ctx, err)
+	}
+	return ctx.Write(errorshandler.SuccessMessage())
+}
+
+// reportComment method is for a reporting of the comment by the current user
+func (c reportController) reportComment(ctx *routing.Context) error {
+	entity, err := c.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = c.Service.ReportComment(ctx.Request.Context(), ctx.Param("postId"), ctx.Param("id"), entity); err != nil {
+		return c.error(ctx, err)
+	}
+	return ctx.Write(errorshandler.SuccessMessage())
+}
+
+func (c reportController) read(ctx *routing.Context) (*report.Report, error) {
+	entity := c.Service.NewEntity()
+	if err := ctx.Read(entity); err != nil {
+		c.Logger.With(ctx.Request.Context()).Info(err)
+		return nil, errorshandler.BadRequest(err.Error())
+	}
+
+	if err := entity.Validate(); err != nil {
+		return nil, errorshandler.BadRequest(err.Error())
+	}
+	return entity, nil
+}
+
+// queue method is for a getting a list of the items of the moderation queue
+func (c reportController) queue(ctx *routing.Context) error {
+	rctx := ctx.Request.Context()
+
+	offset, limit, err := offsetParams(ctx)
+	if err != nil {
+		c.Logger.With(rctx).Info(err)
+		return errorshandler.BadRequest("")
+	}
+
+	filter := report.QueueFilter{
+		Status:     ctx.Query("status"),
+		TargetType: ctx.Query("type"),
+	}
+	if category := ctx.Query("category"); category != "" {
+		filter.Categories = []string{category}
+	}
+	if err := filter.Validate(); err != nil {
+		return errorshandler.BadRequest(err.Error())
+	}
+
+	items, err := c.Service.Queue(rctx, filter, offset, limit)
+	if err != nil {
+		return c.error(ctx, err)
+	}
+	return ctx.Write(items)
+}
+
+// approve method leaves the post or the comment of the queue item as is
+func (c reportController) approve(ctx *routing.Context) error {
+	item, err := c.Service.Approve(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		return c.error(ctx, err)
+	}
+	return ctx.Write(item)
+}
+
+// remove method deletes the post or the comment of the queue item
+func (c reportController) remove(ctx *routing.Context) error {
+	input := &removal{}
+	if err := ctx.Read(input); err != nil {
+		c.Logger.With(ctx.Request.Context()).Info(err)
+		return errorshandler.BadRequest(err.Error())
+	}
+
+	item, err := c.Service.Remove(ctx.Request.Context(), ctx.Param("id"), input.Reason)
+	if err != nil {
+		return c.error(ctx, err)
+	}
+	return ctx.Write(item)
+}
+
+// dismiss method drops the reports of the queue item
+func (c reportController) dismiss(ctx *routing.Context) error {
+	item, err := c.Service.Dismiss(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		return c.error(ctx, err)
+	}
+	return ctx.Write(item)
+}
+
+func (c reportController) error(ctx *routing.Context, err error) error {
+	if er, ok := err.(errorshandler.Response); ok {
+		c.Logger.With(ctx.Request.Context()).Info(err)
+		return er
+	}
+	switch {
+	case errors.Is(err, apperror.ErrNotFound):
+		c.Logger.With(ctx.Request.Context()).Info(err)
+		return errorshandler.NotFound("")
+	case errors.Is(err, apperror.ErrBadRequest):
+		c.Logger.With(ctx.Request.Context()).Info(err)
+		return errorshandler.BadRequest(err.Error())
+	case errors.Is(err, apperror.ErrConflict):
+		c.Logger.With(ctx.Request.Context()).Info(err)
+		return errorshandler.Conflict(err.Error())
+	}
+	c.Logger.With(ctx.Request.Context()).Error(err)
+	return errorshandler.InternalServerError("")
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/minipkg/log"
+	"github.com/pkg/errors"
+
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+
+	"redditclone/internal/domain/search"
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/errorshandler"
+)
+
+// dateLayout is the layout of the dates of the filters, the time is given by RFC 3339 as well
+const dateLayout = "2006-01-02"
+
+type searchController struct {
+	Logger      log.ILogger
+	Service     search.IService
+	UserService user.IService
+}
+
+// RegisterHandlers sets up the routing of the HTTP handlers.
+//	GET /api/search?q={TEXT} - поиск по заголовкам и текстам постов и по комментариям, самые подходящие первыми
+//		?category={CATEGORY_NAME}&author={USER_LOGIN}&type=text|link - фильтры
+//		?from={DATE}&to={DATE} - время создания, дата 2006-01-02 или время RFC 3339, ?offset={N}&limit={N} - постраничный вывод
+func RegisterSearchHandlers(r *routing.RouteGroup, service search.IService, userService user.IService, logger log.ILogger, viewerHandler routing.Handler) {
+	c := searchController{
+		Logger:      logger,
+		Service:     service,
+		UserService: userService,
+	}
+
+	r.Get("/search", viewerHandler, c.search)
+}
+
+// search method is for a getting a list of the posts and the comments matching the query
+func (c searchController) search(ctx *routing.Context) error {
+	rctx := ctx.Request.Context()
+
+	offset, limit, err := offsetParams(ctx)
+	if err != nil {
+		c.Logger.With(rctx).Info(err)
+		return errorshandler.BadRequest("")
+	}
+
+	query := search.Query{
+		Text:     ctx.Query("q"),
+		Category: ctx.Query("category"),
+		Type:     ctx.Query("type"),
+	}
+	if query.From, err = parseDate(ctx.Query("from"), false); err != nil {
+		return errorshandler.BadRequest("from: " + err.Error())
+	}
+	if query.To, err = parseDate(ctx.Query("to"), true); err != nil {
+		return errorshandler.BadRequest("to: " + err.Error())
+	}
+	if err := query.Validate(); err != nil {
+		return errorshandler.BadRequest(err.Error())
+	}
+
+	if userName := ctx.Query("author"); userName != "" {
+		author, err := c.UserService.First(rctx, &user.User{
+			Name: userName,
+		})
+		if err != nil {
+			if errors.Is(err, apperror.ErrNotFound) {
+				c.Logger.With(rctx).Info(errors.Wrapf(err, "Can not find user with name: %q", userName))
+				return errorshandler.NotFound("Can not find user")
+			}
+			c.Logger.With(rctx).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+		query.UserID = author.ID
+	}
+
+	items, err := c.Service.Search(rctx, query, offset, limit)
+	if err != nil {
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+	return ctx.Write(items)
+}
+
+// parseDate parses the date or the time of a filter, the date of the end of a period includes the whole day
+func parseDate(s string, isEnd bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return time.Parse(time.RFC3339, s)
+	}
+	if isEnd {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
@@ -1,50 +1,156 @@
 package controller
 
 import (
-	"redditclone/internal/domain/user"
-	"redditclone/internal/pkg/apperror"
+	"time"
 
 	"github.com/minipkg/log"
-	ozzo_routing "github.com/minipkg/ozzo_routing"
-	"github.com/minipkg/ozzo_routing/errorshandler"
+	"github.com/pkg/errors"
 
 	routing "github.com/go-ozzo/ozzo-routing/v2"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+
+	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/auth"
+	"redditclone/internal/pkg/errorshandler"
 )
 
 type userController struct {
-	Logger  log.ILogger
-	Service user.IService
+	Logger      log.ILogger
+	Service     user.IService
+	PostService post.IService
+}
+
+// profile is the public data of a user
+type profile struct {
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"createdAt"`
+	Bio       string    `json:"bio"`
+	AvatarURL string    `json:"avatarUrl"`
+	post.AuthorStats
+}
+
+// me is the user with the data which are shown to the user only
+type me struct {
+	*user.User
+	Email               string     `json:"email,omitempty"`
+	Prefs               user.Prefs `json:"prefs"`
+	Role                string     `json:"role"`
+	ModeratedCategories []string   `json:"moderatedCategories,omitempty"`
+}
+
+// profileRequest is the editable part of the data of a user, the fields which are not given stay the same
+type profileRequest struct {
+	Bio       string     `json:"bio"`
+	AvatarURL string     `json:"avatarUrl"`
+	Prefs     user.Prefs `json:"prefs"`
+}
+
+func (r profileRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Bio, validation.Length(0, 500)),
+		validation.Field(&r.AvatarURL, validation.Length(0, 255), is.URL),
+		validation.Field(&r.Prefs, validation.By(func(value interface{}) error {
+			if sort := value.(user.Prefs).DefaultSort; sort != "" && !post.HasRanker(sort) {
+				return errors.Errorf("unknown sort: %q", sort)
+			}
+			return nil
+		})),
+	)
 }
 
 // RegisterHandlers sets up the routing of the HTTP handlers.
-func RegisterUserHandlers(r *routing.RouteGroup, service user.IService, logger log.ILogger, authHandler routing.Handler) {
+//	GET /api/u/{USER_LOGIN}/about - профиль пользователя: дата регистрации, о себе, аватар, карма и число постов и комментариев
+//	PATCH /api/me - редактирование профиля: {"bio": "...", "avatarUrl": "...", "prefs": {"nightMode": true, "defaultSort": "new", "hideScores": false}}
+func RegisterUserHandlers(r *routing.RouteGroup, service user.IService, postService post.IService, logger log.ILogger, authHandler routing.Handler) {
 	c := userController{
-		Logger:  logger,
-		Service: service,
+		Logger:      logger,
+		Service:     service,
+		PostService: postService,
 	}
 
-	r.Get(`/user/<id:\d+>`, c.get)
+	r.Get(`/u/<userName:[\p{L}\p{N}]+>/about`, c.about)
 	//r.Get("/users", c.list)
 
+	r.Use(authHandler)
+
+	r.Patch(`/me`, c.patch)
 }
 
-// get method is for a getting a one enmtity by ID
-func (c userController) get(ctx *routing.Context) error {
-	id, err := ozzo_routing.ParseUintParam(ctx, "id")
+// about method is for a getting the public profile of a user by the name
+func (c userController) about(ctx *routing.Context) error {
+	rctx := ctx.Request.Context()
+	userName := ctx.Param("userName")
+
+	entity, err := c.Service.First(rctx, &user.User{
+		Name: userName,
+	})
 	if err != nil {
-		return errorshandler.BadRequest("ID is required to be uint")
+		if errors.Is(err, apperror.ErrNotFound) {
+			c.Logger.With(rctx).Info(errors.Wrapf(err, "Can not find user with name: %q", userName))
+			return errorshandler.NotFound("Can not find user")
+		}
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
 	}
 
-	entity, err := c.Service.Get(ctx.Request.Context(), uint(id))
+	stats, err := c.PostService.AuthorStats(rctx, entity.ID)
 	if err != nil {
-		if err == apperror.ErrNotFound {
-			c.Logger.With(ctx.Request.Context()).Info(err)
-			return errorshandler.NotFound("")
-		}
-		c.Logger.With(ctx.Request.Context()).Error(err)
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+
+	return ctx.Write(profile{
+		Username:    entity.Name,
+		CreatedAt:   entity.CreatedAt,
+		Bio:         entity.Bio,
+		AvatarURL:   entity.AvatarURL,
+		AuthorStats: *stats,
+	})
+}
+
+// patch method changes only the given fields of the profile of the current user
+func (c userController) patch(ctx *routing.Context) error {
+	rctx := ctx.Request.Context()
+
+	entity, err := c.Service.Get(rctx, auth.CurrentSession(rctx).UserID)
+	if err != nil {
+		c.Logger.With(rctx).Error(err)
 		return errorshandler.InternalServerError("")
 	}
-	return ctx.Write(entity)
+
+	input := profileRequest{
+		Bio:       entity.Bio,
+		AvatarURL: entity.AvatarURL,
+		Prefs:     entity.Prefs,
+	}
+	if err := ctx.Read(&input); err != nil {
+		c.Logger.With(rctx).Info(err)
+		return errorshandler.BadRequest(err.Error())
+	}
+
+	if err = input.Validate(); err != nil {
+		return errorshandler.BadRequest(err.Error())
+	}
+
+	entity.Bio = input.Bio
+	entity.AvatarURL = input.AvatarURL
+	entity.Prefs = input.Prefs
+
+	if err = c.Service.Update(rctx, entity); err != nil {
+		c.Logger.With(rctx).Error(err)
+		return errorshandler.InternalServerError("")
+	}
+
+	return ctx.Write(me{
+		User:  entity,
+		Email:               entity.Email,
+		Prefs:               entity.Prefs,
+		Role:                entity.Role,
+		ModeratedCategories: entity.ModeratedCategories,
+	})
 }
 
 // list method is for a getting a list of all entities
@@ -11,15 +11,24 @@ import (
 const (
 	EntityName = "comment"
 	TableName  = "comment"
+	// DeletedBody is shown instead of the body of a deleted comment
+	DeletedBody = "[deleted]"
 )
 
 // Comment is the user entity
 type Comment struct {
-	ID     string    `gorm:"PRIMARY_KEY" json:"id"`
-	PostID string    `sql:"type:varchar REFERENCES post(id)" json:"postId"`
-	UserID uint      `sql:"type:int REFERENCES \"user\"(id)" json:"userId"`
-	User   user.User `gorm:"FOREIGNKEY:UserID;association_autoupdate:false" json:"author"`
-	Body   string    `json:"body"`
+	ID       string    `gorm:"PRIMARY_KEY" json:"id"`
+	PostID   string    `sql:"type:varchar REFERENCES post(id)" json:"postId"`
+	ParentID string    `sql:"type:varchar REFERENCES comment(id)" json:"parentId,omitempty"`
+	UserID   uint      `sql:"type:int REFERENCES \"user\"(id)" json:"userId"`
+	User     user.User `gorm:"FOREIGNKEY:UserID;association_autoupdate:false" json:"author"`
+	Body     string    `json:"body"`
+	Score    int       `json:"score"`
+	// Shadowed is set for the comments of a shadowbanned user, they are seen by the author, the moderators and the admins only
+	Shadowed bool `json:"-"`
+
+	Replies     []Comment `gorm:"-" bson:"-" json:"replies,omitempty"`
+	MoreReplies uint      `gorm:"-" bson:"-" json:"moreReplies,omitempty"`
 
 	CreatedAt time.Time  `json:"created"`
 	UpdatedAt time.Time  `json:"updated"`
@@ -33,6 +42,21 @@ func (e Comment) Validate() error {
 	)
 }
 
+// IsDeleted returns true if the comment has been deleted, a deleted comment is kept until it is purged
+func (e Comment) IsDeleted() bool {
+	return e.DeletedAt != nil
+}
+
+// Mask replaces the body and the author of a deleted comment by "[deleted]"
+func (e *Comment) Mask() {
+	if !e.IsDeleted() {
+		return
+	}
+	e.Body = DeletedBody
+	e.UserID = 0
+	e.User = user.User{Name: user.DeletedName}
+}
+
 func (e Comment) TableName() string {
 	return TableName
 }
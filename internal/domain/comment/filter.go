@@ -0,0 +1,49 @@
+package comment
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/pagination"
+)
+
+// cursorKey is the sort key of comments listings
+const cursorKey = "createdat"
+
+// Filter is the condition of a comments listing
+type Filter struct {
+	Comment
+	// Cursor limits the listing by the items which follow the cursor in the sort order
+	Cursor *pagination.Cursor
+}
+
+// NewCursor returns the cursor of the comment in a listing
+func NewCursor(entity Comment) pagination.Cursor {
+	return pagination.Cursor{
+		Key:   cursorKey,
+		Value: entity.CreatedAt.UnixNano(),
+		ID:    entity.ID,
+	}
+}
+
+// ParseCursor decodes the cursor of a listing and converts its value to the time
+func ParseCursor(s string) (*pagination.Cursor, error) {
+	c, err := pagination.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+
+	n, ok := c.Value.(json.Number)
+	if c.Key != cursorKey || !ok {
+		return nil, errors.Wrapf(apperror.ErrBadRequest, "invalid cursor: %q", s)
+	}
+	v, err := n.Int64()
+	if err != nil {
+		return nil, errors.Wrapf(apperror.ErrBadRequest, "invalid cursor: %q", s)
+	}
+	c.Value = time.Unix(0, v)
+	return c, nil
+}
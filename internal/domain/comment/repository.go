@@ -2,6 +2,7 @@ package comment
 
 import (
 	"context"
+	"time"
 
 	"github.com/minipkg/selection_condition"
 )
@@ -19,7 +20,23 @@ type Repository interface {
 	Create(ctx context.Context, entity *Comment) error
 	// Update updates the album with given ID in the storage.
 	Update(ctx context.Context, entity *Comment) error
-	// Delete removes the album with given ID from the storage.
+	// Delete marks the comment with given ID as deleted, the comment is kept until it is purged.
+	// It returns apperror.ErrNotFound if there is no such comment which is not deleted.
 	Delete(ctx context.Context, id string) error
+	// Restore removes the deletion mark of the comment with given ID.
+	// It returns apperror.ErrNotFound if there is no such deleted comment.
+	Restore(ctx context.Context, id string) error
+	// Purge removes from the storage the comments deleted before the time together with their votes.
+	// A comment with replies keeps its place in the tree, only its body and author are erased.
+	// It returns the number of the removed comments.
+	Purge(ctx context.Context, before time.Time) (uint, error)
+	// AnonymizeAuthor replaces the author of all the comments of the user by the deleted user.
+	AnonymizeAuthor(ctx context.Context, userID uint) error
+	// AuthorStats returns the number of the comments of the user and the sum of their scores, the deleted comments are not counted.
+	AuthorStats(ctx context.Context, userID uint) (count uint, karma int, err error)
+	// ChangeScore atomically changes the score of the comment by the diff.
+	ChangeScore(ctx context.Context, id string, diff int) error
+	// SetScore sets the score of the comment.
+	SetScore(ctx context.Context, id string, score int) error
 	//First(ctx context.Context, user *Comment) (*Comment, error)
 }
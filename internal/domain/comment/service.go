@@ -2,10 +2,17 @@ package comment
 
 import (
 	"context"
+	"time"
 
 	"github.com/minipkg/log"
 	"github.com/minipkg/selection_condition"
 	"github.com/pkg/errors"
+
+	"redditclone/internal/domain/modlog"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/auth"
+	"redditclone/internal/pkg/errorshandler"
+	"redditclone/internal/pkg/pagination"
 )
 
 const MaxLIstLimit = 1000
@@ -18,22 +25,36 @@ type IService interface {
 	List(ctx context.Context) ([]Comment, error)
 	//Count(ctx context.Context) (uint, error)
 	Create(ctx context.Context, entity *Comment) error
+	Thread(ctx context.Context, postId string, id string) (*Comment, error)
+	Page(ctx context.Context, postId string, params pagination.Params) (*pagination.Page, error)
 	//Update(ctx context.Context, id string, input *Comment) (*Comment, error)
 	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) error
 	//First(ctx context.Context, user *Comment) (*Comment, error)
 }
 
+// PostService is the part of the post service which is needed for comments.
+type PostService interface {
+	GetCategory(ctx context.Context, id string) (string, error)
+	GetActiveCategory(ctx context.Context, id string) (string, error)
+	CheckParticipation(ctx context.Context, category string) error
+}
+
 type service struct {
 	//Domain     Domain
-	logger     log.ILogger
-	repository Repository
+	logger        log.ILogger
+	repository    Repository
+	postService   PostService
+	modLogService modlog.IService
 }
 
 // NewService creates a new service.
-func NewService(logger log.ILogger, repo Repository) IService {
+func NewService(logger log.ILogger, repo Repository, postService PostService, modLogService modlog.IService) IService {
 	s := &service{
-		logger:     logger,
-		repository: repo,
+		logger:        logger,
+		repository:    repo,
+		postService:   postService,
+		modLogService: modLogService,
 	}
 	repo.SetDefaultConditions(s.defaultConditions())
 	return s
@@ -48,13 +69,13 @@ func (s *service) NewEntity() *Comment {
 	return &Comment{}
 }
 
-// Get returns the entity with the specified ID.
+// Get returns the entity with the specified ID, a deleted entity is shown as "[deleted]".
 func (s *service) Get(ctx context.Context, id string) (*Comment, error) {
 	entity, err := s.repository.Get(ctx, id)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Can not get a comment by id: %v", id)
 	}
-	return entity, nil
+	return &MaskDeleted(auth.CurrentSession(ctx), []Comment{*entity})[0], nil
 }
 
 /*
@@ -69,7 +90,7 @@ func (s *service) Query(ctx context.Context, offset, limit uint) ([]Comment, err
 	if err != nil {
 		return nil, errors.Wrapf(err, "Can not find a list of comments by ctx")
 	}
-	return items, nil
+	return MaskDeleted(auth.CurrentSession(ctx), items), nil
 }
 
 // List returns the items list.
@@ -78,13 +99,200 @@ func (s *service) List(ctx context.Context) ([]Comment, error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "Can not find a list of comments by ctx")
 	}
-	return items, nil
+	return MaskDeleted(auth.CurrentSession(ctx), items), nil
 }
 
+// Create saves a new entity. A reply must belong to the same post as its parent.
+// A deleted post or comment can not be commented. The comments of a shadowbanned user are shadowed.
 func (s *service) Create(ctx context.Context, entity *Comment) error {
+	if entity.ParentID != "" {
+		parent, err := s.repository.Get(ctx, entity.ParentID)
+		if err != nil {
+			if err == apperror.ErrNotFound {
+				return errorshandler.BadRequest("Parent comment not found")
+			}
+			return err
+		}
+		if parent.PostID != entity.PostID {
+			return errorshandler.BadRequest("Parent comment belongs to another post")
+		}
+		if parent.IsDeleted() {
+			return errorshandler.BadRequest("Parent comment is deleted")
+		}
+	}
+
+	category, err := s.postService.GetActiveCategory(ctx, entity.PostID)
+	if err != nil {
+		return err
+	}
+	if err = s.postService.CheckParticipation(ctx, category); err != nil {
+		return err
+	}
+	entity.Shadowed = auth.IsShadowbanned(auth.CurrentSession(ctx))
+
+	if entity.CreatedAt.IsZero() {
+		entity.CreatedAt = time.Now()
+		entity.UpdatedAt = entity.CreatedAt
+	}
 	return s.repository.Create(ctx, entity)
 }
 
+// Thread returns the entity with the specified ID with the tree of its replies, the deleted comments are shown as "[deleted]".
+func (s *service) Thread(ctx context.Context, postId string, id string) (*Comment, error) {
+	entity, err := s.repository.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if entity.PostID != postId {
+		return nil, apperror.ErrNotFound
+	}
+
+	items, err := s.repository.Query(ctx, selection_condition.SelectionCondition{
+		Where: &Comment{PostID: postId},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find a list of comments by post id: %v", postId)
+	}
+
+	entity.Replies = BuildTree(items, entity.ID, MaxTreeDepth)
+	thread, err := s.withoutShadowed(ctx, postId, []Comment{*entity})
+	if err != nil {
+		return nil, err
+	}
+	if len(thread) == 0 {
+		return nil, apperror.ErrNotFound
+	}
+	return &MaskDeleted(auth.CurrentSession(ctx), thread)[0], nil
+}
+
+// withoutShadowed removes the shadowed comments of the post which the current user is not allowed to see.
+// The category of the post is read only if there are shadowed comments.
+func (s *service) withoutShadowed(ctx context.Context, postId string, items []Comment) ([]Comment, error) {
+	if !HasShadowed(items) {
+		return items, nil
+	}
+
+	category, err := s.postService.GetCategory(ctx, postId)
+	if err != nil {
+		return nil, err
+	}
+	return WithoutShadowed(auth.CurrentSession(ctx), items, category), nil
+}
+
+// Delete deletes the entity with the specified ID if the current user is allowed to do it.
+// The entity is kept in the tree as "[deleted]" with its replies and votes until it is purged, so it can be restored.
 func (s *service) Delete(ctx context.Context, id string) error {
-	return s.repository.Delete(ctx, id)
+	entity, err := s.repository.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if entity.IsDeleted() {
+		return apperror.ErrNotFound
+	}
+
+	category, err := s.postService.GetCategory(ctx, entity.PostID)
+	if err != nil {
+		return err
+	}
+
+	if err = auth.CheckDeleteAccess(ctx, entity.UserID, category); err != nil {
+		return err
+	}
+	if err = s.repository.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	if !auth.IsAuthor(auth.CurrentSession(ctx), entity.UserID) {
+		s.modLogService.Record(ctx, modlog.NewEntry(modlog.ActionDeleteComment, modlog.TargetComment, entity.ID, category, "", entity, nil))
+	}
+	return nil
+}
+
+// Restore restores the deleted entity with the specified ID, only the admins are allowed to do it.
+func (s *service) Restore(ctx context.Context, id string) error {
+	if !auth.IsAdmin(auth.CurrentSession(ctx)) {
+		return errorshandler.Forbidden("")
+	}
+
+	entity, err := s.repository.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !entity.IsDeleted() {
+		return apperror.ErrNotFound
+	}
+
+	category, err := s.postService.GetCategory(ctx, entity.PostID)
+	if err != nil {
+		return err
+	}
+	if err = s.repository.Restore(ctx, id); err != nil {
+		return err
+	}
+
+	after := *entity
+	after.DeletedAt = nil
+	s.modLogService.Record(ctx, modlog.NewEntry(modlog.ActionRestoreComment, modlog.TargetComment, entity.ID, category, "", nil, after))
+	return nil
+}
+
+// Page returns the page of the comments of the post in the chronological order.
+// Pages are built by cursors, so they stay stable when new comments are added.
+func (s *service) Page(ctx context.Context, postId string, params pagination.Params) (*pagination.Page, error) {
+	if params.After != "" && params.Before != "" {
+		return nil, errors.Wrap(apperror.ErrBadRequest, "only one of the cursors can be given")
+	}
+
+	limit := params.GetLimit()
+	filter := &Filter{Comment: Comment{PostID: postId}}
+	direction := selection_condition.SortOrderAsc
+	isBackward := params.Before != ""
+	cursor := params.After
+	if isBackward {
+		direction = selection_condition.SortOrderDesc
+		cursor = params.Before
+	}
+
+	var err error
+	if cursor != "" {
+		if filter.Cursor, err = ParseCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	items, err := s.repository.Query(ctx, selection_condition.SelectionCondition{
+		Where:     filter,
+		SortOrder: []map[string]string{{cursorKey: direction}, {"id": direction}},
+		Limit:     limit + 1,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find a list of comments by post id: %v", postId)
+	}
+
+	hasMore := uint(len(items)) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+	if isBackward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	page := &pagination.Page{}
+	if len(items) > 0 {
+		if hasMore || isBackward {
+			page.Next = NewCursor(items[len(items)-1]).Encode()
+		}
+		if (hasMore && isBackward) || params.After != "" {
+			page.Prev = NewCursor(items[0]).Encode()
+		}
+	}
+
+	//	the cursors are built before, so the pages do not depend on the viewer
+	if items, err = s.withoutShadowed(ctx, postId, items); err != nil {
+		return nil, err
+	}
+	page.Items = MaskDeleted(auth.CurrentSession(ctx), items)
+	return page, nil
 }
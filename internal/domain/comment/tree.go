@@ -0,0 +1,85 @@
+package comment
+
+import (
+	"sort"
+
+	"redditclone/internal/pkg/auth"
+	"redditclone/internal/pkg/session"
+)
+
+// MaxTreeDepth is the max depth of a comments tree returned at once
+const MaxTreeDepth = 8
+
+// BuildTree builds a tree of comments from the flat list.
+// The roots of the tree are the comments with the ParentID equal to parentID.
+// Siblings are sorted by the score, the comments with equal score keep the original order.
+// Replies deeper than maxDepth are not included, for such comments MoreReplies contains the number of omitted direct replies.
+func BuildTree(items []Comment, parentID string, maxDepth uint) []Comment {
+	children := make(map[string][]Comment, len(items))
+	for _, item := range items {
+		children[item.ParentID] = append(children[item.ParentID], item)
+	}
+	return buildLevel(children, parentID, 1, maxDepth)
+}
+
+func buildLevel(children map[string][]Comment, parentID string, depth uint, maxDepth uint) []Comment {
+	items, ok := children[parentID]
+	if !ok {
+		return nil
+	}
+
+	res := make([]Comment, 0, len(items))
+	for _, item := range items {
+		if depth < maxDepth {
+			item.Replies = buildLevel(children, item.ID, depth+1, maxDepth)
+		} else {
+			item.MoreReplies = uint(len(children[item.ID]))
+		}
+		res = append(res, item)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Score > res[j].Score
+	})
+	return res
+}
+
+// WithoutShadowed returns the comments without the shadowed ones which the session is not allowed to see, the replies of such a comment go with it.
+// The comments are returned as is if there are no shadowed ones.
+func WithoutShadowed(sess *session.Session, items []Comment, category string) []Comment {
+	if !HasShadowed(items) {
+		return items
+	}
+
+	res := make([]Comment, 0, len(items))
+	for _, item := range items {
+		if item.Shadowed && !auth.CanSeeShadowed(sess, item.UserID, category) {
+			continue
+		}
+		item.Replies = WithoutShadowed(sess, item.Replies, category)
+		res = append(res, item)
+	}
+	return res
+}
+
+// MaskDeleted masks the deleted comments of the tree for the session, their replies are kept, so the tree keeps its structure.
+// The admins see the deleted comments as is.
+func MaskDeleted(sess *session.Session, items []Comment) []Comment {
+	if auth.IsAdmin(sess) {
+		return items
+	}
+	for i := range items {
+		items[i].Mask()
+		items[i].Replies = MaskDeleted(sess, items[i].Replies)
+	}
+	return items
+}
+
+// HasShadowed returns true if there is a shadowed comment in the tree.
+func HasShadowed(items []Comment) bool {
+	for _, item := range items {
+		if item.Shadowed || HasShadowed(item.Replies) {
+			return true
+		}
+	}
+	return false
+}
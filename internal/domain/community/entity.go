@@ -0,0 +1,102 @@
+package community
+
+import (
+	"regexp"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+
+	"redditclone/internal/domain/user"
+)
+
+const (
+	EntityName            = "community"
+	TableName             = "community"
+	SubscriptionTableName = "community_subscription"
+	BanTableName          = "community_ban"
+
+	// MaxRules is the max number of the rules of a community
+	MaxRules = 15
+)
+
+// nameRule is the format of the names of communities, they are used in the URLs of the post listings
+var nameRule = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Community is the community entity, the category of a post is the name of its community
+type Community struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Rules       []string  `json:"rules"`
+	OwnerID     uint      `json:"ownerId"`
+	Owner       user.User `json:"owner"`
+	Subscribers int       `json:"subscribers"`
+
+	CreatedAt time.Time `json:"created"`
+	UpdatedAt time.Time `json:"updated"`
+}
+
+// Subscription is the subscription of a user to a community
+type Subscription struct {
+	ID        string    `json:"id"`
+	Community string    `json:"community"`
+	UserID    uint      `json:"userId"`
+	CreatedAt time.Time `json:"created"`
+}
+
+// Ban is the ban of a user in a community, the user can not post, comment and vote in the community while it lasts
+type Ban struct {
+	ID          string `json:"id"`
+	Community   string `json:"community"`
+	UserID      uint   `json:"userId"`
+	ModeratorID uint   `json:"moderatorId"`
+	// Reason is shown to the banned user
+	Reason string `json:"reason"`
+	// Until is the end of a temporary ban, nil means a permanent ban
+	Until     *time.Time `json:"until,omitempty"`
+	CreatedAt time.Time  `json:"created"`
+}
+
+func (e Ban) Validate() error {
+	return validation.ValidateStruct(&e,
+		validation.Field(&e.UserID, validation.Required),
+		validation.Field(&e.Reason, validation.Required, validation.RuneLength(1, 500)),
+		validation.Field(&e.Until, validation.Min(time.Now())),
+	)
+}
+
+// IsActive returns true if the ban lasts at the time.
+func (e Ban) IsActive(now time.Time) bool {
+	return e.Until == nil || now.Before(*e.Until)
+}
+
+// Message returns the message about the ban which is shown to the banned user.
+func (e Ban) Message() string {
+	if e.Until != nil {
+		return "You are banned in the community " + e.Community + " until " + e.Until.Format(time.RFC3339) + ": " + e.Reason
+	}
+	return "You are banned in the community " + e.Community + ": " + e.Reason
+}
+
+func (e Community) Validate() error {
+	return validation.ValidateStruct(&e,
+		validation.Field(&e.Name, validation.Required, validation.Length(2, 21), validation.Match(nameRule).Error("must consist of lowercase English letters, digits and underscores and start with a letter")),
+		validation.Field(&e.Description, validation.Length(0, 500)),
+		validation.Field(&e.Rules, validation.Length(0, MaxRules), validation.Each(validation.Required, validation.Length(1, 300))),
+	)
+}
+
+// New func is a constructor for the Community
+func New() *Community {
+	return &Community{}
+}
+
+// Defaults are the communities which are created at the start if they do not exist, they are the former fixed categories of the posts
+var Defaults = []Community{
+	{Name: "music", Description: "Music of all genres and times"},
+	{Name: "funny", Description: "Jokes, memes and everything funny"},
+	{Name: "videos", Description: "Videos worth watching"},
+	{Name: "programming", Description: "Programming languages, tools and practices"},
+	{Name: "news", Description: "News from around the world"},
+	{Name: "fashion", Description: "Clothes, style and trends"},
+}
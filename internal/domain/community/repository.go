@@ -0,0 +1,39 @@
+package community
+
+import (
+	"context"
+
+	"github.com/minipkg/selection_condition"
+)
+
+// Repository encapsulates the logic to access communities and subscriptions from the data source.
+type Repository interface {
+	SetDefaultConditions(conditions selection_condition.SelectionCondition)
+	// Get returns the community with the specified name.
+	Get(ctx context.Context, name string) (*Community, error)
+	// Query returns the list of communities with the given sort order, offset and limit.
+	Query(ctx context.Context, cond selection_condition.SelectionCondition) ([]Community, error)
+	// Search returns the communities which name or description contains the text, the most subscribed ones go first.
+	Search(ctx context.Context, text string, offset, limit uint) ([]Community, error)
+	// Create saves a new community in the storage.
+	// It returns apperror.ErrConflict if a community with the name already exists.
+	Create(ctx context.Context, entity *Community) error
+	// Subscribe saves the subscription and increments the number of the subscribers of the community.
+	// It returns apperror.ErrConflict if the user is already subscribed.
+	Subscribe(ctx context.Context, entity *Subscription) error
+	// Unsubscribe deletes the subscription and decrements the number of the subscribers of the community.
+	// It returns apperror.ErrNotFound if the user is not subscribed.
+	Unsubscribe(ctx context.Context, name string, userID uint) error
+	// Subscriptions returns the communities which the user is subscribed to.
+	Subscriptions(ctx context.Context, userID uint) ([]Community, error)
+	// CreateBan saves a new ban of a user in a community.
+	// It returns apperror.ErrConflict if the user is already banned in the community.
+	CreateBan(ctx context.Context, entity *Ban) error
+	// DeleteBan deletes the ban of the user in the community.
+	// It returns apperror.ErrNotFound if the user is not banned.
+	DeleteBan(ctx context.Context, name string, userID uint) error
+	// GetBan returns the ban of the user in the community, an expired ban is returned as well.
+	GetBan(ctx context.Context, name string, userID uint) (*Ban, error)
+	// Bans returns the bans in the community, the latest ones go first.
+	Bans(ctx context.Context, name string) ([]Ban, error)
+}
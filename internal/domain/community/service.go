@@ -0,0 +1,283 @@
+package community
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/minipkg/log"
+	"github.com/minipkg/selection_condition"
+
+	"redditclone/internal/domain/modlog"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/auth"
+	"redditclone/internal/pkg/errorshandler"
+)
+
+const (
+	MaxLIstLimit = 100
+	// DefaultListLimit is the number of communities in a list if a limit is not given
+	DefaultListLimit = 25
+)
+
+// IService encapsulates usecase logic for community.
+type IService interface {
+	NewEntity() *Community
+	Get(ctx context.Context, name string) (*Community, error)
+	List(ctx context.Context, offset, limit uint) ([]Community, error)
+	Search(ctx context.Context, text string, offset, limit uint) ([]Community, error)
+	Create(ctx context.Context, entity *Community) error
+	Subscribe(ctx context.Context, name string) (*Community, error)
+	Unsubscribe(ctx context.Context, name string) (*Community, error)
+	Subscriptions(ctx context.Context) ([]Community, error)
+	Seed(ctx context.Context) (uint, error)
+	NewBanEntity() *Ban
+	Ban(ctx context.Context, name string, entity *Ban) error
+	Unban(ctx context.Context, name string, userID uint) error
+	Bans(ctx context.Context, name string) ([]Ban, error)
+}
+
+type service struct {
+	logger        log.ILogger
+	repository    Repository
+	modLogService modlog.IService
+}
+
+// NewService creates a new service.
+func NewService(logger log.ILogger, repo Repository, modLogService modlog.IService) IService {
+	s := &service{
+		logger:        logger,
+		repository:    repo,
+		modLogService: modLogService,
+	}
+	repo.SetDefaultConditions(s.defaultConditions())
+	return s
+}
+
+// Defaults returns defaults params
+func (s *service) defaultConditions() selection_condition.SelectionCondition {
+	return selection_condition.SelectionCondition{
+		SortOrder: []map[string]string{
+			{"subscribers": selection_condition.SortOrderDesc},
+			{"name": selection_condition.SortOrderAsc},
+		},
+		Limit: DefaultListLimit,
+	}
+}
+
+func (s *service) NewEntity() *Community {
+	return &Community{}
+}
+
+// Get returns the community with the specified name.
+func (s *service) Get(ctx context.Context, name string) (*Community, error) {
+	entity, err := s.repository.Get(ctx, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not get a community by name: %q", name)
+	}
+	return entity, nil
+}
+
+// List returns the communities with the specified offset and limit, the most subscribed ones go first.
+func (s *service) List(ctx context.Context, offset, limit uint) ([]Community, error) {
+	cond := selection_condition.SelectionCondition{
+		SortOrder: s.defaultConditions().SortOrder,
+		Offset:    offset,
+		Limit:     limitOrDefault(limit),
+	}
+	items, err := s.repository.Query(ctx, cond)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find a list of communities by query: %v", cond)
+	}
+	return items, nil
+}
+
+// Search returns the communities which name or description contains the text.
+func (s *service) Search(ctx context.Context, text string, offset, limit uint) ([]Community, error) {
+	items, err := s.repository.Search(ctx, text, offset, limitOrDefault(limit))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not search communities by text: %q", text)
+	}
+	return items, nil
+}
+
+func limitOrDefault(limit uint) uint {
+	if limit == 0 {
+		return DefaultListLimit
+	}
+	if limit > MaxLIstLimit {
+		return MaxLIstLimit
+	}
+	return limit
+}
+
+// Create saves a new community, the current user becomes its owner.
+// It returns apperror.ErrConflict if a community with the name already exists.
+func (s *service) Create(ctx context.Context, entity *Community) error {
+	if sess := auth.CurrentSession(ctx); sess != nil {
+		entity.OwnerID = sess.UserID
+		entity.Owner = sess.User
+	}
+	if err := entity.Validate(); err != nil {
+		return errors.Wrapf(apperror.ErrBadRequest, "%v", err)
+	}
+
+	entity.Subscribers = 0
+	if entity.CreatedAt.IsZero() {
+		entity.CreatedAt = time.Now()
+		entity.UpdatedAt = entity.CreatedAt
+	}
+	if err := s.repository.Create(ctx, entity); err != nil {
+		return errors.Wrapf(err, "Can not create a community: %q", entity.Name)
+	}
+	return nil
+}
+
+// Subscribe subscribes the current user to the community, subscribing again changes nothing.
+func (s *service) Subscribe(ctx context.Context, name string) (*Community, error) {
+	entity, err := s.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repository.Subscribe(ctx, &Subscription{
+		Community: entity.Name,
+		UserID:    auth.CurrentSession(ctx).UserID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		if errors.Is(err, apperror.ErrConflict) {
+			return entity, nil
+		}
+		return nil, errors.Wrapf(err, "Can not subscribe to a community: %q", name)
+	}
+	entity.Subscribers++
+	return entity, nil
+}
+
+// Unsubscribe unsubscribes the current user from the community, unsubscribing again changes nothing.
+func (s *service) Unsubscribe(ctx context.Context, name string) (*Community, error) {
+	entity, err := s.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repository.Unsubscribe(ctx, entity.Name, auth.CurrentSession(ctx).UserID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return entity, nil
+		}
+		return nil, errors.Wrapf(err, "Can not unsubscribe from a community: %q", name)
+	}
+	entity.Subscribers--
+	return entity, nil
+}
+
+// Subscriptions returns the communities which the current user is subscribed to.
+func (s *service) Subscriptions(ctx context.Context) ([]Community, error) {
+	userID := auth.CurrentSession(ctx).UserID
+	items, err := s.repository.Subscriptions(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find subscriptions of user id: %v", userID)
+	}
+	return items, nil
+}
+
+// Seed creates the default communities which do not exist yet and returns the number of the created ones.
+// It is safe to run it repeatedly.
+func (s *service) Seed(ctx context.Context) (uint, error) {
+	var created uint
+	for _, d := range Defaults {
+		entity := d
+		entity.Rules = []string{}
+		err := s.Create(ctx, &entity)
+		if err != nil {
+			if errors.Is(err, apperror.ErrConflict) {
+				continue
+			}
+			return created, errors.Wrapf(err, "Can not seed the communities")
+		}
+		created++
+	}
+	return created, nil
+}
+
+func (s *service) NewBanEntity() *Ban {
+	return &Ban{}
+}
+
+// Ban bans the user in the community, only a moderator of the community or an admin are allowed to do it.
+// An expired ban of the user is replaced, it returns apperror.ErrConflict if the user is already banned.
+func (s *service) Ban(ctx context.Context, name string, entity *Ban) error {
+	if err := s.checkModeration(ctx, name); err != nil {
+		return err
+	}
+
+	entity.Community = name
+	entity.ModeratorID = auth.CurrentSession(ctx).UserID
+	entity.CreatedAt = time.Now()
+	if err := entity.Validate(); err != nil {
+		return errors.Wrapf(apperror.ErrBadRequest, "%v", err)
+	}
+
+	err := s.repository.CreateBan(ctx, entity)
+	if errors.Is(err, apperror.ErrConflict) {
+		prev, er := s.repository.GetBan(ctx, name, entity.UserID)
+		if er != nil || prev.IsActive(entity.CreatedAt) {
+			return err
+		}
+		if err = s.repository.DeleteBan(ctx, name, entity.UserID); err != nil && !errors.Is(err, apperror.ErrNotFound) {
+			return errors.Wrapf(err, "Can not delete an expired ban of user id: %v in community: %q", entity.UserID, name)
+		}
+		err = s.repository.CreateBan(ctx, entity)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Can not ban user id: %v in community: %q", entity.UserID, name)
+	}
+
+	s.modLogService.Record(ctx, modlog.NewEntry(modlog.ActionBanUser, modlog.TargetUser, strconv.FormatUint(uint64(entity.UserID), 10), name, entity.Reason, nil, entity))
+	return nil
+}
+
+// Unban lifts the ban of the user in the community, only a moderator of the community or an admin are allowed to do it.
+func (s *service) Unban(ctx context.Context, name string, userID uint) error {
+	if err := s.checkModeration(ctx, name); err != nil {
+		return err
+	}
+
+	ban, err := s.repository.GetBan(ctx, name, userID)
+	if err != nil {
+		return errors.Wrapf(err, "Can not get a ban of user id: %v in community: %q", userID, name)
+	}
+	if err = s.repository.DeleteBan(ctx, name, userID); err != nil {
+		return errors.Wrapf(err, "Can not unban user id: %v in community: %q", userID, name)
+	}
+
+	s.modLogService.Record(ctx, modlog.NewEntry(modlog.ActionUnbanUser, modlog.TargetUser, strconv.FormatUint(uint64(userID), 10), name, "", ban, nil))
+	return nil
+}
+
+// Bans returns the bans in the community, only a moderator of the community or an admin are allowed to see them.
+func (s *service) Bans(ctx context.Context, name string) ([]Ban, error) {
+	if err := s.checkModeration(ctx, name); err != nil {
+		return nil, err
+	}
+
+	items, err := s.repository.Bans(ctx, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find bans in community: %q", name)
+	}
+	return items, nil
+}
+
+// checkModeration checks that the community exists and the current user is its moderator or an admin.
+func (s *service) checkModeration(ctx context.Context, name string) error {
+	sess := auth.CurrentSession(ctx)
+	if !auth.IsModerator(sess, name) && !auth.IsAdmin(sess) {
+		return errorshandler.Forbidden("")
+	}
+	_, err := s.Get(ctx, name)
+	return err
+}
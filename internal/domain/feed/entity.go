@@ -0,0 +1,31 @@
+package feed
+
+import (
+	"time"
+)
+
+const (
+	EntityName = "feed"
+	// FollowTableName is the table of the follows of the users by other users
+	FollowTableName = "follow"
+)
+
+// Follow is the following of a user by another user, the posts of the followed users are shown in the feed
+type Follow struct {
+	ID           string    `json:"id"`
+	UserID       uint      `json:"userId"`
+	FollowedID   uint      `json:"followedId"`
+	FollowedName string    `json:"followedName"`
+	CreatedAt    time.Time `json:"created"`
+}
+
+// Config is the config of the feeds.
+// The feeds of the heavy users, which have many sources, are read from the precomputed timelines instead of the posts of all the sources.
+type Config struct {
+	// TimelineThreshold is the number of the subscriptions and the follows of a heavy user. Zero disables the timelines
+	TimelineThreshold uint
+	// TimelineSize is the number of the posts of a timeline, the later pages are read from the sources. Defaults to 500
+	TimelineSize uint
+	// TimelineLifeTime is the lifetime of a timeline in minutes, the new posts appear in the feed after that. Defaults to 5
+	TimelineLifeTime uint
+}
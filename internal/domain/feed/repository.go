@@ -0,0 +1,32 @@
+package feed
+
+import (
+	"context"
+	"time"
+
+	"redditclone/internal/domain/post"
+)
+
+// Repository encapsulates the logic to access the follows from the data source.
+type Repository interface {
+	// Follow saves the follow.
+	// It returns apperror.ErrConflict if the user already follows the followed user.
+	Follow(ctx context.Context, entity *Follow) error
+	// Unfollow deletes the follow.
+	// It returns apperror.ErrNotFound if the user does not follow the followed user.
+	Unfollow(ctx context.Context, userID, followedID uint) error
+	// Following returns the follows of the user ordered by the name of the followed user.
+	Following(ctx context.Context, userID uint) ([]Follow, error)
+}
+
+// TimelineRepository encapsulates the logic to access the precomputed timelines.
+// A timeline is kept for each ranking of the feed of a user.
+type TimelineRepository interface {
+	// Get returns the timeline of the user for the ranking.
+	// It returns apperror.ErrNotFound if the timeline does not exist or has expired.
+	Get(ctx context.Context, userID uint, ranking string) ([]post.Post, error)
+	// Set saves the timeline of the user for the ranking, all the timelines of the user expire after the lifetime.
+	Set(ctx context.Context, userID uint, ranking string, items []post.Post, lifeTime time.Duration) error
+	// Delete deletes all the timelines of the user.
+	Delete(ctx context.Context, userID uint) error
+}
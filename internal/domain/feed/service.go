@@ -0,0 +1,251 @@
+package feed
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/minipkg/log"
+
+	"redditclone/internal/domain/community"
+	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/auth"
+	"redditclone/internal/pkg/pagination"
+)
+
+const (
+	defaultTimelineSize     = 500
+	defaultTimelineLifeTime = 5
+)
+
+// IService encapsulates usecase logic for feed.
+type IService interface {
+	Follow(ctx context.Context, followed *user.User) (*Follow, error)
+	Unfollow(ctx context.Context, followed *user.User) error
+	Following(ctx context.Context) ([]Follow, error)
+	Page(ctx context.Context, sort string, period string, params pagination.Params) (*pagination.Page, error)
+}
+
+type service struct {
+	logger              log.ILogger
+	cfg                 Config
+	repository          Repository
+	timelineRepository  TimelineRepository
+	postService         post.IService
+	communityRepository community.Repository
+}
+
+// NewService creates a new service.
+func NewService(logger log.ILogger, cfg Config, repo Repository, timelineRepo TimelineRepository, postService post.IService, communityRepo community.Repository) IService {
+	if cfg.TimelineSize == 0 {
+		cfg.TimelineSize = defaultTimelineSize
+	}
+	if cfg.TimelineLifeTime == 0 {
+		cfg.TimelineLifeTime = defaultTimelineLifeTime
+	}
+	return &service{
+		logger:              logger,
+		cfg:                 cfg,
+		repository:          repo,
+		timelineRepository:  timelineRepo,
+		postService:         postService,
+		communityRepository: communityRepo,
+	}
+}
+
+// Follow makes the current user a follower of the user, following again changes nothing.
+func (s *service) Follow(ctx context.Context, followed *user.User) (*Follow, error) {
+	userID := auth.CurrentSession(ctx).UserID
+	if followed.ID == userID {
+		return nil, errors.Wrap(apperror.ErrBadRequest, "can not follow yourself")
+	}
+
+	entity := &Follow{
+		UserID:       userID,
+		FollowedID:   followed.ID,
+		FollowedName: followed.Name,
+		CreatedAt:    time.Now(),
+	}
+	if err := s.repository.Follow(ctx, entity); err != nil && !errors.Is(err, apperror.ErrConflict) {
+		return nil, errors.Wrapf(err, "Can not follow user id: %v", followed.ID)
+	}
+
+	s.dropTimelines(ctx, userID)
+	return entity, nil
+}
+
+// Unfollow stops the following of the user by the current user, unfollowing again changes nothing.
+func (s *service) Unfollow(ctx context.Context, followed *user.User) error {
+	userID := auth.CurrentSession(ctx).UserID
+	if err := s.repository.Unfollow(ctx, userID, followed.ID); err != nil && !errors.Is(err, apperror.ErrNotFound) {
+		return errors.Wrapf(err, "Can not unfollow user id: %v", followed.ID)
+	}
+
+	s.dropTimelines(ctx, userID)
+	return nil
+}
+
+// Following returns the follows of the current user.
+func (s *service) Following(ctx context.Context) ([]Follow, error) {
+	userID := auth.CurrentSession(ctx).UserID
+	items, err := s.repository.Following(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find follows of user id: %v", userID)
+	}
+	return items, nil
+}
+
+// dropTimelines deletes the timelines of the user after the change of the sources, so they are precomputed again.
+// The feed is still correct without it after the timelines expire, so an error is only logged.
+func (s *service) dropTimelines(ctx context.Context, userID uint) {
+	if s.cfg.TimelineThreshold == 0 {
+		return
+	}
+	if err := s.timelineRepository.Delete(ctx, userID); err != nil {
+		s.logger.With(ctx).Error(errors.Wrapf(err, "Can not delete timelines of user id: %v", userID))
+	}
+}
+
+// Page returns the page of the posts of the communities which the current user is subscribed to and of the followed users.
+// The posts are ordered by the ranking with the specified name, the newest posts go first by default.
+// The first pages of the feed of a heavy user are read from the precomputed timeline,
+// the others are read from the posts of all the sources like the feeds of the other users.
+func (s *service) Page(ctx context.Context, sort string, period string, params pagination.Params) (*pagination.Page, error) {
+	userID := auth.CurrentSession(ctx).UserID
+	if sort == "" {
+		sort = post.SortNew
+	}
+	if params.After != "" && params.Before != "" {
+		return nil, errors.Wrap(apperror.ErrBadRequest, "only one of the cursors can be given")
+	}
+	key, err := post.SortKey(sort, period)
+	if err != nil {
+		return nil, err
+	}
+	ranking := sort
+	if period != "" {
+		ranking += "_" + period
+	}
+
+	var timeline []post.Post
+	if s.cfg.TimelineThreshold > 0 {
+		timeline, err = s.timelineRepository.Get(ctx, userID, ranking)
+		switch {
+		case err == nil:
+			if page, ok := s.timelinePage(timeline, key, params); ok {
+				return page, nil
+			}
+		case !errors.Is(err, apperror.ErrNotFound):
+			s.logger.With(ctx).Error(errors.Wrapf(err, "Can not get timeline of user id: %v", userID))
+		}
+	}
+
+	sources, err := s.sources(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if timeline == nil && s.cfg.TimelineThreshold > 0 && uint(sources.Len()) >= s.cfg.TimelineThreshold {
+		timeline, err = s.postService.Timeline(ctx, *sources, sort, period, s.cfg.TimelineSize)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Can not precompute timeline of user id: %v", userID)
+		}
+		if err = s.timelineRepository.Set(ctx, userID, ranking, timeline, time.Duration(s.cfg.TimelineLifeTime)*time.Minute); err != nil {
+			s.logger.With(ctx).Error(errors.Wrapf(err, "Can not save timeline of user id: %v", userID))
+		}
+		if page, ok := s.timelinePage(timeline, key, params); ok {
+			return page, nil
+		}
+	}
+
+	page, err := s.postService.Feed(ctx, *sources, sort, period, params)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not get feed of user id: %v", userID)
+	}
+	return page, nil
+}
+
+// sources returns the names of the communities which the user is subscribed to and the IDs of the followed users.
+func (s *service) sources(ctx context.Context, userID uint) (*post.Sources, error) {
+	communities, err := s.communityRepository.Subscriptions(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find subscriptions of user id: %v", userID)
+	}
+	follows, err := s.repository.Following(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find follows of user id: %v", userID)
+	}
+
+	sources := &post.Sources{
+		Categories: make([]string, 0, len(communities)),
+		UserIDs:    make([]uint, 0, len(follows)),
+	}
+	for _, c := range communities {
+		sources.Categories = append(sources.Categories, c.Name)
+	}
+	for _, f := range follows {
+		sources.UserIDs = append(sources.UserIDs, f.FollowedID)
+	}
+	return sources, nil
+}
+
+// timelinePage returns the page of the timeline by the cursor which points to a post of the timeline.
+// It returns false if the page is not in the timeline, then the page has to be read from the sources.
+// The cursors are the same as the cursors of the pages read from the sources, so a feed is turned over through both of them.
+func (s *service) timelinePage(timeline []post.Post, key string, params pagination.Params) (*pagination.Page, bool) {
+	limit := int(params.GetLimit())
+	// a full timeline is cut off, the posts which follow it are read from the sources
+	isFull := uint(len(timeline)) >= s.cfg.TimelineSize
+	start, end := 0, limit
+
+	if cursor := params.After + params.Before; cursor != "" {
+		c, err := post.ParseCursor(cursor, key)
+		if err != nil {
+			return nil, false
+		}
+		i := indexOf(timeline, c.ID)
+		if i < 0 {
+			return nil, false
+		}
+
+		if params.After != "" {
+			start, end = i+1, i+1+limit
+		} else {
+			start, end = i-limit, i
+			if start < 0 {
+				start = 0
+			}
+		}
+	}
+
+	if end > len(timeline) {
+		if isFull {
+			return nil, false
+		}
+		end = len(timeline)
+	}
+
+	items := timeline[start:end]
+	page := &pagination.Page{Items: items}
+	if len(items) > 0 {
+		if end < len(timeline) || isFull {
+			page.Next = post.NewCursor(items[len(items)-1], key).Encode()
+		}
+		if start > 0 {
+			page.Prev = post.NewCursor(items[0], key).Encode()
+		}
+	}
+	return page, true
+}
+
+func indexOf(items []post.Post, id string) int {
+	for i := range items {
+		if items[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
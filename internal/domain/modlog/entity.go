@@ -0,0 +1,115 @@
+package modlog
+
+import (
+	"encoding/json"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	EntityName = "modlog"
+	TableName  = "mod_log"
+
+	ActionDeletePost    = "delete_post"
+	ActionDeleteComment = "delete_comment"
+	ActionApproveReport = "approve_report"
+	ActionRemoveReport  = "remove_report"
+	ActionDismissReport = "dismiss_report"
+	// ActionBanUser is a ban of a user in a community
+	ActionBanUser = "ban_user"
+	// ActionUnbanUser is a lifting of a ban of a user in a community
+	ActionUnbanUser = "unban_user"
+	// ActionSetAccountState is a suspension, a ban, a shadowban of an account or a lifting of them
+	ActionSetAccountState = "set_account_state"
+	// ActionRestorePost and ActionRestoreComment are the restorations of the deleted posts and comments by the admins
+	ActionRestorePost    = "restore_post"
+	ActionRestoreComment = "restore_comment"
+
+	TargetPost    = "post"
+	TargetComment = "comment"
+	TargetUser    = "user"
+)
+
+var Actions []interface{} = []interface{}{
+	ActionDeletePost,
+	ActionDeleteComment,
+	ActionApproveReport,
+	ActionRemoveReport,
+	ActionDismissReport,
+	ActionBanUser,
+	ActionUnbanUser,
+	ActionSetAccountState,
+	ActionRestorePost,
+	ActionRestoreComment,
+}
+
+var TargetTypes []interface{} = []interface{}{
+	TargetPost,
+	TargetComment,
+	TargetUser,
+}
+
+// Entry is a record of the moderation log about an action of a moderator or an admin, the entries are never changed
+type Entry struct {
+	ID string `json:"id"`
+	// ActorID is the moderator or the admin who acted, 0 means the command line
+	ActorID    uint   `json:"actorId"`
+	ActorName  string `json:"actorName"`
+	Action     string `json:"action"`
+	TargetType string `json:"targetType"`
+	TargetID   string `json:"targetId"`
+	// Category is the community of the target, the account actions have none and are seen by the admins only
+	Category string `json:"category,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	// Before and After are the JSON snapshots of the target around the action
+	Before    json.RawMessage `bson:",omitempty" json:"before,omitempty"`
+	After     json.RawMessage `bson:",omitempty" json:"after,omitempty"`
+	CreatedAt time.Time       `json:"created"`
+}
+
+// NewEntry returns a new entry about the action on the target, the snapshots are taken of the given states of the target, nil gives no snapshot.
+func NewEntry(action string, targetType string, targetID string, category string, reason string, before interface{}, after interface{}) *Entry {
+	return &Entry{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Category:   category,
+		Reason:     reason,
+		Before:     snapshot(before),
+		After:      snapshot(after),
+	}
+}
+
+// snapshot returns the JSON of the entity, nil is returned for nil and for an entity which can not be encoded
+func snapshot(entity interface{}) json.RawMessage {
+	if entity == nil {
+		return nil
+	}
+	b, err := json.Marshal(entity)
+	if err != nil || string(b) == "null" {
+		return nil
+	}
+	return b
+}
+
+// Filter is the condition of a listing of the moderation log
+type Filter struct {
+	ActorID    uint
+	Action     string
+	TargetType string
+	TargetID   string
+	// Categories limit the entries by the categories, nil means all the entries
+	Categories []string
+	// From and To limit the time of the entries including the bounds, the zero time is no limit
+	From time.Time
+	To   time.Time
+}
+
+func (f Filter) Validate() error {
+	return validation.ValidateStruct(&f,
+		validation.Field(&f.Action, validation.In(Actions...)),
+		validation.Field(&f.TargetType, validation.In(TargetTypes...)),
+		validation.Field(&f.To, validation.When(!f.From.IsZero() && !f.To.IsZero(), validation.Min(f.From).Error("must be no earlier than from"))),
+	)
+}
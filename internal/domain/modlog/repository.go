@@ -0,0 +1,16 @@
+package modlog
+
+import (
+	"context"
+
+	"github.com/minipkg/selection_condition"
+)
+
+// Repository encapsulates the logic to access the moderation log from the data source.
+// The log is append-only, so there are no methods to change or to delete an entry.
+type Repository interface {
+	// Create appends a new entry to the log.
+	Create(ctx context.Context, entity *Entry) error
+	// Query returns the list of the entries with the given offset and limit, the where part of the condition is *Filter.
+	Query(ctx context.Context, cond selection_condition.SelectionCondition) ([]Entry, error)
+}
@@ -0,0 +1,142 @@
+package modlog
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/minipkg/log"
+	"github.com/minipkg/selection_condition"
+
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/auth"
+	"redditclone/internal/pkg/errorshandler"
+)
+
+const (
+	MaxLimit = 100
+	// DefaultLimit is the number of the entries in a list if a limit is not given
+	DefaultLimit = 50
+	// exportBatch is the number of the entries which are read at once by an export
+	exportBatch = 500
+)
+
+// IService encapsulates usecase logic for the moderation log.
+type IService interface {
+	Record(ctx context.Context, entity *Entry)
+	RecordAccountState(ctx context.Context, entity *user.User, prev user.AccountState)
+	Query(ctx context.Context, filter Filter, offset, limit uint) ([]Entry, error)
+	Export(ctx context.Context, filter Filter, w io.Writer) (uint, error)
+}
+
+type service struct {
+	logger     log.ILogger
+	repository Repository
+}
+
+var _ auth.ModLogService = (*service)(nil)
+
+// NewService creates a new service.
+func NewService(logger log.ILogger, repo Repository) IService {
+	return &service{
+		logger:     logger,
+		repository: repo,
+	}
+}
+
+// Record appends the entry to the log, the current user is the actor, the command line is the actor if there is no session.
+// The action is already done, so a failure is logged and the action is not failed by it.
+func (s *service) Record(ctx context.Context, entity *Entry) {
+	if sess := auth.CurrentSession(ctx); sess != nil {
+		entity.ActorID = sess.UserID
+		entity.ActorName = sess.Data.UserName
+	}
+	entity.CreatedAt = time.Now()
+
+	if err := s.repository.Create(ctx, entity); err != nil {
+		s.logger.With(ctx).Errorf("Can not record the moderation log entry: %v, error: %v", entity, err)
+	}
+}
+
+// RecordAccountState appends the change of the state of the account of the user to the log.
+func (s *service) RecordAccountState(ctx context.Context, entity *user.User, prev user.AccountState) {
+	s.Record(ctx, NewEntry(ActionSetAccountState, TargetUser, strconv.FormatUint(uint64(entity.ID), 10), "", entity.State.Reason, prev, entity.State))
+}
+
+// Query returns the entries with the specified offset and limit, the newest ones go first.
+// A moderator gets the entries of the moderated categories only, an admin gets all the entries.
+func (s *service) Query(ctx context.Context, filter Filter, offset, limit uint) ([]Entry, error) {
+	sess := auth.CurrentSession(ctx)
+	if !auth.IsAdmin(sess) {
+		if len(filter.Categories) == 0 {
+			filter.Categories = auth.ModeratedCategories(sess)
+		}
+		if len(filter.Categories) == 0 {
+			return nil, errorshandler.Forbidden("")
+		}
+		for _, category := range filter.Categories {
+			if !auth.IsModerator(sess, category) {
+				return nil, errorshandler.Forbidden("")
+			}
+		}
+	}
+
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	cond := selection_condition.SelectionCondition{
+		Where: &filter,
+		SortOrder: []map[string]string{
+			{"createdat": selection_condition.SortOrderDesc},
+			{"id": selection_condition.SortOrderDesc},
+		},
+		Offset: offset,
+		Limit:  limit,
+	}
+	items, err := s.repository.Query(ctx, cond)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find a list of moderation log entries by query: %v", cond)
+	}
+	return items, nil
+}
+
+// Export writes the entries to w in the chronological order as JSON lines and returns the number of the written entries.
+// The log is append-only, so the pages stay stable while the entries are read. The caller is trusted, there are no access checks.
+func (s *service) Export(ctx context.Context, filter Filter, w io.Writer) (uint, error) {
+	var count uint
+	enc := json.NewEncoder(w)
+
+	cond := selection_condition.SelectionCondition{
+		Where: &filter,
+		SortOrder: []map[string]string{
+			{"createdat": selection_condition.SortOrderAsc},
+			{"id": selection_condition.SortOrderAsc},
+		},
+		Limit: exportBatch,
+	}
+	for {
+		items, err := s.repository.Query(ctx, cond)
+		if err != nil {
+			return count, errors.Wrapf(err, "Can not find a list of moderation log entries by query: %v", cond)
+		}
+
+		for i := range items {
+			if err = enc.Encode(items[i]); err != nil {
+				return count, errors.Wrapf(err, "Can not write the moderation log entry id: %v", items[i].ID)
+			}
+			count++
+		}
+		if len(items) < exportBatch {
+			return count, nil
+		}
+		cond.Offset += exportBatch
+	}
+}
@@ -0,0 +1,66 @@
+package post
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/pagination"
+)
+
+// NewCursor returns the cursor of the post in a listing sorted by the key
+func NewCursor(entity Post, key string) pagination.Cursor {
+	var value interface{}
+	switch key {
+	case "hot":
+		value = entity.Hot
+	case "controversy":
+		value = entity.Controversy
+	case "rising":
+		value = entity.Rising
+	case "score":
+		value = entity.Score
+	case "createdat":
+		value = entity.CreatedAt.UnixNano()
+	}
+	return pagination.Cursor{
+		Key:   key,
+		Value: value,
+		ID:    entity.ID,
+	}
+}
+
+// ParseCursor decodes the cursor of a listing sorted by the key and converts its value to the type of the key
+func ParseCursor(s string, key string) (*pagination.Cursor, error) {
+	c, err := pagination.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if c.Key != key {
+		return nil, errors.Wrapf(apperror.ErrBadRequest, "cursor of another sort order: %q", c.Key)
+	}
+
+	n, ok := c.Value.(json.Number)
+	if !ok {
+		return nil, errors.Wrapf(apperror.ErrBadRequest, "invalid cursor value: %v", c.Value)
+	}
+
+	switch key {
+	case "hot", "controversy", "rising":
+		c.Value, err = n.Float64()
+	case "score":
+		var v int64
+		v, err = n.Int64()
+		c.Value = int(v)
+	case "createdat":
+		var v int64
+		v, err = n.Int64()
+		c.Value = time.Unix(0, v)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(apperror.ErrBadRequest, "invalid cursor value: %v", c.Value)
+	}
+	return c, nil
+}
@@ -23,6 +23,9 @@ const (
 	CategoryProgramming = "programming"
 	CategoryNews        = "news"
 	CategoryFashion     = "fashion"
+
+	// DeletedTitle is shown instead of the title of a deleted post
+	DeletedTitle = "[deleted]"
 )
 
 var Types []interface{} = []interface{}{
@@ -30,13 +33,13 @@ var Types []interface{} = []interface{}{
 	TypeLink,
 }
 
-var Categories []string = []string{
-	CategoryMusic,
-	CategoryFunny,
-	CategoryVideos,
-	CategoryProgramming,
-	CategoryNews,
-	CategoryFashion,
+// AuthorStats are the numbers of the posts and the comments of a user and the karma got for them.
+// The karma is the sum of the scores, which are kept equal to the sums of the votes.
+type AuthorStats struct {
+	PostCount    uint `json:"postCount"`
+	PostKarma    int  `json:"postKarma"`
+	CommentCount uint `json:"commentCount"`
+	CommentKarma int  `json:"commentKarma"`
 }
 
 // Post is the user entity
@@ -50,11 +53,21 @@ type Post struct {
 	Text     string `json:"text,omitempty"`
 	Link     string `gorm:"type:varchar(100)" json:"link,omitempty"`
 
+	Ups         int     `json:"ups"`
+	Downs       int     `json:"downs"`
+	Hot         float64 `json:"-"`
+	Controversy float64 `json:"-"`
+	Rising      float64 `json:"-"`
+
 	UserID uint      `sql:"type:int REFERENCES \"user\"(id)" json:"userId"`
 	User   user.User `gorm:"FOREIGNKEY:UserID;association_autoupdate:false" json:"author"`
 
 	Votes    []vote.Vote       `gorm:"FOREIGNKEY:PostID" json:"votes"`
 	Comments []comment.Comment `gorm:"FOREIGNKEY:PostID" json:"comments"`
+	// Reports is the number of the open reports of the post, it is shown to the moderators of the category only
+	Reports *uint `gorm:"-" bson:"-" json:"reports,omitempty"`
+	// Shadowed is set for the posts of a shadowbanned user, they are seen by the author, the moderators and the admins only
+	Shadowed bool `json:"-"`
 
 	CreatedAt time.Time  `json:"created"`
 	UpdatedAt time.Time  `json:"updated"`
@@ -65,7 +78,7 @@ func (e Post) Validate() error {
 
 	err := validation.ValidateStruct(&e,
 		validation.Field(&e.Type, validation.Required, validation.Length(2, 100), is.Alpha, validation.In(Types...)),
-		validation.Field(&e.Category, validation.Required, validation.Length(2, 100), is.Alpha),
+		validation.Field(&e.Category, validation.Required, validation.Length(2, 100)),
 		validation.Field(&e.Title, validation.Required, validation.Length(2, 100)),
 	)
 	if err != nil {
@@ -93,6 +106,23 @@ func (e Post) validateLink() error {
 	)
 }
 
+// IsDeleted returns true if the post has been deleted, a deleted post is kept until it is purged
+func (e Post) IsDeleted() bool {
+	return e.DeletedAt != nil
+}
+
+// Mask replaces the title and the author of a deleted post by "[deleted]" and clears its content
+func (e *Post) Mask() {
+	if !e.IsDeleted() {
+		return
+	}
+	e.Title = DeletedTitle
+	e.Text = ""
+	e.Link = ""
+	e.UserID = 0
+	e.User = user.User{Name: user.DeletedName}
+}
+
 func (e Post) TableName() string {
 	return TableName
 }
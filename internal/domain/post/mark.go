@@ -0,0 +1,46 @@
+package post
+
+import (
+	"time"
+
+	"redditclone/internal/pkg/pagination"
+)
+
+const (
+	MarkEntityName = "post_mark"
+	MarkTableName  = "post_mark"
+
+	// MarkSave is the mark of a post saved by a user to read it later
+	MarkSave = "save"
+	// MarkHide is the mark of a post hidden by a user from the listings
+	MarkHide = "hide"
+)
+
+// Mark is a per-user state of a post, a post has one mark of a kind by a user
+type Mark struct {
+	ID     string `json:"id"`
+	UserID uint   `json:"userId"`
+	PostID string `json:"postId"`
+	Kind   string `json:"kind"`
+
+	CreatedAt time.Time `json:"created"`
+}
+
+// MarkFilter is the condition of a marks listing
+type MarkFilter struct {
+	Mark
+	// Cursor limits the listing by the items which follow the cursor in the sort order
+	Cursor *pagination.Cursor
+}
+
+// markSortKey is the key of the cursors of the marks listings, the newest marks go first
+const markSortKey = "createdat"
+
+// NewMarkCursor returns the cursor which points to the mark in a listing
+func NewMarkCursor(entity Mark) pagination.Cursor {
+	return pagination.Cursor{
+		Key:   markSortKey,
+		Value: entity.CreatedAt.UnixNano(),
+		ID:    entity.ID,
+	}
+}
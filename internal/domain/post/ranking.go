@@ -0,0 +1,235 @@
+package post
+
+import (
+	"math"
+	"time"
+
+	"github.com/minipkg/selection_condition"
+	"github.com/pkg/errors"
+
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/pagination"
+)
+
+const (
+	SortHot           = "hot"
+	SortTop           = "top"
+	SortNew           = "new"
+	SortRising        = "rising"
+	SortControversial = "controversial"
+
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+	PeriodAll   = "all"
+
+	// hotEpoch is the start of the epoch for the hot ranking, it is the same as in Reddit
+	hotEpoch = 1134028003
+	// hotDecay is the number of seconds which gives the same weight to a post as its score is ten times more
+	hotDecay = 45000
+	// risingGravity is the number of hours added to the age of a post by the rising ranking,
+	// so a post which has just been created does not rise above all by its first votes
+	risingGravity = 2
+	// risingWindow is the age of posts which are taken into account by the rising ranking
+	risingWindow = 24 * time.Hour
+)
+
+// Periods is the list of time windows for the top ranking
+var Periods map[string]time.Duration = map[string]time.Duration{
+	PeriodDay:   24 * time.Hour,
+	PeriodWeek:  7 * 24 * time.Hour,
+	PeriodMonth: 30 * 24 * time.Hour,
+	PeriodAll:   0,
+}
+
+// Filter is the condition of a posts listing
+type Filter struct {
+	Post
+	CreatedAfter time.Time
+	// Cursor limits the listing by the items which follow the cursor in the sort order
+	Cursor *pagination.Cursor
+	// Sources limits the listing by the items of any of the categories or any of the users, it is used by the feeds
+	Sources *Sources
+	// IDs limits the listing by the items with the IDs
+	IDs []string
+	// ExcludeIDs are the IDs of the items which are not shown in the listing, like the items hidden by the viewer
+	ExcludeIDs []string
+}
+
+// Sources are the categories and the authors which items are shown in a feed
+type Sources struct {
+	Categories []string
+	UserIDs    []uint
+}
+
+// IsEmpty returns true if there are no sources
+func (s Sources) IsEmpty() bool {
+	return len(s.Categories) == 0 && len(s.UserIDs) == 0
+}
+
+// Len returns the number of the sources
+func (s Sources) Len() int {
+	return len(s.Categories) + len(s.UserIDs)
+}
+
+// Ranker defines the order of a posts listing
+type Ranker interface {
+	// Prepare adds the time bounds and the sort order of the ranking to a storage query
+	Prepare(filter *Filter, cond *selection_condition.SelectionCondition, now time.Time)
+	// Rank orders the items got from the storage. It is needed for the rankings which depend on the current time.
+	Rank(items []Post, now time.Time)
+}
+
+// RankerFactory creates a ranker for the given period
+type RankerFactory func(period string) (Ranker, error)
+
+var rankers = map[string]RankerFactory{
+	SortHot:           fixedRanker(sortRanker{field: "hot"}),
+	SortNew:           fixedRanker(sortRanker{field: "createdat"}),
+	SortControversial: fixedRanker(sortRanker{field: "controversy"}),
+	SortRising:        fixedRanker(risingRanker{sortRanker{field: "rising"}}),
+	SortTop:           newTopRanker,
+}
+
+// RegisterRanker adds the ranker to the list of available rankings or replaces an existing one
+func RegisterRanker(name string, factory RankerFactory) {
+	rankers[name] = factory
+}
+
+// HasRanker returns true if the ranking with the name is available
+func HasRanker(name string) bool {
+	_, ok := rankers[name]
+	return ok
+}
+
+// NewRanker returns the ranker by the name of the ranking and the period
+func NewRanker(name string, period string) (Ranker, error) {
+	factory, ok := rankers[name]
+	if !ok {
+		return nil, errors.Wrapf(apperror.ErrBadRequest, "unknown sort: %q", name)
+	}
+	return factory(period)
+}
+
+// SortKey returns the field which the listing with the ranking is ordered by in the storage.
+// It is the key of the cursors of the listing.
+func SortKey(name string, period string) (string, error) {
+	ranker, err := NewRanker(name, period)
+	if err != nil {
+		return "", err
+	}
+
+	cond := selection_condition.SelectionCondition{}
+	ranker.Prepare(&Filter{}, &cond, time.Now())
+	key, _, err := sortOrder(name, &cond)
+	return key, err
+}
+
+// sortOrder returns the first field of the sort order of the query prepared by the ranking and its direction.
+// A ranking without the sort order can not be paged, so an error is returned for it.
+func sortOrder(name string, cond *selection_condition.SelectionCondition) (key string, direction string, err error) {
+	if len(cond.SortOrder) > 0 {
+		for key, direction = range cond.SortOrder[0] {
+			return key, direction, nil
+		}
+	}
+	return "", "", errors.Errorf("ranking %q has no sort order", name)
+}
+
+func fixedRanker(r Ranker) RankerFactory {
+	return func(period string) (Ranker, error) {
+		return r, nil
+	}
+}
+
+// sortRanker orders posts by a precomputed field in descending order
+type sortRanker struct {
+	field string
+}
+
+func (r sortRanker) Prepare(filter *Filter, cond *selection_condition.SelectionCondition, now time.Time) {
+	cond.SortOrder = append(cond.SortOrder, map[string]string{r.field: selection_condition.SortOrderDesc})
+}
+
+func (r sortRanker) Rank(items []Post, now time.Time) {}
+
+// topRanker orders posts by score for the period
+type topRanker struct {
+	period time.Duration
+}
+
+func newTopRanker(period string) (Ranker, error) {
+	if period == "" {
+		period = PeriodAll
+	}
+	d, ok := Periods[period]
+	if !ok {
+		return nil, errors.Wrapf(apperror.ErrBadRequest, "unknown period: %q", period)
+	}
+	return topRanker{period: d}, nil
+}
+
+func (r topRanker) Prepare(filter *Filter, cond *selection_condition.SelectionCondition, now time.Time) {
+	if r.period > 0 {
+		filter.CreatedAfter = now.Add(-r.period)
+	}
+	cond.SortOrder = append(cond.SortOrder, map[string]string{"score": selection_condition.SortOrderDesc})
+}
+
+func (r topRanker) Rank(items []Post, now time.Time) {}
+
+// risingRanker orders the recent posts by the precomputed rising ranking, the speed of getting score
+type risingRanker struct {
+	sortRanker
+}
+
+func (r risingRanker) Prepare(filter *Filter, cond *selection_condition.SelectionCondition, now time.Time) {
+	filter.CreatedAfter = now.Add(-risingWindow)
+	r.sortRanker.Prepare(filter, cond, now)
+}
+
+// UpdateRanking recalculates the precomputed ranking fields of the post at the time.
+// The rising ranking depends on the time, the others do not.
+func (e *Post) UpdateRanking(now time.Time) {
+	e.Hot = hot(e.Score, e.CreatedAt)
+	e.Controversy = controversy(e.Ups, e.Downs)
+	e.Rising = rising(e.Score, e.CreatedAt, now)
+}
+
+func hot(score int, createdAt time.Time) float64 {
+	order := math.Log10(math.Max(math.Abs(float64(score)), 1))
+	sign := 0.
+	switch {
+	case score > 0:
+		sign = 1
+	case score < 0:
+		sign = -1
+	}
+	seconds := float64(createdAt.Unix() - hotEpoch)
+	return sign*order + seconds/hotDecay
+}
+
+// rising is the speed of getting score, the score per hour of the age of the post at the time.
+// It is stored when the post is voted and is refreshed by the recount of the scores, so the listing is ordered
+// by it in the storage.
+func rising(score int, createdAt time.Time, now time.Time) float64 {
+	hours := math.Max(now.Sub(createdAt).Hours(), 0)
+	return float64(score) / (hours + risingGravity)
+}
+
+// isRising returns true if the post is taken into account by the rising ranking at the time
+func (e Post) isRising(now time.Time) bool {
+	return now.Sub(e.CreatedAt) <= risingWindow
+}
+
+func controversy(ups, downs int) float64 {
+	if ups <= 0 || downs <= 0 {
+		return 0
+	}
+	magnitude := float64(ups + downs)
+	balance := float64(downs) / float64(ups)
+	if ups < downs {
+		balance = float64(ups) / float64(downs)
+	}
+	return math.Pow(magnitude, balance)
+}
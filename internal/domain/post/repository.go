@@ -2,6 +2,7 @@ package post
 
 import (
 	"context"
+	"time"
 
 	"github.com/minipkg/selection_condition"
 )
@@ -20,6 +21,37 @@ type Repository interface {
 	Create(ctx context.Context, entity *Post) error
 	// Update updates the album with given ID in the storage.
 	Update(ctx context.Context, entity *Post) error
-	// Delete removes the album with given ID from the storage.
+	// Delete marks the post with given ID as deleted, the post is kept until it is purged.
+	// It returns apperror.ErrNotFound if there is no such post which is not deleted.
 	Delete(ctx context.Context, id string) error
+	// Restore removes the deletion mark of the post with given ID.
+	// It returns apperror.ErrNotFound if there is no such deleted post.
+	Restore(ctx context.Context, id string) error
+	// Purge removes from the storage the posts deleted before the time together with their comments, votes, revisions and marks.
+	// It returns the number of the removed posts.
+	Purge(ctx context.Context, before time.Time) (uint, error)
+	// AnonymizeAuthor replaces the author of all the posts of the user by the deleted user.
+	AnonymizeAuthor(ctx context.Context, userID uint) error
+	// AuthorStats returns the number of the posts of the user and the sum of their scores, the deleted posts are not counted.
+	AuthorStats(ctx context.Context, userID uint) (count uint, karma int, err error)
+	// IncrViews atomically increments the number of the views of the post, a deleted post is not found.
+	IncrViews(ctx context.Context, id string) error
+	// ChangeScore atomically changes the numbers of upvotes and downvotes and the score of the post and updates its rankings.
+	ChangeScore(ctx context.Context, id string, ups, downs int) error
+	// SetScore sets the numbers of upvotes and downvotes, the score and the rankings of the post.
+	SetScore(ctx context.Context, entity *Post) error
+	// CreateRevision saves a previous version of the post in the storage.
+	CreateRevision(ctx context.Context, entity *Revision) error
+	// QueryRevisions returns the list of previous versions of the post ordered by the time of change.
+	QueryRevisions(ctx context.Context, postId string) ([]Revision, error)
+	// CreateMark saves a new mark of the post by the user.
+	// It returns apperror.ErrConflict if the user has already marked the post so.
+	CreateMark(ctx context.Context, entity *Mark) error
+	// DeleteMark removes the mark of the kind of the post by the user.
+	// It returns apperror.ErrNotFound if the post has not been marked so.
+	DeleteMark(ctx context.Context, entity *Mark) error
+	// QueryMarks returns the list of marks with the given sort order and limit, the where part of the condition is *MarkFilter.
+	QueryMarks(ctx context.Context, cond selection_condition.SelectionCondition) ([]Mark, error)
+	// MarkedIDs returns the IDs of all the posts marked by the user with the kind.
+	MarkedIDs(ctx context.Context, userID uint, kind string) ([]string, error)
 }
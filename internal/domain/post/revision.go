@@ -0,0 +1,33 @@
+package post
+
+import (
+	"time"
+)
+
+const (
+	RevisionEntityName = "post_revision"
+	RevisionTableName  = "post_revision"
+)
+
+// Revision is a previous version of a post which was replaced by an edit
+type Revision struct {
+	ID     string `json:"id"`
+	PostID string `json:"postId"`
+	UserID uint   `json:"userId"`
+	Title  string `json:"title"`
+	Text   string `json:"text,omitempty"`
+	Link   string `json:"link,omitempty"`
+
+	CreatedAt time.Time `json:"created"`
+}
+
+// NewRevision returns a revision with the current content of the post
+func NewRevision(entity *Post) *Revision {
+	return &Revision{
+		PostID: entity.ID,
+		UserID: entity.UserID,
+		Title:  entity.Title,
+		Text:   entity.Text,
+		Link:   entity.Link,
+	}
+}
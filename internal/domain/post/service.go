@@ -2,52 +2,84 @@ package post
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/minipkg/log"
 	"github.com/minipkg/selection_condition"
-	"go.mongodb.org/mongo-driver/mongo"
 
 	"redditclone/internal/domain/comment"
+	"redditclone/internal/domain/community"
+	"redditclone/internal/domain/modlog"
 	"redditclone/internal/domain/vote"
 	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/auth"
+	"redditclone/internal/pkg/errorshandler"
+	"redditclone/internal/pkg/pagination"
 )
 
-const MaxLIstLimit = 1000
+const (
+	MaxLIstLimit = 1000
+	// maxVoteAttempts is the number of attempts to apply a vote which races with other votes of the same user
+	maxVoteAttempts = 3
+	// recountBatchSize is the number of posts which scores are recounted at once
+	recountBatchSize = 100
+)
 
 // IService encapsulates usecase logic for user.
 type IService interface {
 	NewEntity() *Post
 	NewVoteEntity(userId uint, postId string, val int) *vote.Vote
 	Get(ctx context.Context, id string) (*Post, error)
+	GetActive(ctx context.Context, id string) (*Post, error)
+	GetCategory(ctx context.Context, id string) (string, error)
+	GetActiveCategory(ctx context.Context, id string) (string, error)
 	//First(ctx context.Context, user *Post) (*Post, error)
 	Query(ctx context.Context, query selection_condition.SelectionCondition) ([]Post, error)
+	Ranked(ctx context.Context, where *Post, sort string, period string) ([]Post, error)
+	Page(ctx context.Context, where *Post, sort string, period string, params pagination.Params) (*pagination.Page, error)
+	Feed(ctx context.Context, sources Sources, sort string, period string, params pagination.Params) (*pagination.Page, error)
+	Timeline(ctx context.Context, sources Sources, sort string, period string, limit uint) ([]Post, error)
+	Saved(ctx context.Context, params pagination.Params) (*pagination.Page, error)
+	Mark(ctx context.Context, id string, kind string) error
+	Unmark(ctx context.Context, id string, kind string) error
 	List(ctx context.Context) ([]Post, error)
 	//Count(ctx context.Context) (uint, error)
 	Create(ctx context.Context, entity *Post) error
 	ViewsIncr(ctx context.Context, entity *Post) error
-	//Update(ctx context.Context, entity *Post) error
+	Update(ctx context.Context, id string, input *Post) (*Post, error)
+	Revisions(ctx context.Context, id string) ([]Revision, error)
 	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) error
+	Purge(ctx context.Context, before time.Time) (posts uint, comments uint, err error)
 	Vote(ctx context.Context, entity *vote.Vote) error
 	Unvote(ctx context.Context, entity *vote.Vote) error
+	RecountScores(ctx context.Context) (uint, error)
+	AnonymizeAuthor(ctx context.Context, userID uint) error
+	AuthorStats(ctx context.Context, userID uint) (*AuthorStats, error)
+	CheckParticipation(ctx context.Context, category string) error
 }
 
 type service struct {
 	//Domain     Domain
-	logger            log.ILogger
-	repository        Repository
-	commentRepository comment.Repository
-	voteReporitory    vote.Repository
+	logger              log.ILogger
+	repository          Repository
+	commentRepository   comment.Repository
+	voteReporitory      vote.Repository
+	communityRepository community.Repository
+	modLogService       modlog.IService
 }
 
 // NewService creates a new service.
-func NewService(logger log.ILogger, repo Repository, commentRepo comment.Repository, voteRepo vote.Repository) IService {
+func NewService(logger log.ILogger, repo Repository, commentRepo comment.Repository, voteRepo vote.Repository, communityRepo community.Repository, modLogService modlog.IService) IService {
 	s := &service{
-		logger:            logger,
-		repository:        repo,
-		commentRepository: commentRepo,
-		voteReporitory:    voteRepo,
+		logger:              logger,
+		repository:          repo,
+		commentRepository:   commentRepo,
+		voteReporitory:      voteRepo,
+		communityRepository: communityRepo,
+		modLogService:       modLogService,
 	}
 	repo.SetDefaultConditions(s.defaultConditions())
 	return s
@@ -71,14 +103,64 @@ func (s *service) NewVoteEntity(userId uint, postId string, val int) *vote.Vote
 }
 
 // Get returns the entity with the specified ID.
+// A shadowed entity is not found for the users who are not allowed to see it, a deleted entity is shown as "[deleted]" with its comments.
 func (s *service) Get(ctx context.Context, id string) (*Post, error) {
 	entity, err := s.repository.Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+
+	items := maskDeleted(ctx, withoutShadowed(ctx, []Post{*entity}))
+	if len(items) == 0 {
+		return nil, apperror.ErrNotFound
+	}
+	return &items[0], nil
+}
+
+// getActive returns the entity with the specified ID, a deleted entity is not found.
+func (s *service) getActive(ctx context.Context, id string) (*Post, error) {
+	entity, err := s.repository.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if entity.IsDeleted() {
+		return nil, apperror.ErrNotFound
+	}
+	return entity, nil
+}
+
+// GetActive returns the entity with the specified ID as it is stored, without the masks, to be edited.
+// A deleted entity is not found, as well as a shadowed one for the users who are not allowed to see it.
+func (s *service) GetActive(ctx context.Context, id string) (*Post, error) {
+	entity, err := s.getActive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if entity.Shadowed && !auth.CanSeeShadowed(auth.CurrentSession(ctx), entity.UserID, entity.Category) {
+		return nil, apperror.ErrNotFound
+	}
 	return entity, nil
 }
 
+// GetCategory returns the category of the entity with the specified ID.
+func (s *service) GetCategory(ctx context.Context, id string) (string, error) {
+	entity, err := s.repository.Get(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return entity.Category, nil
+}
+
+// GetActiveCategory returns the category of the entity with the specified ID.
+// A deleted entity is not found, so it can not be commented or voted.
+func (s *service) GetActiveCategory(ctx context.Context, id string) (string, error) {
+	entity, err := s.getActive(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return entity.Category, nil
+}
+
 /*
 // Count returns the number of items.
 func (s *service) Count(ctx context.Context) (uint, error) {
@@ -87,11 +169,340 @@ func (s *service) Count(ctx context.Context) (uint, error) {
 
 // Query returns the items with the specified offset and limit.
 func (s *service) Query(ctx context.Context, query selection_condition.SelectionCondition) ([]Post, error) {
+	if filter, ok := query.Where.(*Filter); ok {
+		if err := s.excludeHidden(ctx, filter); err != nil {
+			return nil, err
+		}
+	}
 	items, err := s.repository.Query(ctx, query)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Can not find a list of posts by query: %v", query)
 	}
-	return items, nil
+	return maskDeleted(ctx, withoutShadowed(ctx, items)), nil
+}
+
+// Ranked returns the items ordered by the ranking with the specified name.
+// The period limits the age of the items for the rankings which support it.
+func (s *service) Ranked(ctx context.Context, where *Post, sort string, period string) ([]Post, error) {
+	filter := &Filter{Post: *where}
+	if err := s.excludeHidden(ctx, filter); err != nil {
+		return nil, err
+	}
+	return s.ranked(ctx, filter, sort, period, 0)
+}
+
+// Timeline returns the first items of the sources ordered by the ranking with the specified name, the limit is required.
+// It is used to precompute the feeds.
+func (s *service) Timeline(ctx context.Context, sources Sources, sort string, period string, limit uint) ([]Post, error) {
+	if sources.IsEmpty() {
+		return []Post{}, nil
+	}
+	return s.ranked(ctx, &Filter{Sources: &sources}, sort, period, limit)
+}
+
+func (s *service) ranked(ctx context.Context, filter *Filter, sort string, period string, limit uint) ([]Post, error) {
+	ranker, err := NewRanker(sort, period)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cond := selection_condition.SelectionCondition{
+		Where: filter,
+		Limit: limit,
+	}
+	ranker.Prepare(filter, &cond, now)
+
+	items, err := s.repository.Query(ctx, cond)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find a list of posts by query: %v", cond)
+	}
+	ranker.Rank(items, now)
+	return maskDeleted(ctx, withoutShadowed(ctx, items)), nil
+}
+
+// Page returns the page of the items ordered by the ranking with the specified name, the newest items go first by default.
+// Pages are built by cursors, so they stay stable when new items are added.
+func (s *service) Page(ctx context.Context, where *Post, sort string, period string, params pagination.Params) (*pagination.Page, error) {
+	filter := &Filter{Post: *where}
+	if err := s.excludeHidden(ctx, filter); err != nil {
+		return nil, err
+	}
+	return s.page(ctx, filter, sort, period, params)
+}
+
+// Feed returns the page of the items of the sources ordered by the ranking with the specified name, the newest items go first by default.
+// It reads the items of all the sources at once, so the feed is always up to date.
+func (s *service) Feed(ctx context.Context, sources Sources, sort string, period string, params pagination.Params) (*pagination.Page, error) {
+	if sources.IsEmpty() {
+		return &pagination.Page{Items: []Post{}}, nil
+	}
+	return s.page(ctx, &Filter{Sources: &sources}, sort, period, params)
+}
+
+func (s *service) page(ctx context.Context, filter *Filter, sort string, period string, params pagination.Params) (*pagination.Page, error) {
+	if sort == "" {
+		sort = SortNew
+	}
+	if params.After != "" && params.Before != "" {
+		return nil, errors.Wrap(apperror.ErrBadRequest, "only one of the cursors can be given")
+	}
+
+	ranker, err := NewRanker(sort, period)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	limit := params.GetLimit()
+	cond := selection_condition.SelectionCondition{
+		Where: filter,
+		Limit: limit + 1,
+	}
+	ranker.Prepare(filter, &cond, now)
+
+	key, direction, err := sortOrder(sort, &cond)
+	if err != nil {
+		return nil, err
+	}
+	cond.SortOrder = append(cond.SortOrder, map[string]string{"id": direction})
+
+	isBackward := params.Before != ""
+	cursor := params.After
+	if isBackward {
+		cursor = params.Before
+		cond.SortOrder = reverseSortOrder(cond.SortOrder)
+	}
+	if cursor != "" {
+		if filter.Cursor, err = ParseCursor(cursor, key); err != nil {
+			return nil, err
+		}
+	}
+
+	items, err := s.repository.Query(ctx, cond)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find a list of posts by query: %v", cond)
+	}
+
+	hasMore := uint(len(items)) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+	if isBackward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	page := &pagination.Page{}
+	if len(items) > 0 {
+		if hasMore || isBackward {
+			page.Next = NewCursor(items[len(items)-1], key).Encode()
+		}
+		if (hasMore && isBackward) || params.After != "" {
+			page.Prev = NewCursor(items[0], key).Encode()
+		}
+	}
+
+	ranker.Rank(items, now)
+	//	the cursors are built before, so the pages do not depend on the viewer
+	page.Items = maskDeleted(ctx, withoutShadowed(ctx, items))
+	return page, nil
+}
+
+func reverseSortOrder(sortOrder []map[string]string) []map[string]string {
+	res := make([]map[string]string, 0, len(sortOrder))
+	for _, order := range sortOrder {
+		reversed := make(map[string]string, len(order))
+		for field, direction := range order {
+			reversed[field] = selection_condition.SortOrderDesc
+			if direction == selection_condition.SortOrderDesc {
+				reversed[field] = selection_condition.SortOrderAsc
+			}
+		}
+		res = append(res, reversed)
+	}
+	return res
+}
+
+// excludeHidden excludes from the listing the items hidden by the current user, the listings of the anonymous users are not changed.
+func (s *service) excludeHidden(ctx context.Context, filter *Filter) error {
+	sess := auth.CurrentSession(ctx)
+	if sess == nil {
+		return nil
+	}
+
+	ids, err := s.repository.MarkedIDs(ctx, sess.UserID, MarkHide)
+	if err != nil {
+		return errors.Wrapf(err, "Can not find hidden posts of user id: %v", sess.UserID)
+	}
+	filter.ExcludeIDs = ids
+	return nil
+}
+
+// withoutShadowed removes from the items the shadowed posts and comments which the current user is not allowed to see.
+// The items are returned as is if there are no shadowed ones.
+func withoutShadowed(ctx context.Context, items []Post) []Post {
+	isShadowed := false
+	for _, item := range items {
+		if item.Shadowed || comment.HasShadowed(item.Comments) {
+			isShadowed = true
+			break
+		}
+	}
+	if !isShadowed {
+		return items
+	}
+
+	sess := auth.CurrentSession(ctx)
+	res := make([]Post, 0, len(items))
+	for _, item := range items {
+		if item.Shadowed && !auth.CanSeeShadowed(sess, item.UserID, item.Category) {
+			continue
+		}
+		item.Comments = comment.WithoutShadowed(sess, item.Comments, item.Category)
+		res = append(res, item)
+	}
+	return res
+}
+
+// maskDeleted shows the deleted posts and comments of the items as "[deleted]" to the current user, the admins see them as is.
+func maskDeleted(ctx context.Context, items []Post) []Post {
+	sess := auth.CurrentSession(ctx)
+	if auth.IsAdmin(sess) {
+		return items
+	}
+	for i := range items {
+		items[i].Mask()
+		items[i].Comments = comment.MaskDeleted(sess, items[i].Comments)
+	}
+	return items
+}
+
+// Mark marks the entity with the specified ID by the current user with the kind, marking again changes nothing.
+func (s *service) Mark(ctx context.Context, id string, kind string) error {
+	if _, err := s.getActive(ctx, id); err != nil {
+		return err
+	}
+
+	err := s.repository.CreateMark(ctx, &Mark{
+		UserID:    auth.CurrentSession(ctx).UserID,
+		PostID:    id,
+		Kind:      kind,
+		CreatedAt: time.Now(),
+	})
+	if err != nil && !errors.Is(err, apperror.ErrConflict) {
+		return errors.Wrapf(err, "Can not mark a post id: %v by %q", id, kind)
+	}
+	return nil
+}
+
+// Unmark removes the mark of the kind of the entity with the specified ID by the current user, unmarking again changes nothing.
+func (s *service) Unmark(ctx context.Context, id string, kind string) error {
+	err := s.repository.DeleteMark(ctx, &Mark{
+		UserID: auth.CurrentSession(ctx).UserID,
+		PostID: id,
+		Kind:   kind,
+	})
+	if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+		return errors.Wrapf(err, "Can not unmark a post id: %v by %q", id, kind)
+	}
+	return nil
+}
+
+// Saved returns the page of the items saved by the current user, the last saved items go first.
+// The items which have been deleted since they were saved are skipped.
+func (s *service) Saved(ctx context.Context, params pagination.Params) (*pagination.Page, error) {
+	if params.After != "" && params.Before != "" {
+		return nil, errors.Wrap(apperror.ErrBadRequest, "only one of the cursors can be given")
+	}
+
+	userID := auth.CurrentSession(ctx).UserID
+	limit := params.GetLimit()
+	filter := &MarkFilter{Mark: Mark{UserID: userID, Kind: MarkSave}}
+	cond := selection_condition.SelectionCondition{
+		Where: filter,
+		SortOrder: []map[string]string{
+			{markSortKey: selection_condition.SortOrderDesc},
+			{"id": selection_condition.SortOrderDesc},
+		},
+		Limit: limit + 1,
+	}
+
+	isBackward := params.Before != ""
+	cursor := params.After
+	if isBackward {
+		cursor = params.Before
+		cond.SortOrder = reverseSortOrder(cond.SortOrder)
+	}
+	if cursor != "" {
+		var err error
+		if filter.Cursor, err = ParseCursor(cursor, markSortKey); err != nil {
+			return nil, err
+		}
+	}
+
+	marks, err := s.repository.QueryMarks(ctx, cond)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find saved posts of user id: %v", userID)
+	}
+
+	hasMore := uint(len(marks)) > limit
+	if hasMore {
+		marks = marks[:limit]
+	}
+	if isBackward {
+		for i, j := 0, len(marks)-1; i < j; i, j = i+1, j-1 {
+			marks[i], marks[j] = marks[j], marks[i]
+		}
+	}
+
+	page := &pagination.Page{}
+	if len(marks) > 0 {
+		if hasMore || isBackward {
+			page.Next = NewMarkCursor(marks[len(marks)-1]).Encode()
+		}
+		if (hasMore && isBackward) || params.After != "" {
+			page.Prev = NewMarkCursor(marks[0]).Encode()
+		}
+	}
+
+	items, err := s.markedItems(ctx, marks)
+	if err != nil {
+		return nil, err
+	}
+	page.Items = items
+	return page, nil
+}
+
+// markedItems returns the items of the marks in the order of the marks
+func (s *service) markedItems(ctx context.Context, marks []Mark) ([]Post, error) {
+	items := make([]Post, 0, len(marks))
+	if len(marks) == 0 {
+		return items, nil
+	}
+
+	ids := make([]string, 0, len(marks))
+	for _, m := range marks {
+		ids = append(ids, m.PostID)
+	}
+	found, err := s.repository.Query(ctx, selection_condition.SelectionCondition{
+		Where: &Filter{IDs: ids},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find posts by ids: %v", ids)
+	}
+
+	byID := make(map[string]Post, len(found))
+	for _, item := range found {
+		byID[item.ID] = item
+	}
+	for _, id := range ids {
+		if item, ok := byID[id]; ok {
+			items = append(items, item)
+		}
+	}
+	return maskDeleted(ctx, items), nil
 }
 
 // List returns the items list.
@@ -103,48 +514,242 @@ func (s *service) List(ctx context.Context) ([]Post, error) {
 	return items, nil
 }
 
+// Create saves a new entity, the category of the entity has to be the name of an existing community.
 func (s *service) Create(ctx context.Context, entity *Post) error {
+	if _, err := s.communityRepository.Get(ctx, entity.Category); err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return errors.Wrapf(apperror.ErrBadRequest, "unknown community: %q", entity.Category)
+		}
+		return errors.Wrapf(err, "Can not get a community by name: %q", entity.Category)
+	}
+	if err := s.CheckParticipation(ctx, entity.Category); err != nil {
+		return err
+	}
+	entity.Shadowed = auth.IsShadowbanned(auth.CurrentSession(ctx))
+
+	if entity.CreatedAt.IsZero() {
+		entity.CreatedAt = time.Now()
+		entity.UpdatedAt = entity.CreatedAt
+	}
+	entity.UpdateRanking(entity.CreatedAt)
 	return s.repository.Create(ctx, entity)
 }
 
+// ViewsIncr counts a view of the entity, the views of a deleted entity are not counted.
+// Only the counter is incremented in the storage, so a view never reverts a concurrent edit of the entity.
 func (s *service) ViewsIncr(ctx context.Context, entity *Post) error {
+	if entity.IsDeleted() {
+		return nil
+	}
+	if err := s.repository.IncrViews(ctx, entity.ID); err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			//	the entity has been deleted since it was read
+			return nil
+		}
+		return errors.Wrapf(err, "Can not count a view of the entity id: %v", entity.ID)
+	}
 	entity.Views++
-	return s.repository.Update(ctx, entity)
+	return nil
+}
+
+// Update changes the title and the content of the entity with the specified ID if the current user is its author.
+// The previous version of the entity is saved as a revision.
+func (s *service) Update(ctx context.Context, id string, input *Post) (*Post, error) {
+	entity, err := s.getActive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !auth.IsAuthor(auth.CurrentSession(ctx), entity.UserID) {
+		return nil, errorshandler.Forbidden("")
+	}
+
+	revision := NewRevision(entity)
+	entity.Title = input.Title
+	switch entity.Type {
+	case TypeText:
+		entity.Text = input.Text
+	case TypeLink:
+		entity.Link = input.Link
+	}
+
+	if err = entity.Validate(); err != nil {
+		return nil, errorshandler.BadRequest(err.Error())
+	}
+
+	if entity.Title == revision.Title && entity.Text == revision.Text && entity.Link == revision.Link {
+		//	no changes
+		return entity, nil
+	}
+
+	revision.CreatedAt = time.Now()
+	if err = s.repository.CreateRevision(ctx, revision); err != nil {
+		return nil, err
+	}
+
+	entity.UpdatedAt = revision.CreatedAt
+	if err = s.repository.Update(ctx, entity); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// Revisions returns the previous versions of the entity with the specified ID.
+// The revisions of a shadowed entity are not found for the users who are not allowed to see it.
+func (s *service) Revisions(ctx context.Context, id string) ([]Revision, error) {
+	if _, err := s.GetActive(ctx, id); err != nil {
+		return nil, err
+	}
+
+	items, err := s.repository.QueryRevisions(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find a list of revisions by post id: %v", id)
+	}
+	return items, nil
 }
 
+// Delete deletes the entity with the specified ID if the current user is allowed to do it.
+// The entity is kept with its comments and votes until it is purged, so it can be restored.
 func (s *service) Delete(ctx context.Context, id string) error {
-	return s.repository.Delete(ctx, id)
+	entity, err := s.getActive(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = auth.CheckDeleteAccess(ctx, entity.UserID, entity.Category); err != nil {
+		return err
+	}
+	if err = s.repository.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	if !auth.IsAuthor(auth.CurrentSession(ctx), entity.UserID) {
+		before := *entity
+		before.Comments = nil
+		before.Votes = nil
+		s.modLogService.Record(ctx, modlog.NewEntry(modlog.ActionDeletePost, modlog.TargetPost, entity.ID, entity.Category, "", before, nil))
+	}
+	return nil
+}
+
+// Restore restores the deleted entity with the specified ID, only the admins are allowed to do it.
+func (s *service) Restore(ctx context.Context, id string) error {
+	if !auth.IsAdmin(auth.CurrentSession(ctx)) {
+		return errorshandler.Forbidden("")
+	}
+
+	entity, err := s.repository.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !entity.IsDeleted() {
+		return apperror.ErrNotFound
+	}
+	if err = s.repository.Restore(ctx, id); err != nil {
+		return err
+	}
+
+	after := *entity
+	after.Comments = nil
+	after.Votes = nil
+	after.DeletedAt = nil
+	s.modLogService.Record(ctx, modlog.NewEntry(modlog.ActionRestorePost, modlog.TargetPost, entity.ID, entity.Category, "", nil, after))
+	return nil
 }
 
+// Purge removes the posts and the comments deleted before the time for good.
+// The comments, the votes, the revisions and the marks of a removed post are removed with it.
+func (s *service) Purge(ctx context.Context, before time.Time) (posts uint, comments uint, err error) {
+	if posts, err = s.repository.Purge(ctx, before); err != nil {
+		return posts, 0, errors.Wrapf(err, "Can not purge posts deleted before: %v", before)
+	}
+	if comments, err = s.commentRepository.Purge(ctx, before); err != nil {
+		return posts, comments, errors.Wrapf(err, "Can not purge comments deleted before: %v", before)
+	}
+	return posts, comments, nil
+}
+
+// Vote saves the vote of a user for a post or a comment and changes the score of the target.
+// A vote which races with another vote of the same user is applied again over the result of that one.
 func (s *service) Vote(ctx context.Context, entity *vote.Vote) (err error) {
-	item := &vote.Vote{
-		PostID: entity.PostID,
-		UserID: entity.UserID,
+	if err = s.checkVoting(ctx, entity); err != nil {
+		return err
 	}
 
-	if item, err = s.voteReporitory.First(ctx, item); err != nil {
-		if err == apperror.ErrNotFound {
-			err = s.voteReporitory.Create(ctx, entity)
-			return s.PostChangeScore(ctx, entity.PostID, entity.Value)
+	for i := 0; i < maxVoteAttempts; i++ {
+		applied, err := s.applyVote(ctx, entity)
+		if applied || err != nil {
+			return err
 		}
-		return errors.Wrapf(err, "Can not find a vote by params: %v", item)
+	}
+	return errors.Wrapf(apperror.ErrConflict, "Can not apply the vote: %v", entity)
+}
+
+// applyVote creates or changes the vote and changes the score of the target by the difference.
+// It returns false if the vote of the user has been created or changed concurrently.
+func (s *service) applyVote(ctx context.Context, entity *vote.Vote) (bool, error) {
+	item, err := s.voteReporitory.First(ctx, &vote.Vote{
+		PostID:    entity.PostID,
+		CommentID: entity.CommentID,
+		UserID:    entity.UserID,
+	})
+	if err != nil {
+		if err != apperror.ErrNotFound {
+			return false, errors.Wrapf(err, "Can not find a vote by params: %v", entity)
+		}
+
+		if err = s.voteReporitory.Create(ctx, entity); err != nil {
+			if errors.Cause(err) == apperror.ErrConflict {
+				return false, nil
+			}
+			return false, err
+		}
+		ups, downs := entity.Counts()
+		return true, s.changeScore(ctx, entity, ups, downs)
 	}
 
 	if item.Value == entity.Value {
 		//	no action
-		return nil
+		return true, nil
 	}
+	oldValue := item.Value
+	oldUps, oldDowns := item.Counts()
 	item.Value = entity.Value
-	if err = s.voteReporitory.Update(ctx, item); err != nil {
+	item.UpdatedAt = time.Now()
+	if err = s.voteReporitory.UpdateValue(ctx, item, oldValue); err != nil {
+		if err == apperror.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	ups, downs := item.Counts()
+	return true, s.changeScore(ctx, entity, ups-oldUps, downs-oldDowns)
+}
+
+// checkVoting checks that the target of the vote is not deleted and the current user is allowed to vote in its category.
+// It is checked for the removal of a vote as well, since it changes the score too.
+func (s *service) checkVoting(ctx context.Context, entity *vote.Vote) error {
+	if err := s.checkVoteTarget(ctx, entity); err != nil {
+		return err
+	}
+
+	category, err := s.GetActiveCategory(ctx, entity.PostID)
+	if err != nil {
 		return err
 	}
-	return s.PostChangeScore(ctx, entity.PostID, 2*entity.Value)
+	return s.CheckParticipation(ctx, category)
 }
 
+// Unvote removes the vote of a user for a post or a comment and changes the score of the target.
 func (s *service) Unvote(ctx context.Context, entity *vote.Vote) (err error) {
+	if err = s.checkVoting(ctx, entity); err != nil {
+		return err
+	}
+
 	item := &vote.Vote{
-		PostID: entity.PostID,
-		UserID: entity.UserID,
+		PostID:    entity.PostID,
+		CommentID: entity.CommentID,
+		UserID:    entity.UserID,
 	}
 
 	if item, err = s.voteReporitory.First(ctx, item); err != nil {
@@ -155,24 +760,198 @@ func (s *service) Unvote(ctx context.Context, entity *vote.Vote) (err error) {
 	}
 
 	if err = s.voteReporitory.Delete(ctx, item.ID); err != nil {
+		if err == apperror.ErrNotFound {
+			//	the vote has been removed concurrently together with its score
+			return nil
+		}
 		return err
 	}
-	return s.PostChangeScore(ctx, entity.PostID, -1*entity.Value)
+	ups, downs := item.Counts()
+	return s.changeScore(ctx, item, -ups, -downs)
 }
 
-func (s *service) PostChangeScore(ctx context.Context, id string, diff int) error {
-	entity, err := s.repository.Get(ctx, id)
+// CheckParticipation checks that the current user is allowed to post, comment and vote in the category.
+// A suspended or a banned user and a user banned in the community get errorshandler.Forbidden with the reason.
+func (s *service) CheckParticipation(ctx context.Context, category string) error {
+	if err := auth.CheckAccountState(ctx); err != nil {
+		return err
+	}
+	sess := auth.CurrentSession(ctx)
+	if sess == nil {
+		return nil
+	}
+
+	ban, err := s.communityRepository.GetBan(ctx, category, sess.UserID)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return errors.Wrapf(apperror.ErrNotFound, "Post id: %q not found", id)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return nil
 		}
-		return errors.Wrapf(apperror.ErrInternal, "Post id: %q not found", id)
+		return errors.Wrapf(err, "Can not get a ban of user id: %v in community: %q", sess.UserID, category)
 	}
-	entity.Score += diff
+	if ban.IsActive(time.Now()) {
+		return errorshandler.Forbidden(ban.Message())
+	}
+	return nil
+}
 
-	err = s.repository.Update(ctx, entity)
+// checkVoteTarget checks that the comment of a vote belongs to the post of the vote and is not deleted.
+func (s *service) checkVoteTarget(ctx context.Context, entity *vote.Vote) error {
+	if !entity.IsForComment() {
+		return nil
+	}
+
+	c, err := s.commentRepository.Get(ctx, entity.CommentID)
 	if err != nil {
-		return errors.Wrapf(apperror.ErrInternal, "Can not update post: %v, error: %v", entity, err)
+		return err
+	}
+	if c.PostID != entity.PostID || c.IsDeleted() {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+func (s *service) changeScore(ctx context.Context, entity *vote.Vote, ups, downs int) error {
+	if entity.IsForComment() {
+		return s.CommentChangeScore(ctx, entity.CommentID, ups-downs)
+	}
+	return s.PostChangeScore(ctx, entity.PostID, ups, downs)
+}
+
+// PostChangeScore changes the numbers of upvotes and downvotes of the post and recalculates its score and rankings.
+func (s *service) PostChangeScore(ctx context.Context, id string, ups, downs int) error {
+	if err := s.repository.ChangeScore(ctx, id, ups, downs); err != nil {
+		if err == apperror.ErrNotFound {
+			return errors.Wrapf(apperror.ErrNotFound, "Post id: %q not found", id)
+		}
+		return errors.Wrapf(err, "Can not change score of post id: %q", id)
+	}
+	return nil
+}
+
+// CommentChangeScore changes the score of the comment by the diff.
+func (s *service) CommentChangeScore(ctx context.Context, id string, diff int) error {
+	if err := s.commentRepository.ChangeScore(ctx, id, diff); err != nil {
+		if err == apperror.ErrNotFound {
+			return errors.Wrapf(apperror.ErrNotFound, "Comment id: %q not found", id)
+		}
+		return errors.Wrapf(err, "Can not change score of comment id: %q", id)
+	}
+	return nil
+}
+
+// AnonymizeAuthor replaces the author of all the posts and the comments of the user by the deleted user.
+// The content stays, so the discussions are not broken by the deletion of an account.
+func (s *service) AnonymizeAuthor(ctx context.Context, userID uint) error {
+	if err := s.repository.AnonymizeAuthor(ctx, userID); err != nil {
+		return errors.Wrapf(err, "Can not anonymize the posts of the user id: %v", userID)
+	}
+	if err := s.commentRepository.AnonymizeAuthor(ctx, userID); err != nil {
+		return errors.Wrapf(err, "Can not anonymize the comments of the user id: %v", userID)
 	}
 	return nil
 }
+
+// AuthorStats returns the numbers of the posts and the comments of the user and the karma got for them.
+func (s *service) AuthorStats(ctx context.Context, userID uint) (*AuthorStats, error) {
+	var err error
+	stats := &AuthorStats{}
+
+	if stats.PostCount, stats.PostKarma, err = s.repository.AuthorStats(ctx, userID); err != nil {
+		return nil, errors.Wrapf(err, "Can not count the posts of the user id: %v", userID)
+	}
+	if stats.CommentCount, stats.CommentKarma, err = s.commentRepository.AuthorStats(ctx, userID); err != nil {
+		return nil, errors.Wrapf(err, "Can not count the comments of the user id: %v", userID)
+	}
+	return stats, nil
+}
+
+// RecountScores recalculates the scores and the rankings of all posts and comments from the votes and fixes the ones which differ.
+// It returns the number of fixed items. It is idempotent, so it can be run at any time to reconcile the scores,
+// e.g. after a failure between saving a vote and changing the score. The rising ranking of the recent posts
+// depends on the time, so it is refreshed by every run.
+func (s *service) RecountScores(ctx context.Context) (fixed uint, err error) {
+	now := time.Now()
+	for offset := uint(0); ; offset += recountBatchSize {
+		items, err := s.repository.Query(ctx, selection_condition.SelectionCondition{
+			SortOrder: []map[string]string{{"id": selection_condition.SortOrderAsc}},
+			Limit:     recountBatchSize,
+			Offset:    offset,
+		})
+		if err != nil {
+			return fixed, errors.Wrapf(err, "Can not find a list of posts by offset: %v", offset)
+		}
+
+		for i := range items {
+			n, err := s.recountScore(ctx, &items[i], now)
+			fixed += n
+			if err != nil {
+				return fixed, err
+			}
+		}
+
+		if len(items) < recountBatchSize {
+			return fixed, nil
+		}
+	}
+}
+
+// recountScore recalculates the scores of the post and its comments from their votes.
+func (s *service) recountScore(ctx context.Context, entity *Post, now time.Time) (fixed uint, err error) {
+	votes, err := s.voteReporitory.Query(ctx, selection_condition.SelectionCondition{
+		Where: &vote.Vote{PostID: entity.ID},
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "Can not find a list of votes by post id: %v", entity.ID)
+	}
+
+	var postUps, postDowns int
+	commentScores := make(map[string]int)
+	for _, v := range votes {
+		ups, downs := v.Counts()
+		if v.IsForComment() {
+			commentScores[v.CommentID] += ups - downs
+			continue
+		}
+		postUps += ups
+		postDowns += downs
+	}
+
+	isRecounted := entity.Ups != postUps || entity.Downs != postDowns || entity.Score != postUps-postDowns
+	if isRecounted {
+		s.logger.With(ctx).Infof("Post id: %q score %v (+%v/-%v) is recounted to %v (+%v/-%v)", entity.ID, entity.Score, entity.Ups, entity.Downs, postUps-postDowns, postUps, postDowns)
+		entity.Ups = postUps
+		entity.Downs = postDowns
+		entity.Score = postUps - postDowns
+	}
+	//	the rankings are fixed as well, e.g. the ones added after the post was voted
+	ranked := *entity
+	ranked.UpdateRanking(now)
+	if !entity.isRising(now) {
+		//	the post is not in the rising listing anymore, so its rising ranking is kept as is
+		ranked.Rising = entity.Rising
+	}
+	if isRecounted || ranked.Hot != entity.Hot || ranked.Controversy != entity.Controversy || ranked.Rising != entity.Rising {
+		if err = s.repository.SetScore(ctx, &ranked); err != nil {
+			return fixed, err
+		}
+		fixed++
+	}
+
+	comments, err := s.commentRepository.Query(ctx, selection_condition.SelectionCondition{
+		Where: &comment.Comment{PostID: entity.ID},
+	})
+	if err != nil {
+		return fixed, errors.Wrapf(err, "Can not find a list of comments by post id: %v", entity.ID)
+	}
+
+	for _, c := range comments {
+		if score := commentScores[c.ID]; c.Score != score {
+			s.logger.With(ctx).Infof("Comment id: %q score %v is recounted to %v", c.ID, c.Score, score)
+			if err = s.commentRepository.SetScore(ctx, c.ID, score); err != nil {
+				return fixed, err
+			}
+			fixed++
+		}
+	}
+	return fixed, nil
+}
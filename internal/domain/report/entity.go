@@ -0,0 +1,117 @@
+package report
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	EntityName = "report"
+	TableName  = "report"
+	// QueueTableName is the table of the reported items of the moderation queue
+	QueueTableName = "report_queue"
+
+	TargetPost    = "post"
+	TargetComment = "comment"
+
+	ReasonSpam           = "spam"
+	ReasonHarassment     = "harassment"
+	ReasonHate           = "hate"
+	ReasonViolence       = "violence"
+	ReasonSexual         = "sexual"
+	ReasonMisinformation = "misinformation"
+	ReasonOther          = "other"
+
+	// StatusOpen is the status of an item which waits for a moderator
+	StatusOpen = "open"
+	// StatusApproved is the status of an item which is left as is, its reports are unfounded
+	StatusApproved = "approved"
+	// StatusRemoved is the status of an item which is deleted by a moderator
+	StatusRemoved = "removed"
+	// StatusDismissed is the status of an item which reports are dropped without a decision on the item
+	StatusDismissed = "dismissed"
+)
+
+var Reasons []interface{} = []interface{}{
+	ReasonSpam,
+	ReasonHarassment,
+	ReasonHate,
+	ReasonViolence,
+	ReasonSexual,
+	ReasonMisinformation,
+	ReasonOther,
+}
+
+var Statuses []interface{} = []interface{}{
+	StatusOpen,
+	StatusApproved,
+	StatusRemoved,
+	StatusDismissed,
+}
+
+var TargetTypes []interface{} = []interface{}{
+	TargetPost,
+	TargetComment,
+}
+
+// Report is the report of a post or a comment by a user, a user reports an item once
+type Report struct {
+	ID         string `json:"id"`
+	TargetType string `json:"targetType"`
+	TargetID   string `json:"targetId"`
+	UserID     uint   `json:"userId"`
+	Reason     string `json:"reason"`
+	// Details are the words of the user, they are required for the "other" reason
+	Details   string    `json:"details,omitempty"`
+	CreatedAt time.Time `json:"created"`
+}
+
+func (e Report) Validate() error {
+	return validation.ValidateStruct(&e,
+		validation.Field(&e.Reason, validation.Required, validation.In(Reasons...)),
+		validation.Field(&e.Details, validation.When(e.Reason == ReasonOther, validation.Required), validation.RuneLength(0, 500)),
+	)
+}
+
+// Item is a reported post or comment in the moderation queue.
+// The reports are counted until the item is resolved, a new report opens a resolved item again.
+type Item struct {
+	ID         string `json:"id"`
+	TargetType string `json:"targetType"`
+	TargetID   string `json:"targetId"`
+	// PostID is the post of a comment, it is the same as TargetID for a post
+	PostID   string `json:"postId"`
+	Category string `json:"category"`
+	Status   string `json:"status"`
+	// Count is the number of the reports since the item was opened
+	Count uint `json:"count"`
+	// Reasons are the numbers of the reports by the reason codes
+	Reasons    map[string]uint `json:"reasons"`
+	Resolution *Resolution     `bson:",omitempty" json:"resolution,omitempty"`
+	CreatedAt  time.Time       `json:"created"`
+	UpdatedAt  time.Time       `json:"updated"`
+}
+
+// Resolution is the decision of a moderator on a queue item
+type Resolution struct {
+	ModeratorID uint `json:"moderatorId"`
+	// Reason is the reason of the removal of the item
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created"`
+}
+
+// QueueFilter is the condition of a listing of the moderation queue
+type QueueFilter struct {
+	Status     string
+	TargetType string
+	// Categories limit the items by the categories, nil means all the categories
+	Categories []string
+}
+
+func (f QueueFilter) Validate() error {
+	return validation.ValidateStruct(&f,
+		validation.Field(&f.Status, validation.In(Statuses...)),
+		validation.Field(&f.TargetType, validation.In(TargetTypes...)),
+	)
+}
@@ -0,0 +1,26 @@
+package report
+
+import (
+	"context"
+
+	"github.com/minipkg/selection_condition"
+)
+
+// Repository encapsulates the logic to access the reports and the moderation queue from the data source.
+type Repository interface {
+	// Create saves a new report.
+	// It returns apperror.ErrConflict if the user has already reported the item.
+	Create(ctx context.Context, entity *Report) error
+	// AddToQueue counts the report in the queue item of its target, the item is created if there is none.
+	// A resolved item is opened again with the report only.
+	AddToQueue(ctx context.Context, entity *Report, item *Item) error
+	// GetItem returns the queue item with the specified ID.
+	GetItem(ctx context.Context, id string) (*Item, error)
+	// QueryItems returns the list of the queue items with the given offset and limit, the where part of the condition is *QueueFilter.
+	QueryItems(ctx context.Context, cond selection_condition.SelectionCondition) ([]Item, error)
+	// Resolve sets the status and the resolution of the open queue item.
+	// It returns apperror.ErrConflict if the item is not open.
+	Resolve(ctx context.Context, id string, status string, resolution *Resolution) error
+	// Counts returns the numbers of the reports of the open queue items of the targets by their IDs.
+	Counts(ctx context.Context, targetType string, ids []string) (map[string]uint, error)
+}
@@ -0,0 +1,263 @@
+package report
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/minipkg/log"
+	"github.com/minipkg/selection_condition"
+
+	"redditclone/internal/domain/comment"
+	"redditclone/internal/domain/modlog"
+	"redditclone/internal/domain/post"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/auth"
+	"redditclone/internal/pkg/errorshandler"
+)
+
+const (
+	MaxLimit = 100
+	// DefaultLimit is the number of the queue items in a list if a limit is not given
+	DefaultLimit = 25
+)
+
+// resolutionActions are the actions of the moderation log by the statuses of the resolved items
+var resolutionActions = map[string]string{
+	StatusApproved:  modlog.ActionApproveReport,
+	StatusRemoved:   modlog.ActionRemoveReport,
+	StatusDismissed: modlog.ActionDismissReport,
+}
+
+// IService encapsulates usecase logic for reports and the moderation queue.
+type IService interface {
+	NewEntity() *Report
+	ReportPost(ctx context.Context, id string, entity *Report) error
+	ReportComment(ctx context.Context, postID string, id string, entity *Report) error
+	Queue(ctx context.Context, filter QueueFilter, offset, limit uint) ([]Item, error)
+	Approve(ctx context.Context, id string) (*Item, error)
+	Remove(ctx context.Context, id string, reason string) (*Item, error)
+	Dismiss(ctx context.Context, id string) (*Item, error)
+	WithCounts(ctx context.Context, items []post.Post) error
+}
+
+type service struct {
+	logger         log.ILogger
+	repository     Repository
+	postService    post.IService
+	commentService comment.IService
+	modLogService  modlog.IService
+}
+
+// NewService creates a new service.
+func NewService(logger log.ILogger, repo Repository, postService post.IService, commentService comment.IService, modLogService modlog.IService) IService {
+	return &service{
+		logger:         logger,
+		repository:     repo,
+		postService:    postService,
+		commentService: commentService,
+		modLogService:  modLogService,
+	}
+}
+
+func (s *service) NewEntity() *Report {
+	return &Report{}
+}
+
+// ReportPost saves the report of the post with the specified ID by the current user and adds the post to the moderation queue.
+// A repeated report of the user changes nothing.
+func (s *service) ReportPost(ctx context.Context, id string, entity *Report) error {
+	p, err := s.postService.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	entity.TargetType = TargetPost
+	entity.TargetID = id
+	return s.report(ctx, entity, &Item{
+		PostID:   p.ID,
+		Category: p.Category,
+	})
+}
+
+// ReportComment saves the report of the comment of the post by the current user and adds the comment to the moderation queue.
+// A repeated report of the user changes nothing.
+func (s *service) ReportComment(ctx context.Context, postID string, id string, entity *Report) error {
+	c, err := s.commentService.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if c.PostID != postID {
+		return apperror.ErrNotFound
+	}
+
+	category, err := s.postService.GetCategory(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	entity.TargetType = TargetComment
+	entity.TargetID = id
+	return s.report(ctx, entity, &Item{
+		PostID:   postID,
+		Category: category,
+	})
+}
+
+func (s *service) report(ctx context.Context, entity *Report, item *Item) error {
+	entity.UserID = auth.CurrentSession(ctx).UserID
+	entity.CreatedAt = time.Now()
+
+	if err := s.repository.Create(ctx, entity); err != nil {
+		if errors.Is(err, apperror.ErrConflict) {
+			return nil
+		}
+		return errors.Wrapf(err, "Can not create a report: %v", entity)
+	}
+
+	if err := s.repository.AddToQueue(ctx, entity, item); err != nil {
+		return errors.Wrapf(err, "Can not add a report to the queue: %v", entity)
+	}
+	return nil
+}
+
+// Queue returns the queue items with the specified offset and limit, the most reported ones go first.
+// A moderator gets the items of the moderated categories only, the open items are listed by default.
+func (s *service) Queue(ctx context.Context, filter QueueFilter, offset, limit uint) ([]Item, error) {
+	sess := auth.CurrentSession(ctx)
+	if !auth.IsAdmin(sess) {
+		if len(filter.Categories) == 0 {
+			filter.Categories = auth.ModeratedCategories(sess)
+		}
+		if len(filter.Categories) == 0 {
+			return nil, errorshandler.Forbidden("")
+		}
+		for _, category := range filter.Categories {
+			if !auth.IsModerator(sess, category) {
+				return nil, errorshandler.Forbidden("")
+			}
+		}
+	}
+	if filter.Status == "" {
+		filter.Status = StatusOpen
+	}
+
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	cond := selection_condition.SelectionCondition{
+		Where: &filter,
+		SortOrder: []map[string]string{
+			{"count": selection_condition.SortOrderDesc},
+			{"createdat": selection_condition.SortOrderAsc},
+		},
+		Offset: offset,
+		Limit:  limit,
+	}
+	items, err := s.repository.QueryItems(ctx, cond)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find a list of queue items by query: %v", cond)
+	}
+	return items, nil
+}
+
+// Approve resolves the open queue item as the one which reports are unfounded, the item is left as is.
+func (s *service) Approve(ctx context.Context, id string) (*Item, error) {
+	return s.resolve(ctx, id, StatusApproved, "")
+}
+
+// Dismiss resolves the open queue item by dropping its reports without a decision on the item.
+func (s *service) Dismiss(ctx context.Context, id string) (*Item, error) {
+	return s.resolve(ctx, id, StatusDismissed, "")
+}
+
+// Remove deletes the post or the comment of the open queue item and resolves the item with the reason.
+func (s *service) Remove(ctx context.Context, id string, reason string) (*Item, error) {
+	if reason == "" {
+		return nil, errors.Wrap(apperror.ErrBadRequest, "reason is required")
+	}
+	return s.resolve(ctx, id, StatusRemoved, reason)
+}
+
+func (s *service) resolve(ctx context.Context, id string, status string, reason string) (*Item, error) {
+	item, err := s.repository.GetItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sess := auth.CurrentSession(ctx)
+	if !auth.IsModerator(sess, item.Category) && !auth.IsAdmin(sess) {
+		return nil, errorshandler.Forbidden("")
+	}
+	if item.Status != StatusOpen {
+		return nil, errors.Wrapf(apperror.ErrConflict, "the item is already %v", item.Status)
+	}
+
+	if status == StatusRemoved {
+		if err = s.deleteTarget(ctx, item); err != nil {
+			return nil, err
+		}
+	}
+
+	resolution := &Resolution{
+		ModeratorID: sess.UserID,
+		Reason:      reason,
+		CreatedAt:   time.Now(),
+	}
+	if err = s.repository.Resolve(ctx, id, status, resolution); err != nil {
+		return nil, errors.Wrapf(err, "Can not resolve a queue item id: %v as %v", id, status)
+	}
+
+	before := *item
+	item.Status = status
+	item.Resolution = resolution
+	item.UpdatedAt = resolution.CreatedAt
+
+	s.modLogService.Record(ctx, modlog.NewEntry(resolutionActions[status], item.TargetType, item.TargetID, item.Category, reason, before, item))
+	return item, nil
+}
+
+// deleteTarget deletes the post or the comment of the item, the target which is already deleted is skipped
+func (s *service) deleteTarget(ctx context.Context, item *Item) (err error) {
+	switch item.TargetType {
+	case TargetPost:
+		err = s.postService.Delete(ctx, item.TargetID)
+	case TargetComment:
+		err = s.commentService.Delete(ctx, item.TargetID)
+	}
+	if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// WithCounts sets the numbers of the reports of the posts which the current user moderates, the other posts are not changed.
+func (s *service) WithCounts(ctx context.Context, items []post.Post) error {
+	sess := auth.CurrentSession(ctx)
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		if auth.IsModerator(sess, item.Category) || auth.IsAdmin(sess) {
+			ids = append(ids, item.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	counts, err := s.repository.Counts(ctx, TargetPost, ids)
+	if err != nil {
+		return errors.Wrapf(err, "Can not count the reports of posts: %v", ids)
+	}
+	for i := range items {
+		if auth.IsModerator(sess, items[i].Category) || auth.IsAdmin(sess) {
+			count := counts[items[i].ID]
+			items[i].Reports = &count
+		}
+	}
+	return nil
+}
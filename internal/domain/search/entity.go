@@ -0,0 +1,85 @@
+package search
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+
+	"redditclone/internal/domain/comment"
+	"redditclone/internal/domain/post"
+)
+
+const (
+	EntityName = "search"
+	// KindPost is the kind of the found posts, the title and the text of a post are searched
+	KindPost = "post"
+	// KindComment is the kind of the found comments, the body of a comment is searched
+	KindComment = "comment"
+	// EngineMongo searches by the text indexes of MongoDB
+	EngineMongo = "mongo"
+	// EngineIndex searches by the in-process inverted index of the posts, it is used when there are no text indexes
+	EngineIndex = "index"
+	// TitleWeight is the weight of the terms of the title of a post against the terms of its text
+	TitleWeight = 3
+)
+
+// Query is the condition of a search
+type Query struct {
+	// Text is the words to search, an item matches any of them, the items with more of them go first
+	Text     string
+	Category string
+	// UserID is the author of the found items
+	UserID uint
+	// Type is the type of the found posts, the comments of the posts of the type are found
+	Type string
+	// From and To limit the time of the creation of the found items, the zero time means no limit
+	From time.Time
+	To   time.Time
+}
+
+func (q Query) Validate() error {
+	return validation.ValidateStruct(&q,
+		validation.Field(&q.Text, validation.Required, validation.RuneLength(2, 200)),
+		validation.Field(&q.Type, validation.In(post.TypeText, post.TypeLink)),
+		validation.Field(&q.To, validation.When(!q.From.IsZero() && !q.To.IsZero(), validation.Min(q.From).Error("must be no earlier than from"))),
+	)
+}
+
+// Terms returns the terms of the text of the query
+func (q Query) Terms() []string {
+	return Terms(q.Text)
+}
+
+// Hit is a post or a comment found by a search
+type Hit struct {
+	Kind string
+	ID   string
+	// PostID is the ID of the post of a comment, it is the same as ID for a post
+	PostID string
+	// Score is the relevance of the item to the query, the scores are comparable within a search only
+	Score float64
+}
+
+// Highlights are the fragments of the texts of a found item, the terms of the search are marked by <em>
+type Highlights struct {
+	Title string `json:"title,omitempty"`
+	// Text is the fragment of the text of a post or of the body of a comment
+	Text string `json:"text,omitempty"`
+}
+
+// Result is a found post or a found comment with its post
+type Result struct {
+	Kind       string           `json:"kind"`
+	Score      float64          `json:"score"`
+	Post       post.Post        `json:"post"`
+	Comment    *comment.Comment `json:"comment,omitempty"`
+	Highlights Highlights       `json:"highlights"`
+}
+
+// Config is the config of the search
+type Config struct {
+	// Engine is either "mongo" or "index". Defaults to "mongo"
+	Engine string
+	// IndexRefresh is the interval in seconds of the rebuilding of the in-process index, the new items are found after that. Defaults to 60
+	IndexRefresh uint
+}
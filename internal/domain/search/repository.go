@@ -0,0 +1,11 @@
+package search
+
+import (
+	"context"
+)
+
+// Repository encapsulates the logic to search the posts and the comments in the data source.
+type Repository interface {
+	// Search returns the posts and the comments matching the query, the most relevant ones go first.
+	Search(ctx context.Context, query *Query, offset, limit uint) ([]Hit, error)
+}
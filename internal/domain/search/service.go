@@ -0,0 +1,137 @@
+package search
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/minipkg/log"
+	"github.com/minipkg/selection_condition"
+
+	"redditclone/internal/domain/comment"
+	"redditclone/internal/domain/post"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/auth"
+)
+
+const (
+	MaxLimit = 100
+	// DefaultLimit is the number of the found items if a limit is not given
+	DefaultLimit = 25
+	// FragmentSize is the size in runes of the highlighted fragment of a text
+	FragmentSize = 200
+)
+
+// IService encapsulates usecase logic for search.
+type IService interface {
+	Search(ctx context.Context, query Query, offset, limit uint) ([]Result, error)
+}
+
+type service struct {
+	logger            log.ILogger
+	repository        Repository
+	postRepository    post.Repository
+	commentRepository comment.Repository
+}
+
+// NewService creates a new service.
+func NewService(logger log.ILogger, repo Repository, postRepository post.Repository, commentRepository comment.Repository) IService {
+	return &service{
+		logger:            logger,
+		repository:        repo,
+		postRepository:    postRepository,
+		commentRepository: commentRepository,
+	}
+}
+
+// Search returns the posts and the comments matching the query with the specified offset and limit, the most relevant ones go first.
+// The found items are highlighted by the terms of the query, the items which have been deleted since they were indexed are skipped.
+// The items of the shadowbanned users are found only for the ones who can see them, the deleted comments of the found posts are shown as "[deleted]".
+func (s *service) Search(ctx context.Context, query Query, offset, limit uint) ([]Result, error) {
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	hits, err := s.repository.Search(ctx, &query, offset, limit)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not search by query: %v", query)
+	}
+
+	posts, err := s.posts(ctx, hits)
+	if err != nil {
+		return nil, err
+	}
+
+	sess := auth.CurrentSession(ctx)
+	terms := query.Terms()
+	items := make([]Result, 0, len(hits))
+	for _, hit := range hits {
+		p, ok := posts[hit.PostID]
+		if !ok {
+			continue
+		}
+		res := Result{
+			Kind:  hit.Kind,
+			Score: hit.Score,
+			Post:  p,
+			Highlights: Highlights{
+				Title: Highlight(p.Title, terms, FragmentSize),
+			},
+		}
+
+		if hit.Kind == KindComment {
+			res.Comment, err = s.commentRepository.Get(ctx, hit.ID)
+			if err != nil {
+				if errors.Is(err, apperror.ErrNotFound) {
+					continue
+				}
+				return nil, errors.Wrapf(err, "Can not get a comment by id: %v", hit.ID)
+			}
+			if res.Comment.IsDeleted() || (res.Comment.Shadowed && !auth.CanSeeShadowed(sess, res.Comment.UserID, p.Category)) {
+				continue
+			}
+			res.Highlights.Text = Highlight(res.Comment.Body, terms, FragmentSize)
+		} else {
+			res.Highlights.Text = Highlight(p.Text, terms, FragmentSize)
+		}
+		items = append(items, res)
+	}
+	return items, nil
+}
+
+// posts returns the posts of the hits by their IDs, the posts of the shadowbanned users are returned only for the ones who can see them
+func (s *service) posts(ctx context.Context, hits []Hit) (map[string]post.Post, error) {
+	res := make(map[string]post.Post, len(hits))
+	if len(hits) == 0 {
+		return res, nil
+	}
+
+	ids := make([]string, 0, len(hits))
+	seen := make(map[string]bool, len(hits))
+	for _, hit := range hits {
+		if !seen[hit.PostID] {
+			seen[hit.PostID] = true
+			ids = append(ids, hit.PostID)
+		}
+	}
+
+	items, err := s.postRepository.Query(ctx, selection_condition.SelectionCondition{
+		Where: &post.Filter{IDs: ids},
+		Limit: uint(len(ids)),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not find posts by ids: %v", ids)
+	}
+	sess := auth.CurrentSession(ctx)
+	for _, item := range items {
+		if item.Shadowed && !auth.CanSeeShadowed(sess, item.UserID, item.Category) {
+			continue
+		}
+		item.Comments = comment.MaskDeleted(sess, comment.WithoutShadowed(sess, item.Comments, item.Category))
+		res[item.ID] = item
+	}
+	return res, nil
+}
@@ -0,0 +1,129 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// span is the position of a word in the runes of a text
+type span struct {
+	start int
+	end   int
+}
+
+// words returns the positions of the words of the text, a word is a sequence of letters and digits
+func words(runes []rune) []span {
+	res := []span{}
+	start := -1
+	for i, r := range runes {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWordRune && start < 0:
+			start = i
+		case !isWordRune && start >= 0:
+			res = append(res, span{start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		res = append(res, span{start: start, end: len(runes)})
+	}
+	return res
+}
+
+// Tokens returns the lower-cased words of the text in the order of the text, including the repeated ones
+func Tokens(text string) []string {
+	runes := []rune(text)
+	spans := words(runes)
+	res := make([]string, 0, len(spans))
+	for _, w := range spans {
+		res = append(res, strings.ToLower(string(runes[w.start:w.end])))
+	}
+	return res
+}
+
+// Terms returns the distinct lower-cased words of the text
+func Terms(text string) []string {
+	tokens := Tokens(text)
+	res := make([]string, 0, len(tokens))
+	seen := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// Highlight returns the fragment of the text of about the size runes around the first of the terms, the terms are marked by <em>.
+// The fragment starts from the beginning of the text if there are no terms in it, the cut ends are marked by "…".
+// The text is escaped, so the fragment is safe to show as HTML.
+func Highlight(text string, terms []string, size int) string {
+	runes := []rune(text)
+	spans := words(runes)
+	isTerm := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		isTerm[t] = true
+	}
+
+	matched := make([]bool, len(spans))
+	first := -1
+	for i, w := range spans {
+		if isTerm[strings.ToLower(string(runes[w.start:w.end]))] {
+			matched[i] = true
+			if first < 0 {
+				first = w.start
+			}
+		}
+	}
+
+	start, end := 0, len(runes)
+	if len(runes) > size {
+		if first > size/4 {
+			start = first - size/4
+		}
+		end = start + size
+		if end > len(runes) {
+			end = len(runes)
+			start = end - size
+		}
+		//	the fragment is cut by the words
+		for _, w := range spans {
+			if w.start < start && w.end > start {
+				start = w.end
+			}
+			if w.start < end && w.end > end {
+				end = w.start
+			}
+		}
+		//	a word longer than the fragment is cut anyway
+		if start >= end {
+			start, end = 0, size
+		}
+	}
+
+	var b strings.Builder
+	pos := start
+	for i, w := range spans {
+		if !matched[i] || w.start < start || w.end > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:w.start])))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(string(runes[w.start:w.end])))
+		b.WriteString("</em>")
+		pos = w.end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+
+	res := strings.TrimSpace(b.String())
+	if start > 0 {
+		res = "…" + res
+	}
+	if end < len(runes) {
+		res += "…"
+	}
+	return res
+}
@@ -5,21 +5,122 @@ import (
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/lib/pq"
 )
 
 const (
 	EntityName = "user"
 	TableName  = "user"
+
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+
+	// DeletedName is shown as the author of the posts and the comments of a deleted user
+	DeletedName = "[deleted]"
+
+	StatusActive = "active"
+	// StatusSuspended is the status of a user who can not log in and act until the end of the suspension
+	StatusSuspended = "suspended"
+	// StatusBanned is the status of a user who can not log in and act anymore
+	StatusBanned = "banned"
+	// StatusShadowbanned is the status of a user who acts as usual, but the new posts and comments of the user are seen by the user only
+	StatusShadowbanned = "shadowbanned"
 )
 
+var Roles []interface{} = []interface{}{
+	RoleUser,
+	RoleModerator,
+	RoleAdmin,
+}
+
+var Statuses []interface{} = []interface{}{
+	StatusActive,
+	StatusSuspended,
+	StatusBanned,
+	StatusShadowbanned,
+}
+
 // User is the user entity
 type User struct {
-	ID        uint       `gorm:"primaryKey"`
-	Name      string     `gorm:"type:varchar(100) not null;unique;index" json:"username"`
-	Passhash  string     `gorm:"type:bytea not null" json:"-"`
-	CreatedAt time.Time  `json:"createdAt"`
-	UpdatedAt time.Time  `json:"updatedAt"`
-	DeletedAt *time.Time `gorm:"index" json:"deletedAt,omitempty"`
+	ID                  uint           `gorm:"primaryKey"`
+	Name                string         `gorm:"type:varchar(100) not null;unique;index" json:"username"`
+	NameNormalized      string         `gorm:"type:varchar(100) not null;default:''" json:"-"`
+	Passhash            string         `gorm:"type:bytea not null" json:"-"`
+	Email               string         `gorm:"type:varchar(255) not null;default:''" json:"-"`
+	Role                string         `gorm:"type:varchar(20) not null;default:'user'" json:"-"`
+	ModeratedCategories pq.StringArray `gorm:"type:varchar(100)[]" json:"-"`
+	CreatedAt           time.Time      `json:"createdAt"`
+	UpdatedAt           time.Time      `json:"updatedAt"`
+	DeletedAt           *time.Time     `gorm:"index" json:"deletedAt,omitempty"`
+	// TOTPSecret is the secret of the two-factor authentication, it is set by the enrollment before TOTPEnabled
+	TOTPSecret  string `gorm:"type:varchar(64) not null;default:''" json:"-"`
+	TOTPEnabled bool   `gorm:"not null;default:false" json:"twoFactorEnabled"`
+	// TOTPLastStep is the time step of the last accepted code, the codes up to it are not accepted again
+	TOTPLastStep int64 `gorm:"not null;default:0" json:"-"`
+	// RecoveryCodes are the SHA-256 hashes of the unused recovery codes of the two-factor authentication
+	RecoveryCodes pq.StringArray `gorm:"type:varchar(64)[]" json:"-"`
+	Bio           string         `gorm:"type:varchar(500) not null;default:''" json:"bio,omitempty"`
+	AvatarURL     string         `gorm:"type:varchar(255) not null;default:''" json:"avatarUrl,omitempty"`
+	// Prefs are shown to the user only
+	Prefs Prefs `gorm:"embedded;embedded_prefix:pref_" json:"-"`
+	// State is set by an admin, it is not shown, so a shadowbanned user does not know about the shadowban
+	State AccountState `gorm:"embedded;embedded_prefix:state_" json:"-"`
+}
+
+// Prefs are the display preferences of a user.
+type Prefs struct {
+	NightMode bool `gorm:"not null;default:false" json:"nightMode"`
+	// DefaultSort is the sort of the lists of posts if it is not set in a request, empty means the default one of the site
+	DefaultSort string `gorm:"type:varchar(20) not null;default:''" json:"defaultSort"`
+	// HideScores hides the scores of the posts and the comments
+	HideScores bool `gorm:"not null;default:false" json:"hideScores"`
+}
+
+// AccountState is the state of the account of a user, an empty status means the active account
+type AccountState struct {
+	Status string `gorm:"type:varchar(20) not null;default:'active'" json:"status"`
+	// Until is the end of a suspension
+	Until *time.Time `json:"until,omitempty"`
+	// Reason is the reason of a suspension or a ban, it is shown to the user
+	Reason string `gorm:"type:varchar(500) not null;default:''" json:"reason,omitempty"`
+}
+
+func (e AccountState) Validate() error {
+	return validation.ValidateStruct(&e,
+		validation.Field(&e.Status, validation.Required, validation.In(Statuses...)),
+		validation.Field(&e.Until, validation.When(e.Status == StatusSuspended, validation.Required, validation.Min(time.Now())).Else(validation.Nil)),
+		validation.Field(&e.Reason, validation.When(e.Status != StatusActive, validation.Required), validation.RuneLength(0, 500)),
+	)
+}
+
+// IsBlocked returns true if the user is banned or is suspended at the time.
+func (e AccountState) IsBlocked(now time.Time) bool {
+	switch e.Status {
+	case StatusBanned:
+		return true
+	case StatusSuspended:
+		return e.Until != nil && now.Before(*e.Until)
+	}
+	return false
+}
+
+// IsShadowbanned returns true if the new content of the user is seen by the user only.
+func (e AccountState) IsShadowbanned() bool {
+	return e.Status == StatusShadowbanned
+}
+
+// Message returns the message about the block of the account which is shown to the user.
+func (e AccountState) Message() string {
+	switch e.Status {
+	case StatusBanned:
+		return "The account is banned: " + e.Reason
+	case StatusSuspended:
+		if e.Until != nil {
+			return "The account is suspended until " + e.Until.Format(time.RFC3339) + ": " + e.Reason
+		}
+	}
+	return ""
 }
 
 func (e User) TableName() string {
@@ -33,6 +134,8 @@ func New() *User {
 
 func (e User) Validate() error {
 	return validation.ValidateStruct(&e,
-		validation.Field(&e.Name, validation.Required, validation.Length(2, 100), is.Alpha),
+		validation.Field(&e.Name, NameRules...),
+		validation.Field(&e.Role, validation.In(Roles...)),
+		validation.Field(&e.Email, is.EmailFormat),
 	)
 }
@@ -0,0 +1,47 @@
+package user
+
+import (
+	"regexp"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// confusables maps the lowercase characters which look like others to the ones they are confused with.
+// It is a part of the Unicode confusables (UTS #39) for the Latin letters and the digits,
+// so the names which differ by the lookalike characters only have the same normalised form.
+// The keys are lowercase since the case is folded before the map is applied. The folding turns "I" into "i",
+// so "i" is mapped to "l" along with "1" and the other lookalikes of "I" and "l", otherwise "Ian" and "lan" would differ.
+// A canonical character is never a key, so the normalised form does not change when it is normalised again.
+var confusables = map[rune]rune{
+	//	digits
+	'0': 'o',
+	'1': 'l',
+	//	Latin
+	'i': 'l', 'ı': 'l',
+	//	Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'к': 'k', 'о': 'o', 'р': 'p', 'с': 'c', 'у': 'y', 'х': 'x', 'і': 'l', 'ӏ': 'l', 'ј': 'j', 'ѕ': 's', 'һ': 'h', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w',
+	//	Greek
+	'α': 'a', 'ε': 'e', 'ι': 'l', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x',
+}
+
+// nameRegexp matches the names of the letters and the digits of any script
+var nameRegexp = regexp.MustCompile(`^[\p{L}\p{N}]+$`)
+
+// NameRules are the validation rules of a user name
+var NameRules = []validation.Rule{validation.Required, validation.RuneLength(2, 100), validation.Match(nameRegexp)}
+
+// NormalizeName returns the form of the name which is unique among the users and is used for the lookups.
+// The name is brought to the compatibility composition (NFKC), the case is folded and then the confusable
+// characters are replaced, so "Alice", "ALICE", "A1ice" and "аlice" with the Cyrillic "а" are the same name.
+func NormalizeName(name string) string {
+	name = cases.Fold().String(norm.NFKC.String(strings.TrimSpace(name)))
+	return strings.Map(func(r rune) rune {
+		if c, ok := confusables[r]; ok {
+			return c
+		}
+		return r
+	}, name)
+}
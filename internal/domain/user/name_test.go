@@ -0,0 +1,39 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name  string
+		other string
+	}{
+		{"ALICE", "alice"},
+		{"Alice", "alice"},
+		{"IVAN", "ivan"},
+		{"BILL", "bill"},
+		{"аlice", "alice"},
+		{"АLICE", "alice"},
+		{"Ｂｏｂ", "bob"},
+		{"b0b", "bob"},
+		{"Straße", "strasse"},
+		{"Ian", "lan"},
+		{"a1ice", "alice"},
+		{"AIICE", "alice"},
+		{"іvan", "ivan"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, NormalizeName(tt.other), NormalizeName(tt.name))
+		})
+	}
+	assert.Equal(t, "allce", NormalizeName("ALICE"))
+	assert.Equal(t, "blll", NormalizeName("BILL"))
+	assert.NotEqual(t, NormalizeName("bob"), NormalizeName("rob"))
+	for _, tt := range tests {
+		assert.Equalf(t, NormalizeName(tt.name), NormalizeName(NormalizeName(tt.name)), "normalised form of %q changes when normalised again", tt.name)
+	}
+}
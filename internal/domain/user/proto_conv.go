@@ -6,10 +6,12 @@ import (
 	"redditclone/internal/pkg/proto"
 )
 
-func UserProto2User(userProto proto.User) (u *User, err error) {
+func UserProto2User(userProto *proto.User) (u *User, err error) {
 	u = &User{
-		ID:   uint(userProto.ID),
-		Name: userProto.Name,
+		ID:                  uint(userProto.ID),
+		Name:                userProto.Name,
+		Role:                userProto.Role,
+		ModeratedCategories: userProto.ModeratedCategories,
 	}
 	if userProto.CreatedAt != nil {
 		u.CreatedAt, err = ptypes.Timestamp(userProto.CreatedAt)
@@ -35,8 +37,10 @@ func UserProto2User(userProto proto.User) (u *User, err error) {
 
 func User2UserProto(user User) (up *proto.User, err error) {
 	up = &proto.User{
-		ID:   uint64(user.ID),
-		Name: user.Name,
+		ID:                  uint64(user.ID),
+		Name:                user.Name,
+		Role:                user.Role,
+		ModeratedCategories: user.ModeratedCategories,
 	}
 	up.CreatedAt, err = ptypes.TimestampProto(user.CreatedAt)
 	if err != nil {
@@ -16,9 +16,14 @@ type Repository interface {
 	SetDefaultConditions(conditions *selection_condition.SelectionCondition)
 	// Create saves a new album in the storage.
 	Create(ctx context.Context, entity *User) error
-	// Update updates the album with given ID in the storage.
-	//Update(ctx context.Context, entity *User) error
-	// Delete removes the album with given ID from the storage.
-	//Delete(ctx context.Context, id uint) error
+	// Update updates the user with given ID in the storage.
+	Update(ctx context.Context, entity *User) error
+	// Delete removes the user with given ID from the storage, the credentials of the user are erased.
+	Delete(ctx context.Context, id uint) error
+	// First returns the first user matching the given fields, the name is matched by its normalised form.
 	First(ctx context.Context, user *User) (*User, error)
+	// QueryWithDeleted returns the users including the deleted ones ordered by ID with the given offset and limit.
+	QueryWithDeleted(ctx context.Context, offset, limit uint) ([]User, error)
+	// SetNameNormalized saves the normalised name of the user.
+	SetNameNormalized(ctx context.Context, id uint, nameNormalized string) error
 }
@@ -7,6 +7,8 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/minipkg/log"
+
+	"redditclone/internal/pkg/apperror"
 )
 
 // IService encapsulates usecase logic for user.
@@ -17,11 +19,15 @@ type IService interface {
 	//List(ctx context.Context) ([]User, error)
 	//Count(ctx context.Context) (uint, error)
 	Create(ctx context.Context, entity *User) error
-	//Update(ctx context.Context, id string, input *User) (*User, error)
-	//Delete(ctx context.Context, id string) (error)
+	Update(ctx context.Context, entity *User) error
+	Delete(ctx context.Context, id uint) error
 	First(ctx context.Context, user *User) (*User, error)
+	NormalizeNames(ctx context.Context, dryRun bool) (updated uint, collisions map[string][]User, err error)
 }
 
+// normalizeBatchSize is the number of users which are read at once by the normalisation of the names
+const normalizeBatchSize = 500
+
 type service struct {
 	//Domain     Domain
 	logger log.ILogger
@@ -80,10 +86,77 @@ func (s service) List(ctx context.Context) ([]User, error) {
 	return items, nil
 }*/
 
+// Create saves the new user, it returns apperror.ErrConflict if the normalised name is taken.
 func (s service) Create(ctx context.Context, entity *User) error {
+	if entity.Role == "" {
+		entity.Role = RoleUser
+	}
+	entity.NameNormalized = NormalizeName(entity.Name)
+
+	_, err := s.repo.First(ctx, &User{Name: entity.Name})
+	if err == nil {
+		return errors.Wrapf(apperror.ErrConflict, "user name %q is taken", entity.Name)
+	}
+	if !errors.Is(err, apperror.ErrNotFound) {
+		return errors.Wrapf(err, "Can not find a user by name: %q", entity.Name)
+	}
 	return s.repo.Create(ctx, entity)
 }
 
+// Update saves the changed user.
+func (s service) Update(ctx context.Context, entity *User) error {
+	if err := s.repo.Update(ctx, entity); err != nil {
+		return errors.Wrapf(err, "Can not update a user by id: %v", entity.ID)
+	}
+	return nil
+}
+
+// Delete removes the user, the name of the user stays taken.
+func (s service) Delete(ctx context.Context, id uint) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return errors.Wrapf(err, "Can not delete a user by id: %v", id)
+	}
+	return nil
+}
+
 func (s service) First(ctx context.Context, user *User) (*User, error) {
 	return s.repo.First(ctx, user)
 }
+
+// NormalizeNames saves the normalised names of the users which have been registered before the names were normalised.
+// The users whose names have the same normalised form are not changed, they are returned by the normalised name
+// to be resolved manually. The lookups of them still find them by the exact name.
+func (s service) NormalizeNames(ctx context.Context, dryRun bool) (updated uint, collisions map[string][]User, err error) {
+	groups := make(map[string][]User)
+	for offset := uint(0); ; offset += normalizeBatchSize {
+		items, err := s.repo.QueryWithDeleted(ctx, offset, normalizeBatchSize)
+		if err != nil {
+			return 0, nil, errors.Wrapf(err, "Can not find a list of users by offset: %v", offset)
+		}
+		for _, item := range items {
+			name := NormalizeName(item.Name)
+			groups[name] = append(groups[name], item)
+		}
+		if len(items) < normalizeBatchSize {
+			break
+		}
+	}
+
+	collisions = make(map[string][]User)
+	for name, items := range groups {
+		if len(items) > 1 {
+			collisions[name] = items
+			continue
+		}
+		if items[0].NameNormalized == name {
+			continue
+		}
+		if !dryRun {
+			if err = s.repo.SetNameNormalized(ctx, items[0].ID, name); err != nil {
+				return updated, collisions, errors.Wrapf(err, "Can not save the normalised name of the user id: %v", items[0].ID)
+			}
+		}
+		updated++
+	}
+	return updated, collisions, nil
+}
@@ -11,19 +11,36 @@ const (
 	TableName  = "vote"
 )
 
-// Vote is the user entity
+// Vote is the user entity. A vote targets a comment if CommentID is set, otherwise it targets a post.
 type Vote struct {
-	ID     string    `gorm:"PRIMARY_KEY" json:"id"`
-	PostID string    `sql:"type:varchar REFERENCES post(id)" json:"postId"`
-	UserID uint      `sql:"type:int REFERENCES \"user\"(id)" json:"user"`
-	User   user.User `gorm:"FOREIGNKEY:UserID;association_autoupdate:false" json:"author"`
-	Value  int       `json:"vote"`
+	ID        string    `gorm:"PRIMARY_KEY" json:"id"`
+	PostID    string    `sql:"type:varchar REFERENCES post(id)" json:"postId"`
+	CommentID string    `sql:"type:varchar REFERENCES comment(id)" bson:",omitempty" json:"commentId,omitempty"`
+	UserID    uint      `sql:"type:int REFERENCES \"user\"(id)" json:"user"`
+	User      user.User `gorm:"FOREIGNKEY:UserID;association_autoupdate:false" json:"author"`
+	Value     int       `json:"vote"`
 
 	CreatedAt time.Time  `json:"created"`
 	UpdatedAt time.Time  `json:"updated"`
 	DeletedAt *time.Time `gorm:"INDEX" json:"deleted"`
 }
 
+// IsForComment returns true if the vote targets a comment
+func (e Vote) IsForComment() bool {
+	return e.CommentID != ""
+}
+
+// Counts returns the numbers of upvotes and downvotes which the vote gives
+func (e Vote) Counts() (ups, downs int) {
+	switch {
+	case e.Value > 0:
+		return 1, 0
+	case e.Value < 0:
+		return 0, 1
+	}
+	return 0, 0
+}
+
 func (e Vote) TableName() string {
 	return TableName
 }
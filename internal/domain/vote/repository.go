@@ -16,10 +16,14 @@ type Repository interface {
 	// Query returns the list of albums with the given offset and limit.
 	Query(ctx context.Context, cond selection_condition.SelectionCondition) ([]Vote, error)
 	// Create saves a new album in the storage.
+	// It returns apperror.ErrConflict if the user has already voted for the target.
 	Create(ctx context.Context, entity *Vote) error
 	// Update updates the album with given ID in the storage.
 	Update(ctx context.Context, entity *Vote) error
+	// UpdateValue changes the value of the vote if it is still equal to the old value, otherwise it returns apperror.ErrNotFound.
+	UpdateValue(ctx context.Context, entity *Vote, oldValue int) error
 	// Delete removes the album with given ID from the storage.
+	// It returns apperror.ErrNotFound if there is no such vote.
 	Delete(ctx context.Context, id string) error
 	First(ctx context.Context, entity *Vote) (*Vote, error)
 }
@@ -0,0 +1,120 @@
+package inmemory
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"redditclone/internal/domain/search"
+)
+
+// bm25K is the saturation of the term frequency: the repeats of a term add less and less to the score
+const bm25K = 1.2
+
+// document is an indexed post or comment with the fields of the filters of a search.
+// A comment has the category and the type of its post.
+type document struct {
+	hit       search.Hit
+	category  string
+	userID    uint
+	postType  string
+	createdAt time.Time
+}
+
+// field is an indexed text of a document, the terms of the text are counted with the weight
+type field struct {
+	text   string
+	weight float64
+}
+
+// index is an inverted index of the documents, it is not safe for concurrent changes.
+type index struct {
+	docs []document
+	// postings are the weighted frequencies of the terms in the documents: term -> document number -> frequency
+	postings map[string]map[int]float64
+}
+
+func newIndex() *index {
+	return &index{
+		postings: make(map[string]map[int]float64),
+	}
+}
+
+// add adds the document with its texts to the index
+func (i *index) add(doc document, fields ...field) {
+	n := len(i.docs)
+	i.docs = append(i.docs, doc)
+
+	for _, f := range fields {
+		for _, term := range search.Tokens(f.text) {
+			posting, ok := i.postings[term]
+			if !ok {
+				posting = make(map[int]float64)
+				i.postings[term] = posting
+			}
+			posting[n] += f.weight
+		}
+	}
+}
+
+// search returns all the documents matching the query ordered by the BM25 score without the length normalization,
+// the documents of the same score go from the newest one.
+func (i *index) search(query *search.Query) []search.Hit {
+	scores := make(map[int]float64)
+	total := float64(len(i.docs))
+
+	for _, term := range query.Terms() {
+		posting := i.postings[term]
+		if len(posting) == 0 {
+			continue
+		}
+		idf := math.Log(1 + total/float64(len(posting)))
+
+		for n, tf := range posting {
+			if !i.docs[n].matches(query) {
+				continue
+			}
+			scores[n] += idf * tf * (bm25K + 1) / (tf + bm25K)
+		}
+	}
+
+	found := make([]int, 0, len(scores))
+	for n := range scores {
+		found = append(found, n)
+	}
+	sort.Slice(found, func(a, b int) bool {
+		docA, docB := i.docs[found[a]], i.docs[found[b]]
+		if scores[found[a]] != scores[found[b]] {
+			return scores[found[a]] > scores[found[b]]
+		}
+		if !docA.createdAt.Equal(docB.createdAt) {
+			return docA.createdAt.After(docB.createdAt)
+		}
+		return docA.hit.ID > docB.hit.ID
+	})
+
+	hits := make([]search.Hit, 0, len(found))
+	for _, n := range found {
+		hit := i.docs[n].hit
+		hit.Score = scores[n]
+		hits = append(hits, hit)
+	}
+	return hits
+}
+
+// matches checks the filters of the query
+func (d document) matches(query *search.Query) bool {
+	switch {
+	case query.Category != "" && d.category != query.Category:
+		return false
+	case query.UserID != 0 && d.userID != query.UserID:
+		return false
+	case query.Type != "" && d.postType != query.Type:
+		return false
+	case !query.From.IsZero() && d.createdAt.Before(query.From):
+		return false
+	case !query.To.IsZero() && d.createdAt.After(query.To):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/minipkg/selection_condition"
+	"github.com/pkg/errors"
+
+	"redditclone/internal/domain/comment"
+	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/search"
+	"redditclone/internal/pkg/pagination"
+)
+
+// indexPageSize is the number of the posts read at once while the index is built
+const indexPageSize = 500
+
+// SearchRepository searches the posts and the comments by the in-process inverted index.
+// The index is rebuilt from all the posts with their comments when it is older than the refresh interval,
+// so it is meant for the setups without the text indexes of the DB, such as the tests.
+type SearchRepository struct {
+	postRepository post.Repository
+	refresh        time.Duration
+
+	mu      sync.Mutex
+	index   *index
+	builtAt time.Time
+}
+
+var _ search.Repository = (*SearchRepository)(nil)
+
+// NewSearchRepository creates a new SearchRepository, zero refresh interval rebuilds the index for every search
+func NewSearchRepository(postRepository post.Repository, refresh time.Duration) *SearchRepository {
+	return &SearchRepository{
+		postRepository: postRepository,
+		refresh:        refresh,
+	}
+}
+
+// Search returns the posts and the comments matching the query with the specified offset and limit, the most relevant ones go first.
+func (r *SearchRepository) Search(ctx context.Context, query *search.Query, offset, limit uint) ([]search.Hit, error) {
+	idx, err := r.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := idx.search(query)
+	if offset >= uint(len(hits)) {
+		return []search.Hit{}, nil
+	}
+	hits = hits[offset:]
+	if limit > 0 && limit < uint(len(hits)) {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// current returns the index, it is rebuilt if it is out of date
+func (r *SearchRepository) current(ctx context.Context) (*index, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.index != nil && time.Since(r.builtAt) < r.refresh {
+		return r.index, nil
+	}
+
+	idx, err := r.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.index = idx
+	r.builtAt = time.Now()
+	return idx, nil
+}
+
+// build reads all the posts from the newest one page by page and indexes them with their comments
+func (r *SearchRepository) build(ctx context.Context) (*index, error) {
+	idx := newIndex()
+	filter := &post.Filter{}
+	cond := selection_condition.SelectionCondition{
+		Where: filter,
+		SortOrder: []map[string]string{
+			{"createdat": selection_condition.SortOrderDesc},
+			{"id": selection_condition.SortOrderDesc},
+		},
+		Limit: indexPageSize,
+	}
+
+	for {
+		items, err := r.postRepository.Query(ctx, cond)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Can not find a list of posts by query: %v", cond)
+		}
+
+		for _, item := range items {
+			indexPost(idx, item)
+		}
+		if len(items) < indexPageSize {
+			return idx, nil
+		}
+
+		last := items[len(items)-1]
+		filter.Cursor = &pagination.Cursor{
+			Key:   "createdat",
+			Value: last.CreatedAt,
+			ID:    last.ID,
+		}
+	}
+}
+
+// indexPost adds the post and all the comments of its tree to the index, the deleted comments are skipped
+func indexPost(idx *index, item post.Post) {
+	idx.add(document{
+		hit: search.Hit{
+			Kind:   search.KindPost,
+			ID:     item.ID,
+			PostID: item.ID,
+		},
+		category:  item.Category,
+		userID:    item.UserID,
+		postType:  item.Type,
+		createdAt: item.CreatedAt,
+	}, field{text: item.Title, weight: search.TitleWeight}, field{text: item.Text, weight: 1})
+
+	var addComments func(comments []comment.Comment)
+	addComments = func(comments []comment.Comment) {
+		for _, c := range comments {
+			if c.IsDeleted() {
+				//	the replies of a deleted comment are still found
+				addComments(c.Replies)
+				continue
+			}
+			idx.add(document{
+				hit: search.Hit{
+					Kind:   search.KindComment,
+					ID:     c.ID,
+					PostID: item.ID,
+				},
+				category:  item.Category,
+				userID:    c.UserID,
+				postType:  item.Type,
+				createdAt: c.CreatedAt,
+			}, field{text: c.Body, weight: 1})
+			addComments(c.Replies)
+		}
+	}
+	addComments(item.Comments)
+}
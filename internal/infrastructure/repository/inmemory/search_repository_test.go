@@ -0,0 +1,139 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/minipkg/selection_condition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"redditclone/internal/domain/comment"
+	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/search"
+	repositoryMock "redditclone/internal/pkg/mock/repository"
+)
+
+type SearchRepositoryTestSuite struct {
+	//	for all tests
+	suite.Suite
+	posts []post.Post
+	//	only for each individual test
+	ctx      context.Context
+	postMock *repositoryMock.PostRepository
+}
+
+func (s *SearchRepositoryTestSuite) SetupSuite() {
+	now := time.Now()
+	s.posts = []post.Post{
+		{
+			ID:        "2",
+			Title:     "Go generics in practice",
+			Type:      post.TypeText,
+			Category:  post.CategoryProgramming,
+			Text:      "How do you use generics?",
+			UserID:    2,
+			CreatedAt: now,
+			Comments: []comment.Comment{
+				{
+					ID:        "20",
+					PostID:    "2",
+					UserID:    1,
+					Body:      "Generics are fine",
+					CreatedAt: now,
+					Replies: []comment.Comment{
+						{ID: "21", PostID: "2", ParentID: "20", UserID: 3, Body: "A reply about the practice of Go", CreatedAt: now},
+					},
+				},
+			},
+		},
+		{
+			ID:        "1",
+			Title:     "What does a good programmer mean?",
+			Type:      post.TypeLink,
+			Category:  post.CategoryMusic,
+			Text:      "Go and write more code in Go, a good programmer practices",
+			UserID:    1,
+			CreatedAt: now.Add(-time.Hour),
+		},
+	}
+}
+
+func (s *SearchRepositoryTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.postMock = &repositoryMock.PostRepository{}
+
+	isIndexQuery := func(cond selection_condition.SelectionCondition) bool {
+		filter, ok := cond.Where.(*post.Filter)
+		return ok && filter.Cursor == nil && cond.Limit == indexPageSize
+	}
+	s.postMock.On("Query", mock.Anything, mock.MatchedBy(isIndexQuery)).Return(s.posts, error(nil))
+}
+
+func TestSearchRepository(t *testing.T) {
+	suite.Run(t, new(SearchRepositoryTestSuite))
+}
+
+func (s *SearchRepositoryTestSuite) ids(hits []search.Hit) []string {
+	res := make([]string, 0, len(hits))
+	for _, h := range hits {
+		res = append(res, h.ID)
+	}
+	return res
+}
+
+func (s *SearchRepositoryTestSuite) TestSearch() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	repo := NewSearchRepository(s.postMock, 0)
+
+	hits, err := repo.Search(s.ctx, &search.Query{Text: "Go practice"}, 0, 10)
+	require.NoError(err)
+
+	//	the terms of the title weigh more, the replies are indexed as well
+	assert.Equal([]string{"2", "21", "1"}, s.ids(hits))
+	assert.Equal(search.KindComment, hits[1].Kind)
+	assert.Equal("2", hits[1].PostID)
+	assert.True(hits[0].Score > hits[1].Score)
+
+	hits, err = repo.Search(s.ctx, &search.Query{Text: "Go practice"}, 1, 1)
+	require.NoError(err)
+	assert.Equal([]string{"21"}, s.ids(hits))
+
+	hits, err = repo.Search(s.ctx, &search.Query{Text: "Go practice"}, 5, 10)
+	require.NoError(err)
+	assert.Empty(hits)
+}
+
+func (s *SearchRepositoryTestSuite) TestSearchFilters() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	repo := NewSearchRepository(s.postMock, 0)
+
+	//	the comments have the category and the type of their posts
+	hits, err := repo.Search(s.ctx, &search.Query{Text: "go generics", Category: post.CategoryProgramming, Type: post.TypeText}, 0, 10)
+	require.NoError(err)
+	assert.Equal([]string{"2", "20", "21"}, s.ids(hits))
+
+	hits, err = repo.Search(s.ctx, &search.Query{Text: "go generics", UserID: 1}, 0, 10)
+	require.NoError(err)
+	assert.Equal([]string{"1", "20"}, s.ids(hits))
+
+	hits, err = repo.Search(s.ctx, &search.Query{Text: "go", To: time.Now().Add(-time.Minute)}, 0, 10)
+	require.NoError(err)
+	assert.Equal([]string{"1"}, s.ids(hits))
+}
+
+func (s *SearchRepositoryTestSuite) TestRefresh() {
+	require := require.New(s.T())
+	repo := NewSearchRepository(s.postMock, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		_, err := repo.Search(s.ctx, &search.Query{Text: "go"}, 0, 10)
+		require.NoError(err)
+	}
+	s.postMock.AssertNumberOfCalls(s.T(), "Query", 1)
+}
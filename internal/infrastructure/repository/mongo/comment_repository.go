@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -14,19 +15,23 @@ import (
 	"redditclone/internal/pkg/apperror"
 
 	"redditclone/internal/domain/comment"
+	"redditclone/internal/domain/user"
+	"redditclone/internal/domain/vote"
 )
 
 // CommentRepository is a repository for the comment entity
 type CommentRepository struct {
 	repository
+	voteCollection minipkg_mongo.ICollection
 }
 
 var _ comment.Repository = (*CommentRepository)(nil)
 
 // New creates a new CommentRepository
-func NewCommentRepository(repository *repository) (*CommentRepository, error) {
+func NewCommentRepository(repository *repository, voteCollection minipkg_mongo.ICollection) (*CommentRepository, error) {
 	return &CommentRepository{
-		repository: *repository,
+		repository:     *repository,
+		voteCollection: voteCollection,
 	}, nil
 }
 
@@ -46,9 +51,9 @@ func (r *CommentRepository) Get(ctx context.Context, id string) (*comment.Commen
 func (r *CommentRepository) Query(ctx context.Context, cond selection_condition.SelectionCondition) ([]comment.Comment, error) {
 	items := []comment.Comment{}
 	var err error
-	condition := minipkg_mongo.QueryWhereCondition(cond.Where)
+	condition := r.queryCondition(cond.Where, cond.SortOrder)
 
-	cursor, err := r.collection.Find(ctx, condition)
+	cursor, err := r.collection.Find(ctx, condition, findOptions(cond, 0))
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return items, apperror.ErrNotFound
@@ -67,6 +72,22 @@ func (r *CommentRepository) Query(ctx context.Context, cond selection_condition.
 	return items, err
 }
 
+// queryCondition converts the where part of a selection condition to a filter.
+// The where part is either *comment.Comment for the equality conditions or *comment.Filter.
+func (r *CommentRepository) queryCondition(where interface{}, sortOrder []map[string]string) bson.M {
+	switch w := where.(type) {
+	case *comment.Comment:
+		return minipkg_mongo.QueryWhereCondition(w)
+	case *comment.Filter:
+		condition := minipkg_mongo.QueryWhereCondition(&w.Comment)
+		if w.Cursor != nil {
+			condition = keysetCondition(condition, w.Cursor, sortOrder)
+		}
+		return condition
+	}
+	return bson.M{}
+}
+
 // Create saves a new album record in the database.
 // It returns the ID of the newly inserted album record.
 func (r *CommentRepository) Create(ctx context.Context, entity *comment.Comment) error {
@@ -89,7 +110,12 @@ func (r *CommentRepository) Update(ctx context.Context, entity *comment.Comment)
 		return errors.Wrap(apperror.ErrBadRequest, "entity is new")
 	}
 
-	res, err := r.collection.UpdateOne(ctx, bson.M{"id": entity.ID}, bson.M{"$set": entity})
+	update, err := setExcept(entity, "score", "deletedat")
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not make an update for entity: %v, error: %v", entity, err)
+	}
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{"id": entity.ID}, update)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return apperror.ErrNotFound
@@ -101,15 +127,148 @@ func (r *CommentRepository) Update(ctx context.Context, entity *comment.Comment)
 	return nil
 }
 
-// Delete deletes an entity with the specified ID from the database.
+// ChangeScore increments the score of the comment by one atomic operation, so concurrent votes are never lost.
+func (r *CommentRepository) ChangeScore(ctx context.Context, id string, diff int) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"id": id}, bson.M{"$inc": bson.M{"score": diff}})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not change score of entity id: %v, error: %v", id, err)
+	}
+	if n, ok := res.(int64); ok && n == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// SetScore overwrites the score of the comment, it is used to reconcile it with the votes.
+func (r *CommentRepository) SetScore(ctx context.Context, id string, score int) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"id": id}, bson.M{"$set": bson.M{"score": score}})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not set score of entity id: %v, error: %v", id, err)
+	}
+	r.logger.Debugf("Set score result: %v", res)
+	return nil
+}
+
+// AuthorStats returns the number of the entities of the user and the sum of their scores, the deleted and the shadowed entities are not counted.
+func (r *CommentRepository) AuthorStats(ctx context.Context, userID uint) (count uint, karma int, err error) {
+	return r.authorStats(ctx, userID)
+}
+
+// AnonymizeAuthor shows the deleted user as the author of all the entities of the user.
+func (r *CommentRepository) AnonymizeAuthor(ctx context.Context, userID uint) error {
+	cursor, err := r.collection.Find(ctx, bson.M{"userid": userID})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	ids := []string{}
+	for cursor.Next(ctx) {
+		entity := &comment.Comment{}
+		if err = cursor.Decode(entity); err != nil {
+			return errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		ids = append(ids, entity.ID)
+	}
+	return r.anonymizeAuthor(ctx, userID, ids)
+}
+
+// Delete marks an entity with the specified ID as deleted, the entity is kept in the database until it is purged.
 func (r *CommentRepository) Delete(ctx context.Context, id string) error {
-	res, err := r.collection.DeleteOne(ctx, bson.M{"id": id})
+	return r.softDelete(ctx, id)
+}
+
+// Restore clears the deletion mark of the deleted entity with the specified ID.
+func (r *CommentRepository) Restore(ctx context.Context, id string) error {
+	return r.restore(ctx, id)
+}
+
+// Purge removes the entities deleted before the time from the database together with their votes.
+// An entity with replies keeps its place in the tree, only its body and author are erased, so it is removed by one of the next purges after its replies.
+func (r *CommentRepository) Purge(ctx context.Context, before time.Time) (count uint, err error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"deletedat": bson.M{"$lte": before}})
+	if err != nil {
+		return 0, errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	items := []comment.Comment{}
+	for cursor.Next(ctx) {
+		item := &comment.Comment{}
+		if err = cursor.Decode(item); err != nil {
+			return 0, errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		items = append(items, *item)
+	}
+
+	for _, item := range items {
+		hasReplies, err := r.hasReplies(ctx, item.ID)
+		if err != nil {
+			return count, err
+		}
+		if hasReplies {
+			if err = r.erase(ctx, item); err != nil {
+				return count, err
+			}
+			continue
+		}
+
+		if err = r.purge(ctx, item.ID); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// hasReplies returns true if the entity with the specified ID has at least one reply.
+func (r *CommentRepository) hasReplies(ctx context.Context, id string) (bool, error) {
+	err := r.collection.FindOne(ctx, bson.M{"parentid": id}).Decode(&comment.Comment{})
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return apperror.ErrNotFound
+			return false, nil
+		}
+		return false, errors.Wrapf(apperror.ErrInternal, "FindOne() error: %v", err)
+	}
+	return true, nil
+}
+
+// erase clears the body and the author of the deleted entity which has been erased not yet.
+func (r *CommentRepository) erase(ctx context.Context, entity comment.Comment) error {
+	if entity.Body == "" {
+		return nil
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"id": entity.ID}, bson.M{"$set": bson.M{
+		"body":   "",
+		"userid": 0,
+		"user":   bson.M{"name": user.DeletedName},
+	}})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not erase entity id: %v, error: %v", entity.ID, err)
+	}
+	return nil
+}
+
+// purge removes the entity with the specified ID and its votes, the entity is removed last, so a failed purge is continued by the next one.
+func (r *CommentRepository) purge(ctx context.Context, id string) error {
+	cursor, err := r.voteCollection.Find(ctx, bson.M{"commentid": id})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	voteIDs := []string{}
+	for cursor.Next(ctx) {
+		item := &vote.Vote{}
+		if err = cursor.Decode(item); err != nil {
+			return errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
 		}
+		voteIDs = append(voteIDs, item.ID)
+	}
+	if err = deleteByIDs(ctx, r.voteCollection, voteIDs); err != nil {
+		return err
+	}
+
+	if _, err = r.collection.DeleteOne(ctx, bson.M{"id": id}); err != nil {
 		return errors.Wrapf(apperror.ErrInternal, "Can not delete entity id: %v, error: %v", id, err)
 	}
-	r.logger.Debugf("Delete result: %v", res)
 	return nil
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	dbmockmongo "github.com/minipkg/db/mongo/mock"
@@ -18,6 +19,9 @@ import (
 
 	"redditclone/internal/domain/comment"
 	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/user"
+	"redditclone/internal/domain/vote"
+	"redditclone/internal/pkg/apperror"
 	"redditclone/internal/pkg/config"
 )
 
@@ -31,6 +35,8 @@ type CommentRepositoryTestSuite struct {
 	ctx                   context.Context
 	dbMock                *dbmockmongo.DB
 	commentCollectionMock *dbmockmongo.Collection
+	voteCollectionMock    *dbmockmongo.Collection
+	commentAggregatorMock *aggregatorMock
 	repository            comment.Repository
 }
 
@@ -55,6 +61,8 @@ func (s *CommentRepositoryTestSuite) SetupSuite() {
 	s.dbMock = &dbmockmongo.DB{}
 
 	s.commentCollectionMock = &dbmockmongo.Collection{}
+	s.voteCollectionMock = &dbmockmongo.Collection{}
+	s.commentAggregatorMock = &aggregatorMock{}
 }
 
 func (s *CommentRepositoryTestSuite) SetupTest() {
@@ -63,9 +71,12 @@ func (s *CommentRepositoryTestSuite) SetupTest() {
 	s.ctx = context.Background()
 
 	*s.commentCollectionMock = dbmockmongo.Collection{}
+	*s.voteCollectionMock = dbmockmongo.Collection{}
+	*s.commentAggregatorMock = aggregatorMock{}
 	s.dbMock.On("Collection", comment.TableName, []*options.CollectionOptions(nil)).Return(s.commentCollectionMock)
+	s.dbMock.On("Collection", vote.TableName, []*options.CollectionOptions(nil)).Return(s.voteCollectionMock)
 
-	r, err := GetRepository(s.logger, s.dbMock, comment.EntityName)
+	r, err := GetRepository(s.logger, s.dbMock, aggregationDBMock{comment.TableName: s.commentAggregatorMock}, comment.EntityName)
 	require.NoError(err)
 
 	s.repository, ok = r.(comment.Repository)
@@ -107,7 +118,7 @@ func (s *CommentRepositoryTestSuite) TestQuery() {
 			PostID: s.comment.PostID,
 		},
 	}
-	s.commentCollectionMock.On("Find", s.ctx, bson.M{"postid": s.comment.PostID}, []*options.FindOptions(nil)).Return(cursor, error(nil))
+	s.commentCollectionMock.On("Find", s.ctx, bson.M{"postid": s.comment.PostID}, []*options.FindOptions{options.Find()}).Return(cursor, error(nil))
 
 	res, err := s.repository.Query(s.ctx, condition)
 	assert.NoError(err)
@@ -131,17 +142,112 @@ func (s *CommentRepositoryTestSuite) TestCreate() {
 func (s *CommentRepositoryTestSuite) TestUpdate() {
 	assert := assert.New(s.T())
 
-	s.commentCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.comment.ID}, bson.M{"$set": s.comment}).Return("update test", error(nil))
+	withoutScore := func(update bson.M) bool {
+		doc, ok := update["$set"].(bson.M)
+		_, hasScore := doc["score"]
+		return ok && doc["id"] == s.comment.ID && !hasScore
+	}
+
+	s.commentCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.comment.ID}, mock.MatchedBy(withoutScore)).Return("update test", error(nil))
 
 	err := s.repository.Update(s.ctx, s.comment)
 	assert.NoError(err)
 }
 
+func (s *CommentRepositoryTestSuite) TestChangeScore() {
+	assert := assert.New(s.T())
+
+	s.commentCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.comment.ID}, bson.M{"$inc": bson.M{"score": -2}}).Return(int64(1), error(nil))
+
+	err := s.repository.ChangeScore(s.ctx, s.comment.ID, -2)
+	assert.NoError(err)
+}
+
 func (s *CommentRepositoryTestSuite) TestDelete() {
 	assert := assert.New(s.T())
 
-	s.commentCollectionMock.On("DeleteOne", s.ctx, bson.M{"id": s.comment.ID}).Return(int64(123), error(nil))
+	setDeletedAt := func(update bson.M) bool {
+		doc, ok := update["$set"].(bson.M)
+		_, isTime := doc["deletedat"].(time.Time)
+		return ok && isTime && len(doc) == 1
+	}
+	s.commentCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.comment.ID, "deletedat": nil}, mock.MatchedBy(setDeletedAt)).Return(int64(1), error(nil))
 
 	err := s.repository.Delete(s.ctx, s.comment.ID)
 	assert.NoError(err)
 }
+
+func (s *CommentRepositoryTestSuite) TestRestoreNotFound() {
+	assert := assert.New(s.T())
+
+	s.commentCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.comment.ID, "deletedat": bson.M{"$ne": nil}}, bson.M{"$set": bson.M{"deletedat": nil}}).Return(int64(0), error(nil))
+
+	err := s.repository.Restore(s.ctx, s.comment.ID)
+	assert.Equal(apperror.ErrNotFound, err)
+}
+
+func (s *CommentRepositoryTestSuite) TestPurge() {
+	assert := assert.New(s.T())
+	before := time.Now().AddDate(0, 0, -30)
+	deletedAt := before.Add(-time.Hour)
+
+	withReplies := &comment.Comment{}
+	*withReplies = *s.comment
+	withReplies.DeletedAt = &deletedAt
+	withoutReplies := &comment.Comment{}
+	*withoutReplies = *withReplies
+	withoutReplies.ID = "11"
+	v := &vote.Vote{ID: "21", PostID: s.comment.PostID, CommentID: withoutReplies.ID, UserID: 2, Value: 1}
+
+	s.commentCollectionMock.On("Find", s.ctx, bson.M{"deletedat": bson.M{"$lte": before}}, []*options.FindOptions(nil)).
+		Return(&dbmockmongo.Cursor{Res: []interface{}{withReplies, withoutReplies}}, error(nil))
+	s.commentCollectionMock.On("FindOne", s.ctx, bson.M{"parentid": withReplies.ID}, []*options.FindOneOptions(nil)).
+		Return(dbmockmongo.SingleResult{Entity: &comment.Comment{ID: "12", ParentID: withReplies.ID}})
+	s.commentCollectionMock.On("FindOne", s.ctx, bson.M{"parentid": withoutReplies.ID}, []*options.FindOneOptions(nil)).
+		Return(dbmockmongo.SingleResult{Entity: &comment.Comment{}, Err: mongo.ErrNoDocuments})
+	erase := bson.M{"$set": bson.M{
+		"body":   "",
+		"userid": 0,
+		"user":   bson.M{"name": user.DeletedName},
+	}}
+	s.commentCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": withReplies.ID}, erase).Return(int64(1), error(nil)).Once()
+	s.voteCollectionMock.On("Find", s.ctx, bson.M{"commentid": withoutReplies.ID}, []*options.FindOptions(nil)).
+		Return(&dbmockmongo.Cursor{Res: []interface{}{v}}, error(nil))
+	s.voteCollectionMock.On("DeleteOne", s.ctx, bson.M{"id": v.ID}).Return(int64(1), error(nil)).Once()
+	s.commentCollectionMock.On("DeleteOne", s.ctx, bson.M{"id": withoutReplies.ID}).Return(int64(1), error(nil)).Once()
+
+	count, err := s.repository.Purge(s.ctx, before)
+	assert.NoError(err)
+	assert.Equal(uint(1), count)
+}
+
+func (s *CommentRepositoryTestSuite) TestAnonymizeAuthor() {
+	assert := assert.New(s.T())
+
+	cursor := &dbmockmongo.Cursor{
+		Res: []interface{}{s.comment},
+	}
+	update := bson.M{"$set": bson.M{
+		"userid": 0,
+		"user":   bson.M{"name": user.DeletedName},
+	}}
+	s.commentCollectionMock.On("Find", s.ctx, bson.M{"userid": s.comment.UserID}, []*options.FindOptions(nil)).Return(cursor, error(nil))
+	s.commentCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.comment.ID}, update).Return(int64(1), error(nil))
+
+	err := s.repository.AnonymizeAuthor(s.ctx, s.comment.UserID)
+	assert.NoError(err)
+}
+
+func (s *CommentRepositoryTestSuite) TestAuthorStats() {
+	assert := assert.New(s.T())
+
+	cursor := &cursorMock{
+		Cursor: dbmockmongo.Cursor{Res: []interface{}{&authorStats{Count: 3, Karma: -2}}},
+	}
+	s.commentAggregatorMock.On("Aggregate", s.ctx, authorStatsPipeline(s.comment.UserID)).Return(cursor, error(nil))
+
+	count, karma, err := s.repository.AuthorStats(s.ctx, s.comment.UserID)
+	assert.NoError(err)
+	assert.Equal(uint(3), count)
+	assert.Equal(-2, karma)
+}
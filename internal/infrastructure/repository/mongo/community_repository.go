@@ -0,0 +1,255 @@
+package mongo
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/pkg/errors"
+
+	"github.com/google/uuid"
+	minipkg_mongo "github.com/minipkg/db/mongo"
+	"github.com/minipkg/selection_condition"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"redditclone/internal/pkg/apperror"
+
+	"redditclone/internal/domain/community"
+)
+
+// CommunityRepository is a repository for the community entity
+type CommunityRepository struct {
+	repository
+	subscriptionCollection minipkg_mongo.ICollection
+	banCollection          minipkg_mongo.ICollection
+}
+
+var _ community.Repository = (*CommunityRepository)(nil)
+
+// New creates a new CommunityRepository
+func NewCommunityRepository(repository *repository, subscriptionCollection minipkg_mongo.ICollection, banCollection minipkg_mongo.ICollection) (*CommunityRepository, error) {
+	return &CommunityRepository{
+		repository:             *repository,
+		subscriptionCollection: subscriptionCollection,
+		banCollection:          banCollection,
+	}, nil
+}
+
+// Get reads the community with the specified name from the database.
+func (r *CommunityRepository) Get(ctx context.Context, name string) (*community.Community, error) {
+	entity := &community.Community{}
+	err := r.collection.FindOne(ctx, bson.M{"name": name}).Decode(entity)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperror.ErrNotFound
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "FindOne() error: %v", err)
+	}
+	return entity, nil
+}
+
+// Query retrieves records with the specified offset and limit from the database.
+func (r *CommunityRepository) Query(ctx context.Context, cond selection_condition.SelectionCondition) ([]community.Community, error) {
+	condition := bson.M{}
+	if cond.Where != nil {
+		condition = minipkg_mongo.QueryWhereCondition(cond.Where)
+	}
+	return r.find(ctx, condition, findOptions(cond, r.Conditions.Limit))
+}
+
+// Search retrieves the communities which name or description contains the text case-insensitively.
+func (r *CommunityRepository) Search(ctx context.Context, text string, offset, limit uint) ([]community.Community, error) {
+	pattern := containsRegex(text)
+	condition := bson.M{"$or": bson.A{
+		bson.M{"name": pattern},
+		bson.M{"description": pattern},
+	}}
+	cond := selection_condition.SelectionCondition{
+		SortOrder: r.Conditions.SortOrder,
+		Offset:    offset,
+		Limit:     limit,
+	}
+	return r.find(ctx, condition, findOptions(cond, r.Conditions.Limit))
+}
+
+// containsRegex returns the case-insensitive condition of a substring match of the text.
+func containsRegex(text string) bson.M {
+	return bson.M{"$regex": regexp.QuoteMeta(text), "$options": "i"}
+}
+
+func (r *CommunityRepository) find(ctx context.Context, condition bson.M, opts *options.FindOptions) ([]community.Community, error) {
+	items := []community.Community{}
+
+	cursor, err := r.collection.Find(ctx, condition, opts)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return items, nil
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	for cursor.Next(ctx) {
+		item := &community.Community{}
+		if err = cursor.Decode(item); err != nil {
+			return nil, errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		items = append(items, *item)
+	}
+	return items, nil
+}
+
+// Create saves a new community in the database.
+// It returns apperror.ErrConflict if a community with the name already exists.
+func (r *CommunityRepository) Create(ctx context.Context, entity *community.Community) error {
+	if entity.ID != "" {
+		return errors.Wrap(apperror.ErrBadRequest, "entity is not new")
+	}
+
+	entity.ID = uuid.New().String()
+
+	id, err := r.collection.InsertOne(ctx, entity)
+	if err != nil {
+		entity.ID = ""
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.Wrapf(apperror.ErrConflict, "The community already exists: %q", entity.Name)
+		}
+		return errors.Wrapf(apperror.ErrInternal, "Can not create a recordset for an object %v, error: %v", entity, err)
+	}
+	r.logger.Debugf("Create records InsertedID: %v", id)
+	return nil
+}
+
+// Subscribe saves the subscription and increments the number of the subscribers of the community.
+// The subscription is unique by the community and the user, so a repeated subscription does not change the number.
+func (r *CommunityRepository) Subscribe(ctx context.Context, entity *community.Subscription) error {
+	if entity.ID != "" {
+		return errors.Wrap(apperror.ErrBadRequest, "entity is not new")
+	}
+
+	entity.ID = uuid.New().String()
+
+	if _, err := r.subscriptionCollection.InsertOne(ctx, entity); err != nil {
+		entity.ID = ""
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.Wrapf(apperror.ErrConflict, "The subscription already exists: %v", entity)
+		}
+		return errors.Wrapf(apperror.ErrInternal, "Can not create a recordset for an object %v, error: %v", entity, err)
+	}
+
+	return r.changeSubscribers(ctx, entity.Community, 1)
+}
+
+// Unsubscribe deletes the subscription and decrements the number of the subscribers of the community.
+func (r *CommunityRepository) Unsubscribe(ctx context.Context, name string, userID uint) error {
+	res, err := r.subscriptionCollection.DeleteOne(ctx, bson.M{"community": name, "userid": userID})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not delete subscription of user id: %v to community: %q, error: %v", userID, name, err)
+	}
+	if res == 0 {
+		return apperror.ErrNotFound
+	}
+
+	return r.changeSubscribers(ctx, name, -1)
+}
+
+// changeSubscribers increments the number of the subscribers of the community by one atomic operation.
+func (r *CommunityRepository) changeSubscribers(ctx context.Context, name string, diff int) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"name": name}, bson.M{"$inc": bson.M{"subscribers": diff}})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not change subscribers of community: %q, error: %v", name, err)
+	}
+	r.logger.Debugf("Change subscribers result: %v", res)
+	return nil
+}
+
+// Subscriptions retrieves the communities which the user is subscribed to ordered by the name.
+func (r *CommunityRepository) Subscriptions(ctx context.Context, userID uint) ([]community.Community, error) {
+	cursor, err := r.subscriptionCollection.Find(ctx, bson.M{"userid": userID})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return []community.Community{}, nil
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	names := bson.A{}
+	for cursor.Next(ctx) {
+		item := &community.Subscription{}
+		if err = cursor.Decode(item); err != nil {
+			return nil, errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		names = append(names, item.Community)
+	}
+	if len(names) == 0 {
+		return []community.Community{}, nil
+	}
+
+	return r.find(ctx, bson.M{"name": bson.M{"$in": names}}, options.Find().SetSort(bson.M{"name": 1}))
+}
+
+// CreateBan saves a new ban in the database.
+// The ban is unique by the community and the user, so a repeated ban returns apperror.ErrConflict.
+func (r *CommunityRepository) CreateBan(ctx context.Context, entity *community.Ban) error {
+	if entity.ID != "" {
+		return errors.Wrap(apperror.ErrBadRequest, "entity is not new")
+	}
+
+	entity.ID = uuid.New().String()
+
+	if _, err := r.banCollection.InsertOne(ctx, entity); err != nil {
+		entity.ID = ""
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.Wrapf(apperror.ErrConflict, "The ban already exists: %v", entity)
+		}
+		return errors.Wrapf(apperror.ErrInternal, "Can not create a recordset for an object %v, error: %v", entity, err)
+	}
+	return nil
+}
+
+// DeleteBan deletes the ban of the user in the community from the database.
+func (r *CommunityRepository) DeleteBan(ctx context.Context, name string, userID uint) error {
+	res, err := r.banCollection.DeleteOne(ctx, bson.M{"community": name, "userid": userID})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not delete ban of user id: %v in community: %q, error: %v", userID, name, err)
+	}
+	if res == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// GetBan reads the ban of the user in the community from the database.
+func (r *CommunityRepository) GetBan(ctx context.Context, name string, userID uint) (*community.Ban, error) {
+	entity := &community.Ban{}
+	err := r.banCollection.FindOne(ctx, bson.M{"community": name, "userid": userID}).Decode(entity)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperror.ErrNotFound
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "FindOne() error: %v", err)
+	}
+	return entity, nil
+}
+
+// Bans retrieves the bans in the community ordered by the creation time descending.
+func (r *CommunityRepository) Bans(ctx context.Context, name string) ([]community.Ban, error) {
+	items := []community.Ban{}
+
+	cursor, err := r.banCollection.Find(ctx, bson.M{"community": name}, options.Find().SetSort(bson.M{"createdat": -1}))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return items, nil
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	for cursor.Next(ctx) {
+		item := &community.Ban{}
+		if err = cursor.Decode(item); err != nil {
+			return nil, errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		items = append(items, *item)
+	}
+	return items, nil
+}
@@ -0,0 +1,261 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/config"
+
+	dbmockmongo "github.com/minipkg/db/mongo/mock"
+	"github.com/minipkg/log"
+
+	"redditclone/internal/domain/community"
+)
+
+type CommunityRepositoryTestSuite struct {
+	//	for all tests
+	suite.Suite
+	cfg          *config.Configuration
+	logger       *log.Logger
+	community    *community.Community
+	subscription *community.Subscription
+	ban          *community.Ban
+	//	only for each individual test
+	ctx                        context.Context
+	dbMock                     *dbmockmongo.DB
+	communityCollectionMock    *dbmockmongo.Collection
+	subscriptionCollectionMock *dbmockmongo.Collection
+	banCollectionMock          *dbmockmongo.Collection
+	repository                 community.Repository
+}
+
+func (s *CommunityRepositoryTestSuite) SetupSuite() {
+	var err error
+
+	s.cfg = config.Get4UnitTest("CommunityRepository")
+
+	s.logger, err = log.New(s.cfg.Log)
+	require.NoError(s.T(), err)
+
+	s.community = &community.Community{
+		ID:          "10",
+		Name:        "programming",
+		Description: "Programming languages, tools and practices",
+		Rules:       []string{"Be nice"},
+		OwnerID:     1,
+		Subscribers: 5,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	s.subscription = &community.Subscription{
+		Community: s.community.Name,
+		UserID:    1,
+		CreatedAt: time.Now(),
+	}
+	s.ban = &community.Ban{
+		Community:   s.community.Name,
+		UserID:      2,
+		ModeratorID: 1,
+		Reason:      "Spam",
+		CreatedAt:   time.Now(),
+	}
+
+	s.dbMock = &dbmockmongo.DB{}
+
+	s.communityCollectionMock = &dbmockmongo.Collection{}
+	s.subscriptionCollectionMock = &dbmockmongo.Collection{}
+	s.banCollectionMock = &dbmockmongo.Collection{}
+}
+
+func (s *CommunityRepositoryTestSuite) SetupTest() {
+	var ok bool
+	require := require.New(s.T())
+	s.ctx = context.Background()
+
+	*s.communityCollectionMock = dbmockmongo.Collection{}
+	*s.subscriptionCollectionMock = dbmockmongo.Collection{}
+	*s.banCollectionMock = dbmockmongo.Collection{}
+	s.dbMock.On("Collection", community.TableName, []*options.CollectionOptions(nil)).Return(s.communityCollectionMock)
+	s.dbMock.On("Collection", community.SubscriptionTableName, []*options.CollectionOptions(nil)).Return(s.subscriptionCollectionMock)
+	s.dbMock.On("Collection", community.BanTableName, []*options.CollectionOptions(nil)).Return(s.banCollectionMock)
+
+	r, err := GetRepository(s.logger, s.dbMock, nil, community.EntityName)
+	require.NoError(err)
+
+	s.repository, ok = r.(community.Repository)
+	require.Truef(ok, "Can not cast DB repository for entity %q to %vRepository. Repo: %v", community.EntityName, community.EntityName, r)
+}
+
+func TestCommunityRepository(t *testing.T) {
+	suite.Run(t, new(CommunityRepositoryTestSuite))
+}
+
+func (s *CommunityRepositoryTestSuite) TestGet() {
+	assert := assert.New(s.T())
+
+	result := &dbmockmongo.SingleResult{
+		Entity: s.community,
+		Err:    nil,
+	}
+
+	s.communityCollectionMock.On("FindOne", s.ctx, bson.M{"name": s.community.Name}, []*options.FindOneOptions(nil)).Return(result)
+
+	res, err := s.repository.Get(s.ctx, s.community.Name)
+	assert.NoError(err)
+
+	assert.Equalf(*s.community, *res, "The two objects should be the same. Expected: %v; have got: %v", *s.community, *res)
+}
+
+func (s *CommunityRepositoryTestSuite) TestSearch() {
+	assert := assert.New(s.T())
+
+	cursor := &dbmockmongo.Cursor{
+		Res: []interface{}{s.community},
+	}
+	pattern := bson.M{"$regex": `c\+\+`, "$options": "i"}
+	condition := bson.M{"$or": bson.A{
+		bson.M{"name": pattern},
+		bson.M{"description": pattern},
+	}}
+	s.communityCollectionMock.On("Find", s.ctx, condition, mock.Anything).Return(cursor, error(nil))
+
+	res, err := s.repository.Search(s.ctx, "c++", 0, 10)
+	assert.NoError(err)
+
+	assert.Equal([]community.Community{*s.community}, res)
+}
+
+func (s *CommunityRepositoryTestSuite) TestCreateDuplicate() {
+	assert := assert.New(s.T())
+	newItem := &community.Community{}
+	*newItem = *s.community
+	newItem.ID = ""
+	duplicate := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}
+
+	s.communityCollectionMock.On("InsertOne", s.ctx, mock.Anything).Return(nil, duplicate)
+
+	err := s.repository.Create(s.ctx, newItem)
+	assert.Equal(apperror.ErrConflict, errors.Cause(err))
+	assert.Empty(newItem.ID, "entity.ID should be empty")
+}
+
+func (s *CommunityRepositoryTestSuite) TestSubscribe() {
+	assert := assert.New(s.T())
+	newItem := &community.Subscription{}
+	*newItem = *s.subscription
+
+	s.subscriptionCollectionMock.On("InsertOne", s.ctx, newItem).Return("create test", error(nil))
+	s.communityCollectionMock.On("UpdateOne", s.ctx, bson.M{"name": s.community.Name}, bson.M{"$inc": bson.M{"subscribers": 1}}).Return(int64(1), error(nil))
+
+	err := s.repository.Subscribe(s.ctx, newItem)
+	assert.NoError(err)
+	assert.NotEmpty(newItem.ID, "entity.ID should be is not empty")
+}
+
+func (s *CommunityRepositoryTestSuite) TestSubscribeDuplicate() {
+	assert := assert.New(s.T())
+	newItem := &community.Subscription{}
+	*newItem = *s.subscription
+	duplicate := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}
+
+	// the number of the subscribers is not changed, the mock panics on an unexpected UpdateOne call
+	s.subscriptionCollectionMock.On("InsertOne", s.ctx, newItem).Return(nil, duplicate)
+
+	err := s.repository.Subscribe(s.ctx, newItem)
+	assert.Equal(apperror.ErrConflict, errors.Cause(err))
+}
+
+func (s *CommunityRepositoryTestSuite) TestUnsubscribe() {
+	assert := assert.New(s.T())
+
+	s.subscriptionCollectionMock.On("DeleteOne", s.ctx, bson.M{"community": s.community.Name, "userid": s.subscription.UserID}).Return(int64(1), error(nil)).Once()
+	s.communityCollectionMock.On("UpdateOne", s.ctx, bson.M{"name": s.community.Name}, bson.M{"$inc": bson.M{"subscribers": -1}}).Return(int64(1), error(nil)).Once()
+	s.subscriptionCollectionMock.On("DeleteOne", s.ctx, bson.M{"community": s.community.Name, "userid": s.subscription.UserID}).Return(int64(0), error(nil))
+
+	err := s.repository.Unsubscribe(s.ctx, s.community.Name, s.subscription.UserID)
+	assert.NoError(err)
+
+	err = s.repository.Unsubscribe(s.ctx, s.community.Name, s.subscription.UserID)
+	assert.Equal(apperror.ErrNotFound, err)
+}
+
+func (s *CommunityRepositoryTestSuite) TestSubscriptions() {
+	assert := assert.New(s.T())
+
+	subscriptions := &dbmockmongo.Cursor{
+		Res: []interface{}{s.subscription},
+	}
+	communities := &dbmockmongo.Cursor{
+		Res: []interface{}{s.community},
+	}
+	s.subscriptionCollectionMock.On("Find", s.ctx, bson.M{"userid": s.subscription.UserID}, []*options.FindOptions(nil)).Return(subscriptions, error(nil))
+	s.communityCollectionMock.On("Find", s.ctx, bson.M{"name": bson.M{"$in": bson.A{s.community.Name}}}, mock.Anything).Return(communities, error(nil))
+
+	res, err := s.repository.Subscriptions(s.ctx, s.subscription.UserID)
+	assert.NoError(err)
+
+	assert.Equal([]community.Community{*s.community}, res)
+}
+
+func (s *CommunityRepositoryTestSuite) TestCreateBan() {
+	assert := assert.New(s.T())
+	newItem := &community.Ban{}
+	*newItem = *s.ban
+
+	s.banCollectionMock.On("InsertOne", s.ctx, newItem).Return("create test", error(nil))
+
+	err := s.repository.CreateBan(s.ctx, newItem)
+	assert.NoError(err)
+	assert.NotEmpty(newItem.ID, "entity.ID should be is not empty")
+}
+
+func (s *CommunityRepositoryTestSuite) TestCreateBanDuplicate() {
+	assert := assert.New(s.T())
+	newItem := &community.Ban{}
+	*newItem = *s.ban
+	duplicate := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}
+
+	s.banCollectionMock.On("InsertOne", s.ctx, mock.Anything).Return(nil, duplicate)
+
+	err := s.repository.CreateBan(s.ctx, newItem)
+	assert.Equal(apperror.ErrConflict, errors.Cause(err))
+	assert.Empty(newItem.ID, "entity.ID should be empty")
+}
+
+func (s *CommunityRepositoryTestSuite) TestGetBan() {
+	assert := assert.New(s.T())
+
+	result := &dbmockmongo.SingleResult{
+		Entity: s.ban,
+		Err:    nil,
+	}
+	s.banCollectionMock.On("FindOne", s.ctx, bson.M{"community": s.ban.Community, "userid": s.ban.UserID}, []*options.FindOneOptions(nil)).Return(result)
+
+	res, err := s.repository.GetBan(s.ctx, s.ban.Community, s.ban.UserID)
+	assert.NoError(err)
+	assert.Equal(s.ban.Reason, res.Reason)
+}
+
+func (s *CommunityRepositoryTestSuite) TestDeleteBan() {
+	assert := assert.New(s.T())
+
+	s.banCollectionMock.On("DeleteOne", s.ctx, bson.M{"community": s.ban.Community, "userid": s.ban.UserID}).Return(int64(1), error(nil)).Once()
+	s.banCollectionMock.On("DeleteOne", s.ctx, bson.M{"community": s.ban.Community, "userid": s.ban.UserID}).Return(int64(0), error(nil))
+
+	err := s.repository.DeleteBan(s.ctx, s.ban.Community, s.ban.UserID)
+	assert.NoError(err)
+
+	err = s.repository.DeleteBan(s.ctx, s.ban.Community, s.ban.UserID)
+	assert.Equal(apperror.ErrNotFound, err)
+}
@@ -0,0 +1,74 @@
+package mongo
+
+import (
+	"context"
+
+	mongodb "github.com/minipkg/db/mongo"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ICursor is a cursor which reports the error happened while iterating it
+type ICursor interface {
+	mongodb.ICursor
+	Err() error
+}
+
+// IAggregator runs the aggregation pipelines on a collection, the collections of the DB do not run them
+type IAggregator interface {
+	Aggregate(ctx context.Context, pipeline interface{}) (ICursor, error)
+}
+
+// IAggregationDB gives the aggregators of the collections by the names
+type IAggregationDB interface {
+	Aggregator(name string) IAggregator
+}
+
+// AggregationDB is the connection to the database which runs the aggregation pipelines
+type AggregationDB struct {
+	db *mongo.Database
+}
+
+var _ IAggregationDB = (*AggregationDB)(nil)
+var _ IAggregator = (*aggregator)(nil)
+
+// NewAggregationDB creates a new connection to the database for the aggregation pipelines
+func NewAggregationDB(conf mongodb.Config) (*AggregationDB, error) {
+	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(conf.DSN))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = client.Ping(context.TODO(), nil); err != nil {
+		return nil, err
+	}
+
+	return &AggregationDB{
+		db: client.Database(conf.DBName),
+	}, nil
+}
+
+// Aggregator returns the aggregator of the collection with the specified name
+func (d *AggregationDB) Aggregator(name string) IAggregator {
+	return &aggregator{
+		collection: d.db.Collection(name),
+	}
+}
+
+// Close disconnects from the database
+func (d *AggregationDB) Close(ctx context.Context) error {
+	return d.db.Client().Disconnect(ctx)
+}
+
+// aggregator is the adapter of the driver collection to IAggregator
+type aggregator struct {
+	collection *mongo.Collection
+}
+
+func (a *aggregator) Aggregate(ctx context.Context, pipeline interface{}) (ICursor, error) {
+	cursor, err := a.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
@@ -0,0 +1,54 @@
+package mongo
+
+import (
+	"context"
+
+	dbmockmongo "github.com/minipkg/db/mongo/mock"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// aggregationDBMock gives the aggregator mocks by the collection names
+type aggregationDBMock map[string]IAggregator
+
+var _ IAggregationDB = (aggregationDBMock)(nil)
+
+func (m aggregationDBMock) Aggregator(name string) IAggregator {
+	return m[name]
+}
+
+type aggregatorMock struct {
+	mock.Mock
+}
+
+var _ IAggregator = (*aggregatorMock)(nil)
+
+func (m *aggregatorMock) Aggregate(ctx context.Context, pipeline interface{}) (ICursor, error) {
+	ret := m.Called(ctx, pipeline)
+
+	var r0 ICursor
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(ICursor)
+	}
+	return r0, ret.Error(1)
+}
+
+// cursorMock is a cursor mock which reports the given error after the iteration
+type cursorMock struct {
+	dbmockmongo.Cursor
+	err error
+}
+
+var _ ICursor = (*cursorMock)(nil)
+
+func (m *cursorMock) Err() error {
+	return m.err
+}
+
+// authorStatsPipeline returns the aggregation pipeline of the stats of the author
+func authorStatsPipeline(userID uint) bson.A {
+	return bson.A{
+		bson.M{"$match": bson.M{"userid": userID, "deletedat": nil, "shadowed": bson.M{"$ne": true}}},
+		bson.M{"$group": bson.M{"_id": nil, "count": bson.M{"$sum": 1}, "karma": bson.M{"$sum": "$score"}}},
+	}
+}
@@ -0,0 +1,85 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"redditclone/internal/pkg/apperror"
+
+	"redditclone/internal/domain/feed"
+)
+
+// FollowRepository is a repository for the follows of the users
+type FollowRepository struct {
+	repository
+}
+
+var _ feed.Repository = (*FollowRepository)(nil)
+
+// New creates a new FollowRepository
+func NewFollowRepository(repository *repository) (*FollowRepository, error) {
+	return &FollowRepository{
+		repository: *repository,
+	}, nil
+}
+
+// Follow saves the follow in the database.
+// The follow is unique by the user and the followed user, so a repeated follow returns apperror.ErrConflict.
+func (r *FollowRepository) Follow(ctx context.Context, entity *feed.Follow) error {
+	if entity.ID != "" {
+		return errors.Wrap(apperror.ErrBadRequest, "entity is not new")
+	}
+
+	entity.ID = uuid.New().String()
+
+	id, err := r.collection.InsertOne(ctx, entity)
+	if err != nil {
+		entity.ID = ""
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.Wrapf(apperror.ErrConflict, "The follow already exists: %v", entity)
+		}
+		return errors.Wrapf(apperror.ErrInternal, "Can not create a recordset for an object %v, error: %v", entity, err)
+	}
+	r.logger.Debugf("Follow records InsertedID: %v", id)
+	return nil
+}
+
+// Unfollow deletes the follow from the database.
+func (r *FollowRepository) Unfollow(ctx context.Context, userID, followedID uint) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"userid": userID, "followedid": followedID})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not delete follow of user id: %v by user id: %v, error: %v", followedID, userID, err)
+	}
+	if res == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// Following retrieves the follows of the user ordered by the name of the followed user.
+func (r *FollowRepository) Following(ctx context.Context, userID uint) ([]feed.Follow, error) {
+	items := []feed.Follow{}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"userid": userID}, options.Find().SetSort(bson.M{"followedname": 1}))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return items, nil
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	for cursor.Next(ctx) {
+		item := &feed.Follow{}
+		if err = cursor.Decode(item); err != nil {
+			return nil, errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		items = append(items, *item)
+	}
+	return items, nil
+}
@@ -0,0 +1,131 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/config"
+
+	dbmockmongo "github.com/minipkg/db/mongo/mock"
+	"github.com/minipkg/log"
+
+	"redditclone/internal/domain/feed"
+)
+
+type FollowRepositoryTestSuite struct {
+	//	for all tests
+	suite.Suite
+	cfg    *config.Configuration
+	logger *log.Logger
+	follow *feed.Follow
+	//	only for each individual test
+	ctx                  context.Context
+	dbMock               *dbmockmongo.DB
+	followCollectionMock *dbmockmongo.Collection
+	repository           feed.Repository
+}
+
+func (s *FollowRepositoryTestSuite) SetupSuite() {
+	var err error
+
+	s.cfg = config.Get4UnitTest("FollowRepository")
+
+	s.logger, err = log.New(s.cfg.Log)
+	require.NoError(s.T(), err)
+
+	s.follow = &feed.Follow{
+		ID:           "10",
+		UserID:       1,
+		FollowedID:   2,
+		FollowedName: "demo2",
+		CreatedAt:    time.Now(),
+	}
+
+	s.dbMock = &dbmockmongo.DB{}
+
+	s.followCollectionMock = &dbmockmongo.Collection{}
+}
+
+func (s *FollowRepositoryTestSuite) SetupTest() {
+	var ok bool
+	require := require.New(s.T())
+	s.ctx = context.Background()
+
+	*s.followCollectionMock = dbmockmongo.Collection{}
+	s.dbMock.On("Collection", feed.FollowTableName, []*options.CollectionOptions(nil)).Return(s.followCollectionMock)
+
+	r, err := GetRepository(s.logger, s.dbMock, nil, feed.EntityName)
+	require.NoError(err)
+
+	s.repository, ok = r.(feed.Repository)
+	require.Truef(ok, "Can not cast DB repository for entity %q to %vRepository. Repo: %v", feed.EntityName, feed.EntityName, r)
+}
+
+func TestFollowRepository(t *testing.T) {
+	suite.Run(t, new(FollowRepositoryTestSuite))
+}
+
+func (s *FollowRepositoryTestSuite) TestFollow() {
+	assert := assert.New(s.T())
+	newItem := &feed.Follow{}
+	*newItem = *s.follow
+	newItem.ID = ""
+
+	s.followCollectionMock.On("InsertOne", s.ctx, newItem).Return("create test", error(nil))
+
+	err := s.repository.Follow(s.ctx, newItem)
+	assert.NoError(err)
+	assert.NotEmpty(newItem.ID, "entity.ID should be is not empty")
+}
+
+func (s *FollowRepositoryTestSuite) TestFollowDuplicate() {
+	assert := assert.New(s.T())
+	newItem := &feed.Follow{}
+	*newItem = *s.follow
+	newItem.ID = ""
+	duplicate := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}
+
+	s.followCollectionMock.On("InsertOne", s.ctx, mock.Anything).Return(nil, duplicate)
+
+	err := s.repository.Follow(s.ctx, newItem)
+	assert.Equal(apperror.ErrConflict, errors.Cause(err))
+	assert.Empty(newItem.ID, "entity.ID should be empty")
+}
+
+func (s *FollowRepositoryTestSuite) TestUnfollow() {
+	assert := assert.New(s.T())
+
+	s.followCollectionMock.On("DeleteOne", s.ctx, bson.M{"userid": s.follow.UserID, "followedid": s.follow.FollowedID}).Return(int64(1), error(nil)).Once()
+	s.followCollectionMock.On("DeleteOne", s.ctx, bson.M{"userid": s.follow.UserID, "followedid": s.follow.FollowedID}).Return(int64(0), error(nil))
+
+	err := s.repository.Unfollow(s.ctx, s.follow.UserID, s.follow.FollowedID)
+	assert.NoError(err)
+
+	err = s.repository.Unfollow(s.ctx, s.follow.UserID, s.follow.FollowedID)
+	assert.Equal(apperror.ErrNotFound, err)
+}
+
+func (s *FollowRepositoryTestSuite) TestFollowing() {
+	assert := assert.New(s.T())
+
+	cursor := &dbmockmongo.Cursor{
+		Res: []interface{}{s.follow},
+	}
+	s.followCollectionMock.On("Find", s.ctx, bson.M{"userid": s.follow.UserID}, mock.Anything).Return(cursor, error(nil))
+
+	res, err := s.repository.Following(s.ctx, s.follow.UserID)
+	assert.NoError(err)
+
+	assert.Equal([]feed.Follow{*s.follow}, res)
+}
@@ -0,0 +1,102 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/minipkg/selection_condition"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"redditclone/internal/pkg/apperror"
+
+	"redditclone/internal/domain/modlog"
+)
+
+// ModLogRepository is a repository for the moderation log, the entries are only inserted and read
+type ModLogRepository struct {
+	repository
+}
+
+var _ modlog.Repository = (*ModLogRepository)(nil)
+
+// New creates a new ModLogRepository
+func NewModLogRepository(repository *repository) (*ModLogRepository, error) {
+	return &ModLogRepository{repository: *repository}, nil
+}
+
+// Create saves a new entry in the database.
+func (r *ModLogRepository) Create(ctx context.Context, entity *modlog.Entry) error {
+	if entity.ID != "" {
+		return errors.Wrap(apperror.ErrBadRequest, "entity is not new")
+	}
+
+	entity.ID = uuid.New().String()
+
+	id, err := r.collection.InsertOne(ctx, entity)
+	if err != nil {
+		entity.ID = ""
+		return errors.Wrapf(apperror.ErrInternal, "Can not create a recordset for an object %v, error: %v", entity, err)
+	}
+	r.logger.Debugf("Create records InsertedID: %v", id)
+	return nil
+}
+
+// Query retrieves the entries with the specified offset and limit from the database.
+func (r *ModLogRepository) Query(ctx context.Context, cond selection_condition.SelectionCondition) ([]modlog.Entry, error) {
+	items := []modlog.Entry{}
+	condition := bson.M{}
+	if w, ok := cond.Where.(*modlog.Filter); ok {
+		condition = modLogCondition(w)
+	}
+
+	cursor, err := r.collection.Find(ctx, condition, findOptions(cond, r.Conditions.Limit))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return items, nil
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	for cursor.Next(ctx) {
+		item := &modlog.Entry{}
+		if err = cursor.Decode(item); err != nil {
+			return nil, errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		items = append(items, *item)
+	}
+	return items, nil
+}
+
+// modLogCondition returns the condition of the query by the filter of the moderation log, the empty fields of the filter are not used
+func modLogCondition(f *modlog.Filter) bson.M {
+	condition := bson.M{}
+	if f.ActorID != 0 {
+		condition["actorid"] = f.ActorID
+	}
+	if f.Action != "" {
+		condition["action"] = f.Action
+	}
+	if f.TargetType != "" {
+		condition["targettype"] = f.TargetType
+	}
+	if f.TargetID != "" {
+		condition["targetid"] = f.TargetID
+	}
+	if len(f.Categories) > 0 {
+		condition["category"] = bson.M{"$in": f.Categories}
+	}
+	if !f.From.IsZero() || !f.To.IsZero() {
+		createdAt := bson.M{}
+		if !f.From.IsZero() {
+			createdAt["$gte"] = f.From
+		}
+		if !f.To.IsZero() {
+			createdAt["$lte"] = f.To
+		}
+		condition["createdat"] = createdAt
+	}
+	return condition
+}
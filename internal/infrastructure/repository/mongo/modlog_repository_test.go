@@ -0,0 +1,117 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/minipkg/selection_condition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"redditclone/internal/pkg/config"
+
+	dbmockmongo "github.com/minipkg/db/mongo/mock"
+	"github.com/minipkg/log"
+
+	"redditclone/internal/domain/modlog"
+)
+
+type ModLogRepositoryTestSuite struct {
+	//	for all tests
+	suite.Suite
+	cfg    *config.Configuration
+	logger *log.Logger
+	entry  *modlog.Entry
+	//	only for each individual test
+	ctx            context.Context
+	dbMock         *dbmockmongo.DB
+	collectionMock *dbmockmongo.Collection
+	repository     modlog.Repository
+}
+
+func (s *ModLogRepositoryTestSuite) SetupSuite() {
+	var err error
+
+	s.cfg = config.Get4UnitTest("ModLogRepository")
+
+	s.logger, err = log.New(s.cfg.Log)
+	require.NoError(s.T(), err)
+
+	s.entry = &modlog.Entry{
+		ActorID:    2,
+		ActorName:  "moderator",
+		Action:     modlog.ActionDeletePost,
+		TargetType: modlog.TargetPost,
+		TargetID:   "1",
+		Category:   "programming",
+		Before:     json.RawMessage(`{"id":"1"}`),
+		CreatedAt:  time.Now(),
+	}
+
+	s.dbMock = &dbmockmongo.DB{}
+
+	s.collectionMock = &dbmockmongo.Collection{}
+}
+
+func (s *ModLogRepositoryTestSuite) SetupTest() {
+	var ok bool
+	require := require.New(s.T())
+	s.ctx = context.Background()
+
+	*s.collectionMock = dbmockmongo.Collection{}
+	s.dbMock.On("Collection", modlog.TableName, []*options.CollectionOptions(nil)).Return(s.collectionMock)
+
+	r, err := GetRepository(s.logger, s.dbMock, nil, modlog.EntityName)
+	require.NoError(err)
+
+	s.repository, ok = r.(modlog.Repository)
+	require.Truef(ok, "Can not cast DB repository for entity %q to %vRepository. Repo: %v", modlog.EntityName, modlog.EntityName, r)
+}
+
+func TestModLogRepository(t *testing.T) {
+	suite.Run(t, new(ModLogRepositoryTestSuite))
+}
+
+func (s *ModLogRepositoryTestSuite) TestCreate() {
+	assert := assert.New(s.T())
+	newItem := &modlog.Entry{}
+	*newItem = *s.entry
+
+	s.collectionMock.On("InsertOne", s.ctx, newItem).Return("create test", error(nil))
+
+	err := s.repository.Create(s.ctx, newItem)
+	assert.NoError(err)
+	assert.NotEmpty(newItem.ID, "entity.ID should be is not empty")
+}
+
+func (s *ModLogRepositoryTestSuite) TestQuery() {
+	assert := assert.New(s.T())
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cursor := &dbmockmongo.Cursor{
+		Res: []interface{}{s.entry},
+	}
+	s.collectionMock.On("Find", s.ctx, bson.M{
+		"action":    modlog.ActionDeletePost,
+		"category":  bson.M{"$in": []string{"programming"}},
+		"createdat": bson.M{"$gte": from},
+	}, mock.Anything).Return(cursor, error(nil))
+
+	res, err := s.repository.Query(s.ctx, selection_condition.SelectionCondition{
+		Where: &modlog.Filter{
+			Action:     modlog.ActionDeletePost,
+			Categories: []string{"programming"},
+			From:       from,
+		},
+	})
+	assert.NoError(err)
+	if assert.Len(res, 1) {
+		assert.Equal(s.entry.TargetID, res[0].TargetID)
+	}
+}
@@ -3,6 +3,7 @@ package mongo
 import (
 	"context"
 	"redditclone/internal/domain/comment"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -11,27 +12,39 @@ import (
 	"github.com/minipkg/selection_condition"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"redditclone/internal/pkg/apperror"
 
 	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/vote"
 )
 
+// scoreFields are the fields of a post which are changed only by votes
+var scoreFields = []string{"ups", "downs", "score", "hot", "controversy", "rising"}
+
+// updateExceptFields are the fields of a post which are not changed by an update, the deletion mark is changed only by Delete and Restore
+var updateExceptFields = append([]string{"deletedat"}, scoreFields...)
+
 // PostRepository is a repository for the post entity
 type PostRepository struct {
 	repository
-	commentRepository *CommentRepository
-	voteRepository    *VoteRepository
+	commentRepository  *CommentRepository
+	voteRepository     *VoteRepository
+	revisionCollection minipkg_mongo.ICollection
+	markCollection     minipkg_mongo.ICollection
 }
 
 var _ post.Repository = (*PostRepository)(nil)
 
 // New creates a new PostRepository
-func NewPostRepository(repository *repository, commentRepository *CommentRepository, voteRepository *VoteRepository) (*PostRepository, error) {
+func NewPostRepository(repository *repository, commentRepository *CommentRepository, voteRepository *VoteRepository, revisionCollection minipkg_mongo.ICollection, markCollection minipkg_mongo.ICollection) (*PostRepository, error) {
 	return &PostRepository{
-		repository:        *repository,
-		commentRepository: commentRepository,
-		voteRepository:    voteRepository,
+		repository:         *repository,
+		commentRepository:  commentRepository,
+		voteRepository:     voteRepository,
+		revisionCollection: revisionCollection,
+		markCollection:     markCollection,
 	}, nil
 }
 
@@ -60,7 +73,7 @@ func (r *PostRepository) populate(ctx context.Context, item *post.Post) (err err
 		Where: &comment.Comment{PostID: item.ID},
 	}
 	voteCond := selection_condition.SelectionCondition{
-		Where: &comment.Comment{PostID: item.ID},
+		Where: &vote.Vote{PostID: item.ID},
 	}
 
 	comments, err := r.commentRepository.Query(ctx, commentCond)
@@ -68,12 +81,19 @@ func (r *PostRepository) populate(ctx context.Context, item *post.Post) (err err
 		return err
 	}
 
-	votes, err := r.voteRepository.Query(ctx, voteCond)
+	allVotes, err := r.voteRepository.Query(ctx, voteCond)
 	if err != nil {
 		return err
 	}
 
-	(*item).Comments = comments
+	votes := make([]vote.Vote, 0, len(allVotes))
+	for _, v := range allVotes {
+		if !v.IsForComment() {
+			votes = append(votes, v)
+		}
+	}
+
+	(*item).Comments = comment.BuildTree(comments, "", comment.MaxTreeDepth)
 	(*item).Votes = votes
 	return nil
 }
@@ -82,9 +102,9 @@ func (r *PostRepository) populate(ctx context.Context, item *post.Post) (err err
 func (r *PostRepository) Query(ctx context.Context, cond selection_condition.SelectionCondition) ([]post.Post, error) {
 	var err error
 	items := []post.Post{}
-	condition := minipkg_mongo.QueryWhereCondition(cond.Where)
+	condition := r.queryCondition(cond.Where, cond.SortOrder)
 
-	cursor, err := r.collection.Find(ctx, condition)
+	cursor, err := r.collection.Find(ctx, condition, findOptions(cond, r.Conditions.Limit))
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return items, apperror.ErrNotFound
@@ -106,6 +126,60 @@ func (r *PostRepository) Query(ctx context.Context, cond selection_condition.Sel
 	return items, err
 }
 
+// queryCondition converts the where part of a selection condition to a filter, the deleted posts are not listed.
+// The where part is either *post.Post for the equality conditions or *post.Filter.
+func (r *PostRepository) queryCondition(where interface{}, sortOrder []map[string]string) bson.M {
+	switch w := where.(type) {
+	case *post.Post:
+		condition := minipkg_mongo.QueryWhereCondition(w)
+		condition["deletedat"] = nil
+		return condition
+	case *post.Filter:
+		condition := minipkg_mongo.QueryWhereCondition(&w.Post)
+		condition["deletedat"] = nil
+		if !w.CreatedAfter.IsZero() {
+			condition["createdat"] = bson.M{"$gte": w.CreatedAfter}
+		}
+		if w.Sources != nil {
+			condition = sourcesCondition(condition, w.Sources)
+		}
+		if ids := idsCondition(w.IDs, w.ExcludeIDs); len(ids) > 0 {
+			condition["id"] = ids
+		}
+		if w.Cursor != nil {
+			condition = keysetCondition(condition, w.Cursor, sortOrder)
+		}
+		return condition
+	}
+	return bson.M{"deletedat": nil}
+}
+
+// idsCondition returns the condition of the IDs which are in the list of the included ones and are not in the list of the excluded ones.
+func idsCondition(ids, excludeIDs []string) bson.M {
+	condition := bson.M{}
+	if len(ids) > 0 {
+		condition["$in"] = ids
+	}
+	if len(excludeIDs) > 0 {
+		condition["$nin"] = excludeIDs
+	}
+	return condition
+}
+
+// sourcesCondition adds to the condition the limit by the items of any of the categories or any of the users.
+// The keyset condition uses $or as well, so the limit is added by $and.
+func sourcesCondition(condition bson.M, sources *post.Sources) bson.M {
+	or := bson.A{}
+	if len(sources.Categories) > 0 {
+		or = append(or, bson.M{"category": bson.M{"$in": sources.Categories}})
+	}
+	if len(sources.UserIDs) > 0 {
+		or = append(or, bson.M{"userid": bson.M{"$in": sources.UserIDs}})
+	}
+	condition["$and"] = bson.A{bson.M{"$or": or}}
+	return condition
+}
+
 // Create saves a new album record in the database.
 // It returns the ID of the newly inserted album record.
 func (r *PostRepository) Create(ctx context.Context, entity *post.Post) error {
@@ -128,7 +202,12 @@ func (r *PostRepository) Update(ctx context.Context, entity *post.Post) error {
 		return errors.Wrap(apperror.ErrBadRequest, "entity is new")
 	}
 
-	res, err := r.collection.UpdateOne(ctx, bson.M{"id": entity.ID}, bson.M{"$set": entity})
+	update, err := setExcept(entity, updateExceptFields...)
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not make an update for entity: %v, error: %v", entity, err)
+	}
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{"id": entity.ID}, update)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return apperror.ErrNotFound
@@ -140,15 +219,339 @@ func (r *PostRepository) Update(ctx context.Context, entity *post.Post) error {
 	return nil
 }
 
-// Delete deletes an entity with the specified ID from the database.
-func (r *PostRepository) Delete(ctx context.Context, id string) error {
-	res, err := r.collection.DeleteOne(ctx, bson.M{"id": id})
+// IncrViews increments the number of the views of the post by one atomic operation, the other fields are not written.
+func (r *PostRepository) IncrViews(ctx context.Context, id string) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"id": id, "deletedat": nil}, bson.M{"$inc": bson.M{"views": 1}})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not increment views of entity id: %v, error: %v", id, err)
+	}
+	if n, ok := res.(int64); ok && n == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// ChangeScore increments the vote counters of the post by one atomic operation, so concurrent votes are never lost.
+// The rankings depend on the counters, so they are recalculated after that.
+func (r *PostRepository) ChangeScore(ctx context.Context, id string, ups, downs int) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"id": id}, bson.M{"$inc": bson.M{
+		"ups":   ups,
+		"downs": downs,
+		"score": ups - downs,
+	}})
 	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not change score of entity id: %v, error: %v", id, err)
+	}
+	if n, ok := res.(int64); ok && n == 0 {
+		return apperror.ErrNotFound
+	}
+
+	return r.updateRanking(ctx, id)
+}
+
+// updateRanking recalculates the rankings of the post by its current vote counters.
+// The rankings are saved only if the counters have not been changed since they were read,
+// otherwise the concurrent vote recalculates them itself.
+func (r *PostRepository) updateRanking(ctx context.Context, id string) error {
+	entity := &post.Post{}
+	if err := r.collection.FindOne(ctx, bson.M{"id": id}).Decode(entity); err != nil {
 		if err == mongo.ErrNoDocuments {
 			return apperror.ErrNotFound
 		}
+		return errors.Wrapf(apperror.ErrInternal, "FindOne() error: %v", err)
+	}
+	entity.UpdateRanking(time.Now())
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{"id": id, "ups": entity.Ups, "downs": entity.Downs}, bson.M{"$set": bson.M{
+		"hot":         entity.Hot,
+		"controversy": entity.Controversy,
+		"rising":      entity.Rising,
+	}})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not update ranking of entity id: %v, error: %v", id, err)
+	}
+	r.logger.Debugf("Update ranking result: %v", res)
+	return nil
+}
+
+// SetScore overwrites the vote counters and the rankings of the post, it is used to reconcile them with the votes.
+func (r *PostRepository) SetScore(ctx context.Context, entity *post.Post) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"id": entity.ID}, bson.M{"$set": bson.M{
+		"ups":         entity.Ups,
+		"downs":       entity.Downs,
+		"score":       entity.Score,
+		"hot":         entity.Hot,
+		"controversy": entity.Controversy,
+		"rising":      entity.Rising,
+	}})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not set score of entity: %v, error: %v", entity, err)
+	}
+	r.logger.Debugf("Set score result: %v", res)
+	return nil
+}
+
+// AuthorStats returns the number of the entities of the user and the sum of their scores, the deleted and the shadowed entities are not counted.
+func (r *PostRepository) AuthorStats(ctx context.Context, userID uint) (count uint, karma int, err error) {
+	return r.authorStats(ctx, userID)
+}
+
+// AnonymizeAuthor shows the deleted user as the author of all the entities of the user.
+func (r *PostRepository) AnonymizeAuthor(ctx context.Context, userID uint) error {
+	cursor, err := r.collection.Find(ctx, bson.M{"userid": userID})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	ids := []string{}
+	for cursor.Next(ctx) {
+		entity := &post.Post{}
+		if err = cursor.Decode(entity); err != nil {
+			return errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		ids = append(ids, entity.ID)
+	}
+	return r.anonymizeAuthor(ctx, userID, ids)
+}
+
+// Delete marks an entity with the specified ID as deleted, the entity is kept in the database until it is purged.
+func (r *PostRepository) Delete(ctx context.Context, id string) error {
+	return r.softDelete(ctx, id)
+}
+
+// Restore clears the deletion mark of the deleted entity with the specified ID.
+func (r *PostRepository) Restore(ctx context.Context, id string) error {
+	return r.restore(ctx, id)
+}
+
+// Purge removes the entities deleted before the time from the database together with their comments, votes, revisions and marks.
+func (r *PostRepository) Purge(ctx context.Context, before time.Time) (count uint, err error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"deletedat": bson.M{"$lte": before}}, options.Find().SetProjection(bson.M{"id": 1}))
+	if err != nil {
+		return 0, errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	ids := []string{}
+	for cursor.Next(ctx) {
+		entity := &post.Post{}
+		if err = cursor.Decode(entity); err != nil {
+			return 0, errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		ids = append(ids, entity.ID)
+	}
+
+	for _, id := range ids {
+		if err = r.purge(ctx, id); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// purge removes the entity with the specified ID with all its dependent documents.
+// The entity is removed last, so a failed purge is continued by the next one.
+func (r *PostRepository) purge(ctx context.Context, id string) error {
+	comments, err := r.commentRepository.Query(ctx, selection_condition.SelectionCondition{
+		Where: &comment.Comment{PostID: id},
+	})
+	if err != nil {
+		return err
+	}
+	commentIDs := make([]string, 0, len(comments))
+	for _, item := range comments {
+		commentIDs = append(commentIDs, item.ID)
+	}
+
+	votes, err := r.voteRepository.Query(ctx, selection_condition.SelectionCondition{
+		Where: &vote.Vote{PostID: id},
+	})
+	if err != nil {
+		return err
+	}
+	voteIDs := make([]string, 0, len(votes))
+	for _, item := range votes {
+		voteIDs = append(voteIDs, item.ID)
+	}
+
+	revisions, err := r.QueryRevisions(ctx, id)
+	if err != nil {
+		return err
+	}
+	revisionIDs := make([]string, 0, len(revisions))
+	for _, item := range revisions {
+		revisionIDs = append(revisionIDs, item.ID)
+	}
+
+	markIDs, err := r.markIDs(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = deleteByIDs(ctx, r.commentRepository.collection, commentIDs); err != nil {
+		return err
+	}
+	if err = deleteByIDs(ctx, r.voteRepository.collection, voteIDs); err != nil {
+		return err
+	}
+	if err = deleteByIDs(ctx, r.revisionCollection, revisionIDs); err != nil {
+		return err
+	}
+	if err = deleteByIDs(ctx, r.markCollection, markIDs); err != nil {
+		return err
+	}
+
+	if _, err = r.collection.DeleteOne(ctx, bson.M{"id": id}); err != nil {
 		return errors.Wrapf(apperror.ErrInternal, "Can not delete entity id: %v, error: %v", id, err)
 	}
-	r.logger.Debugf("Delete result: %v", res)
 	return nil
 }
+
+// markIDs retrieves the IDs of all the marks of the post.
+func (r *PostRepository) markIDs(ctx context.Context, postId string) ([]string, error) {
+	ids := []string{}
+
+	cursor, err := r.markCollection.Find(ctx, bson.M{"postid": postId})
+	if err != nil {
+		return nil, errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	for cursor.Next(ctx) {
+		item := &post.Mark{}
+		if err = cursor.Decode(item); err != nil {
+			return nil, errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		ids = append(ids, item.ID)
+	}
+	return ids, nil
+}
+
+// CreateRevision saves a previous version of the post in the revisions collection.
+func (r *PostRepository) CreateRevision(ctx context.Context, entity *post.Revision) error {
+	if entity.ID != "" {
+		return errors.Wrap(apperror.ErrBadRequest, "entity is not new")
+	}
+
+	entity.ID = uuid.New().String()
+	if entity.CreatedAt.IsZero() {
+		entity.CreatedAt = time.Now()
+	}
+
+	id, err := r.revisionCollection.InsertOne(ctx, entity)
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not create a recordset for an object %v, error: %v", entity, err)
+	}
+	r.logger.Debugf("CreateRevision records InsertedID: %v", id)
+	return nil
+}
+
+// QueryRevisions retrieves previous versions of the post ordered by the time of change.
+func (r *PostRepository) QueryRevisions(ctx context.Context, postId string) ([]post.Revision, error) {
+	var err error
+	items := []post.Revision{}
+
+	cursor, err := r.revisionCollection.Find(ctx, bson.M{"postid": postId}, options.Find().SetSort(bson.M{"createdat": 1}))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return items, apperror.ErrNotFound
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	for cursor.Next(ctx) {
+		item := &post.Revision{}
+		err = cursor.Decode(item)
+		if err != nil {
+			return nil, errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		items = append(items, *item)
+	}
+	return items, err
+}
+
+// CreateMark saves a new mark of the post by the user.
+// The mark is unique by the user, the post and the kind, so a repeated mark returns apperror.ErrConflict.
+func (r *PostRepository) CreateMark(ctx context.Context, entity *post.Mark) error {
+	if entity.ID != "" {
+		return errors.Wrap(apperror.ErrBadRequest, "entity is not new")
+	}
+
+	entity.ID = uuid.New().String()
+	if entity.CreatedAt.IsZero() {
+		entity.CreatedAt = time.Now()
+	}
+
+	id, err := r.markCollection.InsertOne(ctx, entity)
+	if err != nil {
+		entity.ID = ""
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.Wrapf(apperror.ErrConflict, "The mark already exists: %v", entity)
+		}
+		return errors.Wrapf(apperror.ErrInternal, "Can not create a recordset for an object %v, error: %v", entity, err)
+	}
+	r.logger.Debugf("CreateMark records InsertedID: %v", id)
+	return nil
+}
+
+// DeleteMark removes the mark of the kind of the post by the user.
+func (r *PostRepository) DeleteMark(ctx context.Context, entity *post.Mark) error {
+	res, err := r.markCollection.DeleteOne(ctx, bson.M{"userid": entity.UserID, "postid": entity.PostID, "kind": entity.Kind})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not delete mark: %v, error: %v", entity, err)
+	}
+	if res == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// QueryMarks retrieves the marks with the specified sort order and limit from the database.
+func (r *PostRepository) QueryMarks(ctx context.Context, cond selection_condition.SelectionCondition) ([]post.Mark, error) {
+	items := []post.Mark{}
+	condition := bson.M{}
+	if w, ok := cond.Where.(*post.MarkFilter); ok {
+		condition = minipkg_mongo.QueryWhereCondition(&w.Mark)
+		if w.Cursor != nil {
+			condition = keysetCondition(condition, w.Cursor, cond.SortOrder)
+		}
+	}
+
+	cursor, err := r.markCollection.Find(ctx, condition, findOptions(cond, r.Conditions.Limit))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return items, nil
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	for cursor.Next(ctx) {
+		item := &post.Mark{}
+		if err = cursor.Decode(item); err != nil {
+			return nil, errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		items = append(items, *item)
+	}
+	return items, nil
+}
+
+// MarkedIDs retrieves the IDs of all the posts marked by the user with the kind.
+func (r *PostRepository) MarkedIDs(ctx context.Context, userID uint, kind string) ([]string, error) {
+	ids := []string{}
+
+	cursor, err := r.markCollection.Find(ctx, bson.M{"userid": userID, "kind": kind}, options.Find().SetProjection(bson.M{"postid": 1}))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ids, nil
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	for cursor.Next(ctx) {
+		item := &post.Mark{}
+		if err = cursor.Decode(item); err != nil {
+			return nil, errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		ids = append(ids, item.PostID)
+	}
+	return ids, nil
+}
@@ -2,9 +2,11 @@ package mongo
 
 import (
 	"context"
+	"math"
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -20,7 +22,9 @@ import (
 	"redditclone/internal/domain/post"
 	"redditclone/internal/domain/user"
 	"redditclone/internal/domain/vote"
+	"redditclone/internal/pkg/apperror"
 	"redditclone/internal/pkg/config"
+	"redditclone/internal/pkg/pagination"
 )
 
 type PostRepositoryTestSuite struct {
@@ -32,12 +36,15 @@ type PostRepositoryTestSuite struct {
 	comment *comment.Comment
 	vote    *vote.Vote
 	//	only for each individual test
-	ctx                   context.Context
-	dbMock                *dbmockmongo.DB
-	postCollectionMock    *dbmockmongo.Collection
-	commentCollectionMock *dbmockmongo.Collection
-	voteCollectionMock    *dbmockmongo.Collection
-	repository            post.Repository
+	ctx                    context.Context
+	dbMock                 *dbmockmongo.DB
+	postCollectionMock     *dbmockmongo.Collection
+	commentCollectionMock  *dbmockmongo.Collection
+	voteCollectionMock     *dbmockmongo.Collection
+	revisionCollectionMock *dbmockmongo.Collection
+	markCollectionMock     *dbmockmongo.Collection
+	postAggregatorMock     *aggregatorMock
+	repository             post.Repository
 }
 
 func (s *PostRepositoryTestSuite) SetupSuite() {
@@ -94,6 +101,9 @@ func (s *PostRepositoryTestSuite) SetupSuite() {
 	s.postCollectionMock = &dbmockmongo.Collection{}
 	s.commentCollectionMock = &dbmockmongo.Collection{}
 	s.voteCollectionMock = &dbmockmongo.Collection{}
+	s.revisionCollectionMock = &dbmockmongo.Collection{}
+	s.markCollectionMock = &dbmockmongo.Collection{}
+	s.postAggregatorMock = &aggregatorMock{}
 }
 
 func (s *PostRepositoryTestSuite) SetupTest() {
@@ -104,11 +114,16 @@ func (s *PostRepositoryTestSuite) SetupTest() {
 	*s.postCollectionMock = dbmockmongo.Collection{}
 	*s.commentCollectionMock = dbmockmongo.Collection{}
 	*s.voteCollectionMock = dbmockmongo.Collection{}
+	*s.revisionCollectionMock = dbmockmongo.Collection{}
+	*s.markCollectionMock = dbmockmongo.Collection{}
+	*s.postAggregatorMock = aggregatorMock{}
 	s.dbMock.On("Collection", post.TableName, []*options.CollectionOptions(nil)).Return(s.postCollectionMock)
 	s.dbMock.On("Collection", comment.TableName, []*options.CollectionOptions(nil)).Return(s.commentCollectionMock)
 	s.dbMock.On("Collection", vote.TableName, []*options.CollectionOptions(nil)).Return(s.voteCollectionMock)
+	s.dbMock.On("Collection", post.RevisionTableName, []*options.CollectionOptions(nil)).Return(s.revisionCollectionMock)
+	s.dbMock.On("Collection", post.MarkTableName, []*options.CollectionOptions(nil)).Return(s.markCollectionMock)
 
-	r, err := GetRepository(s.logger, s.dbMock, post.EntityName)
+	r, err := GetRepository(s.logger, s.dbMock, aggregationDBMock{post.TableName: s.postAggregatorMock, comment.TableName: &aggregatorMock{}}, post.EntityName)
 	require.NoError(err)
 
 	s.repository, ok = r.(post.Repository)
@@ -132,7 +147,7 @@ func (s *PostRepositoryTestSuite) populatePost() {
 		Res: votes,
 	}
 
-	s.commentCollectionMock.On("Find", s.ctx, bson.M{"postid": s.post.ID}, []*options.FindOptions(nil)).Return(commentCursor, error(nil))
+	s.commentCollectionMock.On("Find", s.ctx, bson.M{"postid": s.post.ID}, []*options.FindOptions{options.Find()}).Return(commentCursor, error(nil))
 	s.voteCollectionMock.On("Find", s.ctx, bson.M{"postid": s.post.ID}, []*options.FindOptions(nil)).Return(voteCursor, error(nil))
 }
 
@@ -169,7 +184,159 @@ func (s *PostRepositoryTestSuite) TestQuery() {
 		},
 	}
 	s.populatePost()
-	s.postCollectionMock.On("Find", s.ctx, bson.M{"userid": s.post.UserID}, []*options.FindOptions(nil)).Return(cursor, error(nil))
+	s.postCollectionMock.On("Find", s.ctx, bson.M{"userid": s.post.UserID, "deletedat": nil}, []*options.FindOptions{options.Find()}).Return(cursor, error(nil))
+
+	res, err := s.repository.Query(s.ctx, condition)
+	assert.NoError(err)
+
+	assert.Equalf(postVals, res, "The two objects should be the same. Expected: %v; have got: %v", postVals, res)
+}
+
+func (s *PostRepositoryTestSuite) TestQueryRanked() {
+	var posts []interface{}
+	var postVals []post.Post
+	assert := assert.New(s.T())
+
+	posts = append(posts, s.post)
+	postVals = append(postVals, *s.post)
+	cursor := &dbmockmongo.Cursor{
+		Res: posts,
+	}
+	createdAfter := time.Now().Add(-24 * time.Hour)
+	condition := selection_condition.SelectionCondition{
+		Where: &post.Filter{
+			Post: post.Post{
+				Category: s.post.Category,
+			},
+			CreatedAfter: createdAfter,
+		},
+		SortOrder: []map[string]string{{"score": selection_condition.SortOrderDesc}},
+		Limit:     10,
+	}
+	filter := bson.M{"category": s.post.Category, "createdat": bson.M{"$gte": createdAfter}, "deletedat": nil}
+	opts := options.Find().SetSort(bson.D{{Key: "score", Value: -1}}).SetLimit(10)
+
+	s.populatePost()
+	s.postCollectionMock.On("Find", s.ctx, filter, []*options.FindOptions{opts}).Return(cursor, error(nil))
+
+	res, err := s.repository.Query(s.ctx, condition)
+	assert.NoError(err)
+
+	assert.Equalf(postVals, res, "The two objects should be the same. Expected: %v; have got: %v", postVals, res)
+}
+
+func (s *PostRepositoryTestSuite) TestQueryPage() {
+	var posts []interface{}
+	var postVals []post.Post
+	assert := assert.New(s.T())
+
+	posts = append(posts, s.post)
+	postVals = append(postVals, *s.post)
+	cursor := &dbmockmongo.Cursor{
+		Res: posts,
+	}
+	after := &pagination.Cursor{Key: "score", Value: 5, ID: "7"}
+	condition := selection_condition.SelectionCondition{
+		Where: &post.Filter{
+			Post: post.Post{
+				Category: s.post.Category,
+			},
+			Cursor: after,
+		},
+		SortOrder: []map[string]string{{"score": selection_condition.SortOrderDesc}, {"id": selection_condition.SortOrderDesc}},
+		Limit:     11,
+	}
+	filter := bson.M{
+		"category":  s.post.Category,
+		"deletedat": nil,
+		"$or": bson.A{
+			bson.M{"score": bson.M{"$lt": 5}},
+			bson.M{"score": 5, "id": bson.M{"$lt": "7"}},
+		},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "score", Value: -1}, {Key: "id", Value: -1}}).SetLimit(11)
+
+	s.populatePost()
+	s.postCollectionMock.On("Find", s.ctx, filter, []*options.FindOptions{opts}).Return(cursor, error(nil))
+
+	res, err := s.repository.Query(s.ctx, condition)
+	assert.NoError(err)
+
+	assert.Equalf(postVals, res, "The two objects should be the same. Expected: %v; have got: %v", postVals, res)
+}
+
+func (s *PostRepositoryTestSuite) TestQueryFeed() {
+	var posts []interface{}
+	var postVals []post.Post
+	assert := assert.New(s.T())
+
+	posts = append(posts, s.post)
+	postVals = append(postVals, *s.post)
+	cursor := &dbmockmongo.Cursor{
+		Res: posts,
+	}
+	after := &pagination.Cursor{Key: "score", Value: 5, ID: "7"}
+	condition := selection_condition.SelectionCondition{
+		Where: &post.Filter{
+			Sources: &post.Sources{
+				Categories: []string{s.post.Category},
+				UserIDs:    []uint{2, 3},
+			},
+			Cursor: after,
+		},
+		SortOrder: []map[string]string{{"score": selection_condition.SortOrderDesc}, {"id": selection_condition.SortOrderDesc}},
+		Limit:     11,
+	}
+	filter := bson.M{
+		"deletedat": nil,
+		"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"category": bson.M{"$in": []string{s.post.Category}}},
+				bson.M{"userid": bson.M{"$in": []uint{2, 3}}},
+			}},
+		},
+		"$or": bson.A{
+			bson.M{"score": bson.M{"$lt": 5}},
+			bson.M{"score": 5, "id": bson.M{"$lt": "7"}},
+		},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "score", Value: -1}, {Key: "id", Value: -1}}).SetLimit(11)
+
+	s.populatePost()
+	s.postCollectionMock.On("Find", s.ctx, filter, []*options.FindOptions{opts}).Return(cursor, error(nil))
+
+	res, err := s.repository.Query(s.ctx, condition)
+	assert.NoError(err)
+
+	assert.Equalf(postVals, res, "The two objects should be the same. Expected: %v; have got: %v", postVals, res)
+}
+
+func (s *PostRepositoryTestSuite) TestQueryExcludeIDs() {
+	var posts []interface{}
+	var postVals []post.Post
+	assert := assert.New(s.T())
+
+	posts = append(posts, s.post)
+	postVals = append(postVals, *s.post)
+	cursor := &dbmockmongo.Cursor{
+		Res: posts,
+	}
+	condition := selection_condition.SelectionCondition{
+		Where: &post.Filter{
+			Post: post.Post{
+				Category: s.post.Category,
+			},
+			ExcludeIDs: []string{"2", "3"},
+		},
+	}
+	filter := bson.M{
+		"category":  s.post.Category,
+		"id":        bson.M{"$nin": []string{"2", "3"}},
+		"deletedat": nil,
+	}
+
+	s.populatePost()
+	s.postCollectionMock.On("Find", s.ctx, filter, []*options.FindOptions{options.Find()}).Return(cursor, error(nil))
 
 	res, err := s.repository.Query(s.ctx, condition)
 	assert.NoError(err)
@@ -193,17 +360,284 @@ func (s *PostRepositoryTestSuite) TestCreate() {
 func (s *PostRepositoryTestSuite) TestUpdate() {
 	assert := assert.New(s.T())
 
-	s.postCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.post.ID}, bson.M{"$set": s.post}).Return("update test", error(nil))
+	withoutScore := func(update bson.M) bool {
+		doc, ok := update["$set"].(bson.M)
+		_, hasScore := doc["score"]
+		_, hasUps := doc["ups"]
+		return ok && doc["id"] == s.post.ID && !hasScore && !hasUps
+	}
+
+	s.postCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.post.ID}, mock.MatchedBy(withoutScore)).Return("update test", error(nil))
 
 	err := s.repository.Update(s.ctx, s.post)
 	assert.NoError(err)
 }
 
+func (s *PostRepositoryTestSuite) TestIncrViews() {
+	assert := assert.New(s.T())
+
+	s.postCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.post.ID, "deletedat": nil}, bson.M{"$inc": bson.M{"views": 1}}).Return(int64(1), error(nil))
+
+	err := s.repository.IncrViews(s.ctx, s.post.ID)
+	assert.NoError(err)
+}
+
+func (s *PostRepositoryTestSuite) TestIncrViewsNotFound() {
+	assert := assert.New(s.T())
+
+	s.postCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.post.ID, "deletedat": nil}, bson.M{"$inc": bson.M{"views": 1}}).Return(int64(0), error(nil))
+
+	err := s.repository.IncrViews(s.ctx, s.post.ID)
+	assert.Equal(apperror.ErrNotFound, err)
+}
+
+func (s *PostRepositoryTestSuite) TestChangeScore() {
+	assert := assert.New(s.T())
+
+	voted := &post.Post{}
+	*voted = *s.post
+	voted.Ups++
+	voted.Score++
+	voted.UpdateRanking(time.Now())
+	result := &dbmockmongo.SingleResult{
+		Entity: voted,
+		Err:    nil,
+	}
+	inc := bson.M{"$inc": bson.M{"ups": 1, "downs": 0, "score": 1}}
+	//	the rising ranking depends on the time of the vote
+	ranking := mock.MatchedBy(func(update bson.M) bool {
+		set, ok := update["$set"].(bson.M)
+		return ok && set["hot"] == voted.Hot && set["controversy"] == voted.Controversy &&
+			math.Abs(set["rising"].(float64)-voted.Rising) < 1e-3
+	})
+
+	s.postCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.post.ID}, inc).Return(int64(1), error(nil))
+	s.postCollectionMock.On("FindOne", s.ctx, bson.M{"id": s.post.ID}, []*options.FindOneOptions(nil)).Return(result)
+	s.postCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.post.ID, "ups": voted.Ups, "downs": voted.Downs}, ranking).Return(int64(1), error(nil))
+
+	err := s.repository.ChangeScore(s.ctx, s.post.ID, 1, 0)
+	assert.NoError(err)
+}
+
+func (s *PostRepositoryTestSuite) TestChangeScoreNotFound() {
+	assert := assert.New(s.T())
+
+	inc := bson.M{"$inc": bson.M{"ups": 0, "downs": 1, "score": -1}}
+	s.postCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.post.ID}, inc).Return(int64(0), error(nil))
+
+	err := s.repository.ChangeScore(s.ctx, s.post.ID, 0, 1)
+	assert.Equal(apperror.ErrNotFound, err)
+}
+
 func (s *PostRepositoryTestSuite) TestDelete() {
 	assert := assert.New(s.T())
 
-	s.postCollectionMock.On("DeleteOne", s.ctx, bson.M{"id": s.post.ID}).Return(int64(123), error(nil))
+	setDeletedAt := func(update bson.M) bool {
+		doc, ok := update["$set"].(bson.M)
+		_, isTime := doc["deletedat"].(time.Time)
+		return ok && isTime && len(doc) == 1
+	}
+	s.postCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.post.ID, "deletedat": nil}, mock.MatchedBy(setDeletedAt)).Return(int64(1), error(nil))
 
 	err := s.repository.Delete(s.ctx, s.post.ID)
 	assert.NoError(err)
 }
+
+func (s *PostRepositoryTestSuite) TestDeleteNotFound() {
+	assert := assert.New(s.T())
+
+	s.postCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.post.ID, "deletedat": nil}, mock.Anything).Return(int64(0), error(nil))
+
+	err := s.repository.Delete(s.ctx, s.post.ID)
+	assert.Equal(apperror.ErrNotFound, err)
+}
+
+func (s *PostRepositoryTestSuite) TestRestore() {
+	assert := assert.New(s.T())
+
+	s.postCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.post.ID, "deletedat": bson.M{"$ne": nil}}, bson.M{"$set": bson.M{"deletedat": nil}}).Return(int64(1), error(nil))
+
+	err := s.repository.Restore(s.ctx, s.post.ID)
+	assert.NoError(err)
+}
+
+func (s *PostRepositoryTestSuite) TestPurge() {
+	assert := assert.New(s.T())
+	before := time.Now().AddDate(0, 0, -30)
+	deletedAt := before.Add(-time.Hour)
+
+	deleted := &post.Post{}
+	*deleted = *s.post
+	deleted.DeletedAt = &deletedAt
+	revision := post.NewRevision(s.post)
+	revision.ID = "20"
+	mark := &post.Mark{ID: "30", UserID: 2, PostID: s.post.ID, Kind: post.MarkSave}
+
+	opts := options.Find().SetProjection(bson.M{"id": 1})
+	s.postCollectionMock.On("Find", s.ctx, bson.M{"deletedat": bson.M{"$lte": before}}, []*options.FindOptions{opts}).
+		Return(&dbmockmongo.Cursor{Res: []interface{}{deleted}}, error(nil))
+	s.populatePost()
+	s.revisionCollectionMock.On("Find", s.ctx, bson.M{"postid": s.post.ID}, mock.Anything).Return(&dbmockmongo.Cursor{Res: []interface{}{revision}}, error(nil))
+	s.markCollectionMock.On("Find", s.ctx, bson.M{"postid": s.post.ID}, []*options.FindOptions(nil)).Return(&dbmockmongo.Cursor{Res: []interface{}{mark}}, error(nil))
+
+	s.commentCollectionMock.On("DeleteOne", s.ctx, bson.M{"id": s.comment.ID}).Return(int64(1), error(nil)).Once()
+	s.voteCollectionMock.On("DeleteOne", s.ctx, bson.M{"id": s.vote.ID}).Return(int64(1), error(nil)).Once()
+	s.revisionCollectionMock.On("DeleteOne", s.ctx, bson.M{"id": revision.ID}).Return(int64(1), error(nil)).Once()
+	s.markCollectionMock.On("DeleteOne", s.ctx, bson.M{"id": mark.ID}).Return(int64(1), error(nil)).Once()
+	s.postCollectionMock.On("DeleteOne", s.ctx, bson.M{"id": s.post.ID}).Return(int64(1), error(nil)).Once()
+
+	count, err := s.repository.Purge(s.ctx, before)
+	assert.NoError(err)
+	assert.Equal(uint(1), count)
+}
+
+func (s *PostRepositoryTestSuite) TestCreateRevision() {
+	assert := assert.New(s.T())
+	revision := post.NewRevision(s.post)
+
+	s.revisionCollectionMock.On("InsertOne", s.ctx, revision).Return("create revision test", error(nil))
+
+	err := s.repository.CreateRevision(s.ctx, revision)
+	assert.NoError(err)
+	assert.NotEmpty(revision.ID, "entity.ID should be is not empty")
+	assert.False(revision.CreatedAt.IsZero(), "entity.CreatedAt should be set")
+}
+
+func (s *PostRepositoryTestSuite) TestQueryRevisions() {
+	var revisions []interface{}
+	assert := assert.New(s.T())
+
+	revision := post.NewRevision(s.post)
+	revision.ID = "20"
+	revision.CreatedAt = time.Now()
+	revisions = append(revisions, revision)
+	cursor := &dbmockmongo.Cursor{
+		Res: revisions,
+	}
+
+	s.revisionCollectionMock.On("Find", s.ctx, bson.M{"postid": s.post.ID}, mock.Anything).Return(cursor, error(nil))
+
+	res, err := s.repository.QueryRevisions(s.ctx, s.post.ID)
+	assert.NoError(err)
+
+	assert.Equalf([]post.Revision{*revision}, res, "The two objects should be the same. Expected: %v; have got: %v", []post.Revision{*revision}, res)
+}
+
+func (s *PostRepositoryTestSuite) TestAuthorStats() {
+	assert := assert.New(s.T())
+
+	cursor := &cursorMock{
+		Cursor: dbmockmongo.Cursor{Res: []interface{}{&authorStats{Count: 2, Karma: 15}}},
+	}
+	s.postAggregatorMock.On("Aggregate", s.ctx, authorStatsPipeline(s.post.UserID)).Return(cursor, error(nil))
+
+	count, karma, err := s.repository.AuthorStats(s.ctx, s.post.UserID)
+	assert.NoError(err)
+	assert.Equal(uint(2), count)
+	assert.Equal(15, karma)
+}
+
+func (s *PostRepositoryTestSuite) TestAuthorStatsNoEntities() {
+	assert := assert.New(s.T())
+
+	s.postAggregatorMock.On("Aggregate", s.ctx, authorStatsPipeline(s.post.UserID)).Return(&cursorMock{}, error(nil))
+
+	count, karma, err := s.repository.AuthorStats(s.ctx, s.post.UserID)
+	assert.NoError(err)
+	assert.Equal(uint(0), count)
+	assert.Equal(0, karma)
+}
+
+func (s *PostRepositoryTestSuite) TestAuthorStatsCursorError() {
+	assert := assert.New(s.T())
+
+	cursor := &cursorMock{err: errors.New("cursor error")}
+	s.postAggregatorMock.On("Aggregate", s.ctx, authorStatsPipeline(s.post.UserID)).Return(cursor, error(nil))
+
+	_, _, err := s.repository.AuthorStats(s.ctx, s.post.UserID)
+	assert.ErrorIs(err, apperror.ErrInternal)
+}
+
+func (s *PostRepositoryTestSuite) TestCreateMark() {
+	assert := assert.New(s.T())
+	mark := &post.Mark{
+		UserID: 2,
+		PostID: s.post.ID,
+		Kind:   post.MarkSave,
+	}
+
+	s.markCollectionMock.On("InsertOne", s.ctx, mark).Return("create mark test", error(nil))
+
+	err := s.repository.CreateMark(s.ctx, mark)
+	assert.NoError(err)
+	assert.NotEmpty(mark.ID, "entity.ID should be is not empty")
+	assert.False(mark.CreatedAt.IsZero(), "entity.CreatedAt should be set")
+}
+
+func (s *PostRepositoryTestSuite) TestDeleteMarkNotFound() {
+	assert := assert.New(s.T())
+	mark := &post.Mark{
+		UserID: 2,
+		PostID: s.post.ID,
+		Kind:   post.MarkHide,
+	}
+
+	s.markCollectionMock.On("DeleteOne", s.ctx, bson.M{"userid": mark.UserID, "postid": mark.PostID, "kind": mark.Kind}).Return(int64(0), error(nil))
+
+	err := s.repository.DeleteMark(s.ctx, mark)
+	assert.Equal(apperror.ErrNotFound, err)
+}
+
+func (s *PostRepositoryTestSuite) TestQueryMarks() {
+	assert := assert.New(s.T())
+
+	mark := &post.Mark{
+		ID:        "30",
+		UserID:    2,
+		PostID:    s.post.ID,
+		Kind:      post.MarkSave,
+		CreatedAt: time.Now(),
+	}
+	cursor := &dbmockmongo.Cursor{
+		Res: []interface{}{mark},
+	}
+	after := &pagination.Cursor{Key: "createdat", Value: 100, ID: "31"}
+	condition := selection_condition.SelectionCondition{
+		Where: &post.MarkFilter{
+			Mark:   post.Mark{UserID: mark.UserID, Kind: post.MarkSave},
+			Cursor: after,
+		},
+		SortOrder: []map[string]string{{"createdat": selection_condition.SortOrderDesc}, {"id": selection_condition.SortOrderDesc}},
+		Limit:     11,
+	}
+	filter := bson.M{
+		"userid": mark.UserID,
+		"kind":   post.MarkSave,
+		"$or": bson.A{
+			bson.M{"createdat": bson.M{"$lt": 100}},
+			bson.M{"createdat": 100, "id": bson.M{"$lt": "31"}},
+		},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdat", Value: -1}, {Key: "id", Value: -1}}).SetLimit(11)
+
+	s.markCollectionMock.On("Find", s.ctx, filter, []*options.FindOptions{opts}).Return(cursor, error(nil))
+
+	res, err := s.repository.QueryMarks(s.ctx, condition)
+	assert.NoError(err)
+
+	assert.Equalf([]post.Mark{*mark}, res, "The two objects should be the same. Expected: %v; have got: %v", []post.Mark{*mark}, res)
+}
+
+func (s *PostRepositoryTestSuite) TestMarkedIDs() {
+	assert := assert.New(s.T())
+
+	cursor := &dbmockmongo.Cursor{
+		Res: []interface{}{&post.Mark{PostID: "1"}, &post.Mark{PostID: "5"}},
+	}
+	opts := options.Find().SetProjection(bson.M{"postid": 1})
+	s.markCollectionMock.On("Find", s.ctx, bson.M{"userid": uint(2), "kind": post.MarkHide}, []*options.FindOptions{opts}).Return(cursor, error(nil))
+
+	ids, err := s.repository.MarkedIDs(s.ctx, 2, post.MarkHide)
+	assert.NoError(err)
+	assert.Equal([]string{"1", "5"}, ids)
+}
@@ -0,0 +1,206 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/google/uuid"
+	minipkg_mongo "github.com/minipkg/db/mongo"
+	"github.com/minipkg/selection_condition"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"redditclone/internal/pkg/apperror"
+
+	"redditclone/internal/domain/report"
+)
+
+// ReportRepository is a repository for the reports, the items of the moderation queue are kept in the queue collection
+type ReportRepository struct {
+	repository
+	queueCollection minipkg_mongo.ICollection
+}
+
+var _ report.Repository = (*ReportRepository)(nil)
+
+// New creates a new ReportRepository
+func NewReportRepository(repository *repository, queueCollection minipkg_mongo.ICollection) (*ReportRepository, error) {
+	return &ReportRepository{
+		repository:      *repository,
+		queueCollection: queueCollection,
+	}, nil
+}
+
+// Create saves a new report in the database.
+// The report is unique by the target and the user, so a repeated report returns apperror.ErrConflict.
+func (r *ReportRepository) Create(ctx context.Context, entity *report.Report) error {
+	if entity.ID != "" {
+		return errors.Wrap(apperror.ErrBadRequest, "entity is not new")
+	}
+
+	entity.ID = uuid.New().String()
+
+	id, err := r.collection.InsertOne(ctx, entity)
+	if err != nil {
+		entity.ID = ""
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.Wrapf(apperror.ErrConflict, "The report already exists: %v", entity)
+		}
+		return errors.Wrapf(apperror.ErrInternal, "Can not create a recordset for an object %v, error: %v", entity, err)
+	}
+	r.logger.Debugf("Create records InsertedID: %v", id)
+	return nil
+}
+
+// AddToQueue increments the counters of the open queue item of the target, or opens the resolved item again, or creates a new item.
+// The item is unique by the target, so the item created concurrently is incremented.
+func (r *ReportRepository) AddToQueue(ctx context.Context, entity *report.Report, item *report.Item) error {
+	ok, err := r.incrementOpen(ctx, entity)
+	if err != nil || ok {
+		return err
+	}
+
+	res, err := r.queueCollection.UpdateOne(ctx, bson.M{
+		"targettype": entity.TargetType,
+		"targetid":   entity.TargetID,
+		"status":     bson.M{"$ne": report.StatusOpen},
+	}, bson.M{
+		"$set": bson.M{
+			"status":    report.StatusOpen,
+			"count":     1,
+			"reasons":   bson.M{entity.Reason: 1},
+			"updatedat": entity.CreatedAt,
+		},
+		"$unset": bson.M{"resolution": ""},
+	})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not open a queue item of report: %v, error: %v", entity, err)
+	}
+	if n, ok := res.(int64); !ok || n > 0 {
+		return nil
+	}
+
+	item.ID = uuid.New().String()
+	item.TargetType = entity.TargetType
+	item.TargetID = entity.TargetID
+	item.Status = report.StatusOpen
+	item.Count = 1
+	item.Reasons = map[string]uint{entity.Reason: 1}
+	item.CreatedAt = entity.CreatedAt
+	item.UpdatedAt = entity.CreatedAt
+
+	if _, err = r.queueCollection.InsertOne(ctx, item); err != nil {
+		item.ID = ""
+		if mongo.IsDuplicateKeyError(err) {
+			_, err = r.incrementOpen(ctx, entity)
+			return err
+		}
+		return errors.Wrapf(apperror.ErrInternal, "Can not create a recordset for an object %v, error: %v", item, err)
+	}
+	return nil
+}
+
+// incrementOpen increments the counters of the open queue item of the target of the report, false means there is no such item
+func (r *ReportRepository) incrementOpen(ctx context.Context, entity *report.Report) (bool, error) {
+	res, err := r.queueCollection.UpdateOne(ctx, bson.M{
+		"targettype": entity.TargetType,
+		"targetid":   entity.TargetID,
+		"status":     report.StatusOpen,
+	}, bson.M{
+		"$inc": bson.M{"count": 1, "reasons." + entity.Reason: 1},
+		"$set": bson.M{"updatedat": entity.CreatedAt},
+	})
+	if err != nil {
+		return false, errors.Wrapf(apperror.ErrInternal, "Can not count report: %v, error: %v", entity, err)
+	}
+	n, ok := res.(int64)
+	return !ok || n > 0, nil
+}
+
+// GetItem reads the queue item with the specified ID from the database.
+func (r *ReportRepository) GetItem(ctx context.Context, id string) (*report.Item, error) {
+	entity := &report.Item{}
+	err := r.queueCollection.FindOne(ctx, bson.M{"id": id}).Decode(entity)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperror.ErrNotFound
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "FindOne() error: %v", err)
+	}
+	return entity, nil
+}
+
+// QueryItems retrieves the queue items with the specified offset and limit from the database.
+func (r *ReportRepository) QueryItems(ctx context.Context, cond selection_condition.SelectionCondition) ([]report.Item, error) {
+	items := []report.Item{}
+	condition := bson.M{}
+	if w, ok := cond.Where.(*report.QueueFilter); ok {
+		if w.Status != "" {
+			condition["status"] = w.Status
+		}
+		if w.TargetType != "" {
+			condition["targettype"] = w.TargetType
+		}
+		if len(w.Categories) > 0 {
+			condition["category"] = bson.M{"$in": w.Categories}
+		}
+	}
+
+	cursor, err := r.queueCollection.Find(ctx, condition, findOptions(cond, r.Conditions.Limit))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return items, nil
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	for cursor.Next(ctx) {
+		item := &report.Item{}
+		if err = cursor.Decode(item); err != nil {
+			return nil, errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		items = append(items, *item)
+	}
+	return items, nil
+}
+
+// Resolve sets the status and the resolution of the open queue item, so of two concurrent moderators only one resolves the item.
+func (r *ReportRepository) Resolve(ctx context.Context, id string, status string, resolution *report.Resolution) error {
+	res, err := r.queueCollection.UpdateOne(ctx, bson.M{"id": id, "status": report.StatusOpen}, bson.M{"$set": bson.M{
+		"status":     status,
+		"resolution": resolution,
+		"updatedat":  resolution.CreatedAt,
+	}})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not resolve queue item id: %v, error: %v", id, err)
+	}
+	if n, ok := res.(int64); ok && n == 0 {
+		return errors.Wrapf(apperror.ErrConflict, "the queue item id: %v is not open", id)
+	}
+	return nil
+}
+
+// Counts retrieves the numbers of the reports of the open queue items of the targets.
+func (r *ReportRepository) Counts(ctx context.Context, targetType string, ids []string) (map[string]uint, error) {
+	counts := make(map[string]uint, len(ids))
+
+	condition := bson.M{"targettype": targetType, "targetid": bson.M{"$in": ids}, "status": report.StatusOpen}
+	cursor, err := r.queueCollection.Find(ctx, condition, options.Find().SetProjection(bson.M{"targetid": 1, "count": 1}))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return counts, nil
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	for cursor.Next(ctx) {
+		item := &report.Item{}
+		if err = cursor.Decode(item); err != nil {
+			return nil, errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		counts[item.TargetID] = item.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,183 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/config"
+
+	dbmockmongo "github.com/minipkg/db/mongo/mock"
+	"github.com/minipkg/log"
+
+	"redditclone/internal/domain/report"
+)
+
+type ReportRepositoryTestSuite struct {
+	//	for all tests
+	suite.Suite
+	cfg    *config.Configuration
+	logger *log.Logger
+	report *report.Report
+	//	only for each individual test
+	ctx                  context.Context
+	dbMock               *dbmockmongo.DB
+	reportCollectionMock *dbmockmongo.Collection
+	queueCollectionMock  *dbmockmongo.Collection
+	repository           report.Repository
+}
+
+func (s *ReportRepositoryTestSuite) SetupSuite() {
+	var err error
+
+	s.cfg = config.Get4UnitTest("ReportRepository")
+
+	s.logger, err = log.New(s.cfg.Log)
+	require.NoError(s.T(), err)
+
+	s.report = &report.Report{
+		TargetType: report.TargetPost,
+		TargetID:   "1",
+		UserID:     1,
+		Reason:     report.ReasonSpam,
+		CreatedAt:  time.Now(),
+	}
+
+	s.dbMock = &dbmockmongo.DB{}
+
+	s.reportCollectionMock = &dbmockmongo.Collection{}
+	s.queueCollectionMock = &dbmockmongo.Collection{}
+}
+
+func (s *ReportRepositoryTestSuite) SetupTest() {
+	var ok bool
+	require := require.New(s.T())
+	s.ctx = context.Background()
+
+	*s.reportCollectionMock = dbmockmongo.Collection{}
+	*s.queueCollectionMock = dbmockmongo.Collection{}
+	s.dbMock.On("Collection", report.TableName, []*options.CollectionOptions(nil)).Return(s.reportCollectionMock)
+	s.dbMock.On("Collection", report.QueueTableName, []*options.CollectionOptions(nil)).Return(s.queueCollectionMock)
+
+	r, err := GetRepository(s.logger, s.dbMock, nil, report.EntityName)
+	require.NoError(err)
+
+	s.repository, ok = r.(report.Repository)
+	require.Truef(ok, "Can not cast DB repository for entity %q to %vRepository. Repo: %v", report.EntityName, report.EntityName, r)
+}
+
+func TestReportRepository(t *testing.T) {
+	suite.Run(t, new(ReportRepositoryTestSuite))
+}
+
+func (s *ReportRepositoryTestSuite) TestCreate() {
+	assert := assert.New(s.T())
+	newItem := &report.Report{}
+	*newItem = *s.report
+
+	s.reportCollectionMock.On("InsertOne", s.ctx, newItem).Return("create test", error(nil))
+
+	err := s.repository.Create(s.ctx, newItem)
+	assert.NoError(err)
+	assert.NotEmpty(newItem.ID, "entity.ID should be is not empty")
+}
+
+func (s *ReportRepositoryTestSuite) TestCreateDuplicate() {
+	assert := assert.New(s.T())
+	newItem := &report.Report{}
+	*newItem = *s.report
+	duplicate := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}
+
+	s.reportCollectionMock.On("InsertOne", s.ctx, mock.Anything).Return(nil, duplicate)
+
+	err := s.repository.Create(s.ctx, newItem)
+	assert.Equal(apperror.ErrConflict, errors.Cause(err))
+	assert.Empty(newItem.ID, "entity.ID should be empty")
+}
+
+func (s *ReportRepositoryTestSuite) TestAddToQueueOpen() {
+	assert := assert.New(s.T())
+
+	s.queueCollectionMock.On("UpdateOne", s.ctx, bson.M{
+		"targettype": s.report.TargetType,
+		"targetid":   s.report.TargetID,
+		"status":     report.StatusOpen,
+	}, mock.Anything).Return(int64(1), error(nil))
+
+	err := s.repository.AddToQueue(s.ctx, s.report, &report.Item{})
+	assert.NoError(err)
+}
+
+func (s *ReportRepositoryTestSuite) TestAddToQueueReopen() {
+	assert := assert.New(s.T())
+
+	s.queueCollectionMock.On("UpdateOne", s.ctx, bson.M{
+		"targettype": s.report.TargetType,
+		"targetid":   s.report.TargetID,
+		"status":     report.StatusOpen,
+	}, mock.Anything).Return(int64(0), error(nil))
+	s.queueCollectionMock.On("UpdateOne", s.ctx, bson.M{
+		"targettype": s.report.TargetType,
+		"targetid":   s.report.TargetID,
+		"status":     bson.M{"$ne": report.StatusOpen},
+	}, mock.Anything).Return(int64(1), error(nil))
+
+	err := s.repository.AddToQueue(s.ctx, s.report, &report.Item{})
+	assert.NoError(err)
+}
+
+func (s *ReportRepositoryTestSuite) TestAddToQueueNew() {
+	assert := assert.New(s.T())
+	item := &report.Item{PostID: s.report.TargetID, Category: "programming"}
+
+	s.queueCollectionMock.On("UpdateOne", s.ctx, mock.Anything, mock.Anything).Return(int64(0), error(nil))
+	s.queueCollectionMock.On("InsertOne", s.ctx, item).Return("create test", error(nil))
+
+	err := s.repository.AddToQueue(s.ctx, s.report, item)
+	assert.NoError(err)
+	assert.NotEmpty(item.ID, "item.ID should be is not empty")
+	assert.Equal(report.StatusOpen, item.Status)
+	assert.Equal(uint(1), item.Count)
+	assert.Equal(map[string]uint{report.ReasonSpam: 1}, item.Reasons)
+}
+
+func (s *ReportRepositoryTestSuite) TestResolve() {
+	assert := assert.New(s.T())
+	resolution := &report.Resolution{ModeratorID: 2, Reason: "Spam", CreatedAt: time.Now()}
+
+	s.queueCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": "21", "status": report.StatusOpen}, mock.Anything).Return(int64(1), error(nil)).Once()
+	s.queueCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": "21", "status": report.StatusOpen}, mock.Anything).Return(int64(0), error(nil))
+
+	err := s.repository.Resolve(s.ctx, "21", report.StatusRemoved, resolution)
+	assert.NoError(err)
+
+	err = s.repository.Resolve(s.ctx, "21", report.StatusRemoved, resolution)
+	assert.Equal(apperror.ErrConflict, errors.Cause(err))
+}
+
+func (s *ReportRepositoryTestSuite) TestCounts() {
+	assert := assert.New(s.T())
+
+	cursor := &dbmockmongo.Cursor{
+		Res: []interface{}{&report.Item{TargetID: "1", Count: 3}},
+	}
+	s.queueCollectionMock.On("Find", s.ctx, bson.M{
+		"targettype": report.TargetPost,
+		"targetid":   bson.M{"$in": []string{"1", "2"}},
+		"status":     report.StatusOpen,
+	}, mock.Anything).Return(cursor, error(nil))
+
+	res, err := s.repository.Counts(s.ctx, report.TargetPost, []string{"1", "2"})
+	assert.NoError(err)
+	assert.Equal(map[string]uint{"1": 3}, res)
+}
@@ -1,15 +1,28 @@
 package mongo
 
 import (
+	"context"
+	"time"
+
 	"github.com/pkg/errors"
 
 	mongodb "github.com/minipkg/db/mongo"
 	"github.com/minipkg/log"
 	"github.com/minipkg/selection_condition"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"redditclone/internal/domain/comment"
+	"redditclone/internal/domain/community"
+	"redditclone/internal/domain/feed"
+	"redditclone/internal/domain/modlog"
 	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/report"
+	"redditclone/internal/domain/search"
+	"redditclone/internal/domain/user"
 	"redditclone/internal/domain/vote"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/pagination"
 )
 
 // IRepository is an interface of repository
@@ -21,12 +34,14 @@ type repository struct {
 	Conditions selection_condition.SelectionCondition
 	db         mongodb.IDB
 	collection mongodb.ICollection
+	// aggregator runs the aggregation pipelines on the collection, it is set for the repositories which need them only
+	aggregator IAggregator
 }
 
 const DefaultLimit = 100
 
-// GetRepository return a repository
-func GetRepository(logger log.ILogger, db mongodb.IDB, entity string) (repo IRepository, err error) {
+// GetRepository return a repository, the aggregation DB is required for the post and the comment repositories only
+func GetRepository(logger log.ILogger, db mongodb.IDB, aggregationDB IAggregationDB, entity string) (repo IRepository, err error) {
 	r := &repository{
 		logger: logger,
 		db:     db,
@@ -35,12 +50,21 @@ func GetRepository(logger log.ILogger, db mongodb.IDB, entity string) (repo IRep
 	switch entity {
 	case post.EntityName:
 		r.collection = r.db.Collection(post.TableName)
+		if r.aggregator, err = getAggregator(aggregationDB, post.TableName); err != nil {
+			return nil, err
+		}
+
+		commentAggregator, err := getAggregator(aggregationDB, comment.TableName)
+		if err != nil {
+			return nil, err
+		}
 
 		commentRepository, err := NewCommentRepository(&repository{
 			logger:     logger,
 			db:         db,
 			collection: r.db.Collection(comment.TableName),
-		})
+			aggregator: commentAggregator,
+		}, r.db.Collection(vote.TableName))
 		if err != nil {
 			return nil, err
 		}
@@ -54,19 +78,45 @@ func GetRepository(logger log.ILogger, db mongodb.IDB, entity string) (repo IRep
 			return nil, err
 		}
 
-		repo, err = NewPostRepository(r, commentRepository, voteRepository)
+		repo, err = NewPostRepository(r, commentRepository, voteRepository, r.db.Collection(post.RevisionTableName), r.db.Collection(post.MarkTableName))
 	case vote.EntityName:
 		r.collection = r.db.Collection(vote.TableName)
 		repo, err = NewVoteRepository(r)
 	case comment.EntityName:
 		r.collection = r.db.Collection(comment.TableName)
-		repo, err = NewCommentRepository(r)
+		if r.aggregator, err = getAggregator(aggregationDB, comment.TableName); err != nil {
+			return nil, err
+		}
+		repo, err = NewCommentRepository(r, r.db.Collection(vote.TableName))
+	case community.EntityName:
+		r.collection = r.db.Collection(community.TableName)
+		repo, err = NewCommunityRepository(r, r.db.Collection(community.SubscriptionTableName), r.db.Collection(community.BanTableName))
+	case feed.EntityName:
+		r.collection = r.db.Collection(feed.FollowTableName)
+		repo, err = NewFollowRepository(r)
+	case report.EntityName:
+		r.collection = r.db.Collection(report.TableName)
+		repo, err = NewReportRepository(r, r.db.Collection(report.QueueTableName))
+	case modlog.EntityName:
+		r.collection = r.db.Collection(modlog.TableName)
+		repo, err = NewModLogRepository(r)
+	case search.EntityName:
+		r.collection = r.db.Collection(post.TableName)
+		repo, err = NewSearchRepository(r, r.db.Collection(comment.TableName))
 	default:
 		err = errors.Errorf("Repository for entity %q not found", entity)
 	}
 	return repo, err
 }
 
+// getAggregator returns the aggregator of the collection with the specified name
+func getAggregator(aggregationDB IAggregationDB, name string) (IAggregator, error) {
+	if aggregationDB == nil {
+		return nil, errors.Errorf("Aggregation DB is required for collection %q", name)
+	}
+	return aggregationDB.Aggregator(name), nil
+}
+
 func (r *repository) SetDefaultConditions(defaultConditions selection_condition.SelectionCondition) {
 	r.Conditions = defaultConditions
 
@@ -74,3 +124,154 @@ func (r *repository) SetDefaultConditions(defaultConditions selection_condition.
 		r.Conditions.Limit = DefaultLimit
 	}
 }
+
+// findOptions converts the sort order, the limit and the offset of a selection condition to find options.
+// The defaultLimit is used if the condition has no limit, zero means no limit.
+func findOptions(cond selection_condition.SelectionCondition, defaultLimit uint) *options.FindOptions {
+	opts := options.Find()
+
+	if len(cond.SortOrder) > 0 {
+		sort := bson.D{}
+		for _, order := range cond.SortOrder {
+			for field, direction := range order {
+				value := 1
+				if direction == selection_condition.SortOrderDesc {
+					value = -1
+				}
+				sort = append(sort, bson.E{Key: field, Value: value})
+			}
+		}
+		opts.SetSort(sort)
+	}
+
+	limit := cond.Limit
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	if cond.Offset > 0 {
+		opts.SetSkip(int64(cond.Offset))
+	}
+	return opts
+}
+
+// keysetCondition adds to the condition the limit by the items which follow the cursor in the sort order.
+// The sort order has to consist of the key of the cursor and the id.
+func keysetCondition(condition bson.M, cursor *pagination.Cursor, sortOrder []map[string]string) bson.M {
+	op := "$gt"
+	for _, order := range sortOrder {
+		if direction, ok := order[cursor.Key]; ok && direction == selection_condition.SortOrderDesc {
+			op = "$lt"
+		}
+	}
+
+	condition["$or"] = bson.A{
+		bson.M{cursor.Key: bson.M{op: cursor.Value}},
+		bson.M{cursor.Key: cursor.Value, "id": bson.M{op: cursor.ID}},
+	}
+	return condition
+}
+
+// setExcept returns the $set update of all the fields of the entity except the given ones.
+// It is used to leave alone the fields which are changed only atomically, like counters.
+func setExcept(entity interface{}, fields ...string) (bson.M, error) {
+	data, err := bson.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := bson.M{}
+	if err = bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		delete(doc, field)
+	}
+	return bson.M{"$set": doc}, nil
+}
+
+// anonymizeAuthor replaces the author of the documents with the given IDs by the deleted user.
+// The collection updates only one document at once, so the documents are updated one by one.
+func (r *repository) anonymizeAuthor(ctx context.Context, userID uint, ids []string) error {
+	update := bson.M{"$set": bson.M{
+		"userid": 0,
+		"user":   bson.M{"name": user.DeletedName},
+	}}
+	for _, id := range ids {
+		if _, err := r.collection.UpdateOne(ctx, bson.M{"id": id}, update); err != nil {
+			return errors.Wrapf(apperror.ErrInternal, "Can not anonymize entity id: %v, error: %v", id, err)
+		}
+	}
+	r.logger.Debugf("Anonymized %v entities of user id: %v", len(ids), userID)
+	return nil
+}
+
+// softDelete sets the deletion time of the document with the specified ID, the document is kept.
+// A document which has been deleted already is not found.
+func (r *repository) softDelete(ctx context.Context, id string) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"id": id, "deletedat": nil}, bson.M{"$set": bson.M{"deletedat": time.Now()}})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not delete entity id: %v, error: %v", id, err)
+	}
+	if n, ok := res.(int64); ok && n == 0 {
+		return apperror.ErrNotFound
+	}
+	r.logger.Debugf("Delete result: %v", res)
+	return nil
+}
+
+// restore clears the deletion time of the deleted document with the specified ID.
+func (r *repository) restore(ctx context.Context, id string) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"id": id, "deletedat": bson.M{"$ne": nil}}, bson.M{"$set": bson.M{"deletedat": nil}})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not restore entity id: %v, error: %v", id, err)
+	}
+	if n, ok := res.(int64); ok && n == 0 {
+		return apperror.ErrNotFound
+	}
+	r.logger.Debugf("Restore result: %v", res)
+	return nil
+}
+
+// authorStats counts the documents of the user and sums their scores by the aggregation in the database.
+// The deleted and the shadowed documents are not counted.
+func (r *repository) authorStats(ctx context.Context, userID uint) (count uint, karma int, err error) {
+	cursor, err := r.aggregator.Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{"userid": userID, "deletedat": nil, "shadowed": bson.M{"$ne": true}}},
+		bson.M{"$group": bson.M{"_id": nil, "count": bson.M{"$sum": 1}, "karma": bson.M{"$sum": "$score"}}},
+	})
+	if err != nil {
+		return 0, 0, errors.Wrapf(apperror.ErrInternal, "Aggregate() error: %v", err)
+	}
+
+	stats := &authorStats{}
+	if cursor.Next(ctx) {
+		if err = cursor.Decode(stats); err != nil {
+			return 0, 0, errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+	}
+	if err = cursor.Err(); err != nil {
+		return 0, 0, errors.Wrapf(apperror.ErrInternal, "Cursor error: %v", err)
+	}
+	return stats.Count, stats.Karma, nil
+}
+
+// authorStats is the result of the aggregation of the documents of an author
+type authorStats struct {
+	Count uint `bson:"count"`
+	Karma int  `bson:"karma"`
+}
+
+// deleteByIDs removes the documents with the given IDs from the collection.
+// The collection deletes only one document at once, so the documents are deleted one by one.
+func deleteByIDs(ctx context.Context, collection mongodb.ICollection, ids []string) error {
+	for _, id := range ids {
+		if _, err := collection.DeleteOne(ctx, bson.M{"id": id}); err != nil {
+			return errors.Wrapf(apperror.ErrInternal, "Can not delete entity id: %v, error: %v", id, err)
+		}
+	}
+	return nil
+}
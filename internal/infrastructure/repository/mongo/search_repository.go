@@ -0,0 +1,168 @@
+package mongo
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	minipkg_mongo "github.com/minipkg/db/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"redditclone/internal/pkg/apperror"
+
+	"redditclone/internal/domain/search"
+)
+
+// SearchRepository searches the posts and the comments by the text indexes, the collection of the repository is the posts one
+type SearchRepository struct {
+	repository
+	commentCollection minipkg_mongo.ICollection
+}
+
+var _ search.Repository = (*SearchRepository)(nil)
+
+// textHit is a found document with its text score
+type textHit struct {
+	ID        string  `bson:"id"`
+	PostID    string  `bson:"postid"`
+	Relevance float64 `bson:"relevance"`
+}
+
+// New creates a new SearchRepository
+func NewSearchRepository(repository *repository, commentCollection minipkg_mongo.ICollection) (*SearchRepository, error) {
+	return &SearchRepository{
+		repository:        *repository,
+		commentCollection: commentCollection,
+	}, nil
+}
+
+// Search finds the posts and the comments by the text indexes and merges them by the text score.
+// The deleted items are not found, so the pages are full. The comments are filtered by their posts:
+// the comments of the deleted posts are not found, as well as the ones of the posts of another category or type.
+func (r *SearchRepository) Search(ctx context.Context, query *search.Query, offset, limit uint) ([]search.Hit, error) {
+	n := offset + limit
+	opts := options.Find().
+		SetProjection(bson.M{"id": 1, "postid": 1, "relevance": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "relevance", Value: bson.M{"$meta": "textScore"}}}).
+		SetLimit(int64(n))
+
+	postCondition := textCondition(query)
+	if query.Category != "" {
+		postCondition["category"] = query.Category
+	}
+	if query.Type != "" {
+		postCondition["type"] = query.Type
+	}
+	posts, err := r.find(ctx, r.collection, postCondition, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := r.find(ctx, r.commentCollection, textCondition(query), opts)
+	if err != nil {
+		return nil, err
+	}
+	if comments, err = r.commentsOfPosts(ctx, comments, query); err != nil {
+		return nil, err
+	}
+
+	hits := make([]search.Hit, 0, len(posts)+len(comments))
+	for _, h := range posts {
+		hits = append(hits, search.Hit{Kind: search.KindPost, ID: h.ID, PostID: h.ID, Score: h.Relevance})
+	}
+	for _, h := range comments {
+		hits = append(hits, search.Hit{Kind: search.KindComment, ID: h.ID, PostID: h.PostID, Score: h.Relevance})
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+
+	if offset >= uint(len(hits)) {
+		return []search.Hit{}, nil
+	}
+	hits = hits[offset:]
+	if limit < uint(len(hits)) {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// textCondition returns the condition of the text, the author and the time of creation of the query, the deleted items are excluded
+func textCondition(query *search.Query) bson.M {
+	condition := bson.M{"$text": bson.M{"$search": query.Text}, "deletedat": nil}
+	if query.UserID != 0 {
+		condition["userid"] = query.UserID
+	}
+
+	createdAt := bson.M{}
+	if !query.From.IsZero() {
+		createdAt["$gte"] = query.From
+	}
+	if !query.To.IsZero() {
+		createdAt["$lte"] = query.To
+	}
+	if len(createdAt) > 0 {
+		condition["createdat"] = createdAt
+	}
+	return condition
+}
+
+// commentsOfPosts returns the comments of the posts which are not deleted and are of the category and the type of the query
+func (r *SearchRepository) commentsOfPosts(ctx context.Context, comments []textHit, query *search.Query) ([]textHit, error) {
+	if len(comments) == 0 {
+		return comments, nil
+	}
+
+	ids := make([]string, 0, len(comments))
+	for _, h := range comments {
+		ids = append(ids, h.PostID)
+	}
+	condition := bson.M{"id": bson.M{"$in": ids}, "deletedat": nil}
+	if query.Category != "" {
+		condition["category"] = query.Category
+	}
+	if query.Type != "" {
+		condition["type"] = query.Type
+	}
+
+	posts, err := r.find(ctx, r.collection, condition, options.Find().SetProjection(bson.M{"id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	isFound := make(map[string]bool, len(posts))
+	for _, p := range posts {
+		isFound[p.ID] = true
+	}
+
+	res := make([]textHit, 0, len(comments))
+	for _, h := range comments {
+		if isFound[h.PostID] {
+			res = append(res, h)
+		}
+	}
+	return res, nil
+}
+
+func (r *SearchRepository) find(ctx context.Context, collection minipkg_mongo.ICollection, condition bson.M, opts *options.FindOptions) ([]textHit, error) {
+	items := []textHit{}
+
+	cursor, err := collection.Find(ctx, condition, opts)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return items, nil
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "Find() error: %v", err)
+	}
+
+	for cursor.Next(ctx) {
+		item := &textHit{}
+		if err = cursor.Decode(item); err != nil {
+			return nil, errors.Wrapf(apperror.ErrInternal, "Decode() error: %v", err)
+		}
+		items = append(items, *item)
+	}
+	return items, nil
+}
@@ -0,0 +1,162 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"redditclone/internal/pkg/config"
+
+	dbmockmongo "github.com/minipkg/db/mongo/mock"
+	"github.com/minipkg/log"
+
+	"redditclone/internal/domain/comment"
+	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/search"
+)
+
+type SearchRepositoryTestSuite struct {
+	//	for all tests
+	suite.Suite
+	cfg    *config.Configuration
+	logger *log.Logger
+	//	only for each individual test
+	ctx                   context.Context
+	dbMock                *dbmockmongo.DB
+	postCollectionMock    *dbmockmongo.Collection
+	commentCollectionMock *dbmockmongo.Collection
+	repository            search.Repository
+}
+
+func (s *SearchRepositoryTestSuite) SetupSuite() {
+	var err error
+
+	s.cfg = config.Get4UnitTest("SearchRepository")
+
+	s.logger, err = log.New(s.cfg.Log)
+	require.NoError(s.T(), err)
+
+	s.dbMock = &dbmockmongo.DB{}
+
+	s.postCollectionMock = &dbmockmongo.Collection{}
+	s.commentCollectionMock = &dbmockmongo.Collection{}
+}
+
+func (s *SearchRepositoryTestSuite) SetupTest() {
+	var ok bool
+	require := require.New(s.T())
+	s.ctx = context.Background()
+
+	*s.postCollectionMock = dbmockmongo.Collection{}
+	*s.commentCollectionMock = dbmockmongo.Collection{}
+	s.dbMock.On("Collection", post.TableName, []*options.CollectionOptions(nil)).Return(s.postCollectionMock)
+	s.dbMock.On("Collection", comment.TableName, []*options.CollectionOptions(nil)).Return(s.commentCollectionMock)
+
+	r, err := GetRepository(s.logger, s.dbMock, nil, search.EntityName)
+	require.NoError(err)
+
+	s.repository, ok = r.(search.Repository)
+	require.Truef(ok, "Can not cast DB repository for entity %q to %vRepository. Repo: %v", search.EntityName, search.EntityName, r)
+}
+
+func TestSearchRepository(t *testing.T) {
+	suite.Run(t, new(SearchRepositoryTestSuite))
+}
+
+func (s *SearchRepositoryTestSuite) textOptions(limit int64) *options.FindOptions {
+	return options.Find().
+		SetProjection(bson.M{"id": 1, "postid": 1, "relevance": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "relevance", Value: bson.M{"$meta": "textScore"}}}).
+		SetLimit(limit)
+}
+
+func (s *SearchRepositoryTestSuite) TestSearch() {
+	assert := assert.New(s.T())
+
+	from := time.Now().Add(-time.Hour)
+	query := &search.Query{
+		Text:   "good programmer",
+		UserID: 1,
+		From:   from,
+	}
+	condition := bson.M{
+		"$text":     bson.M{"$search": query.Text},
+		"deletedat": nil,
+		"userid":    uint(1),
+		"createdat": bson.M{"$gte": from},
+	}
+	posts := &dbmockmongo.Cursor{
+		Res: []interface{}{&textHit{ID: "1", Relevance: 2.5}, &textHit{ID: "2", Relevance: 0.7}},
+	}
+	comments := &dbmockmongo.Cursor{
+		Res: []interface{}{&textHit{ID: "10", PostID: "3", Relevance: 1.1}},
+	}
+	s.postCollectionMock.On("Find", s.ctx, condition, []*options.FindOptions{s.textOptions(3)}).Return(posts, error(nil))
+	s.commentCollectionMock.On("Find", s.ctx, condition, []*options.FindOptions{s.textOptions(3)}).Return(comments, error(nil))
+	s.postCollectionMock.On("Find", s.ctx, bson.M{"id": bson.M{"$in": []string{"3"}}, "deletedat": nil}, []*options.FindOptions{options.Find().SetProjection(bson.M{"id": 1})}).Return(&dbmockmongo.Cursor{Res: []interface{}{&textHit{ID: "3"}}}, error(nil))
+
+	res, err := s.repository.Search(s.ctx, query, 1, 2)
+	assert.NoError(err)
+
+	expected := []search.Hit{
+		{Kind: search.KindComment, ID: "10", PostID: "3", Score: 1.1},
+		{Kind: search.KindPost, ID: "2", PostID: "2", Score: 0.7},
+	}
+	assert.Equal(expected, res)
+}
+
+func (s *SearchRepositoryTestSuite) TestSearchByCategory() {
+	assert := assert.New(s.T())
+
+	query := &search.Query{
+		Text:     "programmer",
+		Category: post.CategoryProgramming,
+	}
+	textCondition := bson.M{"$text": bson.M{"$search": query.Text}, "deletedat": nil}
+	postCondition := bson.M{"$text": bson.M{"$search": query.Text}, "deletedat": nil, "category": query.Category}
+	posts := &dbmockmongo.Cursor{
+		Res: []interface{}{&textHit{ID: "1", Relevance: 1.5}},
+	}
+	comments := &dbmockmongo.Cursor{
+		Res: []interface{}{&textHit{ID: "10", PostID: "1", Relevance: 2}, &textHit{ID: "11", PostID: "4", Relevance: 1}},
+	}
+	//	the comment of the post of another category is skipped
+	postsOfComments := &dbmockmongo.Cursor{
+		Res: []interface{}{&textHit{ID: "1"}},
+	}
+	s.postCollectionMock.On("Find", s.ctx, postCondition, []*options.FindOptions{s.textOptions(25)}).Return(posts, error(nil))
+	s.commentCollectionMock.On("Find", s.ctx, textCondition, []*options.FindOptions{s.textOptions(25)}).Return(comments, error(nil))
+	s.postCollectionMock.On("Find", s.ctx, bson.M{"id": bson.M{"$in": []string{"1", "4"}}, "deletedat": nil, "category": query.Category}, []*options.FindOptions{options.Find().SetProjection(bson.M{"id": 1})}).Return(postsOfComments, error(nil))
+
+	res, err := s.repository.Search(s.ctx, query, 0, 25)
+	assert.NoError(err)
+
+	expected := []search.Hit{
+		{Kind: search.KindComment, ID: "10", PostID: "1", Score: 2},
+		{Kind: search.KindPost, ID: "1", PostID: "1", Score: 1.5},
+	}
+	assert.Equal(expected, res)
+}
+
+func (s *SearchRepositoryTestSuite) TestSearchSkipsCommentsOfDeletedPosts() {
+	assert := assert.New(s.T())
+
+	query := &search.Query{Text: "programmer"}
+	condition := bson.M{"$text": bson.M{"$search": query.Text}, "deletedat": nil}
+	comments := &dbmockmongo.Cursor{
+		Res: []interface{}{&textHit{ID: "10", PostID: "5", Relevance: 2}},
+	}
+	s.postCollectionMock.On("Find", s.ctx, condition, []*options.FindOptions{s.textOptions(25)}).Return(&dbmockmongo.Cursor{}, error(nil))
+	s.commentCollectionMock.On("Find", s.ctx, condition, []*options.FindOptions{s.textOptions(25)}).Return(comments, error(nil))
+	s.postCollectionMock.On("Find", s.ctx, bson.M{"id": bson.M{"$in": []string{"5"}}, "deletedat": nil}, []*options.FindOptions{options.Find().SetProjection(bson.M{"id": 1})}).Return(&dbmockmongo.Cursor{}, error(nil))
+
+	res, err := s.repository.Search(s.ctx, query, 0, 25)
+	assert.NoError(err)
+	assert.Empty(res)
+}
@@ -75,6 +75,10 @@ func (r *VoteRepository) Create(ctx context.Context, entity *vote.Vote) error {
 
 	id, err := r.collection.InsertOne(ctx, entity)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			entity.ID = ""
+			return errors.Wrapf(apperror.ErrConflict, "The vote already exists: %v", entity)
+		}
 		return errors.Wrapf(apperror.ErrInternal, "Can not create a recordset for an object %v, error: %v", entity, err)
 	}
 	r.logger.Debugf("Create records InsertedID: %v", id)
@@ -99,6 +103,25 @@ func (r *VoteRepository) Update(ctx context.Context, entity *vote.Vote) error {
 	return nil
 }
 
+// UpdateValue changes the value of the vote only if nobody has changed it since it was read.
+func (r *VoteRepository) UpdateValue(ctx context.Context, entity *vote.Vote, oldValue int) error {
+	if entity.ID == "" {
+		return errors.Wrap(apperror.ErrBadRequest, "entity is new")
+	}
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{"id": entity.ID, "value": oldValue}, bson.M{"$set": bson.M{
+		"value":     entity.Value,
+		"updatedat": entity.UpdatedAt,
+	}})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Can not update entity: %v, error: %v", entity, err)
+	}
+	if n, ok := res.(int64); ok && n == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
 // Delete deletes an entity with the specified ID from the database.
 func (r *VoteRepository) Delete(ctx context.Context, id string) error {
 
@@ -110,15 +133,26 @@ func (r *VoteRepository) Delete(ctx context.Context, id string) error {
 		return errors.Wrapf(apperror.ErrInternal, "Can not delete entityId: %v, error: %v", id, err)
 	}
 	r.logger.Debugf("Delete result: %v", res)
+	if res == 0 {
+		return apperror.ErrNotFound
+	}
 	return nil
 }
 
 func (r *VoteRepository) First(ctx context.Context, entity *vote.Vote) (*vote.Vote, error) {
 	vote := &vote.Vote{}
 
-	err := r.collection.FindOne(ctx, bson.M{"userid": entity.UserID, "postid": entity.PostID}).Decode(vote)
-	if err == mongo.ErrNoDocuments {
-		return nil, apperror.ErrNotFound
+	filter := bson.M{"userid": entity.UserID, "postid": entity.PostID, "commentid": nil}
+	if entity.IsForComment() {
+		filter["commentid"] = entity.CommentID
+	}
+
+	err := r.collection.FindOne(ctx, filter).Decode(vote)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperror.ErrNotFound
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "FindOne() error: %v", err)
 	}
 	return vote, nil
 }
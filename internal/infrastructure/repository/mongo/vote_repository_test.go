@@ -5,21 +5,22 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"redditclone/internal/pkg/apperror"
 	"redditclone/internal/pkg/config"
 
 	dbmockmongo "github.com/minipkg/db/mongo/mock"
 	"github.com/minipkg/log"
 	"github.com/minipkg/selection_condition"
 
-	"redditclone/internal/domain/comment"
-	"redditclone/internal/domain/post"
 	"redditclone/internal/domain/vote"
 )
 
@@ -39,7 +40,7 @@ type VoteRepositoryTestSuite struct {
 func (s *VoteRepositoryTestSuite) SetupSuite() {
 	var err error
 
-	s.cfg = config.Get4UnitTest("CommentRepository")
+	s.cfg = config.Get4UnitTest("VoteRepository")
 
 	s.logger, err = log.New(s.cfg.Log)
 	require.NoError(s.T(), err)
@@ -65,17 +66,17 @@ func (s *VoteRepositoryTestSuite) SetupTest() {
 	s.ctx = context.Background()
 
 	*s.voteCollectionMock = dbmockmongo.Collection{}
-	s.dbMock.On("Collection", comment.TableName, []*options.CollectionOptions(nil)).Return(s.voteCollectionMock)
+	s.dbMock.On("Collection", vote.TableName, []*options.CollectionOptions(nil)).Return(s.voteCollectionMock)
 
-	r, err := GetRepository(s.logger, s.dbMock, comment.EntityName)
+	r, err := GetRepository(s.logger, s.dbMock, nil, vote.EntityName)
 	require.NoError(err)
 
 	s.repository, ok = r.(vote.Repository)
-	require.Truef(ok, "Can not cast DB repository for entity %q to %vRepository. Repo: %v", post.EntityName, post.EntityName, r)
+	require.Truef(ok, "Can not cast DB repository for entity %q to %vRepository. Repo: %v", vote.EntityName, vote.EntityName, r)
 }
 
 func TestVoteRepository(t *testing.T) {
-	suite.Run(t, new(CommentRepositoryTestSuite))
+	suite.Run(t, new(VoteRepositoryTestSuite))
 }
 
 func (s *VoteRepositoryTestSuite) TestGet() {
@@ -139,6 +140,32 @@ func (s *VoteRepositoryTestSuite) TestUpdate() {
 	assert.NoError(err)
 }
 
+func (s *VoteRepositoryTestSuite) TestCreateDuplicate() {
+	assert := assert.New(s.T())
+	newItem := &vote.Vote{}
+	*newItem = *s.vote
+	(*newItem).ID = ""
+	duplicate := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}
+
+	s.voteCollectionMock.On("InsertOne", s.ctx, mock.Anything).Return(nil, duplicate)
+
+	err := s.repository.Create(s.ctx, newItem)
+	assert.Equal(apperror.ErrConflict, errors.Cause(err))
+	assert.Empty((*newItem).ID, "entity.ID should be empty")
+}
+
+func (s *VoteRepositoryTestSuite) TestUpdateValueChanged() {
+	assert := assert.New(s.T())
+	item := &vote.Vote{}
+	*item = *s.vote
+	item.Value = -1
+
+	s.voteCollectionMock.On("UpdateOne", s.ctx, bson.M{"id": s.vote.ID, "value": 1}, mock.Anything).Return(int64(0), error(nil))
+
+	err := s.repository.UpdateValue(s.ctx, item, 1)
+	assert.Equal(apperror.ErrNotFound, err)
+}
+
 func (s *VoteRepositoryTestSuite) TestDelete() {
 	assert := assert.New(s.T())
 
@@ -156,9 +183,9 @@ func (s *VoteRepositoryTestSuite) TestFirst() {
 		Err:    nil,
 	}
 
-	s.voteCollectionMock.On("FindOne", s.ctx, bson.M{"userid": s.vote.UserID, "postid": s.vote.PostID}, []*options.FindOneOptions(nil)).Return(result)
+	s.voteCollectionMock.On("FindOne", s.ctx, bson.M{"userid": s.vote.UserID, "postid": s.vote.PostID, "commentid": nil}, []*options.FindOneOptions(nil)).Return(result)
 
-	res, err := s.repository.Get(s.ctx, s.vote.ID)
+	res, err := s.repository.First(s.ctx, &vote.Vote{UserID: s.vote.UserID, PostID: s.vote.PostID})
 	assert.NoError(err)
 
 	assert.Equalf(*s.vote, *res, "The two objects should be the same. Expected: %v; have got: %v", *s.vote, *res)
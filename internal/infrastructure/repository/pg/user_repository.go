@@ -7,6 +7,7 @@ import (
 	"github.com/minipkg/selection_condition"
 
 	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 
 	"redditclone/internal/pkg/apperror"
@@ -28,9 +29,17 @@ func NewUserRepository(repository *repository) (*UserRepository, error) {
 	return r, nil
 }
 
+// nameNormalizedIndex is unique for the normalised names only, the users registered before the normalisation
+// have empty ones until the names are normalised by the CLI command
+const nameNormalizedIndex = `CREATE UNIQUE INDEX IF NOT EXISTS "user_name_normalized_key" ON "user" ("name_normalized") WHERE "name_normalized" <> ''`
+
+// uniqueViolation is the PostgreSQL error code of a unique constraint violation
+const uniqueViolation = "23505"
+
 func (r UserRepository) autoMigrate() {
 	if r.db.IsAutoMigrate() {
 		r.db.DB().AutoMigrate(&user.User{})
+		r.db.DB().Exec(nameNormalizedIndex)
 	}
 }
 
@@ -47,8 +56,16 @@ func (r UserRepository) Get(ctx context.Context, id uint) (*user.User, error) {
 	return entity, err
 }
 
+// First returns the first user matching the non-empty fields of the entity.
+// The name is matched by its normalised form, or exactly for the users whose names have not been normalised yet.
 func (r UserRepository) First(ctx context.Context, entity *user.User) (*user.User, error) {
-	err := r.DB().Where(entity).First(entity).Error
+	db := r.DB()
+	if name := entity.Name; name != "" {
+		entity.Name = ""
+		db = db.Where(`"user"."name_normalized" = ? OR ("user"."name_normalized" = '' AND "user"."name" = ?)`, user.NormalizeName(name), name)
+	}
+
+	err := db.Where(entity).First(entity).Error
 	if err != nil {
 		if gorm.IsRecordNotFoundError(err) {
 			return entity, apperror.ErrNotFound
@@ -82,5 +99,48 @@ func (r UserRepository) Create(ctx context.Context, entity *user.User) error {
 	if !r.db.DB().NewRecord(entity) {
 		return errors.New("entity is not new")
 	}
-	return r.db.DB().Create(entity).Error
+	err := r.db.DB().Create(entity).Error
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return errors.Wrapf(apperror.ErrConflict, "user name %q is taken: %v", entity.Name, err)
+	}
+	return err
+}
+
+// Update saves the changed user in the database.
+func (r UserRepository) Update(ctx context.Context, entity *user.User) error {
+
+	if r.db.DB().NewRecord(entity) {
+		return errors.New("entity is new")
+	}
+	return r.db.DB().Save(entity).Error
+}
+
+// QueryWithDeleted retrieves the user records including the deleted ones ordered by ID with the specified offset and limit.
+func (r UserRepository) QueryWithDeleted(ctx context.Context, offset, limit uint) ([]user.User, error) {
+	items := []user.User{}
+
+	err := r.db.DB().Unscoped().Order("id").Offset(offset).Limit(limit).Find(&items).Error
+	return items, err
+}
+
+// SetNameNormalized saves the normalised name of the user without the change of the other fields.
+func (r UserRepository) SetNameNormalized(ctx context.Context, id uint, nameNormalized string) error {
+	return r.db.DB().Unscoped().Model(&user.User{ID: id}).UpdateColumn("name_normalized", nameNormalized).Error
+}
+
+// Delete erases the credentials of the user and marks the user as deleted, so the name can not be registered again.
+func (r UserRepository) Delete(ctx context.Context, id uint) error {
+	entity := &user.User{ID: id}
+
+	err := r.db.DB().Model(entity).Updates(map[string]interface{}{
+		"passhash":       "",
+		"email":          "",
+		"totp_secret":    "",
+		"totp_enabled":   false,
+		"recovery_codes": nil,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return r.db.DB().Delete(entity).Error
 }
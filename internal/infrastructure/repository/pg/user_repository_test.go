@@ -11,10 +11,13 @@ import (
 	"github.com/minipkg/db/gorm/mock"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	"redditclone/internal/pkg/apperror"
 	"redditclone/internal/pkg/config"
 
 	"github.com/minipkg/log"
@@ -49,6 +52,7 @@ func (s *UserRepositoryTestSuite) SetupSuite() {
 		ID:        1,
 		Name:      "demo1",
 		Passhash:  string(passhash),
+		Role:      user.RoleUser,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -87,7 +91,7 @@ func (s *UserRepositoryTestSuite) TestGet() {
 	assert := assert.New(s.T())
 
 	sql := fmt.Sprintf(`SELECT \* FROM "user".*?"user"\."id" = %v.*?LIMIT 1`, s.user.ID)
-	rows := sqlmock.NewRows([]string{"id", "name", "passhash", "created_at", "updated_at", "deleted_at"}).AddRow(s.user.ID, s.user.Name, s.user.Passhash, s.user.CreatedAt, s.user.UpdatedAt, s.user.DeletedAt)
+	rows := sqlmock.NewRows([]string{"id", "name", "passhash", "role", "created_at", "updated_at", "deleted_at"}).AddRow(s.user.ID, s.user.Name, s.user.Passhash, s.user.Role, s.user.CreatedAt, s.user.UpdatedAt, s.user.DeletedAt)
 	s.mock.ExpectQuery(sql).WillReturnRows(rows)
 
 	res, err := s.repository.Get(s.ctx, s.user.ID)
@@ -101,15 +105,17 @@ func (s *UserRepositoryTestSuite) TestCreate() {
 
 	s.mock.ExpectBegin()
 
-	sql := fmt.Sprintf(`INSERT INTO "user".*?VALUES \(\$1,\$2,\$3,\$4,\$5\).*?RETURNING "user"\."id"`)
+	sql := fmt.Sprintf(`INSERT INTO "user".*?VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10\).*?RETURNING "user"\."id"`)
 	rows := sqlmock.NewRows([]string{"id"}).AddRow(s.user.ID)
-	s.mock.ExpectQuery(sql).WithArgs(s.user.Name, s.user.Passhash, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).WillReturnRows(rows)
+	s.mock.ExpectQuery(sql).WithArgs(s.user.Name, "demol", s.user.Passhash, s.user.Role, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), nil).WillReturnRows(rows)
 
 	s.mock.ExpectCommit()
 
 	user := user.New()
 	user.Name = s.user.Name
+	user.NameNormalized = "demol"
 	user.Passhash = s.user.Passhash
+	user.Role = s.user.Role
 
 	err := s.repository.Create(s.ctx, user)
 	assert.Nil(err)
@@ -118,12 +124,12 @@ func (s *UserRepositoryTestSuite) TestCreate() {
 func (s *UserRepositoryTestSuite) TestFirst() {
 	assert := assert.New(s.T())
 
-	sql := fmt.Sprintf(`SELECT \* FROM "user".*?"user"\."name" = \$1.*?LIMIT 1`)
-	rows := sqlmock.NewRows([]string{"id", "name", "passhash", "created_at", "updated_at", "deleted_at"}).AddRow(s.user.ID, s.user.Name, s.user.Passhash, s.user.CreatedAt, s.user.UpdatedAt, s.user.DeletedAt)
-	s.mock.ExpectQuery(sql).WillReturnRows(rows).WithArgs(s.user.Name)
+	sql := fmt.Sprintf(`SELECT \* FROM "user".*?"user"\."name_normalized" = \$1 OR \("user"\."name_normalized" = '' AND "user"\."name" = \$2\).*?LIMIT 1`)
+	rows := sqlmock.NewRows([]string{"id", "name", "passhash", "role", "created_at", "updated_at", "deleted_at"}).AddRow(s.user.ID, s.user.Name, s.user.Passhash, s.user.Role, s.user.CreatedAt, s.user.UpdatedAt, s.user.DeletedAt)
+	s.mock.ExpectQuery(sql).WillReturnRows(rows).WithArgs("demol", "DEMO1")
 
 	user := user.New()
-	user.Name = s.user.Name
+	user.Name = "DEMO1"
 
 	res, err := s.repository.First(s.ctx, user)
 	assert.Nil(err)
@@ -131,3 +137,61 @@ func (s *UserRepositoryTestSuite) TestFirst() {
 	assert.Equalf(*s.user, *res, "The two objects should be the same. Expected: %v; have got: %v", *s.user, *res)
 
 }
+
+func (s *UserRepositoryTestSuite) TestUpdate() {
+	assert := assert.New(s.T())
+
+	s.mock.ExpectBegin()
+
+	sql := `UPDATE "user" SET .*?"passhash" = \$\d+.*?WHERE .*?"user"\."id" = \$\d+`
+	s.mock.ExpectExec(sql).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	s.mock.ExpectCommit()
+
+	user := &user.User{}
+	*user = *s.user
+	user.Passhash = "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$a2V5"
+
+	err := s.repository.Update(s.ctx, user)
+	assert.Nil(err)
+}
+
+func (s *UserRepositoryTestSuite) TestCreateConflict() {
+	assert := assert.New(s.T())
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(`INSERT INTO "user"`).WillReturnError(&pq.Error{Code: uniqueViolation})
+	s.mock.ExpectRollback()
+
+	user := user.New()
+	user.Name = s.user.Name
+	user.NameNormalized = "demol"
+	user.Passhash = s.user.Passhash
+
+	err := s.repository.Create(s.ctx, user)
+	assert.True(errors.Is(err, apperror.ErrConflict), err)
+}
+
+func (s *UserRepositoryTestSuite) TestQueryWithDeleted() {
+	assert := assert.New(s.T())
+
+	sql := `SELECT \* FROM "user" ORDER BY "id" LIMIT 500 OFFSET 1000`
+	rows := sqlmock.NewRows([]string{"id", "name", "deleted_at"}).AddRow(s.user.ID, s.user.Name, time.Now())
+	s.mock.ExpectQuery(sql).WillReturnRows(rows)
+
+	res, err := s.repository.QueryWithDeleted(s.ctx, 1000, 500)
+	assert.Nil(err)
+	assert.Len(res, 1)
+}
+
+func (s *UserRepositoryTestSuite) TestSetNameNormalized() {
+	assert := assert.New(s.T())
+
+	s.mock.ExpectBegin()
+	sql := `UPDATE "user" SET "name_normalized" = \$1 WHERE "user"\."id" = \$2`
+	s.mock.ExpectExec(sql).WithArgs("demol", s.user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repository.SetNameNormalized(s.ctx, s.user.ID, "demol")
+	assert.Nil(err)
+}
@@ -0,0 +1,111 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/auth"
+
+	"github.com/minipkg/db/redis"
+)
+
+const (
+	keyPrefixForAttempts = "attempts_"
+	keyPrefixForLockouts = "lockouts_"
+	keyPrefixForLock     = "lock_"
+)
+
+// LoginAttemptRepository is a repository for the login attempts and lockouts.
+// The attempts of a key are stored in the sorted set scored by the time, so the set is a sliding window.
+type LoginAttemptRepository struct {
+	repository
+}
+
+var _ auth.LoginAttemptRepository = (*LoginAttemptRepository)(nil)
+
+// NewLoginAttemptRepository creates a new LoginAttemptRepository
+func NewLoginAttemptRepository(dbase redis.IDB) (*LoginAttemptRepository, error) {
+	return &LoginAttemptRepository{
+		repository: repository{
+			db: dbase,
+		},
+	}, nil
+}
+
+// AddAttempt registers an attempt at the time and returns the number of the attempts within the window before it.
+func (r *LoginAttemptRepository) AddAttempt(ctx context.Context, key string, at time.Time, window time.Duration) (uint, error) {
+	setKey := keyPrefixForAttempts + key
+	windowStart := strconv.FormatInt(at.Add(-window).UnixNano(), 10)
+
+	if err := r.db.DB().ZRemRangeByScore(ctx, setKey, "-inf", "("+windowStart).Err(); err != nil {
+		return 0, errors.Wrapf(apperror.ErrInternal, "ZRemRangeByScore() error: %v", err)
+	}
+
+	count, err := r.db.DB().ZCard(ctx, setKey).Result()
+	if err != nil {
+		return 0, errors.Wrapf(apperror.ErrInternal, "ZCard() error: %v", err)
+	}
+
+	member := &goredis.Z{
+		Score:  float64(at.UnixNano()),
+		Member: strconv.FormatInt(at.UnixNano(), 10),
+	}
+	if err = r.db.DB().ZAdd(ctx, setKey, member).Err(); err != nil {
+		return 0, errors.Wrapf(apperror.ErrInternal, "ZAdd() error: %v", err)
+	}
+	if err = r.db.DB().Expire(ctx, setKey, window).Err(); err != nil {
+		return 0, errors.Wrapf(apperror.ErrInternal, "Expire() error: %v", err)
+	}
+	return uint(count), nil
+}
+
+// ResetAttempts forgets the attempts of the key.
+func (r *LoginAttemptRepository) ResetAttempts(ctx context.Context, key string) error {
+	if err := r.db.DB().Del(ctx, keyPrefixForAttempts+key).Err(); err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Delete error: %v", err)
+	}
+	return nil
+}
+
+// CountLockout registers a lockout of the key and returns the number of the lockouts of the key within the period.
+// The period starts with the first lockout.
+func (r *LoginAttemptRepository) CountLockout(ctx context.Context, key string, period time.Duration) (uint, error) {
+	counterKey := keyPrefixForLockouts + key
+
+	count, err := r.db.DB().Incr(ctx, counterKey).Result()
+	if err != nil {
+		return 0, errors.Wrapf(apperror.ErrInternal, "Incr() error: %v", err)
+	}
+	if count == 1 {
+		if err = r.db.DB().Expire(ctx, counterKey, period).Err(); err != nil {
+			return 0, errors.Wrapf(apperror.ErrInternal, "Expire() error: %v", err)
+		}
+	}
+	return uint(count), nil
+}
+
+// Lock locks the key for the duration.
+func (r *LoginAttemptRepository) Lock(ctx context.Context, key string, duration time.Duration) error {
+	if err := r.db.DB().Set(ctx, keyPrefixForLock+key, 1, duration).Err(); err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Set() error: %v", err)
+	}
+	return nil
+}
+
+// LockedFor returns the time which the key is locked for, zero if it is not locked.
+func (r *LoginAttemptRepository) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.db.DB().PTTL(ctx, keyPrefixForLock+key).Result()
+	if err != nil {
+		return 0, errors.Wrapf(apperror.ErrInternal, "PTTL() error: %v", err)
+	}
+	//	a negative TTL means that there is no lock
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
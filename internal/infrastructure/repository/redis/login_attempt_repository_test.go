@@ -0,0 +1,113 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/elliotchance/redismock/v8"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	dbredis "github.com/minipkg/db/redis"
+	dbmockredis "github.com/minipkg/db/redis/mock"
+)
+
+const loginAttemptKey = "login_user_demo1"
+
+type LoginAttemptRepositoryTestSuite struct {
+	suite.Suite
+	ctx        context.Context
+	mock       *redismock.ClientMock
+	repository *LoginAttemptRepository
+}
+
+func (s *LoginAttemptRepositoryTestSuite) SetupTest() {
+	var db *dbredis.DB
+	var err error
+	require := require.New(s.T())
+
+	db, s.mock, err = dbmockredis.New()
+	require.NoError(err)
+
+	s.repository, err = NewLoginAttemptRepository(db)
+	require.NoError(err)
+}
+
+func TestLoginAttemptRepository(t *testing.T) {
+	suite.Run(t, new(LoginAttemptRepositoryTestSuite))
+}
+
+func (s *LoginAttemptRepositoryTestSuite) TestAddAttempt() {
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+
+	key := keyPrefixForAttempts + loginAttemptKey
+	at := time.Now()
+	window := 15 * time.Minute
+	member := &redis.Z{
+		Score:  float64(at.UnixNano()),
+		Member: strconv.FormatInt(at.UnixNano(), 10),
+	}
+
+	s.mock.On("ZRemRangeByScore", s.ctx, key, "-inf", "("+strconv.FormatInt(at.Add(-window).UnixNano(), 10)).
+		Return(redis.NewIntResult(1, nil))
+	s.mock.On("ZCard", s.ctx, key).
+		Return(redis.NewIntResult(3, nil))
+	s.mock.On("ZAdd", s.ctx, key, []*redis.Z{member}).
+		Return(redis.NewIntResult(1, nil))
+	s.mock.On("Expire", s.ctx, key, window).
+		Return(redis.NewBoolResult(true, nil))
+
+	res, err := s.repository.AddAttempt(s.ctx, loginAttemptKey, at, window)
+	require.NoError(err)
+	assert.Equal(uint(3), res)
+	s.mock.AssertCalled(s.T(), "ZAdd", s.ctx, key, []*redis.Z{member})
+}
+
+func (s *LoginAttemptRepositoryTestSuite) TestCountLockout() {
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+
+	key := keyPrefixForLockouts + loginAttemptKey
+
+	//	the period starts with the first lockout only
+	s.mock.On("Incr", s.ctx, key).
+		Return(redis.NewIntResult(2, nil))
+
+	res, err := s.repository.CountLockout(s.ctx, loginAttemptKey, 24*time.Hour)
+	require.NoError(err)
+	assert.Equal(uint(2), res)
+	s.mock.AssertNotCalled(s.T(), "Expire", s.ctx, key, 24*time.Hour)
+}
+
+func (s *LoginAttemptRepositoryTestSuite) TestLock() {
+	require := require.New(s.T())
+
+	s.mock.On("Set", s.ctx, keyPrefixForLock+loginAttemptKey, 1, time.Minute).
+		Return(redis.NewStatusResult("OK", nil))
+
+	err := s.repository.Lock(s.ctx, loginAttemptKey, time.Minute)
+	require.NoError(err)
+}
+
+func (s *LoginAttemptRepositoryTestSuite) TestLockedFor() {
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+
+	s.mock.On("PTTL", s.ctx, keyPrefixForLock+loginAttemptKey).
+		Return(redis.NewDurationResult(30*time.Second, nil))
+	s.mock.On("PTTL", s.ctx, keyPrefixForLock+"login_user_demo2").
+		Return(redis.NewDurationResult(-2*time.Millisecond, nil))
+
+	res, err := s.repository.LockedFor(s.ctx, loginAttemptKey)
+	require.NoError(err)
+	assert.Equal(30*time.Second, res)
+
+	res, err = s.repository.LockedFor(s.ctx, "login_user_demo2")
+	require.NoError(err)
+	assert.Equal(time.Duration(0), res)
+}
@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/auth"
+
+	"github.com/minipkg/db/redis"
+)
+
+const keyPrefixForPasswordReset = "password_reset_"
+
+// PasswordResetRepository is a repository for the password reset tokens.
+// A token is stored by its hash, the value is the ID of the user.
+type PasswordResetRepository struct {
+	repository
+}
+
+var _ auth.PasswordResetRepository = (*PasswordResetRepository)(nil)
+
+// NewPasswordResetRepository creates a new PasswordResetRepository
+func NewPasswordResetRepository(dbase redis.IDB) (*PasswordResetRepository, error) {
+	return &PasswordResetRepository{
+		repository: repository{
+			db: dbase,
+		},
+	}, nil
+}
+
+// Create saves the token of the user for the lifetime.
+func (r *PasswordResetRepository) Create(ctx context.Context, tokenHash string, userID uint, lifeTime time.Duration) error {
+	if err := r.db.DB().Set(ctx, keyPrefixForPasswordReset+tokenHash, userID, lifeTime).Err(); err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Set() error: %v", err)
+	}
+	return nil
+}
+
+// Take deletes the token and returns the ID of its user, so a token can be used only once.
+// It returns apperror.ErrNotFound if the token does not exist, has expired or has been taken already.
+func (r *PasswordResetRepository) Take(ctx context.Context, tokenHash string) (uint, error) {
+	key := keyPrefixForPasswordReset + tokenHash
+
+	val, err := r.db.DB().Get(ctx, key).Result()
+	if err != nil {
+		if err == goredis.Nil {
+			return 0, apperror.ErrNotFound
+		}
+		return 0, errors.Wrapf(apperror.ErrInternal, "Get() error: %v", err)
+	}
+
+	deleted, err := r.db.DB().Del(ctx, key).Result()
+	if err != nil {
+		return 0, errors.Wrapf(apperror.ErrInternal, "Delete error: %v", err)
+	}
+	if deleted == 0 {
+		//	a concurrent request has taken the token
+		return 0, apperror.ErrNotFound
+	}
+
+	userID, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(apperror.ErrInternal, "can not parse the user ID %q: %v", val, err)
+	}
+	return uint(userID), nil
+}
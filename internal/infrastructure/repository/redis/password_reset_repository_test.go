@@ -0,0 +1,96 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elliotchance/redismock/v8"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"redditclone/internal/pkg/apperror"
+
+	dbredis "github.com/minipkg/db/redis"
+	dbmockredis "github.com/minipkg/db/redis/mock"
+)
+
+const resetTokenHash = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+type PasswordResetRepositoryTestSuite struct {
+	suite.Suite
+	ctx        context.Context
+	mock       *redismock.ClientMock
+	repository *PasswordResetRepository
+}
+
+func (s *PasswordResetRepositoryTestSuite) SetupTest() {
+	var db *dbredis.DB
+	var err error
+	require := require.New(s.T())
+
+	db, s.mock, err = dbmockredis.New()
+	require.NoError(err)
+
+	s.repository, err = NewPasswordResetRepository(db)
+	require.NoError(err)
+}
+
+func TestPasswordResetRepository(t *testing.T) {
+	suite.Run(t, new(PasswordResetRepositoryTestSuite))
+}
+
+func (s *PasswordResetRepositoryTestSuite) TestCreate() {
+	require := require.New(s.T())
+
+	key := keyPrefixForPasswordReset + resetTokenHash
+	s.mock.On("Set", s.ctx, key, uint(1), time.Hour).
+		Return(redis.NewStatusResult("OK", nil))
+
+	err := s.repository.Create(s.ctx, resetTokenHash, 1, time.Hour)
+	require.NoError(err)
+	s.mock.AssertCalled(s.T(), "Set", s.ctx, key, uint(1), time.Hour)
+}
+
+func (s *PasswordResetRepositoryTestSuite) TestTake() {
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+
+	key := keyPrefixForPasswordReset + resetTokenHash
+	s.mock.On("Get", s.ctx, key).
+		Return(redis.NewStringResult("1", nil))
+	s.mock.On("Del", s.ctx, []string{key}).
+		Return(redis.NewIntResult(1, nil))
+
+	res, err := s.repository.Take(s.ctx, resetTokenHash)
+	require.NoError(err)
+	assert.Equal(uint(1), res)
+	s.mock.AssertCalled(s.T(), "Del", s.ctx, []string{key})
+}
+
+func (s *PasswordResetRepositoryTestSuite) TestTakeUnknown() {
+	assert := assert.New(s.T())
+
+	key := keyPrefixForPasswordReset + resetTokenHash
+	s.mock.On("Get", s.ctx, key).
+		Return(redis.NewStringResult("", redis.Nil))
+
+	_, err := s.repository.Take(s.ctx, resetTokenHash)
+	assert.Equal(apperror.ErrNotFound, err)
+	s.mock.AssertNotCalled(s.T(), "Del", s.ctx, []string{key})
+}
+
+func (s *PasswordResetRepositoryTestSuite) TestTakeTakenConcurrently() {
+	assert := assert.New(s.T())
+
+	key := keyPrefixForPasswordReset + resetTokenHash
+	s.mock.On("Get", s.ctx, key).
+		Return(redis.NewStringResult("1", nil))
+	s.mock.On("Del", s.ctx, []string{key}).
+		Return(redis.NewIntResult(0, nil))
+
+	_, err := s.repository.Take(s.ctx, resetTokenHash)
+	assert.Equal(apperror.ErrNotFound, err)
+}
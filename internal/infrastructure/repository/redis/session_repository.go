@@ -8,6 +8,7 @@ import (
 	"time"
 
 	goredis "github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
 	"redditclone/internal/pkg/apperror"
@@ -20,10 +21,33 @@ import (
 )
 
 const (
-	keyPrefixForSession = "session_"
+	keyPrefixForSession      = "session_"
+	keyPrefixForUserSessions = "user_sessions_"
+	// maxModifyAttempts is the number of the attempts to modify a session which is changed concurrently
+	maxModifyAttempts = 5
 )
 
-// SessionRepository is a repository for the session entity
+// compareAndSetScript replaces the session only if it has not been changed since it was read, the TTL of the session is kept.
+// It returns 1 if the session is replaced, 0 if it has been changed and -1 if it has been removed.
+var compareAndSetScript = goredis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if not current then
+	return -1
+end
+if current ~= ARGV[1] then
+	return 0
+end
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl > 0 then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", string.format("%d", ttl))
+else
+	redis.call("SET", KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// SessionRepository is a repository for the session entity.
+// A session is stored by the key with its ID, the IDs of the sessions of a user are stored in the set by the key with the user ID.
 type SessionRepository struct {
 	repository
 	UserRepo        user.Repository
@@ -44,8 +68,12 @@ func NewSessionRepository(dbase redis.IDB, sessionLifeTimeInHours uint, userRepo
 	return r, nil
 }
 
-func (r *SessionRepository) Key(userId uint) string {
-	return fmt.Sprintf("%s%s", keyPrefixForSession, strconv.FormatUint(uint64(userId), 10))
+func (r *SessionRepository) Key(id string) string {
+	return keyPrefixForSession + id
+}
+
+func (r *SessionRepository) UserSessionsKey(userId uint) string {
+	return fmt.Sprintf("%s%s", keyPrefixForUserSessions, strconv.FormatUint(uint64(userId), 10))
 }
 
 func (r *SessionRepository) NewEntity(ctx context.Context, userId uint) (*session.Session, error) {
@@ -54,6 +82,7 @@ func (r *SessionRepository) NewEntity(ctx context.Context, userId uint) (*sessio
 		return nil, err
 	}
 	return &session.Session{
+		ID:     uuid.New().String(),
 		UserID: userId,
 		User:   *user,
 	}, nil
@@ -72,49 +101,149 @@ func (r *SessionRepository) Save(session *session.Session) error {
 	return r.Update(session.Ctx, session)
 }
 
-// GetByUserID returns the Session with the specified user ID.
-func (r *SessionRepository) Get(ctx context.Context, userId uint) (*session.Session, error) {
+// Get returns the Session with the specified ID.
+func (r *SessionRepository) Get(ctx context.Context, id string) (*session.Session, error) {
+	entity, _, err := r.get(ctx, id)
+	return entity, err
+}
+
+// get returns the Session with the specified ID and its stored form.
+func (r *SessionRepository) get(ctx context.Context, id string) (*session.Session, string, error) {
 	var entity session.Session
-	res, err := r.db.DB().Get(ctx, r.Key(userId)).Result()
+	res, err := r.db.DB().Get(ctx, r.Key(id)).Result()
 
 	if err != nil {
 		if err == goredis.Nil {
-			return nil, apperror.ErrNotFound
+			return nil, "", apperror.ErrNotFound
 		}
-		return nil, errors.Wrapf(apperror.ErrInternal, "Get() error: %v", err)
+		return nil, "", errors.Wrapf(apperror.ErrInternal, "Get() error: %v", err)
 	}
 
 	err = entity.UnmarshalBinary([]byte(res))
 	if err != nil {
-		return nil, errors.Wrapf(apperror.ErrInternal, "json.Unmarshal() error: %v", err)
+		return nil, "", errors.Wrapf(apperror.ErrInternal, "json.Unmarshal() error: %v", err)
 	}
 
-	return &entity, nil
+	return &entity, res, nil
+}
+
+// QueryByUserID returns the sessions of the user. The IDs of the expired sessions are removed from the index of the user sessions.
+func (r *SessionRepository) QueryByUserID(ctx context.Context, userId uint) ([]session.Session, error) {
+	ids, err := r.db.DB().SMembers(ctx, r.UserSessionsKey(userId)).Result()
+	if err != nil {
+		return nil, errors.Wrapf(apperror.ErrInternal, "SMembers() error: %v", err)
+	}
+
+	items := make([]session.Session, 0, len(ids))
+	for _, id := range ids {
+		entity, err := r.Get(ctx, id)
+		if err != nil {
+			if err == apperror.ErrNotFound {
+				if err = r.db.DB().SRem(ctx, r.UserSessionsKey(userId), id).Err(); err != nil {
+					return nil, errors.Wrapf(apperror.ErrInternal, "SRem() error: %v", err)
+				}
+				continue
+			}
+			return nil, err
+		}
+		items = append(items, *entity)
+	}
+	return items, nil
 }
 
 // Create saves a new entity in the storage.
 func (r *SessionRepository) Create(ctx context.Context, entity *session.Session) error {
 	var _ encoding.BinaryMarshaler = entity
 
-	if err := r.db.DB().Set(ctx, r.Key(entity.User.ID), entity, r.SessionLifeTime).Err(); err != nil {
+	if err := r.db.DB().Set(ctx, r.Key(entity.ID), entity, r.SessionLifeTime).Err(); err != nil {
 		return errors.Wrapf(apperror.ErrInternal, "Create() error: %v", err)
 	}
-	return nil
+	return r.addToUserSessions(ctx, entity)
 }
 
-// Update updates the entity with given ID in the storage.
+// Update updates the entity with given ID in the storage, the TTL of the session is kept.
+// A session which has been removed is not recreated, apperror.ErrNotFound is returned for it.
 func (r *SessionRepository) Update(ctx context.Context, entity *session.Session) error {
-
-	if err := r.db.DB().Set(ctx, r.Key(entity.User.ID), entity, r.SessionLifeTime).Err(); err != nil {
+	ok, err := r.db.DB().SetXX(ctx, r.Key(entity.ID), entity, goredis.KeepTTL).Result()
+	if err != nil {
 		return errors.Wrapf(apperror.ErrInternal, "Update() error: %v", err)
 	}
+	if !ok {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// Modify applies the modification to the current state of the session with given ID and saves the session
+// only if it has not been changed meanwhile, otherwise the modification is applied to the new state again.
+// The error of the modification is returned as is, apperror.ErrNotFound is returned for a removed session.
+func (r *SessionRepository) Modify(ctx context.Context, id string, modify func(entity *session.Session) error) error {
+	for i := 0; i < maxModifyAttempts; i++ {
+		entity, current, err := r.get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = modify(entity); err != nil {
+			return err
+		}
+
+		data, err := entity.MarshalBinary()
+		if err != nil {
+			return errors.Wrapf(apperror.ErrInternal, "MarshalBinary() error: %v", err)
+		}
+		res, err := compareAndSetScript.Run(ctx, r.db.DB(), []string{r.Key(id)}, current, data).Int()
+		if err != nil {
+			return errors.Wrapf(apperror.ErrInternal, "Modify() error: %v", err)
+		}
+		switch res {
+		case 1:
+			return nil
+		case -1:
+			return apperror.ErrNotFound
+		}
+	}
+	return errors.Wrapf(apperror.ErrInternal, "Modify() error: the session %q is changed concurrently", id)
+}
+
+// addToUserSessions adds the session to the index of the user sessions and prolongs the index as long as the session.
+func (r *SessionRepository) addToUserSessions(ctx context.Context, entity *session.Session) error {
+	key := r.UserSessionsKey(entity.UserID)
+
+	if err := r.db.DB().SAdd(ctx, key, entity.ID).Err(); err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "SAdd() error: %v", err)
+	}
+	if err := r.db.DB().Expire(ctx, key, r.SessionLifeTime).Err(); err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Expire() error: %v", err)
+	}
 	return nil
 }
 
 // Delete removes the entity with given ID from the storage.
 func (r *SessionRepository) Delete(ctx context.Context, entity *session.Session) error {
 
-	if err := r.db.DB().Del(ctx, r.Key(entity.User.ID)).Err(); err != nil {
+	if err := r.db.DB().Del(ctx, r.Key(entity.ID)).Err(); err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Delete error: %v", err)
+	}
+	if err := r.db.DB().SRem(ctx, r.UserSessionsKey(entity.UserID), entity.ID).Err(); err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "SRem() error: %v", err)
+	}
+	return nil
+}
+
+// DeleteByUserID removes all sessions of the user and the index of them from the storage.
+func (r *SessionRepository) DeleteByUserID(ctx context.Context, userId uint) error {
+	ids, err := r.db.DB().SMembers(ctx, r.UserSessionsKey(userId)).Result()
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "SMembers() error: %v", err)
+	}
+
+	keys := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, r.Key(id))
+	}
+	keys = append(keys, r.UserSessionsKey(userId))
+
+	if err := r.db.DB().Del(ctx, keys...).Err(); err != nil {
 		return errors.Wrapf(apperror.ErrInternal, "Delete error: %v", err)
 	}
 	return nil
@@ -19,6 +19,7 @@ import (
 	"github.com/minipkg/selection_condition"
 
 	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
 )
 
 const sessionLifeTimeInHours = 1
@@ -57,10 +58,26 @@ func (m *userRepoMock) Create(ctx context.Context, entity *user.User) error {
 	return nil
 }
 
+func (m *userRepoMock) Update(ctx context.Context, entity *user.User) error {
+	return nil
+}
+
+func (m *userRepoMock) Delete(ctx context.Context, id uint) error {
+	return nil
+}
+
 func (m *userRepoMock) First(ctx context.Context, user *user.User) (*user.User, error) {
 	return m.user, nil
 }
 
+func (m *userRepoMock) QueryWithDeleted(ctx context.Context, offset, limit uint) ([]user.User, error) {
+	return nil, nil
+}
+
+func (m *userRepoMock) SetNameNormalized(ctx context.Context, id uint, nameNormalized string) error {
+	return nil
+}
+
 func (s *SessionRepositoryTestSuite) SetupSuite() {
 	var passhash, _ = hex.DecodeString("3a73acfdb534ddded4c0109383ee3e5a66314113d1ff691aaf4b3ee073c8fc2edd06d48f0555ec3783f4c479994e3eee3433734c29b05f08be0e9739b956b88d8fe872bd0a0942214e94fd4001e757fa3b66a2b9925de2e800c55ef49baa4c03")
 
@@ -73,6 +90,7 @@ func (s *SessionRepositoryTestSuite) SetupSuite() {
 	}
 
 	s.session = &session.Session{
+		ID:     "a5b3c1d2-0000-4000-8000-000000000001",
 		UserID: s.user.ID,
 		User:   *s.user,
 	}
@@ -102,6 +120,8 @@ func (s *SessionRepositoryTestSuite) TestNewEntity() {
 	res, err := s.repository.NewEntity(s.ctx, s.user.ID)
 	require.NoError(err)
 
+	assert.NotEmpty(res.ID, "entity.ID should be is not empty")
+	res.ID = s.session.ID
 	assert.Equalf(*s.session, *res, "The two objects should be the same. Expected: %v; have got: %v", *s.session, *res)
 }
 
@@ -112,10 +132,10 @@ func (s *SessionRepositoryTestSuite) TestGet() {
 	jsonSess, err := s.session.MarshalBinary()
 	require.NoError(err)
 
-	s.mock.On("Get", s.ctx, s.repository.Key(s.user.ID)).
+	s.mock.On("Get", s.ctx, s.repository.Key(s.session.ID)).
 		Return(redis.NewStringResult(string(jsonSess), nil))
 
-	res, err := s.repository.Get(s.ctx, s.user.ID)
+	res, err := s.repository.Get(s.ctx, s.session.ID)
 	require.NoError(err)
 
 	jsonRes, err := res.MarshalBinary()
@@ -124,12 +144,44 @@ func (s *SessionRepositoryTestSuite) TestGet() {
 	assert.Equalf(jsonSess, jsonRes, "The two objects should be the same. Expected: %v; have got: %v", jsonSess, jsonRes)
 }
 
+func (s *SessionRepositoryTestSuite) TestGetNotFound() {
+	assert := assert.New(s.T())
+
+	s.mock.On("Get", s.ctx, s.repository.Key("unknown")).
+		Return(redis.NewStringResult("", redis.Nil))
+
+	_, err := s.repository.Get(s.ctx, "unknown")
+	assert.Equal(apperror.ErrNotFound, err)
+}
+
+func (s *SessionRepositoryTestSuite) TestQueryByUserID() {
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+
+	jsonSess, err := s.session.MarshalBinary()
+	require.NoError(err)
+
+	s.mock.On("SMembers", s.ctx, s.repository.UserSessionsKey(s.user.ID)).
+		Return(redis.NewStringSliceResult([]string{s.session.ID, "expired"}, nil))
+	s.mock.On("Get", s.ctx, s.repository.Key(s.session.ID)).
+		Return(redis.NewStringResult(string(jsonSess), nil))
+	s.mock.On("Get", s.ctx, s.repository.Key("expired")).
+		Return(redis.NewStringResult("", redis.Nil))
+	s.mock.On("SRem", s.ctx, s.repository.UserSessionsKey(s.user.ID), []interface{}{"expired"}).
+		Return(redis.NewIntResult(1, nil))
+
+	res, err := s.repository.QueryByUserID(s.ctx, s.user.ID)
+	require.NoError(err)
+	require.Len(res, 1)
+	assert.Equal(s.session.ID, res[0].ID)
+	s.mock.AssertCalled(s.T(), "SRem", s.ctx, s.repository.UserSessionsKey(s.user.ID), []interface{}{"expired"})
+}
+
 func (s *SessionRepositoryTestSuite) TestCreate() {
 	var err error
 	require := require.New(s.T())
 
-	s.mock.On("Set", s.ctx, s.repository.Key(s.user.ID), s.session, s.repository.SessionLifeTime).
-		Return(redis.NewStatusResult("", nil))
+	s.mockSave()
 
 	err = s.repository.Create(s.ctx, s.session)
 	require.NoError(err)
@@ -139,24 +191,119 @@ func (s *SessionRepositoryTestSuite) TestUpdate() {
 	var err error
 	require := require.New(s.T())
 
-	s.mock.On("Set", s.ctx, s.repository.Key(s.user.ID), s.session, s.repository.SessionLifeTime).
-		Return(redis.NewStatusResult("", nil))
+	s.mockUpdate(true)
 
 	err = s.repository.Update(s.ctx, s.session)
 	require.NoError(err)
 }
 
+func (s *SessionRepositoryTestSuite) TestUpdateNotFound() {
+	assert := assert.New(s.T())
+
+	s.mockUpdate(false)
+
+	err := s.repository.Update(s.ctx, s.session)
+	assert.Equal(apperror.ErrNotFound, err)
+	s.mock.AssertNotCalled(s.T(), "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *SessionRepositoryTestSuite) TestModify() {
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+	ctx := context.Background()
+	lastSeenAt := time.Now().Truncate(time.Second)
+
+	require.NoError(s.repository.Create(ctx, s.session))
+
+	err := s.repository.Modify(ctx, s.session.ID, func(entity *session.Session) error {
+		entity.LastSeenAt = lastSeenAt
+		return nil
+	})
+	require.NoError(err)
+
+	res, err := s.repository.Get(ctx, s.session.ID)
+	require.NoError(err)
+	assert.True(lastSeenAt.Equal(res.LastSeenAt))
+}
+
+func (s *SessionRepositoryTestSuite) TestModifyChangedConcurrently() {
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+	ctx := context.Background()
+	attempts := 0
+
+	require.NoError(s.repository.Create(ctx, s.session))
+
+	err := s.repository.Modify(ctx, s.session.ID, func(entity *session.Session) error {
+		attempts++
+		if attempts == 1 {
+			//	another writer bumps the generation after the session has been read
+			concurrent := *s.session
+			concurrent.RefreshGeneration = 1
+			require.NoError(s.repository.db.DB().Set(ctx, s.repository.Key(s.session.ID), &concurrent, s.repository.SessionLifeTime).Err())
+		}
+		entity.RefreshGeneration++
+		return nil
+	})
+	require.NoError(err)
+
+	res, err := s.repository.Get(ctx, s.session.ID)
+	require.NoError(err)
+	assert.Equal(2, attempts)
+	assert.Equal(uint(2), res.RefreshGeneration, "the modification should be applied to the new state")
+}
+
+func (s *SessionRepositoryTestSuite) TestModifyNotFound() {
+	assert := assert.New(s.T())
+
+	err := s.repository.Modify(context.Background(), "unknown", func(entity *session.Session) error {
+		return nil
+	})
+	assert.Equal(apperror.ErrNotFound, err)
+}
+
 func (s *SessionRepositoryTestSuite) TestDelete() {
 	var err error
 	require := require.New(s.T())
 
-	s.mock.On("Del", s.ctx, []string{s.repository.Key(s.user.ID)}).
+	s.mock.On("Del", s.ctx, []string{s.repository.Key(s.session.ID)}).
+		Return(redis.NewIntResult(1, nil))
+	s.mock.On("SRem", s.ctx, s.repository.UserSessionsKey(s.user.ID), []interface{}{s.session.ID}).
 		Return(redis.NewIntResult(1, nil))
 
 	err = s.repository.Delete(s.ctx, s.session)
 	require.NoError(err)
 }
 
+func (s *SessionRepositoryTestSuite) TestDeleteByUserID() {
+	var err error
+	require := require.New(s.T())
+
+	s.mock.On("SMembers", s.ctx, s.repository.UserSessionsKey(s.user.ID)).
+		Return(redis.NewStringSliceResult([]string{s.session.ID}, nil))
+	s.mock.On("Del", s.ctx, []string{s.repository.Key(s.session.ID), s.repository.UserSessionsKey(s.user.ID)}).
+		Return(redis.NewIntResult(2, nil))
+
+	err = s.repository.DeleteByUserID(s.ctx, s.user.ID)
+	require.NoError(err)
+}
+
+// mockSave mocks the writing of the session and of the index of the user sessions.
+func (s *SessionRepositoryTestSuite) mockSave() {
+	s.mock.On("Set", s.ctx, s.repository.Key(s.session.ID), s.session, s.repository.SessionLifeTime).
+		Return(redis.NewStatusResult("", nil))
+	s.mock.On("SAdd", s.ctx, s.repository.UserSessionsKey(s.user.ID), []interface{}{s.session.ID}).
+		Return(redis.NewIntResult(1, nil))
+	s.mock.On("Expire", s.ctx, s.repository.UserSessionsKey(s.user.ID), s.repository.SessionLifeTime).
+		Return(redis.NewBoolResult(true, nil))
+}
+
+// mockUpdate mocks the rewriting of the existing session or of the removed one.
+func (s *SessionRepositoryTestSuite) mockUpdate(exists bool) {
+	s.mock.On("SetXX", s.ctx, s.repository.Key(s.session.ID), s.session, time.Duration(redis.KeepTTL)).
+		Return(redis.NewBoolResult(exists, nil))
+}
+
 func (s *SessionRepositoryTestSuite) TestSetData() {
 	var err error
 	require := require.New(s.T())
@@ -164,8 +311,7 @@ func (s *SessionRepositoryTestSuite) TestSetData() {
 	s.session.Data.UserName = "User1"
 	s.session.Data.ExpirationTokenTime = time.Now()
 
-	s.mock.On("Set", s.ctx, s.repository.Key(s.user.ID), s.session, s.repository.SessionLifeTime).
-		Return(redis.NewStatusResult("", nil))
+	s.mockUpdate(true)
 
 	err = s.repository.SetData(s.session, s.session.Data)
 	require.NoError(err)
@@ -177,9 +323,6 @@ func (s *SessionRepositoryTestSuite) TestGetData() {
 	s.session.Data.UserName = "User1"
 	s.session.Data.ExpirationTokenTime = time.Now()
 
-	s.mock.On("Set", s.ctx, s.repository.Key(s.user.ID), *s.session, s.repository.SessionLifeTime).
-		Return(redis.NewStatusResult("", nil))
-
 	res := s.repository.GetData(s.session)
 	require.Equalf(s.session.Data, res, "The two objects should be the same. Expected: %v; have got: %v", s.session.Data, res)
 }
@@ -0,0 +1,100 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+
+	"redditclone/internal/domain/feed"
+	"redditclone/internal/domain/post"
+	"redditclone/internal/pkg/apperror"
+
+	"github.com/minipkg/db/redis"
+)
+
+const keyPrefixForTimeline = "timeline_"
+
+// TimelineRepository is a repository for the precomputed timelines of the feeds.
+// The timelines of a user are stored in one hash by the rankings, so they are deleted at once.
+type TimelineRepository struct {
+	repository
+}
+
+var _ feed.TimelineRepository = (*TimelineRepository)(nil)
+
+// timeline is the stored timeline, the hash expires as a whole, so each timeline keeps its own expiration time
+type timeline struct {
+	Items     []post.Post
+	ExpiresAt time.Time
+}
+
+// NewTimelineRepository creates a new TimelineRepository
+func NewTimelineRepository(dbase redis.IDB) (*TimelineRepository, error) {
+	return &TimelineRepository{
+		repository: repository{
+			db: dbase,
+		},
+	}, nil
+}
+
+func timelineKey(userID uint) string {
+	return keyPrefixForTimeline + strconv.FormatUint(uint64(userID), 10)
+}
+
+// Get returns the timeline of the user for the ranking.
+// It returns apperror.ErrNotFound if the timeline does not exist or has expired.
+func (r *TimelineRepository) Get(ctx context.Context, userID uint, ranking string) ([]post.Post, error) {
+	val, err := r.db.DB().HGet(ctx, timelineKey(userID), ranking).Result()
+	if err != nil {
+		if err == goredis.Nil {
+			return nil, apperror.ErrNotFound
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "HGet() error: %v", err)
+	}
+
+	t := &timeline{}
+	if err = json.Unmarshal([]byte(val), t); err != nil {
+		return nil, errors.Wrapf(apperror.ErrInternal, "can not unmarshal the timeline: %v", err)
+	}
+	if time.Now().After(t.ExpiresAt) {
+		return nil, apperror.ErrNotFound
+	}
+
+	for i := range t.Items {
+		//	the rankings are not marshalled
+		t.Items[i].UpdateRanking(time.Now())
+	}
+	return t.Items, nil
+}
+
+// Set saves the timeline of the user for the ranking, the hash of the timelines expires after the last saved one.
+func (r *TimelineRepository) Set(ctx context.Context, userID uint, ranking string, items []post.Post, lifeTime time.Duration) error {
+	val, err := json.Marshal(timeline{
+		Items:     items,
+		ExpiresAt: time.Now().Add(lifeTime),
+	})
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "can not marshal the timeline: %v", err)
+	}
+
+	key := timelineKey(userID)
+	if err = r.db.DB().HSet(ctx, key, ranking, val).Err(); err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "HSet() error: %v", err)
+	}
+	if err = r.db.DB().Expire(ctx, key, lifeTime).Err(); err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Expire() error: %v", err)
+	}
+	return nil
+}
+
+// Delete deletes all the timelines of the user.
+func (r *TimelineRepository) Delete(ctx context.Context, userID uint) error {
+	if err := r.db.DB().Del(ctx, timelineKey(userID)).Err(); err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "Del() error: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/elliotchance/redismock/v8"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"redditclone/internal/domain/post"
+	"redditclone/internal/pkg/apperror"
+
+	dbredis "github.com/minipkg/db/redis"
+	dbmockredis "github.com/minipkg/db/redis/mock"
+)
+
+type TimelineRepositoryTestSuite struct {
+	suite.Suite
+	ctx        context.Context
+	mock       *redismock.ClientMock
+	repository *TimelineRepository
+}
+
+func (s *TimelineRepositoryTestSuite) SetupTest() {
+	var db *dbredis.DB
+	var err error
+	require := require.New(s.T())
+
+	db, s.mock, err = dbmockredis.New()
+	require.NoError(err)
+
+	s.repository, err = NewTimelineRepository(db)
+	require.NoError(err)
+}
+
+func TestTimelineRepository(t *testing.T) {
+	suite.Run(t, new(TimelineRepositoryTestSuite))
+}
+
+func (s *TimelineRepositoryTestSuite) TestSetGet() {
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+
+	item := post.Post{
+		ID:        "1",
+		Score:     5,
+		Ups:       6,
+		Downs:     1,
+		Title:     "What does a good programmer mean?",
+		CreatedAt: time.Now().Add(-time.Hour).Round(time.Second),
+	}
+	item.UpdateRanking(time.Now())
+
+	var saved string
+	s.mock.On("HSet", s.ctx, "timeline_1", mock.Anything).
+		Run(func(args mock.Arguments) {
+			values := args.Get(2).([]interface{})
+			saved = string(values[1].([]byte))
+		}).
+		Return(redis.NewIntResult(1, nil))
+	s.mock.On("Expire", s.ctx, "timeline_1", 5*time.Minute).
+		Return(redis.NewBoolResult(true, nil))
+
+	err := s.repository.Set(s.ctx, 1, "hot_week", []post.Post{item}, 5*time.Minute)
+	require.NoError(err)
+	s.mock.AssertCalled(s.T(), "Expire", s.ctx, "timeline_1", 5*time.Minute)
+
+	s.mock.On("HGet", s.ctx, "timeline_1", "hot_week").
+		Return(redis.NewStringResult(saved, nil))
+
+	res, err := s.repository.Get(s.ctx, 1, "hot_week")
+	require.NoError(err)
+	require.Len(res, 1)
+	assert.Equal(item.ID, res[0].ID)
+	assert.Equalf(item.Hot, res[0].Hot, "the rankings have to be recalculated")
+	assert.Equal(item.Controversy, res[0].Controversy)
+}
+
+func (s *TimelineRepositoryTestSuite) TestGetExpired() {
+	assert := assert.New(s.T())
+
+	val, _ := json.Marshal(timeline{
+		Items:     []post.Post{{ID: "1"}},
+		ExpiresAt: time.Now().Add(-time.Second),
+	})
+	s.mock.On("HGet", s.ctx, "timeline_1", "new").
+		Return(redis.NewStringResult(string(val), nil))
+
+	_, err := s.repository.Get(s.ctx, 1, "new")
+	assert.Equal(apperror.ErrNotFound, err)
+}
+
+func (s *TimelineRepositoryTestSuite) TestGetUnknown() {
+	assert := assert.New(s.T())
+
+	s.mock.On("HGet", s.ctx, "timeline_1", "new").
+		Return(redis.NewStringResult("", redis.Nil))
+
+	_, err := s.repository.Get(s.ctx, 1, "new")
+	assert.Equal(apperror.ErrNotFound, err)
+}
+
+func (s *TimelineRepositoryTestSuite) TestDelete() {
+	require := require.New(s.T())
+
+	s.mock.On("Del", s.ctx, []string{"timeline_1"}).
+		Return(redis.NewIntResult(1, nil))
+
+	err := s.repository.Delete(s.ctx, 1)
+	require.NoError(err)
+	s.mock.AssertCalled(s.T(), "Del", s.ctx, []string{"timeline_1"})
+}
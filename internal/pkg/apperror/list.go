@@ -10,7 +10,34 @@ var ErrNotFound error = errors.New("Not found")
 // ErrBadRequest is error for case when bad request
 var ErrBadRequest error = errors.New("Bad request")
 
+// ErrConflict is error for case when entity already exists
+var ErrConflict error = errors.New("Conflict")
+
 // ErrInternal is error for case when smth went wrong
 var ErrInternal error = errors.New("Internal error")
 
 var ErrTokenHasExpired error = errors.New("Token has expired")
+
+var ErrTokenHasBeenRevoked error = errors.New("Token has been revoked")
+
+var ErrInvalidRefreshToken error = errors.New("Invalid refresh token")
+
+var ErrRefreshTokenReused error = errors.New("Refresh token has been reused")
+
+// ErrInvalidCredentials is the same for an unknown user and a wrong password, so it does not disclose whether a user exists
+var ErrInvalidCredentials error = errors.New("Invalid username or password")
+
+// ErrNotAccessToken is error for case when a pre-auth token of the two-factor login is used as an access token
+var ErrNotAccessToken error = errors.New("Token is not an access token")
+
+var ErrInvalidTwoFactorCode error = errors.New("Invalid two-factor authentication code")
+
+var ErrTwoFactorEnabled error = errors.New("Two-factor authentication is already enabled")
+
+var ErrTwoFactorNotEnrolled error = errors.New("Two-factor authentication is not enrolled")
+
+var ErrTwoFactorNotEnabled error = errors.New("Two-factor authentication is not enabled")
+
+var ErrInvalidResetToken error = errors.New("Invalid or expired password reset token")
+
+var ErrUsernameTaken error = errors.New("Username is already taken")
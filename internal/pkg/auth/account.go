@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/errorshandler"
+	"redditclone/internal/pkg/mail"
+)
+
+const (
+	// resetTokenSize is the size of a password reset token in bytes
+	resetTokenSize = 32
+	// defaultResetTokenLifeTime is the lifetime of a password reset token in minutes if it is not configured
+	defaultResetTokenLifeTime = 60
+)
+
+// PasswordResetRepository keeps the password reset tokens.
+type PasswordResetRepository interface {
+	// Create saves the hash of a token of the user for the lifetime.
+	Create(ctx context.Context, tokenHash string, userID uint, lifeTime time.Duration) error
+	// Take deletes the token and returns the ID of its user, apperror.ErrNotFound is returned for an unknown token.
+	Take(ctx context.Context, tokenHash string) (uint, error)
+}
+
+// PasswordResetConfig is the config of the password reset.
+type PasswordResetConfig struct {
+	// URL is the page of the password reset, the token is added to it as the "token" query parameter
+	URL string
+	// TokenLifeTime is the lifetime of a token in minutes. Defaults to 60
+	TokenLifeTime uint
+}
+
+// Reauthenticate checks the password of the current user before a sensitive action, like an account deletion.
+// The wrong passwords are throttled as the failed logins.
+func (s service) Reauthenticate(ctx context.Context, password string, client Client) (*user.User, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.throttle.checkLogin(ctx, user.Name, client.IP); err != nil {
+		return nil, err
+	}
+
+	ok, _, err := s.passwordHasher.Verify(user.Passhash, password)
+	if err != nil {
+		s.logger.With(ctx, "user", user.Name).Errorf("can not verify the password: %v", err)
+	}
+	if !ok {
+		if err = s.throttle.loginFailed(ctx, user.Name, client.IP); err != nil {
+			s.logger.With(ctx).Errorf("can not count the failed login: %v", err)
+		}
+		return nil, errorshandler.Unauthorized(apperror.ErrInvalidCredentials.Error())
+	}
+	return user, nil
+}
+
+// ChangePassword sets the new password of the current user after the check of the old one.
+// The other sessions of the user are ended, the current one stays.
+func (s service) ChangePassword(ctx context.Context, oldPassword, newPassword string, client Client) error {
+	user, err := s.Reauthenticate(ctx, oldPassword, client)
+	if err != nil {
+		return err
+	}
+
+	if err = s.setPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+
+	current := CurrentSession(ctx)
+	items, err := s.sessionRepository.QueryByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if items[i].ID == current.ID {
+			continue
+		}
+		if err = s.sessionRepository.Delete(ctx, &items[i]); err != nil {
+			return err
+		}
+	}
+
+	s.logger.With(ctx, "user", user.Name).Infof("the password has been changed, %v other sessions are ended", len(items)-1)
+	return nil
+}
+
+// RequestPasswordReset sends the link with a password reset token to the email of the user.
+// The result is the same for an unknown user or a user without an email, so it does not disclose whether a user exists.
+func (s service) RequestPasswordReset(ctx context.Context, username string, client Client) error {
+	if err := s.throttle.passwordReset(ctx, client.IP); err != nil {
+		return err
+	}
+	logger := s.logger.With(ctx, "user", username)
+
+	user := s.userService.NewEntity()
+	user.Name = username
+	user, err := s.userService.First(ctx, user)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			logger.Infof("password reset of an unknown user")
+			return nil
+		}
+		return err
+	}
+	if user.Email == "" {
+		logger.Infof("password reset of a user without an email")
+		return nil
+	}
+
+	b, err := generateRandomBytes(resetTokenSize)
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "could not get a password reset token: %v", err)
+	}
+	token := hex.EncodeToString(b)
+
+	lifeTime := time.Duration(int64(s.resetCfg.TokenLifeTime)) * time.Minute
+	if err = s.resetRepository.Create(ctx, hashResetToken(token), user.ID, lifeTime); err != nil {
+		return err
+	}
+
+	err = s.mailSender.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Hello, %v!\n\nTo set a new password, follow the link: %v?token=%v\nThe link is valid for %v minutes. If you did not request a password reset, ignore this message.",
+			user.Name, s.resetCfg.URL, token, s.resetCfg.TokenLifeTime),
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("password reset link has been sent")
+	return nil
+}
+
+// ResetPassword sets the new password of the user of the reset token, the token can be used only once.
+// All sessions of the user are ended, as the old password may have been stolen.
+func (s service) ResetPassword(ctx context.Context, token, newPassword string) error {
+	userID, err := s.resetRepository.Take(ctx, hashResetToken(token))
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return errorshandler.BadRequest(apperror.ErrInvalidResetToken.Error())
+		}
+		return err
+	}
+
+	user, err := s.userService.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err = s.setPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+	if err = s.sessionRepository.DeleteByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	if err = s.throttle.loginSucceeded(ctx, user.Name); err != nil {
+		s.logger.With(ctx).Errorf("can not reset the failed logins: %v", err)
+	}
+
+	s.logger.With(ctx, "user", user.Name).Infof("the password has been reset, all sessions are ended")
+	return nil
+}
+
+func (s service) setPassword(ctx context.Context, user *user.User, password string) error {
+	passhash, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	user.Passhash = passhash
+	return s.userService.Update(ctx, user)
+}
+
+// hashResetToken returns the hex encoded SHA-256 hash of the token, so the stored tokens can not be used if they leak.
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,12 +1,17 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	routing "github.com/go-ozzo/ozzo-routing/v2"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
 	"redditclone/internal/pkg/errorshandler"
 
 	"github.com/minipkg/log"
@@ -15,21 +20,140 @@ import (
 type identity struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	// Email is optional, it is read at the registration only
+	Email string `json:"email,omitempty"`
 }
 
 func (i identity) Validate() error {
 	return validation.ValidateStruct(&i,
-		validation.Field(&i.Username, validation.Required, validation.Length(2, 100), is.Alphanumeric),
+		validation.Field(&i.Username, user.NameRules...),
 		validation.Field(&i.Password, validation.Required, validation.Length(4, 100)),
+		validation.Field(&i.Email, validation.Length(0, 255), is.EmailFormat),
+	)
+}
+
+type changePasswordRequest struct {
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+func (r changePasswordRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.OldPassword, validation.Required, validation.Length(4, 100)),
+		validation.Field(&r.NewPassword, validation.Required, validation.Length(4, 100)),
+	)
+}
+
+type passwordResetRequest struct {
+	Username string `json:"username"`
+}
+
+func (r passwordResetRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Username, user.NameRules...),
+	)
+}
+
+type passwordResetConfirmRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (r passwordResetConfirmRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Token, validation.Required, validation.Length(1, 200)),
+		validation.Field(&r.Password, validation.Required, validation.Length(4, 100)),
+	)
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+func (r refreshRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.RefreshToken, validation.Required, validation.Length(1, 500)),
+	)
+}
+
+type secondFactorRequest struct {
+	PreAuthToken string `json:"preAuthToken"`
+	Code         string `json:"code"`
+}
+
+func (r secondFactorRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.PreAuthToken, validation.Required, validation.Length(1, 2000)),
+		validation.Field(&r.Code, validation.Required, validation.Length(6, 20)),
+	)
+}
+
+type totpCodeRequest struct {
+	Code string `json:"code"`
+}
+
+func (r totpCodeRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Code, validation.Required, validation.Length(6, 6), is.Digit),
+	)
+}
+
+type disableTOTPRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+func (r disableTOTPRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Password, validation.Required, validation.Length(4, 100)),
+		validation.Field(&r.Code, validation.Required, validation.Length(6, 20)),
 	)
 }
 
 // RegisterHandlers registers handlers for different HTTP requests.
 //	POST /api/register - регистрация
 //	POST /api/login - логин
-func RegisterHandlers(rg *routing.RouteGroup, service Service, logger log.ILogger) {
+//	POST /api/login/2fa - второй шаг логина: pre-auth токен и код TOTP или код восстановления
+//	POST /api/token/refresh - получение новой пары токенов по refresh-токену
+//	POST /api/password/reset - отправка ссылки для сброса пароля на email пользователя
+//	POST /api/password/reset/confirm - установка нового пароля по токену из ссылки, завершение всех сессий
+//	POST /api/logout - выход, отзыв токена текущего запроса
+//	POST /api/logout-all - выход на всех устройствах, отзыв всех токенов пользователя
+//	GET /api/sessions - список активных сессий пользователя
+//	DELETE /api/sessions/<id> - завершение сессии пользователя
+//	POST /api/me/2fa/enroll - новый секрет TOTP и otpauth URI для приложения-аутентификатора
+//	POST /api/me/2fa/confirm - включение двухфакторной аутентификации по коду, возвращает коды восстановления
+//	POST /api/me/2fa/disable - выключение двухфакторной аутентификации по паролю и коду
+//	PUT /api/me/password - смена пароля по старому паролю, завершение остальных сессий
+func RegisterHandlers(rg *routing.RouteGroup, service Service, logger log.ILogger, authHandler routing.Handler) {
 	rg.Post("/login", login(service, logger))
+	rg.Post("/login/2fa", loginSecondFactor(service, logger))
 	rg.Post("/register", register(service, logger))
+	rg.Post("/token/refresh", refresh(service, logger))
+	rg.Post("/password/reset", requestPasswordReset(service, logger))
+	rg.Post("/password/reset/confirm", resetPassword(service, logger))
+
+	rg.Use(authHandler)
+
+	rg.Post("/logout", logout(service, logger))
+	rg.Post("/logout-all", logoutAll(service, logger))
+	rg.Get("/sessions", sessions(service, logger))
+	rg.Delete("/sessions/<id>", revokeSession(service, logger))
+	rg.Post("/me/2fa/enroll", enrollTOTP(service, logger))
+	rg.Post("/me/2fa/confirm", confirmTOTP(service, logger))
+	rg.Post("/me/2fa/disable", disableTOTP(service, logger))
+	rg.Put("/me/password", changePassword(service, logger))
+}
+
+// sessionView is a session as it is shown to the user, without the token.
+type sessionView struct {
+	ID        string    `json:"id"`
+	Device    string    `json:"device"`
+	UserAgent string    `json:"userAgent"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created"`
+	LastSeen  time.Time `json:"lastSeen"`
+	Current   bool      `json:"current"`
 }
 
 func register(service Service, logger log.ILogger) routing.Handler {
@@ -45,17 +169,18 @@ func register(service Service, logger log.ILogger) routing.Handler {
 			return err
 		}
 		ctx := c.Request.Context()
-		token, err := service.Register(ctx, req.Username, req.Password)
+		tokens, err := service.Register(ctx, req.Username, req.Password, req.Email, service.RequestClient(c.Request))
 		if err != nil {
+			if er, ok := err.(ThrottledError); ok {
+				return ThrottledResponse(c, er)
+			}
 			if er, ok := err.(errorshandler.Response); ok {
 				logger.Errorf("Error while registering user. Status: %v; err: %q; details: %v", er.StatusCode(), er.Message, er.Details)
 				return er
 			}
 			return err
 		}
-		return c.WriteWithStatus(struct {
-			Token string `json:"token"`
-		}{token}, http.StatusCreated)
+		return c.WriteWithStatus(tokens, http.StatusCreated)
 	}
 }
 
@@ -73,12 +198,306 @@ func login(service Service, logger log.ILogger) routing.Handler {
 			return err
 		}
 
-		token, err := service.Login(c.Request.Context(), req.Username, req.Password)
+		tokens, err := service.Login(c.Request.Context(), req.Username, req.Password, service.RequestClient(c.Request))
 		if err != nil {
+			if er, ok := err.(ThrottledError); ok {
+				return ThrottledResponse(c, er)
+			}
+			return err
+		}
+		return c.Write(tokens)
+	}
+}
+
+// ThrottledResponse returns the response to a throttled request, the Retry-After header tells the client when to retry.
+func ThrottledResponse(c *routing.Context, err ThrottledError) errorshandler.Response {
+	c.Response.Header().Set("Retry-After", strconv.Itoa(err.RetrySeconds()))
+	return errorshandler.TooManyRequests(err.Error())
+}
+
+// refresh returns a handler that exchanges a refresh token for a new pair of tokens.
+func refresh(service Service, logger log.ILogger) routing.Handler {
+	return func(c *routing.Context) error {
+		var req refreshRequest
+
+		if err := c.Read(&req); err != nil {
+			logger.With(c.Request.Context()).Errorf("invalid request: %v", err)
+			return errorshandler.BadRequest("")
+		}
+
+		if err := req.Validate(); err != nil {
 			return err
 		}
-		return c.Write(struct {
-			Token string `json:"token"`
-		}{token})
+
+		tokens, err := service.Refresh(c.Request.Context(), req.RefreshToken)
+		if err != nil {
+			if er, ok := err.(errorshandler.Response); ok {
+				return er
+			}
+			logger.With(c.Request.Context()).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+		return c.Write(tokens)
+	}
+}
+
+// logout returns a handler that revokes the token of the request.
+func logout(service Service, logger log.ILogger) routing.Handler {
+	return func(c *routing.Context) error {
+		if err := service.Logout(c.Request.Context()); err != nil {
+			if er, ok := err.(errorshandler.Response); ok {
+				return er
+			}
+			logger.With(c.Request.Context()).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+		return c.Write(errorshandler.SuccessMessage())
+	}
+}
+
+// logoutAll returns a handler that revokes all tokens of the user.
+func logoutAll(service Service, logger log.ILogger) routing.Handler {
+	return func(c *routing.Context) error {
+		if err := service.LogoutAll(c.Request.Context()); err != nil {
+			if er, ok := err.(errorshandler.Response); ok {
+				return er
+			}
+			logger.With(c.Request.Context()).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+		return c.Write(errorshandler.SuccessMessage())
+	}
+}
+
+// sessions returns a handler that lists the active sessions of the user.
+func sessions(service Service, logger log.ILogger) routing.Handler {
+	return func(c *routing.Context) error {
+		ctx := c.Request.Context()
+		items, err := service.Sessions(ctx)
+		if err != nil {
+			if er, ok := err.(errorshandler.Response); ok {
+				return er
+			}
+			logger.With(ctx).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+
+		var currentId string
+		if sess := CurrentSession(ctx); sess != nil {
+			currentId = sess.ID
+		}
+
+		views := make([]sessionView, 0, len(items))
+		for _, item := range items {
+			views = append(views, sessionView{
+				ID:        item.ID,
+				Device:    item.Device,
+				UserAgent: item.UserAgent,
+				IP:        item.IP,
+				CreatedAt: item.CreatedAt,
+				LastSeen:  item.LastSeenAt,
+				Current:   item.ID == currentId,
+			})
+		}
+		return c.Write(views)
+	}
+}
+
+// revokeSession returns a handler that ends the session of the user with the specified ID.
+func revokeSession(service Service, logger log.ILogger) routing.Handler {
+	return func(c *routing.Context) error {
+		if err := service.RevokeSession(c.Request.Context(), c.Param("id")); err != nil {
+			if er, ok := err.(errorshandler.Response); ok {
+				return er
+			}
+			if errors.Is(err, apperror.ErrNotFound) {
+				return errorshandler.NotFound("")
+			}
+			logger.With(c.Request.Context()).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+		return c.Write(errorshandler.SuccessMessage())
+	}
+}
+
+// loginSecondFactor returns a handler that completes the login of a user with the two-factor authentication.
+func loginSecondFactor(service Service, logger log.ILogger) routing.Handler {
+	return func(c *routing.Context) error {
+		var req secondFactorRequest
+
+		if err := c.Read(&req); err != nil {
+			logger.With(c.Request.Context()).Errorf("invalid request: %v", err)
+			return errorshandler.BadRequest("")
+		}
+
+		if err := req.Validate(); err != nil {
+			return err
+		}
+
+		tokens, err := service.LoginSecondFactor(c.Request.Context(), req.PreAuthToken, req.Code, service.RequestClient(c.Request))
+		if err != nil {
+			if er, ok := err.(ThrottledError); ok {
+				return ThrottledResponse(c, er)
+			}
+			if er, ok := err.(errorshandler.Response); ok {
+				return er
+			}
+			logger.With(c.Request.Context()).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+		return c.Write(tokens)
+	}
+}
+
+// enrollTOTP returns a handler that generates a new TOTP secret of the user.
+func enrollTOTP(service Service, logger log.ILogger) routing.Handler {
+	return func(c *routing.Context) error {
+		enrollment, err := service.EnrollTOTP(c.Request.Context())
+		if err != nil {
+			if er, ok := err.(errorshandler.Response); ok {
+				return er
+			}
+			logger.With(c.Request.Context()).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+		return c.Write(enrollment)
+	}
+}
+
+// confirmTOTP returns a handler that enables the two-factor authentication of the user.
+func confirmTOTP(service Service, logger log.ILogger) routing.Handler {
+	return func(c *routing.Context) error {
+		var req totpCodeRequest
+
+		if err := c.Read(&req); err != nil {
+			logger.With(c.Request.Context()).Errorf("invalid request: %v", err)
+			return errorshandler.BadRequest("")
+		}
+
+		if err := req.Validate(); err != nil {
+			return err
+		}
+
+		codes, err := service.ConfirmTOTP(c.Request.Context(), req.Code)
+		if err != nil {
+			if er, ok := err.(errorshandler.Response); ok {
+				return er
+			}
+			logger.With(c.Request.Context()).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+		return c.Write(map[string][]string{
+			"recoveryCodes": codes,
+		})
+	}
+}
+
+// disableTOTP returns a handler that disables the two-factor authentication of the user.
+func disableTOTP(service Service, logger log.ILogger) routing.Handler {
+	return func(c *routing.Context) error {
+		var req disableTOTPRequest
+
+		if err := c.Read(&req); err != nil {
+			logger.With(c.Request.Context()).Errorf("invalid request: %v", err)
+			return errorshandler.BadRequest("")
+		}
+
+		if err := req.Validate(); err != nil {
+			return err
+		}
+
+		if err := service.DisableTOTP(c.Request.Context(), req.Password, req.Code, service.RequestClient(c.Request)); err != nil {
+			if er, ok := err.(ThrottledError); ok {
+				return ThrottledResponse(c, er)
+			}
+			if er, ok := err.(errorshandler.Response); ok {
+				return er
+			}
+			logger.With(c.Request.Context()).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+		return c.Write(errorshandler.SuccessMessage())
+	}
+}
+
+// changePassword returns a handler that changes the password of the user.
+func changePassword(service Service, logger log.ILogger) routing.Handler {
+	return func(c *routing.Context) error {
+		var req changePasswordRequest
+
+		if err := c.Read(&req); err != nil {
+			logger.With(c.Request.Context()).Errorf("invalid request: %v", err)
+			return errorshandler.BadRequest("")
+		}
+
+		if err := req.Validate(); err != nil {
+			return err
+		}
+
+		if err := service.ChangePassword(c.Request.Context(), req.OldPassword, req.NewPassword, service.RequestClient(c.Request)); err != nil {
+			if er, ok := err.(ThrottledError); ok {
+				return ThrottledResponse(c, er)
+			}
+			if er, ok := err.(errorshandler.Response); ok {
+				return er
+			}
+			logger.With(c.Request.Context()).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+		return c.Write(errorshandler.SuccessMessage())
+	}
+}
+
+// requestPasswordReset returns a handler that sends a password reset link to the user.
+// The response is the same whether the user exists or not.
+func requestPasswordReset(service Service, logger log.ILogger) routing.Handler {
+	return func(c *routing.Context) error {
+		var req passwordResetRequest
+
+		if err := c.Read(&req); err != nil {
+			logger.With(c.Request.Context()).Errorf("invalid request: %v", err)
+			return errorshandler.BadRequest("")
+		}
+
+		if err := req.Validate(); err != nil {
+			return err
+		}
+
+		if err := service.RequestPasswordReset(c.Request.Context(), req.Username, service.RequestClient(c.Request)); err != nil {
+			if er, ok := err.(ThrottledError); ok {
+				return ThrottledResponse(c, er)
+			}
+			if er, ok := err.(errorshandler.Response); ok {
+				return er
+			}
+			logger.With(c.Request.Context()).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+		return c.Write(errorshandler.SuccessMessage())
+	}
+}
+
+// resetPassword returns a handler that sets a new password by a password reset token.
+func resetPassword(service Service, logger log.ILogger) routing.Handler {
+	return func(c *routing.Context) error {
+		var req passwordResetConfirmRequest
+
+		if err := c.Read(&req); err != nil {
+			logger.With(c.Request.Context()).Errorf("invalid request: %v", err)
+			return errorshandler.BadRequest("")
+		}
+
+		if err := req.Validate(); err != nil {
+			return err
+		}
+
+		if err := service.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+			if er, ok := err.(errorshandler.Response); ok {
+				return er
+			}
+			logger.With(c.Request.Context()).Error(err)
+			return errorshandler.InternalServerError("")
+		}
+		return c.Write(errorshandler.SuccessMessage())
 	}
 }
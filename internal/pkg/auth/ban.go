@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/session"
+)
+
+// SetAccountState saves the state of the account of the user and applies it to the active sessions of the user at once,
+// so a suspended or a banned user gets the reason on the next request. The sessions are kept to be used again after a suspension.
+// The caller checks that the current user is an admin, the change is recorded in the moderation log.
+func (s service) SetAccountState(ctx context.Context, userID uint, state user.AccountState) (*user.User, error) {
+	if state.Status != user.StatusSuspended {
+		state.Until = nil
+	}
+	if state.Status == user.StatusActive {
+		state.Reason = ""
+	}
+	if err := state.Validate(); err != nil {
+		return nil, errors.Wrapf(apperror.ErrBadRequest, "%v", err)
+	}
+
+	entity, err := s.userService.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	prev := entity.State
+	entity.State = state
+	if err = s.userService.Update(ctx, entity); err != nil {
+		return nil, err
+	}
+	s.modLogService.RecordAccountState(ctx, entity, prev)
+
+	sessions, err := s.sessionRepository.QueryByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not get the sessions of user id: %v", userID)
+	}
+	for i := range sessions {
+		//	only the state is written, a session which has been ended meanwhile is skipped
+		err = s.sessionRepository.Modify(ctx, sessions[i].ID, func(entity *session.Session) error {
+			entity.Data.State = state
+			return nil
+		})
+		if err != nil && err != apperror.ErrNotFound {
+			return nil, errors.Wrapf(err, "Can not update the session %q", sessions[i].ID)
+		}
+	}
+
+	s.logger.With(ctx, "user", userID).Infof("the account state is set to %q", state.Status)
+	return entity, nil
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"net/http"
+	"redditclone/internal/pkg/errorshandler"
 	"redditclone/internal/pkg/session"
 	"strings"
 
@@ -22,6 +23,10 @@ func Middleware(logger log.ILogger, authService Service) routing.Handler {
 				*c.Request = *c.Request.WithContext(ctx)
 				return nil
 			}
+			//	the reason of a suspension or a ban is shown to the user
+			if er, ok := err.(errorshandler.Response); ok {
+				return er
+			}
 			if err != nil {
 				message = err.Error()
 			}
@@ -35,6 +40,35 @@ func Middleware(logger log.ILogger, authService Service) routing.Handler {
 	}
 }
 
+// OptionalMiddleware returns a JWT-based authentication middleware for the public handlers which depend on the viewer.
+// A request without a valid token is handled as the request of an anonymous user.
+func OptionalMiddleware(logger log.ILogger, authService Service) routing.Handler {
+	return func(c *routing.Context) error {
+		header := c.Request.Header.Get("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") {
+			return nil
+		}
+
+		ctx, ok, err := authService.StringTokenValidation(c.Request.Context(), header[7:])
+		if err != nil || !ok {
+			logger.With(c.Request.Context()).Debugf("the request is handled as anonymous, token is not valid: %v", err)
+			return nil
+		}
+		*c.Request = *c.Request.WithContext(ctx)
+		return nil
+	}
+}
+
+// AdminMiddleware returns a middleware which lets the requests of the admins only, it goes after the authentication middleware.
+func AdminMiddleware() routing.Handler {
+	return func(c *routing.Context) error {
+		if !IsAdmin(CurrentSession(c.Request.Context())) {
+			return errorshandler.Forbidden("")
+		}
+		return nil
+	}
+}
+
 // CurrentUser returns the user identity from the given context.
 // Nil is returned if no user identity is found in the context.
 func CurrentSession(ctx context.Context) *session.Session {
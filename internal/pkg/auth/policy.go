@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/errorshandler"
+	"redditclone/internal/pkg/session"
+)
+
+// IsAdmin returns true if the session belongs to an admin.
+func IsAdmin(sess *session.Session) bool {
+	return sess != nil && sess.Data.Role == user.RoleAdmin
+}
+
+// IsModerator returns true if the session belongs to a moderator of the given category.
+func IsModerator(sess *session.Session, category string) bool {
+	if sess == nil || sess.Data.Role != user.RoleModerator {
+		return false
+	}
+	for _, c := range sess.Data.ModeratedCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// ModeratedCategories returns the categories of a moderator, there are none for the other users.
+func ModeratedCategories(sess *session.Session) []string {
+	if sess == nil || sess.Data.Role != user.RoleModerator {
+		return nil
+	}
+	return sess.Data.ModeratedCategories
+}
+
+// IsAuthor returns true if the session belongs to the author of an entity.
+func IsAuthor(sess *session.Session, authorID uint) bool {
+	return sess != nil && sess.UserID == authorID
+}
+
+// CheckDeleteAccess checks that the current user is allowed to delete an entity of the author in the category.
+// Only the author, a moderator of the category or an admin are allowed, otherwise errorshandler.Forbidden is returned.
+func CheckDeleteAccess(ctx context.Context, authorID uint, category string) error {
+	sess := CurrentSession(ctx)
+	if IsAuthor(sess, authorID) || IsModerator(sess, category) || IsAdmin(sess) {
+		return nil
+	}
+	return errorshandler.Forbidden("")
+}
+
+// CheckAccountState checks that the current user is allowed to post, comment and vote.
+// A suspended or a banned user gets errorshandler.Forbidden with the reason.
+func CheckAccountState(ctx context.Context) error {
+	sess := CurrentSession(ctx)
+	if sess != nil && sess.Data.State.IsBlocked(time.Now()) {
+		return errorshandler.Forbidden(sess.Data.State.Message())
+	}
+	return nil
+}
+
+// IsShadowbanned returns true if the session belongs to a shadowbanned user.
+func IsShadowbanned(sess *session.Session) bool {
+	return sess != nil && sess.Data.State.IsShadowbanned()
+}
+
+// CanSeeShadowed returns true if the session is allowed to see a shadowed entity of the author in the category.
+// Only the author, a moderator of the category or an admin see it.
+func CanSeeShadowed(sess *session.Session, authorID uint, category string) bool {
+	return IsAuthor(sess, authorID) || IsModerator(sess, category) || IsAdmin(sess)
+}
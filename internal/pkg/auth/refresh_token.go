@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/session"
+)
+
+// refreshSecretSize is the size of the secret which signs the refresh tokens of a session
+const refreshSecretSize = 32
+
+// refreshToken is a single-use token which is exchanged for a new pair of tokens.
+// The string form is "<session ID>.<generation>.<signature>", every refresh of the session increments the generation,
+// so only the last issued token is valid. The signature proves that an older token has been issued by us indeed.
+type refreshToken struct {
+	SessionID  string
+	Generation uint
+	Signature  string
+}
+
+// newRefreshToken returns the refresh token of the current generation of the session.
+func newRefreshToken(sess *session.Session) refreshToken {
+	return refreshToken{
+		SessionID:  sess.ID,
+		Generation: sess.RefreshGeneration,
+		Signature:  signRefreshToken(sess.RefreshSecret, sess.ID, sess.RefreshGeneration),
+	}
+}
+
+// parseRefreshToken parses the string form of a refresh token.
+func parseRefreshToken(str string) (refreshToken, error) {
+	parts := strings.Split(str, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return refreshToken{}, errors.Wrap(apperror.ErrBadRequest, "malformed refresh token")
+	}
+
+	generation, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return refreshToken{}, errors.Wrapf(apperror.ErrBadRequest, "malformed generation of the refresh token: %v", err)
+	}
+
+	return refreshToken{
+		SessionID:  parts[0],
+		Generation: uint(generation),
+		Signature:  parts[2],
+	}, nil
+}
+
+func (t refreshToken) String() string {
+	return t.SessionID + "." + strconv.FormatUint(uint64(t.Generation), 10) + "." + t.Signature
+}
+
+// isSignedBy checks that the token has been issued for the session.
+func (t refreshToken) isSignedBy(sess *session.Session) bool {
+	expected := signRefreshToken(sess.RefreshSecret, sess.ID, t.Generation)
+	return hmac.Equal([]byte(expected), []byte(t.Signature))
+}
+
+func signRefreshToken(secret []byte, sessionId string, generation uint) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(sessionId + "." + strconv.FormatUint(uint64(generation), 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -1,18 +1,21 @@
 package auth
 
 import (
-	"bytes"
 	"context"
 	"crypto/rand"
-	"crypto/sha256"
+	"net/http"
 	"redditclone/internal/pkg/session"
+	"sort"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
-	"golang.org/x/crypto/pbkdf2"
 
 	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
 	"redditclone/internal/pkg/errorshandler"
+	"redditclone/internal/pkg/mail"
+	"redditclone/internal/pkg/password"
 
 	"github.com/minipkg/log"
 )
@@ -20,11 +23,55 @@ import (
 // Service encapsulates the authentication logic.
 type Service interface {
 	// authenticate authenticates a user using username and password.
-	// It returns a JWT token if authentication succeeds. Otherwise, an error is returned.
-	Login(ctx context.Context, username, password string) (string, error)
-	Register(ctx context.Context, username, password string) (string, error)
+	// It returns an access and a refresh tokens if authentication succeeds. Otherwise, an error is returned.
+	Login(ctx context.Context, username, password string, client Client) (Tokens, error)
+	Register(ctx context.Context, username, password, email string, client Client) (Tokens, error)
+	// Refresh exchanges the refresh token for a new pair of tokens, the refresh token can be used only once.
+	Refresh(ctx context.Context, refreshToken string) (Tokens, error)
 	NewUser(username, password string) (*user.User, error)
 	StringTokenValidation(ctx context.Context, stringToken string) (resCtx context.Context, isValid bool, err error)
+	// Logout ends the session of the current request.
+	Logout(ctx context.Context) error
+	// LogoutAll ends all sessions of the current user.
+	LogoutAll(ctx context.Context) error
+	// Sessions returns the active sessions of the current user.
+	Sessions(ctx context.Context) ([]session.Session, error)
+	// RevokeSession ends the session of the current user with the specified ID.
+	RevokeSession(ctx context.Context, id string) error
+	// LoginSecondFactor exchanges the pre-auth token of Login and the code of the second factor for a pair of tokens.
+	LoginSecondFactor(ctx context.Context, preAuthToken, code string, client Client) (Tokens, error)
+	// EnrollTOTP generates a new TOTP secret of the current user.
+	EnrollTOTP(ctx context.Context) (TOTPEnrollment, error)
+	// ConfirmTOTP enables the two-factor authentication by a code of the enrolled secret and returns the recovery codes.
+	ConfirmTOTP(ctx context.Context, code string) ([]string, error)
+	// DisableTOTP disables the two-factor authentication after the user authenticates again.
+	DisableTOTP(ctx context.Context, password, code string, client Client) error
+	// Reauthenticate checks the password of the current user and returns the user.
+	Reauthenticate(ctx context.Context, password string, client Client) (*user.User, error)
+	// ChangePassword sets the new password of the current user and ends the other sessions of the user.
+	ChangePassword(ctx context.Context, oldPassword, newPassword string, client Client) error
+	// RequestPasswordReset sends a password reset link to the email of the user.
+	RequestPasswordReset(ctx context.Context, username string, client Client) error
+	// ResetPassword sets the new password by the token of the password reset link.
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	// SetAccountState suspends, bans, shadowbans the user or makes the account active again.
+	SetAccountState(ctx context.Context, userID uint, state user.AccountState) (*user.User, error)
+	// RequestClient returns the description of the client that has sent the request.
+	RequestClient(r *http.Request) Client
+}
+
+// Client describes the device a user logs in from.
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// Tokens is a pair of a short-lived access token and a refresh token for getting the next pair.
+// A user with the two-factor authentication gets only a pre-auth token for LoginSecondFactor by the password.
+type Tokens struct {
+	AccessToken  string `json:"token,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	PreAuthToken string `json:"preAuthToken,omitempty"`
 }
 
 // Identity represents an authenticated user identity.
@@ -38,32 +85,78 @@ type Identity interface {
 type UserService interface {
 }
 
+// ModLogService is the part of the moderation log service which records the admin actions on the accounts.
+type ModLogService interface {
+	RecordAccountState(ctx context.Context, entity *user.User, prev user.AccountState)
+}
+
 type service struct {
-	signingKey        string
-	tokenExpiration   uint
-	userService       user.IService
-	logger            log.ILogger
-	sessionRepository SessionRepository
-	tokenRepository   TokenRepository
+	accessTokenLifeTime uint
+	passwordHasher      *password.Hasher
+	userService         user.IService
+	logger              log.ILogger
+	sessionRepository   SessionRepository
+	tokenRepository     TokenRepository
+	throttle            *throttle
+	mailSender          mail.Sender
+	resetCfg            PasswordResetConfig
+	resetRepository     PasswordResetRepository
+	modLogService       ModLogService
+	// dummyPasshash is verified for an unknown user, so the response time does not disclose whether a user exists
+	dummyPasshash string
 }
 
 type contextKey int
 
 const (
-	saltSize                  = 64
-	iterations                = 1e4
 	userSessionKey contextKey = iota
+	tokenDataKey
+)
+
+const (
+	// lastSeenUpdateInterval limits how often the last seen time of a session is written to the storage.
+	lastSeenUpdateInterval = time.Minute
+	// defaultAccessTokenLifeTime is the lifetime of an access token in minutes if it is not configured.
+	defaultAccessTokenLifeTime = 15
 )
 
 // NewService creates a new authentication service.
-func NewService(signingKey string, tokenExpiration uint, userService user.IService, logger log.ILogger, sessionRepo SessionRepository, tokenRepo TokenRepository) *service {
+// The access tokens are valid for accessTokenLifeTime minutes.
+// The failed logins and the registrations are throttled as throttleCfg sets.
+// The password reset links are sent by mailSender.
+func NewService(accessTokenLifeTime uint, passwordHasher *password.Hasher, userService user.IService, logger log.ILogger, sessionRepo SessionRepository, tokenRepo TokenRepository, throttleCfg ThrottleConfig, loginAttemptRepo LoginAttemptRepository, mailSender mail.Sender, resetCfg PasswordResetConfig, resetRepo PasswordResetRepository, modLogService ModLogService) *service {
+	if accessTokenLifeTime == 0 {
+		accessTokenLifeTime = defaultAccessTokenLifeTime
+	}
+	if resetCfg.TokenLifeTime == 0 {
+		resetCfg.TokenLifeTime = defaultResetTokenLifeTime
+	}
+	dummyPasshash, err := passwordHasher.Hash(uuid.New().String())
+	if err != nil {
+		logger.Errorf("can not hash the dummy password: %v", err)
+	}
 	return &service{
-		signingKey:        signingKey,
-		tokenExpiration:   tokenExpiration,
-		userService:       userService,
-		logger:            logger,
-		sessionRepository: sessionRepo,
-		tokenRepository:   tokenRepo,
+		throttle:            newThrottle(throttleCfg, loginAttemptRepo, logger),
+		mailSender:          mailSender,
+		resetCfg:            resetCfg,
+		resetRepository:     resetRepo,
+		modLogService:       modLogService,
+		dummyPasshash:       dummyPasshash,
+		accessTokenLifeTime: accessTokenLifeTime,
+		passwordHasher:      passwordHasher,
+		userService:         userService,
+		logger:              logger,
+		sessionRepository:   sessionRepo,
+		tokenRepository:     tokenRepo,
+	}
+}
+
+// RequestClient returns the description of the client that has sent the request.
+// The IP of the client is taken from the forwarding headers only behind a trusted proxy.
+func (s service) RequestClient(r *http.Request) Client {
+	return Client{
+		UserAgent: r.UserAgent(),
+		IP:        s.throttle.clientIP(r),
 	}
 }
 
@@ -71,160 +164,383 @@ func (s service) NewUser(username, password string) (*user.User, error) {
 	user := s.userService.NewEntity()
 	user.Name = username
 
-	salt, err := generateRandomBytes(saltSize)
+	passhash, err := s.passwordHasher.Hash(password)
 	if err != nil {
-		return user, errors.Wrapf(err, "could not get salt: %v", err)
+		return user, err
 	}
-	user.Passhash = string(hashPassword([]byte(password), salt))
+	user.Passhash = passhash
 	return user, nil
 }
 
-// Login authenticates a user and generates a JWT token if authentication succeeds.
+// Login authenticates a user and generates a pair of tokens if authentication succeeds.
 // Otherwise, an error is returned.
-func (s service) Login(ctx context.Context, username, password string) (string, error) {
+// Every login starts a new session, so the user stays logged in on the other devices.
+// The failed logins lock the user and the IP of the client for a while, ThrottledError is returned then.
+// A user with the two-factor authentication gets a pre-auth token instead of the tokens.
+func (s service) Login(ctx context.Context, username, password string, client Client) (Tokens, error) {
+	if err := s.throttle.checkLogin(ctx, username, client.IP); err != nil {
+		return Tokens{}, err
+	}
+
 	user, err := s.authenticate(ctx, username, password)
+	if err != nil {
+		if er, ok := err.(errorshandler.Response); ok && er.StatusCode() == http.StatusUnauthorized {
+			if err := s.throttle.loginFailed(ctx, username, client.IP); err != nil {
+				s.logger.With(ctx).Errorf("can not count the failed login: %v", err)
+			}
+		}
+		return Tokens{}, err
+	}
+
+	if user.TOTPEnabled {
+		//	the failed logins are reset by the second factor
+		return s.issuePreAuthToken(*user)
+	}
+
+	if err = s.throttle.loginSucceeded(ctx, username); err != nil {
+		s.logger.With(ctx).Errorf("can not reset the failed logins: %v", err)
+	}
+	return s.createSession(ctx, *user, client)
+}
+
+func (s service) createSession(ctx context.Context, user user.User, client Client) (Tokens, error) {
+	sess, err := s.sessionRepository.NewEntity(ctx, user.ID)
+	if err != nil {
+		return Tokens{}, err
+	}
 
+	sess.RefreshSecret, err = generateRandomBytes(refreshSecretSize)
 	if err != nil {
-		return "", err
+		return Tokens{}, errors.Wrapf(apperror.ErrInternal, "could not get a refresh secret: %v", err)
 	}
+	sess.CreatedAt = time.Now()
+	sess.UserAgent = client.UserAgent
+	sess.IP = client.IP
+	sess.Device = session.DeviceName(client.UserAgent)
 
-	session, err := s.sessionRepository.Get(ctx, user.ID)
+	tokens, err := s.issueTokens(sess, user)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	err = s.sessionRepository.Create(ctx, sess)
 	if err != nil {
-		return s.createSession(ctx, *user)
+		return Tokens{}, err
 	}
-	return s.updateSession(ctx, *user, session)
+
+	ctx = context.WithValue(
+		ctx,
+		userSessionKey,
+		sess,
+	)
+	sess.Ctx = ctx
+	return tokens, nil
 }
 
-func (s service) updateSession(ctx context.Context, user user.User, sess *session.Session) (string, error) {
-	token, err := s.getStringTokenByUser(user)
+// issueTokens generates a new access token and the refresh token of the current generation of the session.
+// The caller saves the session.
+// A suspended or a banned user gets errorshandler.Forbidden with the reason, so neither a login nor a refresh succeeds.
+func (s service) issueTokens(sess *session.Session, user user.User) (Tokens, error) {
+	now := time.Now()
+	if user.State.IsBlocked(now) {
+		return Tokens{}, errorshandler.Forbidden(user.State.Message())
+	}
+
+	token, err := s.getStringTokenByUser(user, sess.ID, now)
 	if err != nil {
-		return "", err
+		return Tokens{}, err
 	}
+
+	sess.LastSeenAt = now
 	sess.Token = token
 	sess.User = user
 	sess.Data = session.Data{
 		UserID:              user.ID,
 		UserName:            user.Name,
+		Role:                user.Role,
+		ModeratedCategories: user.ModeratedCategories,
 		ExpirationTokenTime: s.getTokenExpirationTime(),
+		State:               user.State,
 	}
 
-	return token, s.sessionRepository.Update(ctx, sess)
+	return Tokens{
+		AccessToken:  token,
+		RefreshToken: newRefreshToken(sess).String(),
+	}, nil
 }
 
-func (s service) createSession(ctx context.Context, user user.User) (string, error) {
-	token, err := s.getStringTokenByUser(user)
+// Refresh rotates the refresh token of the session: the presented token becomes invalid and a new pair of tokens is returned.
+// A replay of an already used refresh token means that the token may have been stolen, so the whole session is ended.
+func (s service) Refresh(ctx context.Context, str string) (Tokens, error) {
+	invalidToken := errorshandler.Unauthorized(apperror.ErrInvalidRefreshToken.Error())
+
+	rt, err := parseRefreshToken(str)
 	if err != nil {
-		return "", err
+		return Tokens{}, invalidToken
 	}
 
-	sess, err := s.sessionRepository.NewEntity(ctx, user.ID)
+	sess, err := s.sessionRepository.Get(ctx, rt.SessionID)
 	if err != nil {
-		return "", err
+		if err == apperror.ErrNotFound {
+			return Tokens{}, errorshandler.Unauthorized(apperror.ErrTokenHasBeenRevoked.Error())
+		}
+		return Tokens{}, err
 	}
-	sess.Token = token
-	sess.User = user
-	sess.Data = session.Data{
-		UserID:              user.ID,
-		UserName:            user.Name,
-		ExpirationTokenTime: s.getTokenExpirationTime(),
+
+	if !rt.isSignedBy(sess) || rt.Generation > sess.RefreshGeneration {
+		return Tokens{}, invalidToken
 	}
 
-	err = s.sessionRepository.Create(ctx, sess)
+	if rt.Generation < sess.RefreshGeneration {
+		return Tokens{}, s.endReusedSession(ctx, sess, rt.Generation, sess.RefreshGeneration)
+	}
+
+	//	the role of the user may have been changed since the previous token
+	user, err := s.userService.Get(ctx, sess.UserID)
 	if err != nil {
-		return "", err
+		return Tokens{}, err
 	}
 
-	ctx = context.WithValue(
-		ctx,
-		userSessionKey,
-		sess,
-	)
-	sess.Ctx = ctx
-	return token, nil
+	//	the generation is compared and bumped atomically, so only one of the concurrent presentations of the token wins
+	//	and the others are treated as a reuse
+	var tokens Tokens
+	generation := rt.Generation
+	err = s.sessionRepository.Modify(ctx, sess.ID, func(entity *session.Session) (err error) {
+		if entity.RefreshGeneration != rt.Generation {
+			generation = entity.RefreshGeneration
+			return apperror.ErrRefreshTokenReused
+		}
+		entity.RefreshGeneration++
+		tokens, err = s.issueTokens(entity, *user)
+		return err
+	})
+	switch err {
+	case nil:
+		return tokens, nil
+	case apperror.ErrNotFound:
+		return Tokens{}, errorshandler.Unauthorized(apperror.ErrTokenHasBeenRevoked.Error())
+	case apperror.ErrRefreshTokenReused:
+		return Tokens{}, s.endReusedSession(ctx, sess, rt.Generation, generation)
+	}
+	return Tokens{}, err
+}
+
+// endReusedSession ends the session whose refresh token of an old generation has been presented.
+func (s service) endReusedSession(ctx context.Context, sess *session.Session, generation, current uint) error {
+	s.logger.With(ctx, "user", sess.UserID, "session", sess.ID).Warnf("reuse of the refresh token of generation %v, the current generation is %v; the session is ended", generation, current)
+	if err := s.sessionRepository.Delete(ctx, sess); err != nil {
+		return err
+	}
+	return errorshandler.Unauthorized(apperror.ErrRefreshTokenReused.Error())
 }
 
 func (s service) getTokenExpirationTime() time.Time {
-	return time.Now().Add(time.Duration(int64(s.tokenExpiration)) * time.Hour)
+	return time.Now().Add(time.Duration(int64(s.accessTokenLifeTime)) * time.Minute)
 }
 
-func (s service) getStringTokenByUser(user user.User) (string, error) {
+func (s service) getStringTokenByUser(user user.User, sessionId string, issuedAt time.Time) (string, error) {
 	token := s.tokenRepository.NewTokenByData(TokenData{
+		ID:                  uuid.New().String(),
+		IssuedAt:            issuedAt,
+		SessionID:           sessionId,
 		UserID:              user.ID,
 		UserName:            user.Name,
+		Role:                user.Role,
 		ExpirationTokenTime: s.getTokenExpirationTime(),
 	})
-	return token.GenerateStringToken(s.signingKey)
+	return token.GenerateStringToken()
 }
 
 // authenticate authenticates a user using username and password.
 // If username and password are correct, an *user.User is returned. Otherwise, error is returned.
+// An unknown user and a wrong password get the same error.
 func (s service) authenticate(ctx context.Context, username, password string) (*user.User, error) {
 	logger := s.logger.With(ctx, "user", username)
+	invalidCredentials := errorshandler.Unauthorized(apperror.ErrInvalidCredentials.Error())
 
 	user := s.userService.NewEntity()
 	user.Name = username
 
 	user, err := s.userService.First(ctx, user)
 	if err != nil {
-		return user, errorshandler.BadRequest("User not found")
+		if !errors.Is(err, apperror.ErrNotFound) {
+			return user, err
+		}
+		//	the password is verified anyway to spend the same time as for an existing user
+		s.passwordHasher.Verify(s.dummyPasshash, password)
+		logger.Infof("authentication failed: user not found")
+		return user, invalidCredentials
+	}
+
+	ok, needsRehash, err := s.passwordHasher.Verify(user.Passhash, password)
+	if err != nil {
+		logger.Errorf("can not verify the password: %v", err)
+		return user, invalidCredentials
 	}
 
-	if comparePassword([]byte(user.Passhash), []byte(password)) {
+	if ok {
 		logger.Infof("authentication successful")
+		if needsRehash {
+			s.rehashPassword(ctx, user, password)
+		}
 		return user, nil
 	}
 
 	logger.Infof("authentication failed")
-	return user, errorshandler.Unauthorized("")
+	return user, invalidCredentials
 }
 
-func (s service) Register(ctx context.Context, username, password string) (string, error) {
+// Register creates a new user and starts a session of the user.
+// The registrations from the IP of the client are throttled, ThrottledError is returned when the IP is locked.
+// The email is optional, it is needed for the password reset only.
+func (s service) Register(ctx context.Context, username, password, email string, client Client) (Tokens, error) {
+	if err := s.throttle.register(ctx, client.IP); err != nil {
+		return Tokens{}, err
+	}
+
 	user, err := s.NewUser(username, password)
 	if err != nil {
-		return "", errorshandler.InternalServerError(err.Error())
+		return Tokens{}, errorshandler.InternalServerError(err.Error())
 	}
+	user.Email = email
 
 	if err := s.userService.Create(ctx, user); err != nil {
-		return "", errorshandler.BadRequest(err.Error())
+		if errors.Is(err, apperror.ErrConflict) {
+			return Tokens{}, errorshandler.Conflict(apperror.ErrUsernameTaken.Error())
+		}
+		return Tokens{}, errorshandler.BadRequest(err.Error())
 	}
 
-	return s.createSession(ctx, *user)
+	return s.createSession(ctx, *user, client)
+}
+
+// touchSession saves the last seen time of the session. Only the time is written,
+// so a session which has been ended meanwhile is not restored and its other data is not reverted.
+func (s service) touchSession(ctx context.Context, id string, lastSeenAt time.Time) error {
+	return s.sessionRepository.Modify(ctx, id, func(entity *session.Session) error {
+		entity.LastSeenAt = lastSeenAt
+		return nil
+	})
 }
 
 func (s service) StringTokenValidation(ctx context.Context, stringToken string) (resCtx context.Context, isValid bool, err error) {
 	resCtx = ctx
-	token, err := s.tokenRepository.ParseStringToken(stringToken, s.signingKey)
+	token, err := s.tokenRepository.ParseStringToken(stringToken)
 	if err != nil {
 		return resCtx, isValid, err
 	}
 
-	session, err := s.sessionRepository.Get(ctx, token.GetData().UserID)
+	data := token.GetData()
+	if data.Purpose != "" {
+		return resCtx, isValid, apperror.ErrNotAccessToken
+	}
+	session, err := s.sessionRepository.Get(ctx, data.SessionID)
 	if err != nil {
+		if err == apperror.ErrNotFound {
+			//	the session has been ended by a logout or has expired
+			return resCtx, isValid, apperror.ErrTokenHasBeenRevoked
+		}
 		return resCtx, isValid, err
 	}
+	if session.UserID != data.UserID {
+		return resCtx, isValid, apperror.ErrTokenHasBeenRevoked
+	}
+	if session.Data.State.IsBlocked(time.Now()) {
+		return resCtx, isValid, errorshandler.Forbidden(session.Data.State.Message())
+	}
 	isValid = true
 
+	if time.Since(session.LastSeenAt) > lastSeenUpdateInterval {
+		session.LastSeenAt = time.Now()
+		if err = s.touchSession(ctx, session.ID, session.LastSeenAt); err != nil && err != apperror.ErrNotFound {
+			s.logger.With(ctx).Errorf("can not update the last seen time of the session %q: %v", session.ID, err)
+		}
+	}
+
 	resCtx = context.WithValue(
 		ctx,
 		userSessionKey,
 		session,
 	)
+	resCtx = context.WithValue(
+		resCtx,
+		tokenDataKey,
+		&data,
+	)
 	session.Ctx = resCtx
 	return resCtx, isValid, nil
 }
 
-// Source: https://play.golang.org/p/tAZtO7L6pm
-// hash provided clear text password and compare it to provided hash
-func comparePassword(hash, pw []byte) bool {
-	return bytes.Equal(hash, hashPassword(pw, hash[:saltSize]))
+// Logout deletes the session of the current request, the other sessions of the user stay active.
+func (s service) Logout(ctx context.Context) error {
+	sess := CurrentSession(ctx)
+	if sess == nil {
+		return errorshandler.Unauthorized("")
+	}
+	return s.sessionRepository.Delete(ctx, sess)
+}
+
+// LogoutAll deletes all sessions of the current user, so all tokens of the user become invalid.
+func (s service) LogoutAll(ctx context.Context) error {
+	sess := CurrentSession(ctx)
+	if sess == nil {
+		return errorshandler.Unauthorized("")
+	}
+	return s.sessionRepository.DeleteByUserID(ctx, sess.UserID)
+}
+
+// Sessions returns the active sessions of the current user, the most recently used first.
+func (s service) Sessions(ctx context.Context) ([]session.Session, error) {
+	sess := CurrentSession(ctx)
+	if sess == nil {
+		return nil, errorshandler.Unauthorized("")
+	}
+
+	items, err := s.sessionRepository.QueryByUserID(ctx, sess.UserID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].LastSeenAt.After(items[j].LastSeenAt)
+	})
+	return items, nil
+}
+
+// RevokeSession deletes the session with the specified ID if it belongs to the current user.
+func (s service) RevokeSession(ctx context.Context, id string) error {
+	sess := CurrentSession(ctx)
+	if sess == nil {
+		return errorshandler.Unauthorized("")
+	}
+
+	entity, err := s.sessionRepository.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if entity.UserID != sess.UserID {
+		//	do not disclose that the session exists
+		return apperror.ErrNotFound
+	}
+	return s.sessionRepository.Delete(ctx, entity)
 }
 
-// hash the password with the provided salt using the pbkdf2 algorithm
-// return byte slice containing salt (first 64 bytes) and hash (last 32 bytes) => total of 96 bytes
-func hashPassword(pw, salt []byte) []byte {
-	ret := make([]byte, len(salt))
-	copy(ret, salt)
-	return append(ret, pbkdf2.Key(pw, salt, iterations, sha256.Size, sha256.New)...)
+// rehashPassword hashes the password by the current algorithm and parameters.
+// The old hash stays valid, so an error only is logged and does not prevent the login.
+func (s service) rehashPassword(ctx context.Context, user *user.User, password string) {
+	logger := s.logger.With(ctx, "user", user.Name)
+
+	passhash, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		logger.Errorf("can not rehash the password: %v", err)
+		return
+	}
+
+	user.Passhash = passhash
+	if err = s.userService.Update(ctx, user); err != nil {
+		logger.Errorf("can not save the rehashed password: %v", err)
+		return
+	}
+	logger.Infof("the password has been rehashed")
 }
 
 func generateRandomBytes(n int) ([]byte, error) {
@@ -8,18 +8,27 @@ import (
 )
 
 // SessionRepository encapsulates the logic to access session from the data source.
+// It is the source of truth for the authentication: a token is valid only while its session exists.
 type SessionRepository interface {
 	SetDefaultConditions(defaultConditions selection_condition.SelectionCondition)
+	// NewEntity returns a new session of the user with a new unique ID.
 	NewEntity(ctx context.Context, userId uint) (*session.Session, error)
-	// GetByUserID returns the session with the specified user ID.
-	Get(ctx context.Context, userId uint) (*session.Session, error)
+	// Get returns the session with the specified ID.
+	Get(ctx context.Context, id string) (*session.Session, error)
+	// QueryByUserID returns the active sessions of the user.
+	QueryByUserID(ctx context.Context, userId uint) ([]session.Session, error)
 	// Create saves a new entity in the storage.
 	Create(ctx context.Context, entity *session.Session) error
-	// Update updates the entity with given ID in the storage.
+	// Update updates the entity with given ID in the storage. A removed session is not recreated, apperror.ErrNotFound is returned for it.
 	Update(ctx context.Context, entity *session.Session) error
+	// Modify applies the modification to the current state of the session and saves it atomically.
+	// The error of the modification is returned as is, apperror.ErrNotFound is returned for a removed session.
+	Modify(ctx context.Context, id string, modify func(entity *session.Session) error) error
 	Save(session *session.Session) error
 	// Delete removes the entity with given ID from the storage.
 	Delete(ctx context.Context, entity *session.Session) error
+	// DeleteByUserID removes all sessions of the user from the storage.
+	DeleteByUserID(ctx context.Context, userId uint) error
 	GetData(session *session.Session) session.Data
 	SetData(session *session.Session, data session.Data) error
 }
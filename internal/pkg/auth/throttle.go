@@ -0,0 +1,279 @@
+package auth
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minipkg/log"
+
+	"redditclone/internal/domain/user"
+)
+
+// LoginAttemptRepository keeps the attempts and the lockouts of the throttled keys.
+type LoginAttemptRepository interface {
+	// AddAttempt registers an attempt at the time and returns the number of the attempts within the window before it.
+	AddAttempt(ctx context.Context, key string, at time.Time, window time.Duration) (uint, error)
+	// ResetAttempts forgets the attempts of the key.
+	ResetAttempts(ctx context.Context, key string) error
+	// CountLockout registers a lockout of the key and returns the number of the lockouts of the key within the period.
+	CountLockout(ctx context.Context, key string, period time.Duration) (uint, error)
+	// Lock locks the key for the duration.
+	Lock(ctx context.Context, key string, duration time.Duration) error
+	// LockedFor returns the time which the key is locked for, zero if it is not locked.
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+}
+
+// ThrottleConfig is the config of the throttling of logins and registrations.
+type ThrottleConfig struct {
+	// Window of the sliding counting of the attempts in minutes. Defaults to 15
+	Window uint
+	// MaxUserFailures is the number of the failed logins to a user within the window which locks the user. Defaults to 5
+	MaxUserFailures uint
+	// MaxIPFailures is the number of the failed logins from an IP within the window which locks the IP. Defaults to 20
+	MaxIPFailures uint
+	// MaxIPRegistrations is the number of the registrations from an IP within the window. Defaults to 5
+	MaxIPRegistrations uint
+	// MaxIPPasswordResets is the number of the password reset requests from an IP within the window. Defaults to 5
+	MaxIPPasswordResets uint
+	// LockoutBase is the first lockout in seconds, every next lockout within a day is twice as long. Defaults to 60
+	LockoutBase uint
+	// LockoutMax is the longest lockout in seconds. Defaults to 3600
+	LockoutMax uint
+	// TrustedProxies are the IPs or the CIDR networks of the reverse proxies whose X-Forwarded-For and X-Real-IP headers are trusted.
+	// By default the headers are ignored and the IP of the connection is throttled
+	TrustedProxies []string
+}
+
+// ThrottledError is the error of an attempt which is rejected until RetryAfter passes.
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e ThrottledError) Error() string {
+	return fmt.Sprintf("Too many attempts, retry after %v seconds", e.RetrySeconds())
+}
+
+// RetrySeconds returns RetryAfter rounded up to seconds, as it is sent in the Retry-After header.
+func (e ThrottledError) RetrySeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+const (
+	throttleKeyPrefixUser     = "login_user_"
+	throttleKeyPrefixIP       = "login_ip_"
+	throttleKeyPrefixRegister = "register_ip_"
+	throttleKeyPrefixReset    = "reset_ip_"
+	// lockoutPeriod is the period which the lockouts are counted within for the progression
+	lockoutPeriod = 24 * time.Hour
+)
+
+// throttleMetrics are the counters of the throttling published by expvar at /debug/vars
+var throttleMetrics = expvar.NewMap("auth_throttle")
+
+// throttle limits the failed logins per user and per IP and the registrations per IP by sliding windows.
+// Exceeding of a limit locks the user or the IP, the lockouts grow twice with each next one.
+type throttle struct {
+	cfg            ThrottleConfig
+	trustedProxies []*net.IPNet
+	repo           LoginAttemptRepository
+	logger         log.ILogger
+}
+
+func newThrottle(cfg ThrottleConfig, repo LoginAttemptRepository, logger log.ILogger) *throttle {
+	if cfg.Window == 0 {
+		cfg.Window = 15
+	}
+	if cfg.MaxUserFailures == 0 {
+		cfg.MaxUserFailures = 5
+	}
+	if cfg.MaxIPFailures == 0 {
+		cfg.MaxIPFailures = 20
+	}
+	if cfg.MaxIPRegistrations == 0 {
+		cfg.MaxIPRegistrations = 5
+	}
+	if cfg.MaxIPPasswordResets == 0 {
+		cfg.MaxIPPasswordResets = 5
+	}
+	if cfg.LockoutBase == 0 {
+		cfg.LockoutBase = 60
+	}
+	if cfg.LockoutMax == 0 {
+		cfg.LockoutMax = 3600
+	}
+	return &throttle{
+		cfg:            cfg,
+		trustedProxies: parseNetworks(cfg.TrustedProxies, logger),
+		repo:           repo,
+		logger:         logger,
+	}
+}
+
+// parseNetworks parses the IPs and the CIDR networks, the invalid ones are logged and skipped.
+func parseNetworks(items []string, logger log.ILogger) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(items))
+	for _, item := range items {
+		if !strings.Contains(item, "/") {
+			if ip := net.ParseIP(item); ip != nil {
+				networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+				continue
+			}
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			logger.Errorf("invalid trusted proxy %q: %v", item, err)
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// isTrustedProxy returns true if the IP belongs to a trusted proxy.
+func (t *throttle) isTrustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range t.trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the IP address of the client which the request is throttled by.
+// The forwarding headers are read only if the request comes from a trusted proxy, X-Forwarded-For is read from the right
+// to the first address which is not a trusted proxy, so the addresses added by the client itself are ignored.
+func (t *throttle) clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !t.isTrustedProxy(ip) {
+		return ip
+	}
+
+	if header := r.Header.Get("X-Forwarded-For"); header != "" {
+		ips := strings.Split(header, ",")
+		for i := len(ips) - 1; i >= 0; i-- {
+			ip = strings.TrimSpace(ips[i])
+			if !t.isTrustedProxy(ip) {
+				return ip
+			}
+		}
+		return ip
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		return realIP
+	}
+	return ip
+}
+
+func (t *throttle) window() time.Duration {
+	return time.Duration(int64(t.cfg.Window)) * time.Minute
+}
+
+// userKey returns the key of a username, the usernames with the same normalised form are throttled together.
+func userKey(username string) string {
+	return throttleKeyPrefixUser + user.NormalizeName(username)
+}
+
+// checkLogin returns ThrottledError if the user or the IP is locked.
+func (t *throttle) checkLogin(ctx context.Context, username, ip string) error {
+	return t.check(ctx, userKey(username), throttleKeyPrefixIP+ip)
+}
+
+// loginFailed counts the failed login and locks the user or the IP if the limit is exceeded.
+func (t *throttle) loginFailed(ctx context.Context, username, ip string) error {
+	throttleMetrics.Add("login_failures", 1)
+
+	if err := t.count(ctx, userKey(username), t.cfg.MaxUserFailures); err != nil {
+		return err
+	}
+	return t.count(ctx, throttleKeyPrefixIP+ip, t.cfg.MaxIPFailures)
+}
+
+// loginSucceeded forgets the failed logins to the user. The failures from the IP are still counted,
+// so a successful login to an own account does not reset the guessing of the others.
+func (t *throttle) loginSucceeded(ctx context.Context, username string) error {
+	return t.repo.ResetAttempts(ctx, userKey(username))
+}
+
+// register counts the registration from the IP, it returns ThrottledError if the IP is locked.
+func (t *throttle) register(ctx context.Context, ip string) error {
+	key := throttleKeyPrefixRegister + ip
+	if err := t.check(ctx, key); err != nil {
+		return err
+	}
+	return t.count(ctx, key, t.cfg.MaxIPRegistrations)
+}
+
+// passwordReset counts the password reset request from the IP, it returns ThrottledError if the IP is locked.
+func (t *throttle) passwordReset(ctx context.Context, ip string) error {
+	key := throttleKeyPrefixReset + ip
+	if err := t.check(ctx, key); err != nil {
+		return err
+	}
+	return t.count(ctx, key, t.cfg.MaxIPPasswordResets)
+}
+
+func (t *throttle) check(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		lockedFor, err := t.repo.LockedFor(ctx, key)
+		if err != nil {
+			return err
+		}
+		if lockedFor > 0 {
+			throttleMetrics.Add("rejected", 1)
+			return ThrottledError{RetryAfter: lockedFor}
+		}
+	}
+	return nil
+}
+
+// count registers an attempt of the key and locks the key when the attempts reach the limit.
+func (t *throttle) count(ctx context.Context, key string, limit uint) error {
+	attempts, err := t.repo.AddAttempt(ctx, key, time.Now(), t.window())
+	if err != nil {
+		return err
+	}
+	if attempts+1 < limit {
+		return nil
+	}
+
+	lockouts, err := t.repo.CountLockout(ctx, key, lockoutPeriod)
+	if err != nil {
+		return err
+	}
+	if err = t.repo.Lock(ctx, key, t.lockout(lockouts)); err != nil {
+		return err
+	}
+	if err = t.repo.ResetAttempts(ctx, key); err != nil {
+		return err
+	}
+
+	throttleMetrics.Add("lockouts", 1)
+	t.logger.With(ctx, "key", key).Infof("locked out for %v after %v attempts, lockout %v within %v", t.lockout(lockouts), attempts+1, lockouts, lockoutPeriod)
+	return nil
+}
+
+// lockout returns the duration of the n-th lockout: the base one doubled for each previous lockout, but no more than the max one.
+func (t *throttle) lockout(n uint) time.Duration {
+	d := time.Duration(int64(t.cfg.LockoutBase)) * time.Second
+	max := time.Duration(int64(t.cfg.LockoutMax)) * time.Second
+	for i := uint(1); i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
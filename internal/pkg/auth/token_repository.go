@@ -6,17 +6,25 @@ import (
 
 type TokenRepository interface {
 	NewTokenByData(data TokenData) Token
-	ParseStringToken(tokenString string, signingKey string) (Token, error)
+	ParseStringToken(tokenString string) (Token, error)
 }
 
 type Token interface {
 	GetData() TokenData
-	GenerateStringToken(signingKey string) (string, error)
+	GenerateStringToken() (string, error)
 	Valid() error
 }
 
 type TokenData struct {
+	// ID is the unique ID of the token
+	ID       string    `json:"-"`
+	IssuedAt time.Time `json:"-"`
+	// SessionID is the ID of the session which the token is issued for
+	SessionID string `json:"sid"`
+	// Purpose is empty for an access token, a token for another purpose is not accepted as an access token
+	Purpose             string `json:"pur,omitempty"`
 	UserID              uint
 	UserName            string
+	Role                string
 	ExpirationTokenTime time.Time
 }
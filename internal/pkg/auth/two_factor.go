@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/errorshandler"
+	"redditclone/internal/pkg/totp"
+)
+
+const (
+	// totpIssuer is the name of the account issuer shown by the authenticator apps
+	totpIssuer = "redditclone"
+	// tokenPurposeTwoFactor is the purpose of a pre-auth token, it is exchanged for the tokens by the second factor only
+	tokenPurposeTwoFactor = "2fa"
+	// preAuthTokenLifeTime is the time to enter the code of the second factor after the password
+	preAuthTokenLifeTime = 5 * time.Minute
+	// recoveryCodesCount is the number of the recovery codes given at the enabling of the two-factor authentication
+	recoveryCodesCount = 10
+	// recoveryCodeSize is the size of a recovery code in bytes, 5 bytes are 8 base32 characters
+	recoveryCodeSize = 5
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTPEnrollment is the secret of the two-factor authentication which is being enrolled.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	// URI is the otpauth URI of the secret for a QR code
+	URI string `json:"uri"`
+}
+
+// issuePreAuthToken issues the token which proves the password of the user until the code of the second factor is entered.
+func (s service) issuePreAuthToken(user user.User) (Tokens, error) {
+	now := time.Now()
+	token, err := s.tokenRepository.NewTokenByData(TokenData{
+		ID:                  uuid.New().String(),
+		IssuedAt:            now,
+		Purpose:             tokenPurposeTwoFactor,
+		UserID:              user.ID,
+		UserName:            user.Name,
+		ExpirationTokenTime: now.Add(preAuthTokenLifeTime),
+	}).GenerateStringToken()
+	if err != nil {
+		return Tokens{}, err
+	}
+	return Tokens{
+		PreAuthToken: token,
+	}, nil
+}
+
+// LoginSecondFactor completes the login of a user with the two-factor authentication.
+// The code is either the current TOTP code or an unused recovery code, the wrong codes are throttled as the wrong passwords.
+func (s service) LoginSecondFactor(ctx context.Context, preAuthToken, code string, client Client) (Tokens, error) {
+	token, err := s.tokenRepository.ParseStringToken(preAuthToken)
+	if err != nil {
+		return Tokens{}, errorshandler.Unauthorized(err.Error())
+	}
+	data := token.GetData()
+	if data.Purpose != tokenPurposeTwoFactor {
+		return Tokens{}, errorshandler.Unauthorized("")
+	}
+
+	if err = s.throttle.checkLogin(ctx, data.UserName, client.IP); err != nil {
+		return Tokens{}, err
+	}
+
+	user, err := s.userService.Get(ctx, data.UserID)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	ok, err := s.verifySecondFactor(ctx, user, code)
+	if err != nil {
+		return Tokens{}, err
+	}
+	if !ok {
+		if err = s.throttle.loginFailed(ctx, user.Name, client.IP); err != nil {
+			s.logger.With(ctx).Errorf("can not count the failed login: %v", err)
+		}
+		return Tokens{}, errorshandler.Unauthorized(apperror.ErrInvalidTwoFactorCode.Error())
+	}
+
+	if err = s.throttle.loginSucceeded(ctx, user.Name); err != nil {
+		s.logger.With(ctx).Errorf("can not reset the failed logins: %v", err)
+	}
+	return s.createSession(ctx, *user, client)
+}
+
+// EnrollTOTP generates a new secret for the current user. The two-factor authentication is enabled by ConfirmTOTP,
+// so a user who has not added the secret to an authenticator app is not locked out.
+func (s service) EnrollTOTP(ctx context.Context) (TOTPEnrollment, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	if user.TOTPEnabled {
+		return TOTPEnrollment{}, errorshandler.BadRequest(apperror.ErrTwoFactorEnabled.Error())
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	user.TOTPSecret = secret
+	user.TOTPLastStep = 0
+	if err = s.userService.Update(ctx, user); err != nil {
+		return TOTPEnrollment{}, err
+	}
+
+	return TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, user.Name, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables the two-factor authentication of the current user by a code of the enrolled secret.
+// It returns the recovery codes, they are shown only once: only their hashes are stored.
+func (s service) ConfirmTOTP(ctx context.Context, code string) ([]string, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errorshandler.BadRequest(apperror.ErrTwoFactorEnabled.Error())
+	}
+	if user.TOTPSecret == "" {
+		return nil, errorshandler.BadRequest(apperror.ErrTwoFactorNotEnrolled.Error())
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return nil, errorshandler.BadRequest(apperror.ErrInvalidTwoFactorCode.Error())
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	user.TOTPEnabled = true
+	user.TOTPLastStep = step
+	user.RecoveryCodes = hashes
+	if err = s.userService.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.logger.With(ctx, "user", user.Name).Infof("two-factor authentication enabled")
+	return codes, nil
+}
+
+// DisableTOTP disables the two-factor authentication of the current user.
+// The user authenticates again by the password and a code of the second factor, so a stolen access token is not enough.
+func (s service) DisableTOTP(ctx context.Context, password, code string, client Client) error {
+	user, err := s.Reauthenticate(ctx, password, client)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return errorshandler.BadRequest(apperror.ErrTwoFactorNotEnabled.Error())
+	}
+
+	ok, err := s.verifySecondFactor(ctx, user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err = s.throttle.loginFailed(ctx, user.Name, client.IP); err != nil {
+			s.logger.With(ctx).Errorf("can not count the failed login: %v", err)
+		}
+		return errorshandler.Unauthorized(apperror.ErrInvalidTwoFactorCode.Error())
+	}
+
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	user.RecoveryCodes = nil
+	if err = s.userService.Update(ctx, user); err != nil {
+		return err
+	}
+
+	s.logger.With(ctx, "user", user.Name).Infof("two-factor authentication disabled")
+	return nil
+}
+
+// verifySecondFactor checks the TOTP code or the recovery code of the user.
+// An accepted code can not be used again: the step of the TOTP code is saved and the recovery code is removed.
+func (s service) verifySecondFactor(ctx context.Context, user *user.User, code string) (bool, error) {
+	if !user.TOTPEnabled {
+		return false, nil
+	}
+
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep); ok {
+		user.TOTPLastStep = step
+		return true, s.userService.Update(ctx, user)
+	}
+
+	hash := hashRecoveryCode(code)
+	for i, h := range user.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			user.RecoveryCodes = append(user.RecoveryCodes[:i:i], user.RecoveryCodes[i+1:]...)
+			s.logger.With(ctx, "user", user.Name).Infof("a recovery code is used, %v codes left", len(user.RecoveryCodes))
+			return true, s.userService.Update(ctx, user)
+		}
+	}
+	return false, nil
+}
+
+// currentUser returns the current user as it is stored, the user of the session may be outdated.
+func (s service) currentUser(ctx context.Context) (*user.User, error) {
+	sess := CurrentSession(ctx)
+	if sess == nil {
+		return nil, errorshandler.Unauthorized("")
+	}
+	return s.userService.Get(ctx, sess.UserID)
+}
+
+// newRecoveryCodes generates the recovery codes in the form of "xxxx-xxxx" and their hashes.
+func newRecoveryCodes() (codes []string, hashes []string, err error) {
+	codes = make([]string, 0, recoveryCodesCount)
+	hashes = make([]string, 0, recoveryCodesCount)
+
+	for i := 0; i < recoveryCodesCount; i++ {
+		b, err := generateRandomBytes(recoveryCodeSize)
+		if err != nil {
+			return nil, nil, errors.Wrapf(apperror.ErrInternal, "could not get a recovery code: %v", err)
+		}
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		code = code[:4] + "-" + code[4:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode returns the hex encoded SHA-256 hash of the recovery code.
+// The codes are random, so a fast hash is enough. The case and the separators do not matter.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
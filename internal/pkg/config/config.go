@@ -11,6 +11,12 @@ import (
 	"github.com/minipkg/log"
 
 	"github.com/spf13/viper"
+
+	"redditclone/internal/domain/feed"
+	"redditclone/internal/domain/search"
+	"redditclone/internal/pkg/auth"
+	"redditclone/internal/pkg/mail"
+	"redditclone/internal/pkg/password"
 )
 
 // Configuration is the struct for app configuration
@@ -20,10 +26,26 @@ type Configuration struct {
 	}
 	Log log.Config
 	DB  DB
-	// JWT signing key. required.
+	// JWT signing key. It signs tokens by HS256 if JWTKeySetPath is empty.
 	JWTSigningKey string
-	// JWT expiration in hours. Defaults to 72 hours (3 days)
-	JWTExpiration   uint
+	// Path to the JSON file of the JWT keyset, the keyset is made by the "keys" CLI command.
+	JWTKeySetPath string
+	// Access token lifetime in minutes. A client gets a new access token by the refresh token.
+	AccessTokenLifeTime uint
+	// Password hashing of new passwords, the passwords hashed differently are rehashed at login
+	Password password.Config
+	// Throttling of the failed logins and the registrations
+	LoginThrottle auth.ThrottleConfig
+	// Password reset links, they are sent by Mail
+	PasswordReset auth.PasswordResetConfig
+	Mail          mail.Config
+	// Precomputed timelines of the feeds of the heavy users
+	Feed feed.Config
+	// Full-text search of the posts and the comments
+	Search search.Config
+	// Retention of the deleted posts and comments in days, the "purge-deleted" CLI command removes them for good after that. Defaults to 30
+	DeletedRetention uint
+	// Session lifetime in hours, the refresh token of a session is valid as long as the session.
 	SessionLifeTime uint
 	CacheLifeTime   uint
 }
@@ -102,8 +124,12 @@ func Get4UnitTest(logAppPostfix string) *Configuration {
 			Mongo: mongo.Config{},
 			Redis: redis.Config{},
 		},
-		JWTSigningKey:   "test",
-		JWTExpiration:   1,
+		JWTSigningKey:       "test",
+		AccessTokenLifeTime: 15,
+		Password: password.Config{
+			Algorithm:  password.AlgBcrypt,
+			BcryptCost: 4,
+		},
 		SessionLifeTime: 1,
 		CacheLifeTime:   1,
 	}
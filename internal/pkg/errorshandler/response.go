@@ -91,6 +91,28 @@ func BadRequest(msg string) Response {
 	}
 }
 
+// Conflict creates a new error response representing a conflict with an existing entity (HTTP 409)
+func Conflict(msg string) Response {
+	if msg == "" {
+		msg = "The entity already exists."
+	}
+	return Response{
+		Status:  http.StatusConflict,
+		Message: msg,
+	}
+}
+
+// TooManyRequests creates a new error response representing a rate limit excess (HTTP 429)
+func TooManyRequests(msg string) Response {
+	if msg == "" {
+		msg = "Too many requests, please retry later."
+	}
+	return Response{
+		Status:  http.StatusTooManyRequests,
+		Message: msg,
+	}
+}
+
 type invalidField struct {
 	Field string `json:"field"`
 	Error string `json:"error"`
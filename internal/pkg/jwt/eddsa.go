@@ -0,0 +1,54 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"errors"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SigningMethodEd25519 implements the EdDSA signing method with the Ed25519 keys (RFC 8037).
+// Expects ed25519.PrivateKey for signing and ed25519.PublicKey for validation.
+type SigningMethodEd25519 struct{}
+
+// SigningMethodEdDSA is the instance of the EdDSA signing method
+var SigningMethodEdDSA = &SigningMethodEd25519{}
+
+var errEd25519Verification = errors.New("ed25519: verification error")
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+func (m *SigningMethodEd25519) Alg() string {
+	return AlgEdDSA
+}
+
+// Verify the signature of the EdDSA token. Returns nil if the signature is valid.
+func (m *SigningMethodEd25519) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok || len(publicKey) != ed25519.PublicKeySize {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return errEd25519Verification
+	}
+	return nil
+}
+
+// Sign implements the Sign method from SigningMethod for the EdDSA signing method.
+func (m *SigningMethodEd25519) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok || len(privateKey) != ed25519.PrivateKeySize {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
@@ -0,0 +1,365 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"redditclone/internal/pkg/apperror"
+)
+
+// The supported signing algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+const (
+	// DefaultKeyID is the ID of the key made from the JWTSigningKey of the config
+	DefaultKeyID = "default"
+	// hmacKeySize is the size of a generated HS256 secret
+	hmacKeySize = 64
+	// rsaKeySize is the size of a generated RSA key in bits
+	rsaKeySize   = 2048
+	pemBlockType = "PRIVATE KEY"
+)
+
+// Key is a key of the keyset. The HS256 keys are secrets, the RS256 and EdDSA keys are private keys.
+type Key struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+	secret    []byte
+	private   crypto.Signer
+}
+
+// KeySet is the set of the keys which sign and verify tokens.
+// Only the signing key signs new tokens, the other keys verify the tokens issued before a rotation.
+type KeySet struct {
+	SigningKeyID string
+	Keys         []Key
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKS is a set of the public keys in the JSON Web Key Set format
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// keySetFile is the form of the keyset in a file
+type keySetFile struct {
+	SigningKeyID string    `json:"signingKeyId"`
+	Keys         []keyFile `json:"keys"`
+}
+
+// keyFile is the form of a key in a file: a base64 encoded secret for HS256 or a PEM encoded PKCS #8 private key
+type keyFile struct {
+	ID        string    `json:"kid"`
+	Algorithm string    `json:"alg"`
+	CreatedAt time.Time `json:"created"`
+	Key       string    `json:"key"`
+}
+
+// NewKey generates a new key for the algorithm.
+func NewKey(alg string) (*Key, error) {
+	key := &Key{
+		ID:        uuid.New().String(),
+		Algorithm: alg,
+		CreatedAt: time.Now(),
+	}
+
+	var err error
+	switch alg {
+	case AlgHS256:
+		key.secret = make([]byte, hmacKeySize)
+		_, err = rand.Read(key.secret)
+	case AlgRS256:
+		key.private, err = rsa.GenerateKey(rand.Reader, rsaKeySize)
+	case AlgEdDSA:
+		_, key.private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, errors.Wrapf(apperror.ErrBadRequest, "unsupported algorithm %q, supported ones are %v, %v, %v", alg, AlgHS256, AlgRS256, AlgEdDSA)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(apperror.ErrInternal, "can not generate a key: %v", err)
+	}
+	return key, nil
+}
+
+// NewHMACKeySet returns the keyset of the single HS256 key with the secret.
+func NewHMACKeySet(secret string) *KeySet {
+	return &KeySet{
+		SigningKeyID: DefaultKeyID,
+		Keys: []Key{
+			{
+				ID:        DefaultKeyID,
+				Algorithm: AlgHS256,
+				secret:    []byte(secret),
+			},
+		},
+	}
+}
+
+// LoadKeySet reads the keyset from the file.
+func LoadKeySet(path string) (*KeySet, error) {
+	var f keySetFile
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Wrapf(apperror.ErrNotFound, "the keyset file %q does not exist", path)
+		}
+		return nil, errors.Wrapf(apperror.ErrInternal, "can not read the keyset file %q: %v", path, err)
+	}
+	if err = json.Unmarshal(b, &f); err != nil {
+		return nil, errors.Wrapf(apperror.ErrInternal, "can not parse the keyset file %q: %v", path, err)
+	}
+
+	ks := &KeySet{
+		SigningKeyID: f.SigningKeyID,
+		Keys:         make([]Key, 0, len(f.Keys)),
+	}
+	for _, kf := range f.Keys {
+		key, err := kf.key()
+		if err != nil {
+			return nil, errors.Wrapf(err, "key %q of the keyset file %q", kf.ID, path)
+		}
+		ks.Keys = append(ks.Keys, *key)
+	}
+
+	if _, err = ks.SigningKey(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Save writes the keyset to the file, the file is readable for the owner only.
+func (ks *KeySet) Save(path string) error {
+	f := keySetFile{
+		SigningKeyID: ks.SigningKeyID,
+		Keys:         make([]keyFile, 0, len(ks.Keys)),
+	}
+	for _, key := range ks.Keys {
+		kf, err := key.file()
+		if err != nil {
+			return err
+		}
+		f.Keys = append(f.Keys, kf)
+	}
+
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "can not marshal the keyset: %v", err)
+	}
+	if err = ioutil.WriteFile(path, b, 0600); err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "can not write the keyset file %q: %v", path, err)
+	}
+	return nil
+}
+
+// Get returns the key with the ID.
+func (ks *KeySet) Get(id string) (*Key, bool) {
+	for i := range ks.Keys {
+		if ks.Keys[i].ID == id {
+			return &ks.Keys[i], true
+		}
+	}
+	return nil, false
+}
+
+// SigningKey returns the key which signs new tokens.
+func (ks *KeySet) SigningKey() (*Key, error) {
+	key, ok := ks.Get(ks.SigningKeyID)
+	if !ok {
+		return nil, errors.Wrapf(apperror.ErrInternal, "the signing key %q is not in the keyset", ks.SigningKeyID)
+	}
+	return key, nil
+}
+
+// Add adds the key to the keyset. The first key becomes the signing key.
+func (ks *KeySet) Add(key Key) {
+	ks.Keys = append(ks.Keys, key)
+	if ks.SigningKeyID == "" {
+		ks.SigningKeyID = key.ID
+	}
+}
+
+// Rotate generates a new signing key. The previous keys are kept for the verification of the tokens signed by them,
+// but no more than keep of them, the oldest ones are removed.
+func (ks *KeySet) Rotate(alg string, keep int) (*Key, error) {
+	key, err := NewKey(alg)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(ks.Keys, func(i, j int) bool {
+		return ks.Keys[i].CreatedAt.After(ks.Keys[j].CreatedAt)
+	})
+	if keep < 0 {
+		keep = 0
+	}
+	if len(ks.Keys) > keep {
+		ks.Keys = ks.Keys[:keep]
+	}
+
+	ks.Keys = append([]Key{*key}, ks.Keys...)
+	ks.SigningKeyID = key.ID
+	return key, nil
+}
+
+// JWKS returns the public keys of the keyset. The HS256 keys are secrets, so they are never published.
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{
+		Keys: make([]JWK, 0, len(ks.Keys)),
+	}
+	for _, key := range ks.Keys {
+		if jwk, ok := key.jwk(); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+	return jwks
+}
+
+// signingMethod returns the JWT signing method of the key.
+func (k Key) signingMethod() jwt.SigningMethod {
+	switch k.Algorithm {
+	case AlgRS256:
+		return jwt.SigningMethodRS256
+	case AlgEdDSA:
+		return SigningMethodEdDSA
+	}
+	return jwt.SigningMethodHS256
+}
+
+// signKey returns the key in the form which the signing method signs with.
+func (k Key) signKey() interface{} {
+	if k.private != nil {
+		return k.private
+	}
+	return k.secret
+}
+
+// verifyKey returns the key in the form which the signing method verifies with.
+func (k Key) verifyKey() interface{} {
+	if k.private != nil {
+		return k.private.Public()
+	}
+	return k.secret
+}
+
+func (k Key) jwk() (JWK, bool) {
+	jwk := JWK{
+		KeyID:     k.ID,
+		Algorithm: k.Algorithm,
+		Use:       "sig",
+	}
+
+	switch public := k.verifyKey().(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	default:
+		return jwk, false
+	}
+	return jwk, true
+}
+
+func (k Key) file() (keyFile, error) {
+	kf := keyFile{
+		ID:        k.ID,
+		Algorithm: k.Algorithm,
+		CreatedAt: k.CreatedAt,
+	}
+
+	if k.private == nil {
+		kf.Key = base64.StdEncoding.EncodeToString(k.secret)
+		return kf, nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(k.private)
+	if err != nil {
+		return kf, errors.Wrapf(apperror.ErrInternal, "can not marshal the key %q: %v", k.ID, err)
+	}
+	kf.Key = string(pem.EncodeToMemory(&pem.Block{Type: pemBlockType, Bytes: der}))
+	return kf, nil
+}
+
+func (kf keyFile) key() (*Key, error) {
+	key := &Key{
+		ID:        kf.ID,
+		Algorithm: kf.Algorithm,
+		CreatedAt: kf.CreatedAt,
+	}
+
+	if kf.Algorithm == AlgHS256 {
+		secret, err := base64.StdEncoding.DecodeString(kf.Key)
+		if err != nil {
+			return nil, errors.Wrapf(apperror.ErrInternal, "can not decode the secret: %v", err)
+		}
+		key.secret = secret
+		return key, nil
+	}
+
+	block, _ := pem.Decode([]byte(kf.Key))
+	if block == nil || block.Type != pemBlockType {
+		return nil, errors.Wrap(apperror.ErrInternal, "the key is not a PEM encoded private key")
+	}
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(apperror.ErrInternal, "can not parse the private key: %v", err)
+	}
+
+	switch private.(type) {
+	case *rsa.PrivateKey:
+		if kf.Algorithm != AlgRS256 {
+			return nil, errors.Wrapf(apperror.ErrInternal, "an RSA key can not be used for %v", kf.Algorithm)
+		}
+	case ed25519.PrivateKey:
+		if kf.Algorithm != AlgEdDSA {
+			return nil, errors.Wrapf(apperror.ErrInternal, "an Ed25519 key can not be used for %v", kf.Algorithm)
+		}
+	default:
+		return nil, errors.Errorf("unsupported type of the private key %T", private)
+	}
+	key.private = private.(crypto.Signer)
+	return key, nil
+}
+
+// JWKSHandler returns a handler that publishes the public keys of the keyset.
+func JWKSHandler(keySet *KeySet) routing.Handler {
+	return func(c *routing.Context) error {
+		return c.Write(keySet.JWKS())
+	}
+}
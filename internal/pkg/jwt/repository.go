@@ -7,14 +7,18 @@ import (
 	"redditclone/internal/pkg/apperror"
 	"redditclone/internal/pkg/auth"
 	"strconv"
+	"time"
 )
 
+// Repository issues and parses the tokens signed by the keys of the keyset.
 type Repository struct {
+	keySet *KeySet
 }
 
 type Token struct {
 	Data   auth.TokenData
 	claims claims
+	keySet *KeySet
 }
 
 type claims struct {
@@ -25,26 +29,41 @@ type claims struct {
 var _ auth.TokenRepository = (*Repository)(nil)
 var _ auth.Token = (*Token)(nil)
 
-func NewRepository() *Repository {
-	return &Repository{}
+func NewRepository(keySet *KeySet) *Repository {
+	return &Repository{
+		keySet: keySet,
+	}
 }
 
 func (r Repository) NewTokenByData(data auth.TokenData) auth.Token {
 	return &Token{
-		Data: data,
+		Data:   data,
+		keySet: r.keySet,
 		claims: claims{
 			TokenData: data,
 			StandardClaims: jwt.StandardClaims{
+				Id:        data.ID,
 				Subject:   strconv.Itoa(int(data.UserID)),
+				IssuedAt:  data.IssuedAt.Unix(),
 				ExpiresAt: data.ExpirationTokenTime.Unix(),
 			},
 		},
 	}
 }
 
-func (r Repository) ParseStringToken(tokenString string, signingKey string) (auth.Token, error) {
+// ParseStringToken parses the token and verifies it by the key from its "kid" header.
+// The token must be signed by the algorithm of the key, so a public key can not be used as an HMAC secret.
+func (r Repository) ParseStringToken(tokenString string) (auth.Token, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(signingKey), nil
+		kid, _ := token.Header["kid"].(string)
+		key, ok := r.keySet.Get(kid)
+		if !ok {
+			return nil, errors.Errorf("unknown key %q", kid)
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, errors.Errorf("the key %q is for %v, but the token is signed by %v", kid, key.Algorithm, token.Method.Alg())
+		}
+		return key.verifyKey(), nil
 	})
 	if err != nil {
 		return nil, errors.Wrapf(apperror.ErrBadRequest, "Repository.ParseToken error: %v", err)
@@ -61,16 +80,30 @@ func (r Repository) ParseStringToken(tokenString string, signingKey string) (aut
 
 	return &Token{
 		claims: *claims,
+		keySet: r.keySet,
 		Data: auth.TokenData{
+			ID:                  claims.Id,
+			IssuedAt:            time.Unix(claims.StandardClaims.IssuedAt, 0),
+			SessionID:           claims.SessionID,
+			Purpose:             claims.Purpose,
 			UserID:              claims.UserID,
 			UserName:            claims.UserName,
+			Role:                claims.Role,
 			ExpirationTokenTime: claims.ExpirationTokenTime,
 		},
 	}, nil
 }
 
-func (t Token) GenerateStringToken(signingKey string) (string, error) {
-	return jwt.NewWithClaims(jwt.SigningMethodHS256, t.claims).SignedString([]byte(signingKey))
+// GenerateStringToken signs the token by the signing key of the keyset.
+func (t Token) GenerateStringToken() (string, error) {
+	key, err := t.keySet.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.signingMethod(), t.claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signKey())
 }
 
 func (t Token) GetData() auth.TokenData {
@@ -80,12 +113,3 @@ func (t Token) GetData() auth.TokenData {
 func (t Token) Valid() error {
 	return t.claims.Valid()
 }
-
-//  generates a JWT that encodes an identity.
-/*func (r SessionRepository) generateJWT(user *user.User) (string, error) {
-	return jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":   user.ID,
-		"name": user.Name,
-		"exp":  time.Now().Add(time.Duration(r.tokenExpiration) * time.Hour).Unix(),
-	}).SignedString([]byte(r.signingKey))
-}*/
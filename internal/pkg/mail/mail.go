@@ -0,0 +1,107 @@
+// Package mail sends the email messages of the app, e.g. the password reset links.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/minipkg/log"
+
+	"redditclone/internal/pkg/apperror"
+)
+
+// The types of the senders
+const (
+	SenderLog  = "log"
+	SenderFile = "file"
+)
+
+// defaultFrom is the sender address if it is not configured
+const defaultFrom = "noreply@localhost"
+
+// Message is an email message.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender sends the email messages.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config is the config of the sender.
+type Config struct {
+	// Sender is "log" to write the messages to the log or "file" to write them to the files in Dir. Defaults to "log"
+	Sender string
+	// From is the sender address
+	From string
+	// Dir is the directory of the messages of the file sender
+	Dir string
+}
+
+// NewSender creates the sender of the config.
+func NewSender(cfg Config, logger log.ILogger) (Sender, error) {
+	if cfg.From == "" {
+		cfg.From = defaultFrom
+	}
+
+	switch cfg.Sender {
+	case SenderLog, "":
+		return &LogSender{
+			from:   cfg.From,
+			logger: logger,
+		}, nil
+	case SenderFile:
+		if cfg.Dir == "" {
+			return nil, errors.Wrap(apperror.ErrBadRequest, "the directory of the file mail sender is not set")
+		}
+		return &FileSender{
+			from: cfg.From,
+			dir:  cfg.Dir,
+		}, nil
+	}
+	return nil, errors.Wrapf(apperror.ErrBadRequest, "unknown mail sender %q, supported ones are %v, %v", cfg.Sender, SenderLog, SenderFile)
+}
+
+// LogSender writes the messages to the log instead of sending them, it is for local use.
+type LogSender struct {
+	from   string
+	logger log.ILogger
+}
+
+// Send writes the message to the log.
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	s.logger.With(ctx, "from", s.from, "to", msg.To).Infof("mail %q: %v", msg.Subject, msg.Body)
+	return nil
+}
+
+// FileSender writes each message to a new .eml file of the directory instead of sending it, it is for local use.
+type FileSender struct {
+	from string
+	dir  string
+}
+
+// Send writes the message to a file.
+func (s *FileSender) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "can not create the mail directory %q: %v", s.dir, err)
+	}
+
+	now := time.Now()
+	data := fmt.Sprintf("From: %v\r\nTo: %v\r\nSubject: %v\r\nDate: %v\r\n\r\n%v\r\n", s.from, msg.To, msg.Subject, now.Format(time.RFC1123Z), msg.Body)
+	path := filepath.Join(s.dir, now.Format("20060102-150405")+"-"+uuid.New().String()+".eml")
+
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		return errors.Wrapf(apperror.ErrInternal, "can not write the mail file %q: %v", path, err)
+	}
+	return nil
+}
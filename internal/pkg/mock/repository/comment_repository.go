@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/minipkg/selection_condition"
 	"github.com/stretchr/testify/mock"
@@ -16,9 +17,9 @@ type CommentRepository struct {
 
 var _ comment.Repository = (*CommentRepository)(nil)
 
-func (m CommentRepository) SetDefaultConditions(conditions selection_condition.SelectionCondition) {}
+func (m *CommentRepository) SetDefaultConditions(conditions selection_condition.SelectionCondition) {}
 
-func (m CommentRepository) Get(a0 context.Context, a1 string) (*comment.Comment, error) {
+func (m *CommentRepository) Get(a0 context.Context, a1 string) (*comment.Comment, error) {
 	ret := m.Called(a0, a1)
 
 	var r0 *comment.Comment
@@ -40,7 +41,7 @@ func (m CommentRepository) Get(a0 context.Context, a1 string) (*comment.Comment,
 	return r0, r1
 }
 
-func (m CommentRepository) Query(a0 context.Context, a1 selection_condition.SelectionCondition) ([]comment.Comment, error) {
+func (m *CommentRepository) Query(a0 context.Context, a1 selection_condition.SelectionCondition) ([]comment.Comment, error) {
 	ret := m.Called(a0, a1)
 
 	var r0 []comment.Comment
@@ -62,7 +63,7 @@ func (m CommentRepository) Query(a0 context.Context, a1 selection_condition.Sele
 	return r0, r1
 }
 
-func (m CommentRepository) Create(a0 context.Context, a1 *comment.Comment) error {
+func (m *CommentRepository) Create(a0 context.Context, a1 *comment.Comment) error {
 	ret := m.Called(a0, a1)
 
 	var r0 error
@@ -75,7 +76,7 @@ func (m CommentRepository) Create(a0 context.Context, a1 *comment.Comment) error
 	return r0
 }
 
-func (m CommentRepository) Update(a0 context.Context, a1 *comment.Comment) error {
+func (m *CommentRepository) Update(a0 context.Context, a1 *comment.Comment) error {
 	ret := m.Called(a0, a1)
 
 	var r0 error
@@ -88,7 +89,7 @@ func (m CommentRepository) Update(a0 context.Context, a1 *comment.Comment) error
 	return r0
 }
 
-func (m CommentRepository) Delete(a0 context.Context, a1 string) error {
+func (m *CommentRepository) Delete(a0 context.Context, a1 string) error {
 	ret := m.Called(a0, a1)
 
 	var r0 error
@@ -100,3 +101,102 @@ func (m CommentRepository) Delete(a0 context.Context, a1 string) error {
 
 	return r0
 }
+
+func (m *CommentRepository) Restore(a0 context.Context, a1 string) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *CommentRepository) Purge(a0 context.Context, a1 time.Time) (uint, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) uint); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *CommentRepository) ChangeScore(a0 context.Context, a1 string, a2 int) error {
+	ret := m.Called(a0, a1, a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) error); ok {
+		r0 = rf(a0, a1, a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *CommentRepository) SetScore(a0 context.Context, a1 string, a2 int) error {
+	ret := m.Called(a0, a1, a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) error); ok {
+		r0 = rf(a0, a1, a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *CommentRepository) AnonymizeAuthor(a0 context.Context, a1 uint) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *CommentRepository) AuthorStats(a0 context.Context, a1 uint) (uint, int, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, uint) uint); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, uint) int); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, uint) error); ok {
+		r2 = rf(a0, a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/minipkg/selection_condition"
+	"github.com/stretchr/testify/mock"
+
+	"redditclone/internal/domain/community"
+)
+
+// CommunityRepository is a mock for CommunityRepository
+type CommunityRepository struct {
+	mock.Mock
+}
+
+var _ community.Repository = (*CommunityRepository)(nil)
+
+func (m *CommunityRepository) SetDefaultConditions(conditions selection_condition.SelectionCondition) {
+}
+
+func (m *CommunityRepository) Get(a0 context.Context, a1 string) (*community.Community, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 *community.Community
+	if rf, ok := ret.Get(0).(func(context.Context, string) *community.Community); ok {
+		r0 = rf(a0, a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*community.Community)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *CommunityRepository) Query(a0 context.Context, a1 selection_condition.SelectionCondition) ([]community.Community, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 []community.Community
+	if rf, ok := ret.Get(0).(func(context.Context, selection_condition.SelectionCondition) []community.Community); ok {
+		r0 = rf(a0, a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]community.Community)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, selection_condition.SelectionCondition) error); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *CommunityRepository) Search(a0 context.Context, a1 string, a2 uint, a3 uint) ([]community.Community, error) {
+	ret := m.Called(a0, a1, a2, a3)
+
+	var r0 []community.Community
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint, uint) []community.Community); ok {
+		r0 = rf(a0, a1, a2, a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]community.Community)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, uint, uint) error); ok {
+		r1 = rf(a0, a1, a2, a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *CommunityRepository) Create(a0 context.Context, a1 *community.Community) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *community.Community) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *CommunityRepository) Subscribe(a0 context.Context, a1 *community.Subscription) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *community.Subscription) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *CommunityRepository) Unsubscribe(a0 context.Context, a1 string, a2 uint) error {
+	ret := m.Called(a0, a1, a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint) error); ok {
+		r0 = rf(a0, a1, a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *CommunityRepository) Subscriptions(a0 context.Context, a1 uint) ([]community.Community, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 []community.Community
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []community.Community); ok {
+		r0 = rf(a0, a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]community.Community)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *CommunityRepository) CreateBan(a0 context.Context, a1 *community.Ban) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *community.Ban) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *CommunityRepository) DeleteBan(a0 context.Context, a1 string, a2 uint) error {
+	ret := m.Called(a0, a1, a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint) error); ok {
+		r0 = rf(a0, a1, a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *CommunityRepository) GetBan(a0 context.Context, a1 string, a2 uint) (*community.Ban, error) {
+	ret := m.Called(a0, a1, a2)
+
+	var r0 *community.Ban
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint) *community.Ban); ok {
+		r0 = rf(a0, a1, a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*community.Ban)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, uint) error); ok {
+		r1 = rf(a0, a1, a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *CommunityRepository) Bans(a0 context.Context, a1 string) ([]community.Ban, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 []community.Ban
+	if rf, ok := ret.Get(0).(func(context.Context, string) []community.Ban); ok {
+		r0 = rf(a0, a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]community.Ban)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+
+	"redditclone/internal/domain/feed"
+)
+
+// FollowRepository is a mock for FollowRepository
+type FollowRepository struct {
+	mock.Mock
+}
+
+var _ feed.Repository = (*FollowRepository)(nil)
+
+func (m *FollowRepository) Follow(a0 context.Context, a1 *feed.Follow) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *feed.Follow) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *FollowRepository) Unfollow(a0 context.Context, a1 uint, a2 uint) error {
+	ret := m.Called(a0, a1, a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) error); ok {
+		r0 = rf(a0, a1, a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *FollowRepository) Following(a0 context.Context, a1 uint) ([]feed.Follow, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 []feed.Follow
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []feed.Follow); ok {
+		r0 = rf(a0, a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]feed.Follow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
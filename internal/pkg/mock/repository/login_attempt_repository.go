@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"redditclone/internal/pkg/auth"
+)
+
+// LoginAttemptRepository is a mock for LoginAttemptRepository
+type LoginAttemptRepository struct {
+	mock.Mock
+}
+
+var _ auth.LoginAttemptRepository = (*LoginAttemptRepository)(nil)
+
+func (m *LoginAttemptRepository) AddAttempt(a0 context.Context, a1 string, a2 time.Time, a3 time.Duration) (uint, error) {
+	ret := m.Called(a0, a1, a2, a3)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Duration) uint); ok {
+		r0 = rf(a0, a1, a2, a3)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, time.Duration) error); ok {
+		r1 = rf(a0, a1, a2, a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *LoginAttemptRepository) ResetAttempts(a0 context.Context, a1 string) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *LoginAttemptRepository) CountLockout(a0 context.Context, a1 string, a2 time.Duration) (uint, error) {
+	ret := m.Called(a0, a1, a2)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) uint); ok {
+		r0 = rf(a0, a1, a2)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration) error); ok {
+		r1 = rf(a0, a1, a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *LoginAttemptRepository) Lock(a0 context.Context, a1 string, a2 time.Duration) error {
+	ret := m.Called(a0, a1, a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) error); ok {
+		r0 = rf(a0, a1, a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *LoginAttemptRepository) LockedFor(a0 context.Context, a1 string) (time.Duration, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 time.Duration
+	if rf, ok := ret.Get(0).(func(context.Context, string) time.Duration); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
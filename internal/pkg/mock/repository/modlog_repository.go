@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/minipkg/selection_condition"
+	"github.com/stretchr/testify/mock"
+
+	"redditclone/internal/domain/modlog"
+)
+
+// ModLogRepository is a mock for ModLogRepository
+type ModLogRepository struct {
+	mock.Mock
+}
+
+var _ modlog.Repository = (*ModLogRepository)(nil)
+
+func (m *ModLogRepository) Create(a0 context.Context, a1 *modlog.Entry) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *modlog.Entry) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *ModLogRepository) Query(a0 context.Context, a1 selection_condition.SelectionCondition) ([]modlog.Entry, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 []modlog.Entry
+	if rf, ok := ret.Get(0).(func(context.Context, selection_condition.SelectionCondition) []modlog.Entry); ok {
+		r0 = rf(a0, a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]modlog.Entry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, selection_condition.SelectionCondition) error); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
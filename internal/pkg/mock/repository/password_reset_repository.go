@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"redditclone/internal/pkg/auth"
+)
+
+// PasswordResetRepository is a mock for PasswordResetRepository
+type PasswordResetRepository struct {
+	mock.Mock
+}
+
+var _ auth.PasswordResetRepository = (*PasswordResetRepository)(nil)
+
+func (m *PasswordResetRepository) Create(a0 context.Context, a1 string, a2 uint, a3 time.Duration) error {
+	ret := m.Called(a0, a1, a2, a3)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint, time.Duration) error); ok {
+		r0 = rf(a0, a1, a2, a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *PasswordResetRepository) Take(a0 context.Context, a1 string) (uint, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, string) uint); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/minipkg/selection_condition"
 	"github.com/stretchr/testify/mock"
@@ -16,9 +17,9 @@ type PostRepository struct {
 
 var _ post.Repository = (*PostRepository)(nil)
 
-func (m PostRepository) SetDefaultConditions(conditions selection_condition.SelectionCondition) {}
+func (m *PostRepository) SetDefaultConditions(conditions selection_condition.SelectionCondition) {}
 
-func (m PostRepository) Get(a0 context.Context, a1 string) (*post.Post, error) {
+func (m *PostRepository) Get(a0 context.Context, a1 string) (*post.Post, error) {
 	ret := m.Called(a0, a1)
 
 	var r0 *post.Post
@@ -40,7 +41,7 @@ func (m PostRepository) Get(a0 context.Context, a1 string) (*post.Post, error) {
 	return r0, r1
 }
 
-func (m PostRepository) Query(a0 context.Context, a1 selection_condition.SelectionCondition) ([]post.Post, error) {
+func (m *PostRepository) Query(a0 context.Context, a1 selection_condition.SelectionCondition) ([]post.Post, error) {
 	ret := m.Called(a0, a1)
 
 	var r0 []post.Post
@@ -62,7 +63,7 @@ func (m PostRepository) Query(a0 context.Context, a1 selection_condition.Selecti
 	return r0, r1
 }
 
-func (m PostRepository) Create(a0 context.Context, a1 *post.Post) error {
+func (m *PostRepository) Create(a0 context.Context, a1 *post.Post) error {
 	ret := m.Called(a0, a1)
 
 	var r0 error
@@ -75,7 +76,7 @@ func (m PostRepository) Create(a0 context.Context, a1 *post.Post) error {
 	return r0
 }
 
-func (m PostRepository) Update(a0 context.Context, a1 *post.Post) error {
+func (m *PostRepository) Update(a0 context.Context, a1 *post.Post) error {
 	ret := m.Called(a0, a1)
 
 	var r0 error
@@ -88,7 +89,7 @@ func (m PostRepository) Update(a0 context.Context, a1 *post.Post) error {
 	return r0
 }
 
-func (m PostRepository) Delete(a0 context.Context, a1 string) error {
+func (m *PostRepository) Delete(a0 context.Context, a1 string) error {
 	ret := m.Called(a0, a1)
 
 	var r0 error
@@ -100,3 +101,220 @@ func (m PostRepository) Delete(a0 context.Context, a1 string) error {
 
 	return r0
 }
+
+func (m *PostRepository) Restore(a0 context.Context, a1 string) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *PostRepository) Purge(a0 context.Context, a1 time.Time) (uint, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) uint); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *PostRepository) CreateRevision(a0 context.Context, a1 *post.Revision) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *post.Revision) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *PostRepository) QueryRevisions(a0 context.Context, a1 string) ([]post.Revision, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 []post.Revision
+	if rf, ok := ret.Get(0).(func(context.Context, string) []post.Revision); ok {
+		r0 = rf(a0, a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]post.Revision)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *PostRepository) IncrViews(a0 context.Context, a1 string) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *PostRepository) ChangeScore(a0 context.Context, a1 string, a2 int, a3 int) error {
+	ret := m.Called(a0, a1, a2, a3)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) error); ok {
+		r0 = rf(a0, a1, a2, a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *PostRepository) SetScore(a0 context.Context, a1 *post.Post) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *post.Post) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *PostRepository) AnonymizeAuthor(a0 context.Context, a1 uint) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *PostRepository) AuthorStats(a0 context.Context, a1 uint) (uint, int, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, uint) uint); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(context.Context, uint) int); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, uint) error); ok {
+		r2 = rf(a0, a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+func (m *PostRepository) CreateMark(a0 context.Context, a1 *post.Mark) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *post.Mark) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *PostRepository) DeleteMark(a0 context.Context, a1 *post.Mark) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *post.Mark) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *PostRepository) QueryMarks(a0 context.Context, a1 selection_condition.SelectionCondition) ([]post.Mark, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 []post.Mark
+	if rf, ok := ret.Get(0).(func(context.Context, selection_condition.SelectionCondition) []post.Mark); ok {
+		r0 = rf(a0, a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]post.Mark)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, selection_condition.SelectionCondition) error); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *PostRepository) MarkedIDs(a0 context.Context, a1 uint, a2 string) ([]string, error) {
+	ret := m.Called(a0, a1, a2)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) []string); ok {
+		r0 = rf(a0, a1, a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string) error); ok {
+		r1 = rf(a0, a1, a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/minipkg/selection_condition"
+	"github.com/stretchr/testify/mock"
+
+	"redditclone/internal/domain/report"
+)
+
+// ReportRepository is a mock for ReportRepository
+type ReportRepository struct {
+	mock.Mock
+}
+
+var _ report.Repository = (*ReportRepository)(nil)
+
+func (m *ReportRepository) Create(a0 context.Context, a1 *report.Report) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *report.Report) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *ReportRepository) AddToQueue(a0 context.Context, a1 *report.Report, a2 *report.Item) error {
+	ret := m.Called(a0, a1, a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *report.Report, *report.Item) error); ok {
+		r0 = rf(a0, a1, a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *ReportRepository) GetItem(a0 context.Context, a1 string) (*report.Item, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 *report.Item
+	if rf, ok := ret.Get(0).(func(context.Context, string) *report.Item); ok {
+		r0 = rf(a0, a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*report.Item)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *ReportRepository) QueryItems(a0 context.Context, a1 selection_condition.SelectionCondition) ([]report.Item, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 []report.Item
+	if rf, ok := ret.Get(0).(func(context.Context, selection_condition.SelectionCondition) []report.Item); ok {
+		r0 = rf(a0, a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]report.Item)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, selection_condition.SelectionCondition) error); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *ReportRepository) Resolve(a0 context.Context, a1 string, a2 string, a3 *report.Resolution) error {
+	ret := m.Called(a0, a1, a2, a3)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *report.Resolution) error); ok {
+		r0 = rf(a0, a1, a2, a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *ReportRepository) Counts(a0 context.Context, a1 string, a2 []string) (map[string]uint, error) {
+	ret := m.Called(a0, a1, a2)
+
+	var r0 map[string]uint
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) map[string]uint); ok {
+		r0 = rf(a0, a1, a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]uint)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(a0, a1, a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -17,10 +17,10 @@ type SessionRepository struct {
 
 var _ auth.SessionRepository = (*SessionRepository)(nil)
 
-func (m SessionRepository) SetDefaultConditions(defaultConditions selection_condition.SelectionCondition) {
+func (m *SessionRepository) SetDefaultConditions(defaultConditions selection_condition.SelectionCondition) {
 }
 
-func (m SessionRepository) NewEntity(a0 context.Context, a1 uint) (*session.Session, error) {
+func (m *SessionRepository) NewEntity(a0 context.Context, a1 uint) (*session.Session, error) {
 	ret := m.Called(a0, a1)
 
 	var r0 *session.Session
@@ -42,11 +42,11 @@ func (m SessionRepository) NewEntity(a0 context.Context, a1 uint) (*session.Sess
 	return r0, r1
 }
 
-func (m SessionRepository) Get(a0 context.Context, a1 uint) (*session.Session, error) {
+func (m *SessionRepository) Get(a0 context.Context, a1 string) (*session.Session, error) {
 	ret := m.Called(a0, a1)
 
 	var r0 *session.Session
-	if rf, ok := ret.Get(0).(func(context.Context, uint) *session.Session); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) *session.Session); ok {
 		r0 = rf(a0, a1)
 	} else {
 		if ret.Get(0) != nil {
@@ -54,6 +54,28 @@ func (m SessionRepository) Get(a0 context.Context, a1 uint) (*session.Session, e
 		}
 	}
 
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(a0, a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *SessionRepository) QueryByUserID(a0 context.Context, a1 uint) ([]session.Session, error) {
+	ret := m.Called(a0, a1)
+
+	var r0 []session.Session
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []session.Session); ok {
+		r0 = rf(a0, a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]session.Session)
+		}
+	}
+
 	var r1 error
 	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
 		r1 = rf(a0, a1)
@@ -64,7 +86,7 @@ func (m SessionRepository) Get(a0 context.Context, a1 uint) (*session.Session, e
 	return r0, r1
 }
 
-func (m SessionRepository) Create(a0 context.Context, a1 *session.Session) error {
+func (m *SessionRepository) Create(a0 context.Context, a1 *session.Session) error {
 	ret := m.Called(a0, a1)
 
 	var r0 error
@@ -77,7 +99,7 @@ func (m SessionRepository) Create(a0 context.Context, a1 *session.Session) error
 	return r0
 }
 
-func (m SessionRepository) Update(a0 context.Context, a1 *session.Session) error {
+func (m *SessionRepository) Update(a0 context.Context, a1 *session.Session) error {
 	ret := m.Called(a0, a1)
 
 	var r0 error
@@ -90,7 +112,17 @@ func (m SessionRepository) Update(a0 context.Context, a1 *session.Session) error
 	return r0
 }
 
-func (m SessionRepository) Save(a0 *session.Session) error {
+// Modify applies the modification to the session returned by the mocked call
+func (m *SessionRepository) Modify(a0 context.Context, a1 string, a2 func(*session.Session) error) error {
+	ret := m.Called(a0, a1)
+
+	if err := ret.Error(1); err != nil {
+		return err
+	}
+	return a2(ret.Get(0).(*session.Session))
+}
+
+func (m *SessionRepository) Save(a0 *session.Session) error {
 	ret := m.Called(a0)
 
 	var r0 error
@@ -103,7 +135,7 @@ func (m SessionRepository) Save(a0 *session.Session) error {
 	return r0
 }
 
-func (m SessionRepository) Delete(a0 context.Context, a1 *session.Session) error {
+func (m *SessionRepository) Delete(a0 context.Context, a1 *session.Session) error {
 	ret := m.Called(a0, a1)
 
 	var r0 error
@@ -116,7 +148,20 @@ func (m SessionRepository) Delete(a0 context.Context, a1 *session.Session) error
 	return r0
 }
 
-func (m SessionRepository) GetData(a0 *session.Session) session.Data {
+func (m *SessionRepository) DeleteByUserID(a0 context.Context, a1 uint) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *SessionRepository) GetData(a0 *session.Session) session.Data {
 	ret := m.Called(a0)
 
 	var r0 session.Data
@@ -131,7 +176,7 @@ func (m SessionRepository) GetData(a0 *session.Session) session.Data {
 	return r0
 }
 
-func (m SessionRepository) SetData(a0 *session.Session, a1 session.Data) error {
+func (m *SessionRepository) SetData(a0 *session.Session, a1 session.Data) error {
 	ret := m.Called(a0, a1)
 
 	var r0 error
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"redditclone/internal/domain/feed"
+	"redditclone/internal/domain/post"
+)
+
+// TimelineRepository is a mock for TimelineRepository
+type TimelineRepository struct {
+	mock.Mock
+}
+
+var _ feed.TimelineRepository = (*TimelineRepository)(nil)
+
+func (m *TimelineRepository) Get(a0 context.Context, a1 uint, a2 string) ([]post.Post, error) {
+	ret := m.Called(a0, a1, a2)
+
+	var r0 []post.Post
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) []post.Post); ok {
+		r0 = rf(a0, a1, a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]post.Post)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string) error); ok {
+		r1 = rf(a0, a1, a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *TimelineRepository) Set(a0 context.Context, a1 uint, a2 string, a3 []post.Post, a4 time.Duration) error {
+	ret := m.Called(a0, a1, a2, a3, a4)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string, []post.Post, time.Duration) error); ok {
+		r0 = rf(a0, a1, a2, a3, a4)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *TimelineRepository) Delete(a0 context.Context, a1 uint) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -16,9 +16,9 @@ type UserRepository struct {
 
 var _ user.Repository = (*UserRepository)(nil)
 
-func (m UserRepository) SetDefaultConditions(conditions *selection_condition.SelectionCondition) {}
+func (m *UserRepository) SetDefaultConditions(conditions *selection_condition.SelectionCondition) {}
 
-func (m UserRepository) Get(a0 context.Context, a1 uint) (*user.User, error) {
+func (m *UserRepository) Get(a0 context.Context, a1 uint) (*user.User, error) {
 	ret := m.Called(a0, a1)
 
 	var r0 *user.User
@@ -40,7 +40,7 @@ func (m UserRepository) Get(a0 context.Context, a1 uint) (*user.User, error) {
 	return r0, r1
 }
 
-func (m UserRepository) First(a0 context.Context, a1 *user.User) (*user.User, error) {
+func (m *UserRepository) First(a0 context.Context, a1 *user.User) (*user.User, error) {
 	ret := m.Called(a0, a1)
 
 	var r0 *user.User
@@ -62,7 +62,7 @@ func (m UserRepository) First(a0 context.Context, a1 *user.User) (*user.User, er
 	return r0, r1
 }
 
-func (m UserRepository) Query(a0 context.Context, a1 *selection_condition.SelectionCondition) ([]user.User, error) {
+func (m *UserRepository) Query(a0 context.Context, a1 *selection_condition.SelectionCondition) ([]user.User, error) {
 	ret := m.Called(a0, a1)
 
 	var r0 []user.User
@@ -84,7 +84,7 @@ func (m UserRepository) Query(a0 context.Context, a1 *selection_condition.Select
 	return r0, r1
 }
 
-func (m UserRepository) Create(a0 context.Context, a1 *user.User) error {
+func (m *UserRepository) Create(a0 context.Context, a1 *user.User) error {
 	ret := m.Called(a0, a1)
 
 	var r0 error
@@ -96,3 +96,64 @@ func (m UserRepository) Create(a0 context.Context, a1 *user.User) error {
 
 	return r0
 }
+
+func (m *UserRepository) Update(a0 context.Context, a1 *user.User) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *user.User) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *UserRepository) Delete(a0 context.Context, a1 uint) error {
+	ret := m.Called(a0, a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(a0, a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+func (m *UserRepository) QueryWithDeleted(a0 context.Context, a1 uint, a2 uint) ([]user.User, error) {
+	ret := m.Called(a0, a1, a2)
+
+	var r0 []user.User
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) []user.User); ok {
+		r0 = rf(a0, a1, a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]user.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(a0, a1, a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+func (m *UserRepository) SetNameNormalized(a0 context.Context, a1 uint, a2 string) error {
+	ret := m.Called(a0, a1, a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) error); ok {
+		r0 = rf(a0, a1, a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -16,9 +16,9 @@ type VoteRepository struct {
 
 var _ vote.Repository = (*VoteRepository)(nil)
 
-func (m VoteRepository) SetDefaultConditions(conditions selection_condition.SelectionCondition) {}
+func (m *VoteRepository) SetDefaultConditions(conditions selection_condition.SelectionCondition) {}
 
-func (m VoteRepository) Get(a0 context.Context, a1 string) (*vote.Vote, error) {
+func (m *VoteRepository) Get(a0 context.Context, a1 string) (*vote.Vote, error) {
 	ret := m.Called(a0, a1)
 
 	var r0 *vote.Vote
@@ -40,7 +40,7 @@ func (m VoteRepository) Get(a0 context.Context, a1 string) (*vote.Vote, error) {
 	return r0, r1
 }
 
-func (m VoteRepository) Query(a0 context.Context, a1 selection_condition.SelectionCondition) ([]vote.Vote, error) {
+func (m *VoteRepository) Query(a0 context.Context, a1 selection_condition.SelectionCondition) ([]vote.Vote, error) {
 	ret := m.Called(a0, a1)
 
 	var r0 []vote.Vote
@@ -62,7 +62,7 @@ func (m VoteRepository) Query(a0 context.Context, a1 selection_condition.Selecti
 	return r0, r1
 }
 
-func (m VoteRepository) First(a0 context.Context, a1 *vote.Vote) (*vote.Vote, error) {
+func (m *VoteRepository) First(a0 context.Context, a1 *vote.Vote) (*vote.Vote, error) {
 	ret := m.Called(a0, a1)
 
 	var r0 *vote.Vote
@@ -84,7 +84,7 @@ func (m VoteRepository) First(a0 context.Context, a1 *vote.Vote) (*vote.Vote, er
 	return r0, r1
 }
 
-func (m VoteRepository) Create(a0 context.Context, a1 *vote.Vote) error {
+func (m *VoteRepository) Create(a0 context.Context, a1 *vote.Vote) error {
 	ret := m.Called(a0, a1)
 
 	var r0 error
@@ -97,7 +97,7 @@ func (m VoteRepository) Create(a0 context.Context, a1 *vote.Vote) error {
 	return r0
 }
 
-func (m VoteRepository) Update(a0 context.Context, a1 *vote.Vote) error {
+func (m *VoteRepository) Update(a0 context.Context, a1 *vote.Vote) error {
 	ret := m.Called(a0, a1)
 
 	var r0 error
@@ -110,7 +110,7 @@ func (m VoteRepository) Update(a0 context.Context, a1 *vote.Vote) error {
 	return r0
 }
 
-func (m VoteRepository) Delete(a0 context.Context, a1 string) error {
+func (m *VoteRepository) Delete(a0 context.Context, a1 string) error {
 	ret := m.Called(a0, a1)
 
 	var r0 error
@@ -122,3 +122,16 @@ func (m VoteRepository) Delete(a0 context.Context, a1 string) error {
 
 	return r0
 }
+
+func (m *VoteRepository) UpdateValue(a0 context.Context, a1 *vote.Vote, a2 int) error {
+	ret := m.Called(a0, a1, a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *vote.Vote, int) error); ok {
+		r0 = rf(a0, a1, a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,77 @@
+package pagination
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"redditclone/internal/pkg/apperror"
+)
+
+const (
+	DefaultLimit = 25
+	MaxLimit     = 100
+)
+
+// Params are the pagination params of a request
+type Params struct {
+	After  string
+	Before string
+	Limit  uint
+}
+
+// IsEmpty returns true if no pagination params are given
+func (p Params) IsEmpty() bool {
+	return p.After == "" && p.Before == "" && p.Limit == 0
+}
+
+// GetLimit returns the page size limited by MaxLimit
+func (p Params) GetLimit() uint {
+	switch {
+	case p.Limit == 0:
+		return DefaultLimit
+	case p.Limit > MaxLimit:
+		return MaxLimit
+	}
+	return p.Limit
+}
+
+// Cursor is a position in a listing: the value of the sort key and the ID of an item.
+// The ID breaks ties between the items with the same value of the sort key.
+type Cursor struct {
+	Key   string      `json:"k"`
+	Value interface{} `json:"v"`
+	ID    string      `json:"id"`
+}
+
+// Page is the response envelope of a paginated listing
+type Page struct {
+	Items interface{} `json:"items"`
+	Next  string      `json:"next,omitempty"`
+	Prev  string      `json:"prev,omitempty"`
+}
+
+// Encode returns the opaque string representation of the cursor
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode parses the opaque string representation of a cursor.
+// Numbers are decoded as json.Number, so the caller has to convert the value to the type of the sort key.
+func Decode(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrapf(apperror.ErrBadRequest, "invalid cursor: %v", err)
+	}
+
+	c := &Cursor{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err = d.Decode(c); err != nil || c.Key == "" || c.ID == "" {
+		return nil, errors.Wrapf(apperror.ErrBadRequest, "invalid cursor: %q", s)
+	}
+	return c, nil
+}
@@ -0,0 +1,194 @@
+// Package password hashes passwords and verifies them against the stored hashes.
+//
+// New hashes are strings of the PHC format ("$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>") or of the bcrypt one ("$2a$10$...").
+// The legacy hashes are 96 raw bytes: a 64 bytes salt and a PBKDF2-SHA256 key of 10000 iterations.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+
+	"redditclone/internal/pkg/apperror"
+)
+
+// The supported algorithms of new hashes
+const (
+	AlgArgon2id = "argon2id"
+	AlgBcrypt   = "bcrypt"
+)
+
+const (
+	argon2idPrefix = "$argon2id$"
+	argon2SaltSize = 16
+	argon2KeySize  = 32
+
+	legacySaltSize   = 64
+	legacyIterations = 1e4
+	legacyHashSize   = legacySaltSize + sha256.Size
+)
+
+// Config is the config of hashing. A hash of other parameters is rehashed after a successful verification.
+type Config struct {
+	// Algorithm of new hashes: argon2id or bcrypt. Defaults to argon2id
+	Algorithm string
+	Argon2id  Argon2idConfig
+	// BcryptCost defaults to 12
+	BcryptCost int
+}
+
+// Argon2idConfig is the cost of Argon2id, the defaults follow RFC 9106.
+type Argon2idConfig struct {
+	// Time is the number of passes. Defaults to 3
+	Time uint32
+	// Memory in KiB. Defaults to 64 MiB
+	Memory uint32
+	// Threads defaults to 2
+	Threads uint8
+}
+
+// Hasher hashes passwords by the configured algorithm
+type Hasher struct {
+	cfg Config
+}
+
+// argon2idHash is a parsed Argon2id hash
+type argon2idHash struct {
+	params Argon2idConfig
+	salt   []byte
+	key    []byte
+}
+
+// NewHasher creates a new Hasher, the empty parameters of the config get the defaults.
+func NewHasher(cfg Config) (*Hasher, error) {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = AlgArgon2id
+	}
+	if cfg.Algorithm != AlgArgon2id && cfg.Algorithm != AlgBcrypt {
+		return nil, errors.Wrapf(apperror.ErrBadRequest, "unsupported password hashing algorithm %q", cfg.Algorithm)
+	}
+	if cfg.Argon2id.Time == 0 {
+		cfg.Argon2id.Time = 3
+	}
+	if cfg.Argon2id.Memory == 0 {
+		cfg.Argon2id.Memory = 64 * 1024
+	}
+	if cfg.Argon2id.Threads == 0 {
+		cfg.Argon2id.Threads = 2
+	}
+	if cfg.BcryptCost == 0 {
+		cfg.BcryptCost = 12
+	}
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		return nil, errors.Wrapf(apperror.ErrBadRequest, "bcrypt cost %v is out of range [%v, %v]", cfg.BcryptCost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return &Hasher{cfg: cfg}, nil
+}
+
+// Hash returns the hash of the password by the configured algorithm.
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.cfg.Algorithm == AlgBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cfg.BcryptCost)
+		if err != nil {
+			return "", errors.Wrapf(apperror.ErrInternal, "bcrypt error: %v", err)
+		}
+		return string(hash), nil
+	}
+
+	salt := make([]byte, argon2SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.Wrapf(apperror.ErrInternal, "could not get salt: %v", err)
+	}
+	p := h.cfg.Argon2id
+	return argon2idHash{
+		params: p,
+		salt:   salt,
+		key:    argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, argon2KeySize),
+	}.String(), nil
+}
+
+// Verify checks the password against the hash. needsRehash is true if the password is right,
+// but the hash is made by another algorithm or with other parameters than the configured ones.
+func (h *Hasher) Verify(hash, password string) (ok bool, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(hash, argon2idPrefix):
+		parsed, err := parseArgon2id(hash)
+		if err != nil {
+			return false, false, err
+		}
+		p := parsed.params
+		key := argon2.IDKey([]byte(password), parsed.salt, p.Time, p.Memory, p.Threads, uint32(len(parsed.key)))
+		ok = subtle.ConstantTimeCompare(key, parsed.key) == 1
+		return ok, ok && (h.cfg.Algorithm != AlgArgon2id || p != h.cfg.Argon2id), nil
+
+	case isBcrypt(hash):
+		err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if err != nil {
+			if err == bcrypt.ErrMismatchedHashAndPassword {
+				return false, false, nil
+			}
+			return false, false, errors.Wrapf(apperror.ErrInternal, "bcrypt error: %v", err)
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return true, true, nil
+		}
+		return true, h.cfg.Algorithm != AlgBcrypt || cost != h.cfg.BcryptCost, nil
+
+	case len(hash) == legacyHashSize:
+		ok = subtle.ConstantTimeCompare([]byte(hash), legacyHash([]byte(password), []byte(hash[:legacySaltSize]))) == 1
+		return ok, ok, nil
+	}
+	return false, false, errors.Wrap(apperror.ErrInternal, "unknown format of the password hash")
+}
+
+func (a argon2idHash) String() string {
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, a.params.Memory, a.params.Time, a.params.Threads,
+		base64.RawStdEncoding.EncodeToString(a.salt), base64.RawStdEncoding.EncodeToString(a.key))
+}
+
+func parseArgon2id(hash string) (*argon2idHash, error) {
+	var version int
+	a := &argon2idHash{}
+
+	//	"", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return nil, errors.Wrap(apperror.ErrInternal, "malformed argon2id hash")
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, errors.Wrapf(apperror.ErrInternal, "unsupported argon2id version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &a.params.Memory, &a.params.Time, &a.params.Threads); err != nil {
+		return nil, errors.Wrapf(apperror.ErrInternal, "malformed argon2id parameters %q: %v", parts[3], err)
+	}
+
+	var err error
+	if a.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, errors.Wrapf(apperror.ErrInternal, "malformed argon2id salt: %v", err)
+	}
+	if a.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, errors.Wrapf(apperror.ErrInternal, "malformed argon2id key: %v", err)
+	}
+	return a, nil
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// legacyHash hashes the password with the salt by the PBKDF2 algorithm,
+// the result is the salt (first 64 bytes) and the hash (last 32 bytes)
+func legacyHash(pw, salt []byte) []byte {
+	ret := make([]byte, len(salt))
+	copy(ret, salt)
+	return append(ret, pbkdf2.Key(pw, salt, legacyIterations, sha256.Size, sha256.New)...)
+}
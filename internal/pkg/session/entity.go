@@ -3,6 +3,7 @@ package session
 import (
 	"context"
 	"encoding"
+	"strings"
 	"time"
 
 	"redditclone/internal/pkg/proto"
@@ -18,18 +19,31 @@ const (
 type Data struct {
 	UserID              uint
 	UserName            string
+	Role                string
+	ModeratedCategories []string
 	ExpirationTokenTime time.Time
+	// State is the state of the account, it is updated in all the sessions of the user when an admin changes it
+	State user.AccountState
 }
 
-// Session is the session entity
+// Session is the session entity. A user has a session for each device which the user has logged in from.
 type Session struct {
-	ID     uint            `gorm:"PRIMARY_KEY" json:"id"`
+	ID     string          `gorm:"PRIMARY_KEY" json:"id"`
 	UserID uint            `sql:"type:int NOT NULL REFERENCES \"user\"(id)" json:"userId"`
 	User   user.User       `gorm:"FOREIGNKEY:UserID;association_autoupdate:false" json:"author"`
 	Data   Data            `gorm:"-"`
 	Ctx    context.Context `gorm:"-"`
 	Token  string          `gorm:"type:text;unique_index;not null" json:"token"`
 
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	Device     string    `json:"device"`
+	LastSeenAt time.Time `json:"lastSeen"`
+	// RefreshSecret signs the refresh tokens of the session, it never leaves the storage
+	RefreshSecret []byte `json:"-"`
+	// RefreshGeneration is the number of the only refresh token of the session which is valid now
+	RefreshGeneration uint `json:"-"`
+
 	CreatedAt time.Time  `json:"created"`
 	UpdatedAt time.Time  `json:"updated"`
 	DeletedAt *time.Time `gorm:"INDEX" json:"deleted"`
@@ -63,7 +77,7 @@ func (e *Session) UnmarshalBinary(data []byte) (err error) {
 		return err
 	}
 
-	s, err := SessionProto2Session(*sessionProto)
+	s, err := SessionProto2Session(sessionProto)
 	if err != nil {
 		return err
 	}
@@ -71,3 +85,27 @@ func (e *Session) UnmarshalBinary(data []byte) (err error) {
 	*e = *s
 	return nil
 }
+
+// devices are the substrings of a user agent which name the device, the more specific ones go first
+var devices = []struct {
+	substr string
+	name   string
+}{
+	{"iPhone", "iPhone"},
+	{"iPad", "iPad"},
+	{"Android", "Android"},
+	{"Windows", "Windows"},
+	{"Macintosh", "Mac"},
+	{"CrOS", "Chromebook"},
+	{"Linux", "Linux"},
+}
+
+// DeviceName returns the name of the device by its user agent
+func DeviceName(userAgent string) string {
+	for _, d := range devices {
+		if strings.Contains(userAgent, d.substr) {
+			return d.name
+		}
+	}
+	return "unknown"
+}
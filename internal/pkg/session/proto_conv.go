@@ -8,21 +8,39 @@ import (
 	"redditclone/internal/domain/user"
 )
 
-func SessionProto2Session(sessionProto proto.Session) (s *Session, err error) {
-	user, err := user.UserProto2User(*sessionProto.User)
+func SessionProto2Session(sessionProto *proto.Session) (s *Session, err error) {
+	user, err := user.UserProto2User(sessionProto.User)
 	if err != nil {
 		return nil, err
 	}
-	data, err := DataProto2Data(*sessionProto.Data)
+	data, err := DataProto2Data(sessionProto.Data)
 	if err != nil {
 		return nil, err
 	}
 	s = &Session{
-		ID:     uint(sessionProto.ID),
-		UserID: uint(sessionProto.UserID),
-		Token:  sessionProto.Token,
-		User:   *user,
-		Data:   *data,
+		ID:        sessionProto.ID,
+		UserID:    uint(sessionProto.UserID),
+		Token:     sessionProto.Token,
+		User:      *user,
+		Data:      *data,
+		UserAgent: sessionProto.UserAgent,
+		IP:        sessionProto.IP,
+		Device:    sessionProto.Device,
+
+		RefreshSecret:     sessionProto.RefreshSecret,
+		RefreshGeneration: uint(sessionProto.RefreshGeneration),
+	}
+	if sessionProto.CreatedAt != nil {
+		s.CreatedAt, err = ptypes.Timestamp(sessionProto.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if sessionProto.LastSeenAt != nil {
+		s.LastSeenAt, err = ptypes.Timestamp(sessionProto.LastSeenAt)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return s, nil
 }
@@ -37,19 +55,43 @@ func Session2SessionProto(session Session) (sessionProto *proto.Session, err err
 		return nil, err
 	}
 	sessionProto = &proto.Session{
-		ID:     uint64(session.ID),
-		UserID: uint64(session.UserID),
-		Token:  session.Token,
-		User:   userProto,
-		Data:   dataProto,
+		ID:        session.ID,
+		UserID:    uint64(session.UserID),
+		Token:     session.Token,
+		User:      userProto,
+		Data:      dataProto,
+		UserAgent: session.UserAgent,
+		IP:        session.IP,
+		Device:    session.Device,
+
+		RefreshSecret:     session.RefreshSecret,
+		RefreshGeneration: uint64(session.RefreshGeneration),
+	}
+	if !session.CreatedAt.IsZero() {
+		sessionProto.CreatedAt, err = ptypes.TimestampProto(session.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !session.LastSeenAt.IsZero() {
+		sessionProto.LastSeenAt, err = ptypes.TimestampProto(session.LastSeenAt)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return sessionProto, nil
 }
 
-func DataProto2Data(dataProto proto.Data) (data *Data, err error) {
+func DataProto2Data(dataProto *proto.Data) (data *Data, err error) {
 	data = &Data{
-		UserID:   uint(dataProto.UserID),
-		UserName: dataProto.UserName,
+		UserID:              uint(dataProto.UserID),
+		UserName:            dataProto.UserName,
+		Role:                dataProto.Role,
+		ModeratedCategories: dataProto.ModeratedCategories,
+		State: user.AccountState{
+			Status: dataProto.AccountStatus,
+			Reason: dataProto.BanReason,
+		},
 	}
 	if dataProto.ExpirationTokenTime != nil {
 		data.ExpirationTokenTime, err = ptypes.Timestamp(dataProto.ExpirationTokenTime)
@@ -57,17 +99,34 @@ func DataProto2Data(dataProto proto.Data) (data *Data, err error) {
 			return nil, err
 		}
 	}
+	if dataProto.SuspendedUntil != nil {
+		until, err := ptypes.Timestamp(dataProto.SuspendedUntil)
+		if err != nil {
+			return nil, err
+		}
+		data.State.Until = &until
+	}
 	return data, nil
 }
 
 func Data2DataProto(data Data) (dataProto *proto.Data, err error) {
 	dataProto = &proto.Data{
-		UserID:   uint64(data.UserID),
-		UserName: data.UserName,
+		UserID:              uint64(data.UserID),
+		UserName:            data.UserName,
+		Role:                data.Role,
+		ModeratedCategories: data.ModeratedCategories,
+		AccountStatus:       data.State.Status,
+		BanReason:           data.State.Reason,
 	}
 	dataProto.ExpirationTokenTime, err = ptypes.TimestampProto(data.ExpirationTokenTime)
 	if err != nil {
 		return nil, err
 	}
+	if data.State.Until != nil {
+		dataProto.SuspendedUntil, err = ptypes.TimestampProto(*data.State.Until)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return dataProto, nil
 }
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/session"
+)
+
+// accountUser returns the user with the password "demo1" and an email.
+func (s *ApiTestSuite) accountUser() *user.User {
+	passhash, err := bcrypt.GenerateFromPassword([]byte("demo1"), 4)
+	require.NoError(s.T(), err)
+
+	u := &user.User{}
+	*u = *s.entities.user
+	u.Passhash = string(passhash)
+	u.Email = "demo1@example.com"
+	return u
+}
+
+func (s *ApiTestSuite) TestAccount_ChangePassword() {
+	require := require.New(s.T())
+	u := s.accountUser()
+	s.setupSession()
+	s.setupThrottle()
+
+	otherSession := session.Session{ID: "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d", UserID: u.ID}
+	s.repositoryMocks.user.On("Get", mock.Anything, u.ID).Return(u, error(nil))
+	isChanged := func(changed *user.User) bool {
+		return bcrypt.CompareHashAndPassword([]byte(changed.Passhash), []byte("demo2")) == nil
+	}
+	s.repositoryMocks.user.On("Update", mock.Anything, mock.MatchedBy(isChanged)).Return(error(nil))
+	s.repositoryMocks.session.On("QueryByUserID", mock.Anything, u.ID).Return([]session.Session{*s.entities.session, otherSession}, error(nil))
+	isOtherSession := func(sess *session.Session) bool {
+		return sess.ID == otherSession.ID
+	}
+	s.repositoryMocks.session.On("Delete", mock.Anything, mock.MatchedBy(isOtherSession)).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPut, "/api/me/password", s.token, `{"oldPassword": "demo1", "newPassword": "demo2"}`)
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	s.repositoryMocks.user.AssertNumberOfCalls(s.T(), "Update", 1)
+	s.repositoryMocks.session.AssertNumberOfCalls(s.T(), "Delete", 1)
+}
+
+func (s *ApiTestSuite) TestAccount_ChangePasswordByWrongPassword() {
+	assert := assert.New(s.T())
+	u := s.accountUser()
+	s.setupSession()
+	s.setupThrottle()
+
+	s.repositoryMocks.user.On("Get", mock.Anything, u.ID).Return(u, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPut, "/api/me/password", s.token, `{"oldPassword": "wrong", "newPassword": "demo2"}`)
+	assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(apperror.ErrInvalidCredentials.Error(), strings.TrimSpace(string(resBody)))
+	s.repositoryMocks.user.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything)
+	s.repositoryMocks.loginAttempt.AssertCalled(s.T(), "AddAttempt", mock.Anything, "login_user_demol", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestAccount_PasswordReset() {
+	require := require.New(s.T())
+	u := s.accountUser()
+	s.setupThrottle()
+
+	searchedUser := user.New()
+	searchedUser.Name = u.Name
+	var tokenHash string
+	s.repositoryMocks.user.On("First", mock.Anything, searchedUser).Return(u, error(nil))
+	s.repositoryMocks.passwordReset.On("Create", mock.Anything, mock.Anything, u.ID, mock.Anything).
+		Run(func(args mock.Arguments) {
+			tokenHash = args.String(1)
+		}).
+		Return(error(nil))
+
+	resp, resBody := s.postJSON("/api/password/reset", "", `{"username": "demo1"}`)
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.Len(s.mailBox.messages, 1)
+	require.Equal(u.Email, s.mailBox.messages[0].To)
+
+	token := regexp.MustCompile(`token=([0-9a-f]+)`).FindStringSubmatch(s.mailBox.messages[0].Body)
+	require.Len(token, 2, s.mailBox.messages[0].Body)
+	require.NotEqual(token[1], tokenHash, "the token must be stored by its hash")
+
+	s.repositoryMocks.passwordReset.On("Take", mock.Anything, tokenHash).Return(u.ID, error(nil))
+	s.repositoryMocks.user.On("Get", mock.Anything, u.ID).Return(u, error(nil))
+	isChanged := func(changed *user.User) bool {
+		return bcrypt.CompareHashAndPassword([]byte(changed.Passhash), []byte("demo2")) == nil
+	}
+	s.repositoryMocks.user.On("Update", mock.Anything, mock.MatchedBy(isChanged)).Return(error(nil))
+	s.repositoryMocks.session.On("DeleteByUserID", mock.Anything, u.ID).Return(error(nil))
+
+	resp, resBody = s.postJSON("/api/password/reset/confirm", "", `{"token": "`+token[1]+`", "password": "demo2"}`)
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	s.repositoryMocks.user.AssertNumberOfCalls(s.T(), "Update", 1)
+	s.repositoryMocks.session.AssertNumberOfCalls(s.T(), "DeleteByUserID", 1)
+}
+
+func (s *ApiTestSuite) TestAccount_PasswordResetOfUnknownUser() {
+	assert := assert.New(s.T())
+	s.setupThrottle()
+
+	searchedUser := user.New()
+	searchedUser.Name = "nobody"
+	s.repositoryMocks.user.On("First", mock.Anything, searchedUser).Return(nil, apperror.ErrNotFound)
+
+	resp, resBody := s.postJSON("/api/password/reset", "", `{"username": "nobody"}`)
+	assert.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	assert.Empty(s.mailBox.messages)
+	s.repositoryMocks.passwordReset.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestAccount_PasswordResetByInvalidToken() {
+	assert := assert.New(s.T())
+
+	s.repositoryMocks.passwordReset.On("Take", mock.Anything, mock.Anything).Return(uint(0), apperror.ErrNotFound)
+
+	resp, resBody := s.postJSON("/api/password/reset/confirm", "", `{"token": "0123456789abcdef", "password": "demo2"}`)
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(apperror.ErrInvalidResetToken.Error(), strings.TrimSpace(string(resBody)))
+	s.repositoryMocks.user.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestAccount_Delete() {
+	require := require.New(s.T())
+	u := s.accountUser()
+	s.setupSession()
+	s.setupThrottle()
+
+	s.repositoryMocks.user.On("Get", mock.Anything, u.ID).Return(u, error(nil))
+	s.repositoryMocks.post.On("AnonymizeAuthor", mock.Anything, u.ID).Return(error(nil))
+	s.repositoryMocks.comment.On("AnonymizeAuthor", mock.Anything, u.ID).Return(error(nil))
+	s.repositoryMocks.user.On("Delete", mock.Anything, u.ID).Return(error(nil))
+	s.repositoryMocks.session.On("DeleteByUserID", mock.Anything, u.ID).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodDelete, "/api/me", s.token, `{"password": "demo1"}`)
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	s.repositoryMocks.post.AssertNumberOfCalls(s.T(), "AnonymizeAuthor", 1)
+	s.repositoryMocks.comment.AssertNumberOfCalls(s.T(), "AnonymizeAuthor", 1)
+	s.repositoryMocks.user.AssertNumberOfCalls(s.T(), "Delete", 1)
+	s.repositoryMocks.session.AssertNumberOfCalls(s.T(), "DeleteByUserID", 1)
+}
+
+func (s *ApiTestSuite) TestAccount_DeleteByWrongPassword() {
+	assert := assert.New(s.T())
+	u := s.accountUser()
+	s.setupSession()
+	s.setupThrottle()
+
+	s.repositoryMocks.user.On("Get", mock.Anything, u.ID).Return(u, error(nil))
+
+	resp, _ := s.sendJSON(http.MethodDelete, "/api/me", s.token, `{"password": "wrong"}`)
+	assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "AnonymizeAuthor", mock.Anything, mock.Anything)
+	s.repositoryMocks.user.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+}
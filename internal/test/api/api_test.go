@@ -14,8 +14,10 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	"redditclone/internal/pkg/apperror"
 	"redditclone/internal/pkg/config"
 	"redditclone/internal/pkg/jwt"
+	"redditclone/internal/pkg/mail"
 	repositoryMock "redditclone/internal/pkg/mock/repository"
 
 	"github.com/minipkg/log"
@@ -23,9 +25,11 @@ import (
 	commonapp "redditclone/internal/app"
 	apiapp "redditclone/internal/app/restapi"
 	"redditclone/internal/domain/comment"
+	"redditclone/internal/domain/community"
 	"redditclone/internal/domain/post"
 	"redditclone/internal/domain/user"
 	"redditclone/internal/domain/vote"
+	inmemoryrep "redditclone/internal/infrastructure/repository/inmemory"
 )
 
 type ApiTestSuite struct {
@@ -37,36 +41,63 @@ type ApiTestSuite struct {
 	server   *httptest.Server
 	client   *http.Client
 	token    string
+	keySet   *jwt.KeySet
 	entities entities
+	//	the session created by the login and its refresh token
+	loginSession *session.Session
+	refreshToken string
 	//	only for each individual test
 	ctx             context.Context
 	repositoryMocks repositoryMocks
+	mailBox         *mailBox
 }
 
 type entities struct {
-	user    *user.User
-	post    *post.Post
-	comment *comment.Comment
-	vote    *vote.Vote
+	user      *user.User
+	post      *post.Post
+	comment   *comment.Comment
+	vote      *vote.Vote
+	session   *session.Session
+	community *community.Community
 }
 
 type repositoryMocks struct {
-	user    *repositoryMock.UserRepository
-	session *repositoryMock.SessionRepository
-	post    *repositoryMock.PostRepository
-	comment *repositoryMock.CommentRepository
-	vote    *repositoryMock.VoteRepository
+	user          *repositoryMock.UserRepository
+	session       *repositoryMock.SessionRepository
+	loginAttempt  *repositoryMock.LoginAttemptRepository
+	passwordReset *repositoryMock.PasswordResetRepository
+	post          *repositoryMock.PostRepository
+	comment       *repositoryMock.CommentRepository
+	vote          *repositoryMock.VoteRepository
+	community     *repositoryMock.CommunityRepository
+	follow        *repositoryMock.FollowRepository
+	timeline      *repositoryMock.TimelineRepository
+	report        *repositoryMock.ReportRepository
+	modLog        *repositoryMock.ModLogRepository
+}
+
+// mailBox keeps the sent messages instead of sending them.
+type mailBox struct {
+	messages []mail.Message
+}
+
+func (b *mailBox) Send(ctx context.Context, msg mail.Message) error {
+	b.messages = append(b.messages, msg)
+	return nil
 }
 
 func (s *ApiTestSuite) SetupSuite() {
 	var err error
 
 	s.cfg = config.Get4UnitTest("api")
+	//	the test client is behind the proxy 10.0.0.2 which the loopback connections come from
+	s.cfg.LoginThrottle.TrustedProxies = []string{"127.0.0.0/8", "::1", "10.0.0.2"}
 
 	s.logger, err = log.New(s.cfg.Log)
 	require.NoError(s.T(), err)
 
 	s.client = &http.Client{}
+	s.setupKeySet()
 
 	s.setupEntities()
 	s.initMocks()
@@ -98,13 +129,38 @@ func (s *ApiTestSuite) newCommonApp() *commonapp.App {
 	app.Domain.Post.Repository = s.repositoryMocks.post
 	app.Domain.Comment.Repository = s.repositoryMocks.comment
 	app.Domain.Vote.Repository = s.repositoryMocks.vote
+	app.Domain.Community.Repository = s.repositoryMocks.community
+	app.Domain.Feed.Repository = s.repositoryMocks.follow
+	app.Domain.Feed.TimelineRepository = s.repositoryMocks.timeline
+	app.Domain.Report.Repository = s.repositoryMocks.report
+	app.Domain.ModLog.Repository = s.repositoryMocks.modLog
+	app.Domain.Search.Repository = inmemoryrep.NewSearchRepository(s.repositoryMocks.post, 0)
 	app.Auth.SessionRepository = s.repositoryMocks.session
-	app.Auth.TokenRepository = jwt.NewRepository()
+	app.Auth.LoginAttemptRepository = s.repositoryMocks.loginAttempt
+	app.Auth.PasswordResetRepository = s.repositoryMocks.passwordReset
+	app.Mail = s.mailBox
+	app.Auth.KeySet = s.keySet
+	app.Auth.TokenRepository = jwt.NewRepository(s.keySet)
 
 	app.SetupServices()
 	return app
 }
 
+// setupKeySet makes the keyset of all supported algorithms, the tokens are signed by the EdDSA key.
+func (s *ApiTestSuite) setupKeySet() {
+	require := require.New(s.T())
+	s.keySet = &jwt.KeySet{}
+
+	for _, alg := range []string{jwt.AlgHS256, jwt.AlgRS256} {
+		key, err := jwt.NewKey(alg)
+		require.NoError(err)
+		s.keySet.Add(*key)
+	}
+
+	_, err := s.keySet.Rotate(jwt.AlgEdDSA, 2)
+	require.NoError(err)
+}
+
 func (s *ApiTestSuite) setupEntities() {
 	var passhash, _ = hex.DecodeString("3a73acfdb534ddded4c0109383ee3e5a66314113d1ff691aaf4b3ee073c8fc2edd06d48f0555ec3783f4c479994e3eee3433734c29b05f08be0e9739b956b88d8fe872bd0a0942214e94fd4001e757fa3b66a2b9925de2e800c55ef49baa4c03")
 	s.entities = entities{
@@ -112,6 +168,7 @@ func (s *ApiTestSuite) setupEntities() {
 			ID:        1,
 			Name:      "demo1",
 			Passhash:  string(passhash),
+			Role:      user.RoleUser,
 			CreatedAt: time.Now().Local(),
 			UpdatedAt: time.Now().Local(),
 		},
@@ -125,6 +182,15 @@ func (s *ApiTestSuite) setupEntities() {
 			DeletedAt: nil,
 		},
 	}
+	s.entities.session = &session.Session{
+		ID:        "7f1c2a9e-3b4d-4e5f-8a6b-9c0d1e2f3a4b",
+		UserID:    s.entities.user.ID,
+		User:      *s.entities.user,
+		UserAgent: "Mozilla/5.0 (X11; Linux x86_64)",
+		IP:        "127.0.0.1",
+		Device:    "Linux",
+		CreatedAt: time.Now().Local(),
+	}
 	s.entities.comment = &comment.Comment{
 		ID:        "11",
 		PostID:    "1",
@@ -151,35 +217,73 @@ func (s *ApiTestSuite) setupEntities() {
 		UpdatedAt: time.Now().Local(),
 		DeletedAt: nil,
 	}
+	s.entities.community = &community.Community{
+		ID:          "13",
+		Name:        post.CategoryProgramming,
+		Description: "Programming languages, tools and practices",
+		Rules:       []string{"Be nice"},
+		OwnerID:     1,
+		Owner:       *s.entities.user,
+		Subscribers: 5,
+		CreatedAt:   time.Now().Local(),
+		UpdatedAt:   time.Now().Local(),
+	}
 }
 
 func (s *ApiTestSuite) initMocks() {
 	s.repositoryMocks = repositoryMocks{
-		user:    &repositoryMock.UserRepository{},
-		session: &repositoryMock.SessionRepository{},
-		post:    &repositoryMock.PostRepository{},
-		comment: &repositoryMock.CommentRepository{},
-		vote:    &repositoryMock.VoteRepository{},
+		user:          &repositoryMock.UserRepository{},
+		session:       &repositoryMock.SessionRepository{},
+		loginAttempt:  &repositoryMock.LoginAttemptRepository{},
+		passwordReset: &repositoryMock.PasswordResetRepository{},
+		post:          &repositoryMock.PostRepository{},
+		comment:       &repositoryMock.CommentRepository{},
+		vote:          &repositoryMock.VoteRepository{},
+		community:     &repositoryMock.CommunityRepository{},
+		follow:        &repositoryMock.FollowRepository{},
+		timeline:      &repositoryMock.TimelineRepository{},
+		report:        &repositoryMock.ReportRepository{},
+		modLog:        &repositoryMock.ModLogRepository{},
 	}
 }
 
 func (s *ApiTestSuite) setupMocks() {
 	*s.repositoryMocks.user = repositoryMock.UserRepository{}
 	*s.repositoryMocks.session = repositoryMock.SessionRepository{}
+	*s.repositoryMocks.loginAttempt = repositoryMock.LoginAttemptRepository{}
+	*s.repositoryMocks.passwordReset = repositoryMock.PasswordResetRepository{}
+	s.mailBox = &mailBox{}
 	*s.repositoryMocks.post = repositoryMock.PostRepository{}
 	*s.repositoryMocks.comment = repositoryMock.CommentRepository{}
 	*s.repositoryMocks.vote = repositoryMock.VoteRepository{}
+	*s.repositoryMocks.community = repositoryMock.CommunityRepository{}
+	*s.repositoryMocks.follow = repositoryMock.FollowRepository{}
+	*s.repositoryMocks.timeline = repositoryMock.TimelineRepository{}
+	*s.repositoryMocks.report = repositoryMock.ReportRepository{}
+	*s.repositoryMocks.modLog = repositoryMock.ModLogRepository{}
 }
 
 func (s *ApiTestSuite) setupSession() {
-	newSession := &session.Session{
-		UserID: s.entities.user.ID,
-		User:   *s.entities.user,
-		Data: session.Data{
-			UserID:              s.entities.user.ID,
-			UserName:            s.entities.user.Name,
-			ExpirationTokenTime: time.Now().Local().Add(time.Hour),
-		},
+	newSession := &session.Session{}
+	*newSession = *s.entities.session
+	newSession.LastSeenAt = time.Now()
+	newSession.Data = session.Data{
+		UserID:              s.entities.user.ID,
+		UserName:            s.entities.user.Name,
+		Role:                s.entities.user.Role,
+		ExpirationTokenTime: time.Now().Local().Add(time.Hour),
 	}
-	s.repositoryMocks.session.On("Get", mock.Anything, s.entities.user.ID).Return(newSession, error(nil))
+	s.repositoryMocks.session.On("Get", mock.Anything, s.entities.session.ID).Return(newSession, error(nil))
+}
+
+// setupNoBans sets up the current user which is banned in no community.
+func (s *ApiTestSuite) setupNoBans() {
+	s.repositoryMocks.community.On("GetBan", mock.Anything, mock.Anything, s.entities.user.ID).Return(nil, apperror.ErrNotFound)
+}
+
+// setupThrottle sets up the login attempts of the client which is not locked and has no previous attempts.
+func (s *ApiTestSuite) setupThrottle() {
+	s.repositoryMocks.loginAttempt.On("LockedFor", mock.Anything, mock.Anything).Return(time.Duration(0), error(nil))
+	s.repositoryMocks.loginAttempt.On("AddAttempt", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint(0), error(nil))
+	s.repositoryMocks.loginAttempt.On("ResetAttempts", mock.Anything, mock.Anything).Return(error(nil))
 }
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"redditclone/internal/domain/community"
+	"redditclone/internal/domain/modlog"
+	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/session"
+)
+
+// setupStateSession sets up the session of the current user with the state of the account and the role.
+func (s *ApiTestSuite) setupStateSession(role string, state user.AccountState) {
+	newSession := &session.Session{}
+	*newSession = *s.entities.session
+	newSession.LastSeenAt = time.Now()
+	newSession.Data = session.Data{
+		UserID:              s.entities.user.ID,
+		UserName:            s.entities.user.Name,
+		Role:                role,
+		State:               state,
+		ExpirationTokenTime: time.Now().Local().Add(time.Hour),
+	}
+	s.repositoryMocks.session.On("Get", mock.Anything, s.entities.session.ID).Return(newSession, error(nil))
+}
+
+func (s *ApiTestSuite) TestBan_BannedToken() {
+	assert := assert.New(s.T())
+	s.setupStateSession(user.RoleUser, user.AccountState{Status: user.StatusBanned, Reason: "Spam"})
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/posts", s.token, `{"category": "programming", "type": "text", "title": "Title", "text": "Text"}`)
+
+	assert.Equal(http.StatusForbidden, resp.StatusCode, string(resBody))
+	assert.Contains(string(resBody), "The account is banned: Spam")
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestBan_SuspendedToken() {
+	assert := assert.New(s.T())
+	until := time.Now().Add(time.Hour)
+	s.setupStateSession(user.RoleUser, user.AccountState{Status: user.StatusSuspended, Until: &until, Reason: "Flood"})
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/posts", s.token, `{"category": "programming", "type": "text", "title": "Title", "text": "Text"}`)
+
+	assert.Equal(http.StatusForbidden, resp.StatusCode, string(resBody))
+	assert.Contains(string(resBody), "The account is suspended until")
+}
+
+func (s *ApiTestSuite) TestBan_ExpiredSuspension() {
+	assert := assert.New(s.T())
+	until := time.Now().Add(-time.Hour)
+	s.setupStateSession(user.RoleUser, user.AccountState{Status: user.StatusSuspended, Until: &until, Reason: "Flood"})
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/posts", s.token, `{"category": "programming", "type": "text"}`)
+
+	assert.Equal(http.StatusBadRequest, resp.StatusCode, string(resBody))
+}
+
+func (s *ApiTestSuite) TestBan_CommunityBanBlocksPost() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	ban := &community.Ban{
+		ID:          "31",
+		Community:   post.CategoryProgramming,
+		UserID:      s.entities.user.ID,
+		ModeratorID: 2,
+		Reason:      "Off topic",
+		CreatedAt:   time.Now(),
+	}
+	s.repositoryMocks.community.On("Get", mock.Anything, post.CategoryProgramming).Return(s.entities.community, error(nil))
+	s.repositoryMocks.community.On("GetBan", mock.Anything, post.CategoryProgramming, s.entities.user.ID).Return(ban, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/posts", s.token, `{"category": "programming", "type": "text", "title": "Title", "text": "Text"}`)
+
+	assert.Equal(http.StatusForbidden, resp.StatusCode, string(resBody))
+	assert.Contains(string(resBody), "You are banned in the community programming: Off topic")
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestBan_CommunityBanBlocksUnvote() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	ban := &community.Ban{
+		ID:          "31",
+		Community:   post.CategoryProgramming,
+		UserID:      s.entities.user.ID,
+		ModeratorID: 2,
+		Reason:      "Vote manipulation",
+		CreatedAt:   time.Now(),
+	}
+	s.repositoryMocks.post.On("Get", mock.Anything, s.entities.post.ID).Return(s.entities.post, error(nil))
+	s.repositoryMocks.community.On("GetBan", mock.Anything, post.CategoryProgramming, s.entities.user.ID).Return(ban, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/post/"+s.entities.post.ID+"/unvote", s.token, "")
+
+	assert.Equal(http.StatusForbidden, resp.StatusCode, string(resBody))
+	s.repositoryMocks.vote.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "ChangeScore", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestBan_CommunityBanByModerator() {
+	var result community.Ban
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+	s.setupModeratorSession(post.CategoryProgramming)
+
+	s.repositoryMocks.community.On("Get", mock.Anything, post.CategoryProgramming).Return(s.entities.community, error(nil))
+	s.repositoryMocks.community.On("CreateBan", mock.Anything, mock.MatchedBy(func(b *community.Ban) bool {
+		return b.Community == post.CategoryProgramming && b.UserID == 2 && b.ModeratorID == s.entities.user.ID && b.Until == nil
+	})).Return(error(nil))
+	s.repositoryMocks.modLog.On("Create", mock.Anything, mock.MatchedBy(func(e *modlog.Entry) bool {
+		return e.Action == modlog.ActionBanUser && e.TargetType == modlog.TargetUser && e.TargetID == "2" &&
+			e.Category == post.CategoryProgramming && e.Reason == "Spam" && e.Before == nil && len(e.After) > 0
+	})).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/community/"+post.CategoryProgramming+"/bans", s.token, `{"userId": 2, "reason": "Spam"}`)
+	require.Equal(http.StatusCreated, resp.StatusCode, string(resBody))
+
+	require.NoError(json.Unmarshal(resBody, &result))
+	assert.Equal("Spam", result.Reason)
+	s.repositoryMocks.community.AssertExpectations(s.T())
+	s.repositoryMocks.modLog.AssertExpectations(s.T())
+}
+
+func (s *ApiTestSuite) TestBan_CommunityBanForbidden() {
+	assert := assert.New(s.T())
+	s.setupModeratorSession(post.CategoryMusic)
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/community/"+post.CategoryProgramming+"/bans", s.token, `{"userId": 2, "reason": "Spam"}`)
+
+	assert.Equal(http.StatusForbidden, resp.StatusCode, string(resBody))
+	s.repositoryMocks.community.AssertNotCalled(s.T(), "CreateBan", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestBan_AdminForbidden() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/admin/users/2/ban", s.token, `{"status": "banned", "reason": "Spam"}`)
+
+	assert.Equal(http.StatusForbidden, resp.StatusCode, string(resBody))
+	s.repositoryMocks.user.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestBan_Admin() {
+	var result user.AccountState
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+	s.setupStateSession(user.RoleAdmin, user.AccountState{Status: user.StatusActive})
+
+	banned := &user.User{}
+	*banned = *s.entities.user
+	banned.ID = 2
+	sessions := []session.Session{{ID: "41", UserID: banned.ID}}
+
+	s.repositoryMocks.user.On("Get", mock.Anything, banned.ID).Return(banned, error(nil))
+	s.repositoryMocks.user.On("Update", mock.Anything, mock.MatchedBy(func(u *user.User) bool {
+		return u.ID == banned.ID && u.State.Status == user.StatusBanned && u.State.Reason == "Spam"
+	})).Return(error(nil))
+	s.repositoryMocks.session.On("QueryByUserID", mock.Anything, banned.ID).Return(sessions, error(nil))
+	stored := &session.Session{ID: "41", UserID: banned.ID, LastSeenAt: time.Now()}
+	s.repositoryMocks.session.On("Modify", mock.Anything, "41").Return(stored, error(nil))
+	s.repositoryMocks.modLog.On("Create", mock.Anything, mock.MatchedBy(func(e *modlog.Entry) bool {
+		return e.Action == modlog.ActionSetAccountState && e.TargetID == "2" && e.Category == "" && e.Reason == "Spam" &&
+			string(e.Before) == `{"status":""}` && string(e.After) == `{"status":"banned","reason":"Spam"}`
+	})).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/admin/users/2/ban", s.token, `{"status": "banned", "reason": "Spam"}`)
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+
+	require.NoError(json.Unmarshal(resBody, &result))
+	assert.Equal(user.StatusBanned, result.Status)
+	assert.Equal(user.StatusBanned, stored.Data.State.Status)
+	s.repositoryMocks.user.AssertExpectations(s.T())
+	s.repositoryMocks.session.AssertExpectations(s.T())
+	s.repositoryMocks.modLog.AssertExpectations(s.T())
+}
+
+func (s *ApiTestSuite) TestBan_AdminSuspensionWithoutUntil() {
+	assert := assert.New(s.T())
+	s.setupStateSession(user.RoleAdmin, user.AccountState{Status: user.StatusActive})
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/admin/users/2/ban", s.token, `{"status": "suspended", "reason": "Flood"}`)
+
+	assert.Equal(http.StatusBadRequest, resp.StatusCode, string(resBody))
+	s.repositoryMocks.user.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestBan_ShadowedPostHidden() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	p := &post.Post{}
+	*p = *s.entities.post
+	p.UserID = 2
+	p.Shadowed = true
+
+	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/post/"+p.ID, s.token, "")
+
+	assert.Equal(http.StatusNotFound, resp.StatusCode, string(resBody))
+}
+
+func (s *ApiTestSuite) TestBan_ShadowedPostRevisionsHidden() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	p := &post.Post{}
+	*p = *s.entities.post
+	p.UserID = 2
+	p.Shadowed = true
+
+	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/post/"+p.ID+"/revisions", "", "")
+	assert.Equal(http.StatusNotFound, resp.StatusCode, string(resBody))
+
+	resp, resBody = s.sendJSON(http.MethodGet, "/api/post/"+p.ID+"/revisions", s.token, "")
+	assert.Equal(http.StatusNotFound, resp.StatusCode, string(resBody))
+
+	resp, resBody = s.sendJSON(http.MethodPatch, "/api/post/"+p.ID, s.token, `{"text": "Changed"}`)
+	assert.Equal(http.StatusNotFound, resp.StatusCode, string(resBody))
+
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "QueryRevisions", mock.Anything, mock.Anything)
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestBan_ShadowedPostRevisionsSeenByAuthor() {
+	var result []post.Revision
+	require := require.New(s.T())
+	s.setupSession()
+
+	p := &post.Post{}
+	*p = *s.entities.post
+	p.Shadowed = true
+	revision := post.NewRevision(p)
+	revision.ID = "21"
+
+	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
+	s.repositoryMocks.post.On("QueryRevisions", mock.Anything, p.ID).Return([]post.Revision{*revision}, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/post/"+p.ID+"/revisions", s.token, "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.Len(result, 1)
+}
+
+func (s *ApiTestSuite) TestBan_ShadowbannedPostCreated() {
+	assert := assert.New(s.T())
+	s.setupStateSession(user.RoleUser, user.AccountState{Status: user.StatusShadowbanned, Reason: "Spam"})
+	s.setupNoBans()
+
+	s.repositoryMocks.community.On("Get", mock.Anything, post.CategoryProgramming).Return(s.entities.community, error(nil))
+	s.repositoryMocks.post.On("Create", mock.Anything, mock.MatchedBy(func(p *post.Post) bool {
+		return p.Shadowed
+	})).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/posts", s.token, `{"category": "programming", "type": "text", "title": "Title", "text": "Text"}`)
+
+	assert.Equal(http.StatusCreated, resp.StatusCode, string(resBody))
+	s.repositoryMocks.post.AssertExpectations(s.T())
+}
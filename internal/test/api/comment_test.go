@@ -9,6 +9,12 @@ import (
 	"io/ioutil"
 	"net/http"
 	"redditclone/internal/domain/comment"
+	"redditclone/internal/domain/vote"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/pagination"
+	"time"
+
+	"github.com/minipkg/selection_condition"
 )
 
 func (s *ApiTestSuite) TestComment_Create() {
@@ -17,6 +23,7 @@ func (s *ApiTestSuite) TestComment_Create() {
 	require := require.New(s.T())
 	assert := assert.New(s.T())
 	s.setupSession()
+	s.setupNoBans()
 
 	newComment := &comment.Comment{}
 	*newComment = *s.entities.comment
@@ -64,6 +71,7 @@ func (s *ApiTestSuite) TestComment_Delete() {
 	*newComment = *s.entities.comment
 	newComment.ID = ""
 
+	s.repositoryMocks.comment.On("Get", mock.Anything, s.entities.comment.ID).Return(s.entities.comment, error(nil))
 	s.repositoryMocks.comment.On("Delete", mock.Anything, s.entities.comment.ID).Return(error(nil))
 	s.repositoryMocks.post.On("Get", mock.Anything, newComment.PostID).Return(s.entities.post, error(nil))
 
@@ -94,3 +102,176 @@ func (s *ApiTestSuite) TestComment_Delete() {
 
 	assert.Equalf(expected, result, "results not match\nGot: %#v\nExpected: %#v", result, expectedData)
 }
+
+func (s *ApiTestSuite) TestComment_CreateReplyToAnotherPost() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	parent := &comment.Comment{}
+	*parent = *s.entities.comment
+	parent.PostID = "2"
+
+	newComment := &comment.Comment{
+		ParentID: parent.ID,
+		Body:     "Reply to a comment of another post",
+	}
+
+	s.repositoryMocks.comment.On("Get", mock.Anything, parent.ID).Return(parent, error(nil))
+
+	b, err := json.Marshal(newComment)
+	require.NoErrorf(err, "can not json.Marshal() a value: %v, error", newComment, err)
+
+	uri := "/api/post/" + s.entities.comment.PostID
+	expectedStatus := http.StatusBadRequest
+
+	req, _ := http.NewRequest(http.MethodPost, s.server.URL+uri, bytes.NewReader(b))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+s.token)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	s.repositoryMocks.comment.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestComment_Thread() {
+	var result interface{}
+	var expected interface{}
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	reply := comment.Comment{
+		ID:       "12",
+		PostID:   s.entities.comment.PostID,
+		ParentID: s.entities.comment.ID,
+		UserID:   s.entities.user.ID,
+		User:     *s.entities.user,
+		Body:     "I do",
+	}
+	items := []comment.Comment{*s.entities.comment, reply}
+	c := &comment.Comment{}
+	*c = *s.entities.comment
+
+	s.repositoryMocks.comment.On("Get", mock.Anything, c.ID).Return(c, error(nil))
+	s.repositoryMocks.comment.On("Query", mock.Anything, mock.Anything).Return(items, error(nil))
+
+	uri := "/api/post/" + s.entities.comment.PostID + "/" + s.entities.comment.ID + "/replies"
+	expectedData := &comment.Comment{}
+	*expectedData = *s.entities.comment
+	expectedData.Replies = []comment.Comment{reply}
+	expectedStatus := http.StatusOK
+
+	req, _ := http.NewRequest(http.MethodGet, s.server.URL+uri, nil)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+
+	err = json.Unmarshal(resBody, &result)
+	require.NoErrorf(err, "can not unpack json, error: %v", err)
+
+	jsonData, err := json.Marshal(expectedData)
+	json.Unmarshal(jsonData, &expected)
+
+	assert.Equalf(expected, result, "results not match\nGot: %#v\nExpected: %#v", result, expectedData)
+}
+
+func (s *ApiTestSuite) TestComment_List() {
+	var result interface{}
+	var expected interface{}
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	reply := *s.entities.comment
+	reply.ID = "12"
+	reply.CreatedAt = s.entities.comment.CreatedAt.Add(time.Minute)
+	items := []comment.Comment{reply}
+	isAfterFirst := func(cond selection_condition.SelectionCondition) bool {
+		filter, ok := cond.Where.(*comment.Filter)
+		return ok && filter.PostID == s.entities.comment.PostID && filter.Cursor != nil &&
+			filter.Cursor.ID == s.entities.comment.ID && cond.Limit == 2 &&
+			cond.SortOrder[0]["createdat"] == selection_condition.SortOrderAsc
+	}
+
+	s.repositoryMocks.comment.On("Query", mock.Anything, mock.MatchedBy(isAfterFirst)).Return(items, error(nil))
+
+	uri := "/api/post/" + s.entities.comment.PostID + "/comments?limit=1&after=" + comment.NewCursor(*s.entities.comment).Encode()
+	expectedData := pagination.Page{
+		Items: items,
+		Prev:  comment.NewCursor(reply).Encode(),
+	}
+	expectedStatus := http.StatusOK
+
+	req, _ := http.NewRequest(http.MethodGet, s.server.URL+uri, nil)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+
+	err = json.Unmarshal(resBody, &result)
+	require.NoErrorf(err, "can not unpack json, error: %v", err)
+
+	jsonData, err := json.Marshal(expectedData)
+	json.Unmarshal(jsonData, &expected)
+
+	assert.Equalf(expected, result, "results not match\nGot: %#v\nExpected: %#v", result, expectedData)
+}
+
+func (s *ApiTestSuite) TestComment_Upvote() {
+	var result interface{}
+	var expected interface{}
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+	s.setupNoBans()
+
+	newVote := s.api.Domain.Vote.Service.NewEntity(s.entities.user.ID, s.entities.comment.PostID, 1)
+	newVote.CommentID = s.entities.comment.ID
+	newVote.User = *s.entities.user
+
+	searchedVote := &vote.Vote{
+		PostID:    s.entities.comment.PostID,
+		CommentID: s.entities.comment.ID,
+		UserID:    s.entities.user.ID,
+	}
+	c := &comment.Comment{}
+	*c = *s.entities.comment
+
+	s.repositoryMocks.comment.On("Get", mock.Anything, c.ID).Return(c, error(nil))
+	s.repositoryMocks.comment.On("ChangeScore", mock.Anything, c.ID, 1).Return(error(nil))
+	s.repositoryMocks.vote.On("First", mock.Anything, searchedVote).Return(nil, apperror.ErrNotFound)
+	s.repositoryMocks.vote.On("Create", mock.Anything, newVote).Return(error(nil))
+	s.repositoryMocks.post.On("Get", mock.Anything, s.entities.post.ID).Return(s.entities.post, error(nil))
+
+	uri := "/api/post/" + s.entities.comment.PostID + "/" + s.entities.comment.ID + "/upvote"
+	expectedData := s.entities.post
+	expectedStatus := http.StatusOK
+
+	req, _ := http.NewRequest(http.MethodGet, s.server.URL+uri, nil)
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+s.token)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+
+	err = json.Unmarshal(resBody, &result)
+	require.NoErrorf(err, "can not unpack json, error: %v", err)
+
+	jsonData, err := json.Marshal(expectedData)
+	json.Unmarshal(jsonData, &expected)
+
+	assert.Equalf(expected, result, "results not match\nGot: %#v\nExpected: %#v", result, expectedData)
+	s.repositoryMocks.comment.AssertExpectations(s.T())
+}
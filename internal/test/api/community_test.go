@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"redditclone/internal/domain/community"
+	"redditclone/internal/pkg/apperror"
+)
+
+func (s *ApiTestSuite) TestCommunity_Create() {
+	var result community.Community
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	isNew := func(c *community.Community) bool {
+		return c.Name == "golang" && c.OwnerID == s.entities.user.ID && c.Subscribers == 0 && len(c.Rules) == 1
+	}
+	s.repositoryMocks.community.On("Create", mock.Anything, mock.MatchedBy(isNew)).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/communities", s.token, `{"name": "golang", "description": "The Go programming language", "rules": ["Be nice"], "subscribers": 100}`)
+
+	require.Equal(http.StatusCreated, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	assert.Equal("golang", result.Name)
+	assert.Equal(s.entities.user.Name, result.Owner.Name)
+	assert.Equal(0, result.Subscribers)
+}
+
+func (s *ApiTestSuite) TestCommunity_CreateInvalid() {
+	s.setupSession()
+
+	for _, body := range []string{
+		`{"name": "Go lang"}`,
+		`{"name": "1golang"}`,
+		`{"name": "golang", "rules": [""]}`,
+	} {
+		resp, resBody := s.sendJSON(http.MethodPost, "/api/communities", s.token, body)
+		s.Equalf(http.StatusBadRequest, resp.StatusCode, "body %v, response %v", body, string(resBody))
+	}
+	s.repositoryMocks.community.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestCommunity_CreateTaken() {
+	s.setupSession()
+
+	s.repositoryMocks.community.On("Create", mock.Anything, mock.Anything).Return(apperror.ErrConflict)
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/communities", s.token, `{"name": "programming"}`)
+
+	s.Equal(http.StatusConflict, resp.StatusCode, string(resBody))
+}
+
+func (s *ApiTestSuite) TestCommunity_Get() {
+	var result community.Community
+	require := require.New(s.T())
+
+	s.repositoryMocks.community.On("Get", mock.Anything, "programming").Return(s.entities.community, error(nil))
+	s.repositoryMocks.community.On("Get", mock.Anything, "nothing").Return(nil, apperror.ErrNotFound)
+
+	resp, err := s.client.Get(s.server.URL + "/api/community/programming")
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	s.Equal(s.entities.community.Rules, result.Rules)
+
+	resp, err = s.client.Get(s.server.URL + "/api/community/nothing")
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusNotFound, resp.StatusCode)
+}
+
+func (s *ApiTestSuite) TestCommunity_Search() {
+	var result []community.Community
+	require := require.New(s.T())
+
+	s.repositoryMocks.community.On("Search", mock.Anything, "prog", uint(10), uint(community.MaxLIstLimit)).Return([]community.Community{*s.entities.community}, error(nil))
+
+	resp, err := s.client.Get(s.server.URL + "/api/communities?q=prog&offset=10&limit=1000")
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.Len(result, 1)
+	s.Equal(s.entities.community.Name, result[0].Name)
+	s.repositoryMocks.community.AssertNotCalled(s.T(), "Query", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestCommunity_Subscribe() {
+	var result community.Community
+	require := require.New(s.T())
+	s.setupSession()
+
+	c := &community.Community{}
+	*c = *s.entities.community
+	isSubscription := func(sub *community.Subscription) bool {
+		return sub.Community == c.Name && sub.UserID == s.entities.user.ID
+	}
+	s.repositoryMocks.community.On("Get", mock.Anything, c.Name).Return(c, error(nil))
+	s.repositoryMocks.community.On("Subscribe", mock.Anything, mock.MatchedBy(isSubscription)).Return(error(nil)).Once()
+	s.repositoryMocks.community.On("Subscribe", mock.Anything, mock.MatchedBy(isSubscription)).Return(apperror.ErrConflict)
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/community/"+c.Name+"/subscribe", s.token, "")
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	s.Equal(s.entities.community.Subscribers+1, result.Subscribers)
+
+	*c = *s.entities.community
+	resp, resBody = s.sendJSON(http.MethodPost, "/api/community/"+c.Name+"/subscribe", s.token, "")
+	require.Equalf(http.StatusOK, resp.StatusCode, "subscribing again has to change nothing: %v", string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	s.Equal(s.entities.community.Subscribers, result.Subscribers)
+}
+
+func (s *ApiTestSuite) TestCommunity_Unsubscribe() {
+	var result community.Community
+	require := require.New(s.T())
+	s.setupSession()
+
+	c := &community.Community{}
+	*c = *s.entities.community
+	s.repositoryMocks.community.On("Get", mock.Anything, c.Name).Return(c, error(nil))
+	s.repositoryMocks.community.On("Unsubscribe", mock.Anything, c.Name, s.entities.user.ID).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/community/"+c.Name+"/unsubscribe", s.token, "")
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	s.Equal(s.entities.community.Subscribers-1, result.Subscribers)
+}
+
+func (s *ApiTestSuite) TestCommunity_Subscriptions() {
+	var result []community.Community
+	require := require.New(s.T())
+	s.setupSession()
+
+	s.repositoryMocks.community.On("Subscriptions", mock.Anything, s.entities.user.ID).Return([]community.Community{*s.entities.community}, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/me/subscriptions", s.token, "")
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.Len(result, 1)
+	s.Equal(s.entities.community.Name, result[0].Name)
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"redditclone/internal/domain/comment"
+	"redditclone/internal/domain/modlog"
+	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/report"
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
+)
+
+// deletedPost returns a copy of the post deleted an hour ago with a deleted comment which has a reply
+func (s *ApiTestSuite) deletedPost() *post.Post {
+	deletedAt := time.Now().Add(-time.Hour)
+
+	deleted := *s.entities.comment
+	deleted.DeletedAt = &deletedAt
+	reply := *s.entities.comment
+	reply.ID = "11"
+	reply.ParentID = deleted.ID
+	reply.Body = "The reply is kept"
+	deleted.Replies = []comment.Comment{reply}
+
+	p := &post.Post{}
+	*p = *s.entities.post
+	p.DeletedAt = &deletedAt
+	p.Comments = []comment.Comment{deleted}
+	return p
+}
+
+func (s *ApiTestSuite) TestDeleted_PostMasked() {
+	var result post.Post
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+	s.setupSession()
+
+	p := s.deletedPost()
+	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/post/"+p.ID, s.token, "")
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+
+	require.NoError(json.Unmarshal(resBody, &result))
+	assert.Equal(post.DeletedTitle, result.Title)
+	assert.Empty(result.Text)
+	assert.Equal(user.DeletedName, result.User.Name)
+	require.Len(result.Comments, 1)
+	assert.Equal(comment.DeletedBody, result.Comments[0].Body)
+	assert.Equal(user.DeletedName, result.Comments[0].User.Name)
+	require.Len(result.Comments[0].Replies, 1)
+	assert.Equal("The reply is kept", result.Comments[0].Replies[0].Body)
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestDeleted_PostSeenByAdmin() {
+	var result post.Post
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+	s.setupStateSession(user.RoleAdmin, user.AccountState{Status: user.StatusActive})
+
+	p := s.deletedPost()
+	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
+	s.repositoryMocks.report.On("Counts", mock.Anything, report.TargetPost, []string{p.ID}).Return(map[string]uint{}, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/post/"+p.ID, s.token, "")
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+
+	require.NoError(json.Unmarshal(resBody, &result))
+	assert.Equal(s.entities.post.Title, result.Title)
+	assert.NotNil(result.DeletedAt)
+	require.Len(result.Comments, 1)
+	assert.Equal(s.entities.comment.Body, result.Comments[0].Body)
+}
+
+func (s *ApiTestSuite) TestDeleted_PostDeleteAgain() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	p := s.deletedPost()
+	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodDelete, "/api/post/"+p.ID, s.token, "")
+
+	assert.Equal(http.StatusNotFound, resp.StatusCode, string(resBody))
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestDeleted_CommentOnDeletedPost() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	p := s.deletedPost()
+	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/post/"+p.ID, s.token, `{"body": "Too late"}`)
+
+	assert.Equal(http.StatusBadRequest, resp.StatusCode, string(resBody))
+	s.repositoryMocks.comment.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestDeleted_UnvoteDeletedPost() {
+	assert := assert.New(s.T())
+	s.setupSession()
+	s.setupNoBans()
+
+	p := s.deletedPost()
+	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/post/"+p.ID+"/unvote", s.token, "")
+
+	assert.Equal(http.StatusNotFound, resp.StatusCode, string(resBody))
+	s.repositoryMocks.vote.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "ChangeScore", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestDeleted_RestoreForbidden() {
+	assert := assert.New(s.T())
+	s.setupModeratorSession(post.CategoryProgramming)
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/admin/post/"+s.entities.post.ID+"/restore", s.token, "")
+
+	assert.Equal(http.StatusForbidden, resp.StatusCode, string(resBody))
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Restore", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestDeleted_RestorePost() {
+	assert := assert.New(s.T())
+	s.setupStateSession(user.RoleAdmin, user.AccountState{Status: user.StatusActive})
+
+	p := s.deletedPost()
+	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
+	s.repositoryMocks.post.On("Restore", mock.Anything, p.ID).Return(error(nil))
+	s.repositoryMocks.modLog.On("Create", mock.Anything, mock.MatchedBy(func(e *modlog.Entry) bool {
+		var after post.Post
+		return e.Action == modlog.ActionRestorePost && e.TargetID == p.ID && e.Before == nil &&
+			json.Unmarshal(e.After, &after) == nil && after.Title == p.Title && after.DeletedAt == nil
+	})).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/admin/post/"+p.ID+"/restore", s.token, "")
+
+	assert.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	s.repositoryMocks.post.AssertExpectations(s.T())
+	s.repositoryMocks.modLog.AssertExpectations(s.T())
+}
+
+func (s *ApiTestSuite) TestDeleted_RestoreNotDeleted() {
+	assert := assert.New(s.T())
+	s.setupStateSession(user.RoleAdmin, user.AccountState{Status: user.StatusActive})
+
+	s.repositoryMocks.comment.On("Get", mock.Anything, s.entities.comment.ID).Return(s.entities.comment, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/admin/comment/"+s.entities.comment.ID+"/restore", s.token, "")
+
+	assert.Equal(http.StatusNotFound, resp.StatusCode, string(resBody))
+	s.repositoryMocks.comment.AssertNotCalled(s.T(), "Restore", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestDeleted_RestoreComment() {
+	assert := assert.New(s.T())
+	s.setupStateSession(user.RoleAdmin, user.AccountState{Status: user.StatusActive})
+
+	deletedAt := time.Now().Add(-time.Hour)
+	c := &comment.Comment{}
+	*c = *s.entities.comment
+	c.DeletedAt = &deletedAt
+
+	s.repositoryMocks.comment.On("Get", mock.Anything, c.ID).Return(c, error(nil))
+	s.repositoryMocks.post.On("Get", mock.Anything, c.PostID).Return(s.entities.post, error(nil))
+	s.repositoryMocks.comment.On("Restore", mock.Anything, c.ID).Return(error(nil))
+	s.repositoryMocks.modLog.On("Create", mock.Anything, mock.MatchedBy(func(e *modlog.Entry) bool {
+		return e.Action == modlog.ActionRestoreComment && e.TargetID == c.ID && e.Category == s.entities.post.Category
+	})).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/admin/comment/"+c.ID+"/restore", s.token, "")
+
+	assert.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	s.repositoryMocks.comment.AssertExpectations(s.T())
+	s.repositoryMocks.modLog.AssertExpectations(s.T())
+}
+
+func (s *ApiTestSuite) TestDeleted_RestoreNotFound() {
+	assert := assert.New(s.T())
+	s.setupStateSession(user.RoleAdmin, user.AccountState{Status: user.StatusActive})
+
+	s.repositoryMocks.post.On("Get", mock.Anything, "404").Return(nil, apperror.ErrNotFound)
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/admin/post/404/restore", s.token, "")
+
+	assert.Equal(http.StatusNotFound, resp.StatusCode, string(resBody))
+}
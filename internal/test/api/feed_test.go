@@ -0,0 +1,221 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"time"
+
+	"github.com/minipkg/selection_condition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	apiapp "redditclone/internal/app/restapi"
+	"redditclone/internal/domain/community"
+	"redditclone/internal/domain/feed"
+	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
+)
+
+// feedPage is the page of the feed as it is in a response
+type feedPage struct {
+	Items []post.Post `json:"items"`
+	Next  string      `json:"next"`
+	Prev  string      `json:"prev"`
+}
+
+// setupTimelines restarts the API with the precomputed timelines for the users with the number of the sources from the threshold.
+func (s *ApiTestSuite) setupTimelines(threshold, size uint) {
+	s.server.Close()
+
+	cfg := *s.cfg
+	cfg.Feed = feed.Config{
+		TimelineThreshold: threshold,
+		TimelineSize:      size,
+	}
+	app := s.newCommonApp()
+	app.Cfg = cfg
+	app.SetupServices()
+	s.api = apiapp.New(app, cfg)
+	s.server = httptest.NewServer(s.api.Server.Handler)
+}
+
+// setupSources sets up the subscription of the current user to the community of the test post and the follow of the user with ID 2.
+func (s *ApiTestSuite) setupSources() {
+	s.repositoryMocks.community.On("Subscriptions", mock.Anything, s.entities.user.ID).Return([]community.Community{*s.entities.community}, error(nil))
+	s.repositoryMocks.follow.On("Following", mock.Anything, s.entities.user.ID).Return([]feed.Follow{{UserID: s.entities.user.ID, FollowedID: 2, FollowedName: "demo2"}}, error(nil))
+}
+
+// feedPosts returns the posts of the feed from the newest one
+func (s *ApiTestSuite) feedPosts(n int) []post.Post {
+	items := make([]post.Post, 0, n)
+	for i := 0; i < n; i++ {
+		p := *s.entities.post
+		p.ID = string(rune('a' + i))
+		p.CreatedAt = s.entities.post.CreatedAt.Add(-time.Duration(i) * time.Minute)
+		items = append(items, p)
+	}
+	return items
+}
+
+func (s *ApiTestSuite) TestFeed_FanOutOnRead() {
+	var result feedPage
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+	s.setupSources()
+
+	items := s.feedPosts(3)
+	isFeedQuery := func(cond selection_condition.SelectionCondition) bool {
+		filter, ok := cond.Where.(*post.Filter)
+		return ok && filter.Sources != nil && filter.Category == "" &&
+			reflect.DeepEqual([]string{s.entities.community.Name}, filter.Sources.Categories) &&
+			reflect.DeepEqual([]uint{2}, filter.Sources.UserIDs) &&
+			cond.Limit == 3 && cond.SortOrder[0]["createdat"] == selection_condition.SortOrderDesc
+	}
+	s.repositoryMocks.post.On("Query", mock.Anything, mock.MatchedBy(isFeedQuery)).Return(items, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/feed?limit=2", s.token, "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.Len(result.Items, 2)
+	assert.Equal(items[0].ID, result.Items[0].ID)
+	assert.NotEmpty(result.Next)
+	assert.Empty(result.Prev)
+}
+
+func (s *ApiTestSuite) TestFeed_NoSources() {
+	var result feedPage
+	require := require.New(s.T())
+	s.setupSession()
+
+	s.repositoryMocks.community.On("Subscriptions", mock.Anything, s.entities.user.ID).Return([]community.Community{}, error(nil))
+	s.repositoryMocks.follow.On("Following", mock.Anything, s.entities.user.ID).Return([]feed.Follow{}, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/feed", s.token, "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	s.Empty(result.Items)
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Query", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestFeed_Unauthorized() {
+	resp, _ := s.sendJSON(http.MethodGet, "/api/feed", "", "")
+	s.Equal(http.StatusUnauthorized, resp.StatusCode)
+}
+
+func (s *ApiTestSuite) TestFeed_Timeline() {
+	var result feedPage
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupTimelines(2, 5)
+	s.setupSession()
+	s.setupSources()
+
+	items := s.feedPosts(5)
+	isTimelineQuery := func(cond selection_condition.SelectionCondition) bool {
+		filter, ok := cond.Where.(*post.Filter)
+		return ok && filter.Sources != nil && filter.Cursor == nil && cond.Limit == 5
+	}
+	s.repositoryMocks.timeline.On("Get", mock.Anything, s.entities.user.ID, "new").Return(nil, apperror.ErrNotFound).Once()
+	s.repositoryMocks.post.On("Query", mock.Anything, mock.MatchedBy(isTimelineQuery)).Return(items, error(nil)).Once()
+	s.repositoryMocks.timeline.On("Set", mock.Anything, s.entities.user.ID, "new", items, 5*time.Minute).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/feed?limit=2", s.token, "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.Len(result.Items, 2)
+	assert.Equal(items[1].ID, result.Items[1].ID)
+	require.NotEmpty(result.Next)
+
+	//	the next pages are read from the saved timeline
+	s.repositoryMocks.timeline.On("Get", mock.Anything, s.entities.user.ID, "new").Return(items, error(nil))
+
+	resp, resBody = s.sendJSON(http.MethodGet, "/api/feed?limit=2&after="+result.Next, s.token, "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.Len(result.Items, 2)
+	assert.Equal(items[2].ID, result.Items[0].ID)
+	assert.NotEmpty(result.Prev)
+	require.NotEmpty(result.Next)
+
+	//	the page which goes beyond the timeline is read from the sources by the cursor of the last item
+	isTailQuery := func(cond selection_condition.SelectionCondition) bool {
+		filter, ok := cond.Where.(*post.Filter)
+		return ok && filter.Sources != nil && filter.Cursor != nil && filter.Cursor.ID == items[3].ID
+	}
+	s.repositoryMocks.post.On("Query", mock.Anything, mock.MatchedBy(isTailQuery)).Return(items[4:], error(nil))
+
+	resp, resBody = s.sendJSON(http.MethodGet, "/api/feed?limit=2&after="+result.Next, s.token, "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.Len(result.Items, 1)
+	assert.Equal(items[4].ID, result.Items[0].ID)
+}
+
+func (s *ApiTestSuite) TestFeed_Follow() {
+	var result feed.Follow
+	require := require.New(s.T())
+	s.setupTimelines(2, 5)
+	s.setupSession()
+
+	followed := &user.User{ID: 2, Name: "demo2"}
+	isFollow := func(f *feed.Follow) bool {
+		return f.UserID == s.entities.user.ID && f.FollowedID == followed.ID && f.FollowedName == followed.Name
+	}
+	s.repositoryMocks.user.On("First", mock.Anything, &user.User{Name: followed.Name}).Return(followed, error(nil))
+	s.repositoryMocks.follow.On("Follow", mock.Anything, mock.MatchedBy(isFollow)).Return(apperror.ErrConflict)
+	s.repositoryMocks.timeline.On("Delete", mock.Anything, s.entities.user.ID).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/u/"+followed.Name+"/follow", s.token, "")
+
+	require.Equalf(http.StatusOK, resp.StatusCode, "following again has to change nothing: %v", string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	s.Equal(followed.ID, result.FollowedID)
+	s.repositoryMocks.timeline.AssertCalled(s.T(), "Delete", mock.Anything, s.entities.user.ID)
+}
+
+func (s *ApiTestSuite) TestFeed_FollowYourself() {
+	s.setupSession()
+
+	s.repositoryMocks.user.On("First", mock.Anything, &user.User{Name: s.entities.user.Name}).Return(s.entities.user, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/u/"+s.entities.user.Name+"/follow", s.token, "")
+
+	s.Equal(http.StatusBadRequest, resp.StatusCode, string(resBody))
+	s.repositoryMocks.follow.AssertNotCalled(s.T(), "Follow", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestFeed_Unfollow() {
+	s.setupSession()
+
+	followed := &user.User{ID: 2, Name: "demo2"}
+	s.repositoryMocks.user.On("First", mock.Anything, &user.User{Name: followed.Name}).Return(followed, error(nil))
+	s.repositoryMocks.follow.On("Unfollow", mock.Anything, s.entities.user.ID, followed.ID).Return(apperror.ErrNotFound)
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/u/"+followed.Name+"/unfollow", s.token, "")
+
+	s.Equalf(http.StatusOK, resp.StatusCode, "unfollowing again has to change nothing: %v", string(resBody))
+}
+
+func (s *ApiTestSuite) TestFeed_Following() {
+	var result []feed.Follow
+	require := require.New(s.T())
+	s.setupSession()
+	s.setupSources()
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/me/following", s.token, "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.Len(result, 1)
+	s.Equal("demo2", result[0].FollowedName)
+}
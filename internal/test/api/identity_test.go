@@ -1,6 +1,7 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 
 	"redditclone/internal/domain/user"
 	"redditclone/internal/pkg/session"
@@ -26,6 +28,8 @@ func (s *ApiTestSuite) Test00Identity_Register() {
 	newUser.Name = s.entities.user.Name
 	newUser.Passhash = s.entities.user.Passhash
 
+	s.setupThrottle()
+	s.repositoryMocks.user.On("First", mock.Anything, &user.User{Name: newUser.Name}).Return(nil, apperror.ErrNotFound)
 	s.repositoryMocks.user.On("Create", mock.Anything, mock.Anything).Return(error(nil))
 	s.repositoryMocks.session.On("NewEntity", mock.Anything, uint(0)).Return(session.New(), error(nil))
 	s.repositoryMocks.session.On("Create", mock.Anything, mock.Anything).Return(error(nil))
@@ -66,20 +70,31 @@ func (s *ApiTestSuite) Test01Identity_Login() {
 	searchedUser := user.New()
 	searchedUser.Name = s.entities.user.Name
 	newSession := &session.Session{
+		ID:     s.entities.session.ID,
 		UserID: s.entities.user.ID,
 		User:   *s.entities.user,
-		Data: session.Data{
-			UserID:              s.entities.user.ID,
-			UserName:            s.entities.user.Name,
-			ExpirationTokenTime: time.Now().Local().Add(time.Hour),
-		},
+	}
+	isFromClient := func(sess *session.Session) bool {
+		return sess.ID == s.entities.session.ID && sess.UserAgent == s.entities.session.UserAgent &&
+			sess.Device == s.entities.session.Device && sess.IP == "10.0.0.1"
 	}
 
-	s.repositoryMocks.user.On("First", mock.Anything, searchedUser).Return(s.entities.user, error(nil))
+	//	the user has the legacy PBKDF2 hash, it is rehashed by the login
+	loginUser := &user.User{}
+	*loginUser = *s.entities.user
+	isRehashed := func(u *user.User) bool {
+		return u.ID == s.entities.user.ID && strings.HasPrefix(u.Passhash, "$2a$04$")
+	}
+
+	s.setupThrottle()
+	s.repositoryMocks.user.On("First", mock.Anything, searchedUser).Return(loginUser, error(nil))
+	s.repositoryMocks.user.On("Update", mock.Anything, mock.MatchedBy(isRehashed)).Return(error(nil))
 
-	s.repositoryMocks.session.On("Get", mock.Anything, s.entities.user.ID).Return(nil, apperror.ErrNotFound)
 	s.repositoryMocks.session.On("NewEntity", mock.Anything, s.entities.user.ID).Return(newSession, error(nil))
-	s.repositoryMocks.session.On("Create", mock.Anything, mock.Anything).Return(error(nil))
+	s.repositoryMocks.session.On("Create", mock.Anything, mock.MatchedBy(isFromClient)).Return(error(nil)).
+		Run(func(args mock.Arguments) {
+			s.loginSession = args.Get(1).(*session.Session)
+		})
 
 	reqBody := strings.NewReader(`{
 	"username": "demo1",
@@ -89,6 +104,8 @@ func (s *ApiTestSuite) Test01Identity_Login() {
 
 	req, _ := http.NewRequest(http.MethodPost, s.server.URL+uri, reqBody)
 	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("User-Agent", s.entities.session.UserAgent)
+	req.Header.Add("X-Forwarded-For", "10.0.0.1, 10.0.0.2")
 	resp, err := s.client.Do(req)
 	require.NoErrorf(err, "request error: %v", err)
 	defer resp.Body.Close()
@@ -109,4 +126,455 @@ func (s *ApiTestSuite) Test01Identity_Login() {
 
 	s.token, ok = token.(string)
 	require.Truef(ok, "can not assign to string token %v", token)
+
+	refreshToken, ok := m["refreshToken"]
+	require.Truef(ok, "result %v do not contain a refresh token", m)
+
+	s.refreshToken, ok = refreshToken.(string)
+	require.Truef(ok, "can not assign to string refresh token %v", refreshToken)
+	require.NotEmpty(s.loginSession.RefreshSecret, "the session has no refresh secret")
+	s.repositoryMocks.user.AssertNumberOfCalls(s.T(), "Update", 1)
+	s.repositoryMocks.loginAttempt.AssertCalled(s.T(), "ResetAttempts", mock.Anything, "login_user_demol")
+}
+
+func (s *ApiTestSuite) TestIdentity_Logout() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	isCurrentSession := func(sess *session.Session) bool {
+		return sess.ID == s.entities.session.ID
+	}
+	s.repositoryMocks.session.On("Delete", mock.Anything, mock.MatchedBy(isCurrentSession)).Return(error(nil))
+
+	uri := "/api/logout"
+	expectedStatus := http.StatusOK
+
+	req, _ := http.NewRequest(http.MethodPost, s.server.URL+uri, nil)
+	req.Header.Add("Authorization", "Bearer "+s.token)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	s.repositoryMocks.session.AssertNumberOfCalls(s.T(), "Delete", 1)
+	s.repositoryMocks.session.AssertNotCalled(s.T(), "DeleteByUserID", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestIdentity_LogoutAll() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	s.repositoryMocks.session.On("DeleteByUserID", mock.Anything, s.entities.user.ID).Return(error(nil))
+
+	uri := "/api/logout-all"
+	expectedStatus := http.StatusOK
+
+	req, _ := http.NewRequest(http.MethodPost, s.server.URL+uri, nil)
+	req.Header.Add("Authorization", "Bearer "+s.token)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	s.repositoryMocks.session.AssertNumberOfCalls(s.T(), "DeleteByUserID", 1)
+}
+
+func (s *ApiTestSuite) TestIdentity_TokenOfDeletedSession() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	s.repositoryMocks.session.On("Get", mock.Anything, s.entities.session.ID).Return(nil, apperror.ErrNotFound)
+
+	uri := "/api/logout"
+	expectedStatus := http.StatusUnauthorized
+
+	req, _ := http.NewRequest(http.MethodPost, s.server.URL+uri, nil)
+	req.Header.Add("Authorization", "Bearer "+s.token)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	assert.Equal(apperror.ErrTokenHasBeenRevoked.Error(), strings.TrimSpace(string(resBody)))
+	s.repositoryMocks.session.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestIdentity_Sessions() {
+	var result []map[string]interface{}
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	other := *s.entities.session
+	other.ID = "0b6e4d3c-2a1f-4e5d-9c8b-7a6f5e4d3c2b"
+	other.UserAgent = "Mozilla/5.0 (iPhone; CPU iPhone OS 14_0 like Mac OS X)"
+	other.Device = "iPhone"
+	other.LastSeenAt = time.Now().Add(-time.Hour)
+	current := *s.entities.session
+	current.LastSeenAt = time.Now()
+	items := []session.Session{other, current}
+
+	s.repositoryMocks.session.On("QueryByUserID", mock.Anything, s.entities.user.ID).Return(items, error(nil))
+
+	uri := "/api/sessions"
+	expectedStatus := http.StatusOK
+
+	req, _ := http.NewRequest(http.MethodGet, s.server.URL+uri, nil)
+	req.Header.Add("Authorization", "Bearer "+s.token)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+
+	err = json.Unmarshal(resBody, &result)
+	require.NoErrorf(err, "can not unpack json, error: %v", err)
+	require.Len(result, 2)
+
+	assert.Equal(current.ID, result[0]["id"])
+	assert.Equal(true, result[0]["current"])
+	assert.Equal(other.ID, result[1]["id"])
+	assert.Equal("iPhone", result[1]["device"])
+	assert.Equal(false, result[1]["current"])
+	_, hasToken := result[0]["token"]
+	assert.False(hasToken, "the token of a session must not be shown")
+}
+
+func (s *ApiTestSuite) TestIdentity_RevokeSession() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	other := *s.entities.session
+	other.ID = "0b6e4d3c-2a1f-4e5d-9c8b-7a6f5e4d3c2b"
+
+	s.repositoryMocks.session.On("Get", mock.Anything, other.ID).Return(&other, error(nil))
+	s.repositoryMocks.session.On("Delete", mock.Anything, &other).Return(error(nil))
+
+	uri := "/api/sessions/" + other.ID
+	expectedStatus := http.StatusOK
+
+	req, _ := http.NewRequest(http.MethodDelete, s.server.URL+uri, nil)
+	req.Header.Add("Authorization", "Bearer "+s.token)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	s.repositoryMocks.session.AssertNumberOfCalls(s.T(), "Delete", 1)
+}
+
+func (s *ApiTestSuite) TestIdentity_RevokeSessionOfAnotherUser() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	alien := *s.entities.session
+	alien.ID = "5e4d3c2b-1a0f-4e9d-8c7b-6a5f4e3d2c1b"
+	alien.UserID = s.entities.user.ID + 1
+
+	s.repositoryMocks.session.On("Get", mock.Anything, alien.ID).Return(&alien, error(nil))
+
+	uri := "/api/sessions/" + alien.ID
+	expectedStatus := http.StatusNotFound
+
+	req, _ := http.NewRequest(http.MethodDelete, s.server.URL+uri, nil)
+	req.Header.Add("Authorization", "Bearer "+s.token)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	s.repositoryMocks.session.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestIdentity_Refresh() {
+	var result map[string]interface{}
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	sess := &session.Session{}
+	*sess = *s.loginSession
+
+	s.repositoryMocks.session.On("Get", mock.Anything, sess.ID).Return(sess, error(nil))
+	s.repositoryMocks.session.On("Modify", mock.Anything, sess.ID).Return(sess, error(nil))
+	s.repositoryMocks.user.On("Get", mock.Anything, s.entities.user.ID).Return(s.entities.user, error(nil))
+
+	resp, resBody := s.refresh(s.refreshToken)
+	expectedStatus := http.StatusOK
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+
+	err := json.Unmarshal(resBody, &result)
+	require.NoErrorf(err, "can not unpack json, error: %v", err)
+
+	assert.NotEmpty(result["token"])
+	assert.NotEqual(s.refreshToken, result["refreshToken"], "the refresh token should be rotated")
+	assert.Equal(uint(1), sess.RefreshGeneration)
+	s.repositoryMocks.session.AssertNumberOfCalls(s.T(), "Modify", 1)
+}
+
+func (s *ApiTestSuite) TestIdentity_RefreshConcurrent() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	//	the same refresh token has been exchanged by a concurrent request after the session was read
+	sess := &session.Session{}
+	*sess = *s.loginSession
+	stored := &session.Session{}
+	*stored = *s.loginSession
+	stored.RefreshGeneration = 1
+
+	s.repositoryMocks.session.On("Get", mock.Anything, sess.ID).Return(sess, error(nil))
+	s.repositoryMocks.session.On("Modify", mock.Anything, sess.ID).Return(stored, error(nil))
+	s.repositoryMocks.session.On("Delete", mock.Anything, sess).Return(error(nil))
+	s.repositoryMocks.user.On("Get", mock.Anything, s.entities.user.ID).Return(s.entities.user, error(nil))
+
+	resp, resBody := s.refresh(s.refreshToken)
+	expectedStatus := http.StatusUnauthorized
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	require.Equal(apperror.ErrRefreshTokenReused.Error(), strings.TrimSpace(string(resBody)))
+	assert.Equal(uint(1), stored.RefreshGeneration, "the generation should not be bumped twice")
+	s.repositoryMocks.session.AssertNumberOfCalls(s.T(), "Delete", 1)
+}
+
+func (s *ApiTestSuite) TestIdentity_RefreshReuse() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	//	the refresh token has already been exchanged
+	sess := &session.Session{}
+	*sess = *s.loginSession
+	sess.RefreshGeneration = 1
+
+	s.repositoryMocks.session.On("Get", mock.Anything, sess.ID).Return(sess, error(nil))
+	s.repositoryMocks.session.On("Delete", mock.Anything, sess).Return(error(nil))
+
+	resp, resBody := s.refresh(s.refreshToken)
+	expectedStatus := http.StatusUnauthorized
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	require.Equal(apperror.ErrRefreshTokenReused.Error(), strings.TrimSpace(string(resBody)))
+	s.repositoryMocks.session.AssertNumberOfCalls(s.T(), "Delete", 1)
+	s.repositoryMocks.session.AssertNotCalled(s.T(), "Modify", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestIdentity_RefreshForgedToken() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	sess := &session.Session{}
+	*sess = *s.loginSession
+	sess.RefreshGeneration = 1
+
+	s.repositoryMocks.session.On("Get", mock.Anything, sess.ID).Return(sess, error(nil))
+
+	resp, resBody := s.refresh(sess.ID + ".0.forged")
+	expectedStatus := http.StatusUnauthorized
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	require.Equal(apperror.ErrInvalidRefreshToken.Error(), strings.TrimSpace(string(resBody)))
+	s.repositoryMocks.session.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+}
+
+// refresh sends the refresh token to the refresh endpoint and returns the response with its body.
+func (s *ApiTestSuite) refresh(refreshToken string) (*http.Response, []byte) {
+	require := require.New(s.T())
+
+	b, err := json.Marshal(map[string]string{"refreshToken": refreshToken})
+	require.NoError(err)
+
+	req, _ := http.NewRequest(http.MethodPost, s.server.URL+"/api/token/refresh", bytes.NewReader(b))
+	req.Header.Add("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+	return resp, resBody
+}
+
+func (s *ApiTestSuite) TestIdentity_LoginWithCurrentHash() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	searchedUser := user.New()
+	searchedUser.Name = s.entities.user.Name
+	passhash, err := bcrypt.GenerateFromPassword([]byte("demo1"), 4)
+	require.NoError(err)
+	loginUser := &user.User{}
+	*loginUser = *s.entities.user
+	loginUser.Passhash = string(passhash)
+
+	s.setupThrottle()
+	s.repositoryMocks.user.On("First", mock.Anything, searchedUser).Return(loginUser, error(nil))
+	s.repositoryMocks.session.On("NewEntity", mock.Anything, s.entities.user.ID).Return(&session.Session{ID: s.entities.session.ID}, error(nil))
+	s.repositoryMocks.session.On("Create", mock.Anything, mock.Anything).Return(error(nil))
+
+	reqBody := strings.NewReader(`{
+	"username": "demo1",
+	"password": "demo1"
+}`)
+	uri := "/api/login"
+	expectedStatus := http.StatusOK
+
+	req, _ := http.NewRequest(http.MethodPost, s.server.URL+uri, reqBody)
+	req.Header.Add("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	s.repositoryMocks.user.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestIdentity_LoginUnknownUser() {
+	assert := assert.New(s.T())
+
+	searchedUser := user.New()
+	searchedUser.Name = "unknown"
+
+	s.setupThrottle()
+	s.repositoryMocks.user.On("First", mock.Anything, searchedUser).Return(searchedUser, apperror.ErrNotFound)
+
+	resp, resBody := s.login("unknown", "demo1", "10.0.0.1")
+
+	assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(apperror.ErrInvalidCredentials.Error(), strings.TrimSpace(string(resBody)))
+	s.repositoryMocks.loginAttempt.AssertCalled(s.T(), "AddAttempt", mock.Anything, "login_user_unknown", mock.Anything, 15*time.Minute)
+	s.repositoryMocks.loginAttempt.AssertCalled(s.T(), "AddAttempt", mock.Anything, "login_ip_10.0.0.1", mock.Anything, 15*time.Minute)
+}
+
+func (s *ApiTestSuite) TestIdentity_LoginWrongPassword() {
+	assert := assert.New(s.T())
+
+	searchedUser := user.New()
+	searchedUser.Name = s.entities.user.Name
+
+	s.setupThrottle()
+	s.repositoryMocks.user.On("First", mock.Anything, searchedUser).Return(s.entities.user, error(nil))
+
+	resp, resBody := s.login(s.entities.user.Name, "wrong", "10.0.0.1")
+
+	assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+	//	the same error as for an unknown user
+	assert.Equal(apperror.ErrInvalidCredentials.Error(), strings.TrimSpace(string(resBody)))
+	s.repositoryMocks.loginAttempt.AssertNotCalled(s.T(), "ResetAttempts", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestIdentity_LoginLockout() {
+	assert := assert.New(s.T())
+
+	searchedUser := user.New()
+	searchedUser.Name = s.entities.user.Name
+
+	//	the fifth failure within the window is the second lockout of the user, so it is twice as long as the first one
+	s.repositoryMocks.loginAttempt.On("LockedFor", mock.Anything, mock.Anything).Return(time.Duration(0), error(nil))
+	s.repositoryMocks.loginAttempt.On("AddAttempt", mock.Anything, "login_user_demol", mock.Anything, mock.Anything).Return(uint(4), error(nil))
+	s.repositoryMocks.loginAttempt.On("AddAttempt", mock.Anything, "login_ip_10.0.0.1", mock.Anything, mock.Anything).Return(uint(4), error(nil))
+	s.repositoryMocks.loginAttempt.On("CountLockout", mock.Anything, "login_user_demol", 24*time.Hour).Return(uint(2), error(nil))
+	s.repositoryMocks.loginAttempt.On("Lock", mock.Anything, "login_user_demol", 2*time.Minute).Return(error(nil))
+	s.repositoryMocks.loginAttempt.On("ResetAttempts", mock.Anything, "login_user_demol").Return(error(nil))
+	s.repositoryMocks.user.On("First", mock.Anything, searchedUser).Return(s.entities.user, error(nil))
+
+	resp, _ := s.login(s.entities.user.Name, "wrong", "10.0.0.1")
+
+	assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+	s.repositoryMocks.loginAttempt.AssertCalled(s.T(), "Lock", mock.Anything, "login_user_demol", 2*time.Minute)
+	s.repositoryMocks.loginAttempt.AssertNotCalled(s.T(), "CountLockout", mock.Anything, "login_ip_10.0.0.1", mock.Anything)
+}
+
+func (s *ApiTestSuite) TestIdentity_LoginLocked() {
+	assert := assert.New(s.T())
+
+	s.repositoryMocks.loginAttempt.On("LockedFor", mock.Anything, "login_user_demol").Return(89500*time.Millisecond, error(nil))
+
+	resp, _ := s.login("Demo1", "demo1", "10.0.0.1")
+
+	assert.Equal(http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal("90", resp.Header.Get("Retry-After"))
+	//	the password is not checked while the user is locked
+	s.repositoryMocks.user.AssertNotCalled(s.T(), "First", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestIdentity_RegisterLocked() {
+	assert := assert.New(s.T())
+
+	s.repositoryMocks.loginAttempt.On("LockedFor", mock.Anything, "register_ip_10.0.0.1").Return(time.Minute, error(nil))
+
+	reqBody := strings.NewReader(`{
+	"username": "demo2",
+	"password": "demo2"
+}`)
+	req, _ := http.NewRequest(http.MethodPost, s.server.URL+"/api/register", reqBody)
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Forwarded-For", "10.0.0.1")
+	resp, err := s.client.Do(req)
+	require.NoErrorf(s.T(), err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	assert.Equal(http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal("60", resp.Header.Get("Retry-After"))
+	s.repositoryMocks.user.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestIdentity_RegisterSpoofedForwardedFor() {
+	assert := assert.New(s.T())
+
+	//	10.0.0.3 is not a trusted proxy, so the address which the client has put before it is ignored
+	s.repositoryMocks.loginAttempt.On("LockedFor", mock.Anything, "register_ip_10.0.0.3").Return(time.Minute, error(nil))
+
+	reqBody := strings.NewReader(`{
+	"username": "demo2",
+	"password": "demo2"
+}`)
+	req, _ := http.NewRequest(http.MethodPost, s.server.URL+"/api/register", reqBody)
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Forwarded-For", "10.0.0.9, 10.0.0.3, 10.0.0.2")
+	resp, err := s.client.Do(req)
+	require.NoErrorf(s.T(), err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	assert.Equal(http.StatusTooManyRequests, resp.StatusCode)
+	s.repositoryMocks.loginAttempt.AssertNotCalled(s.T(), "LockedFor", mock.Anything, "register_ip_10.0.0.9")
+	s.repositoryMocks.user.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestIdentity_RegisterTakenName() {
+	assert := assert.New(s.T())
+
+	s.setupThrottle()
+	s.repositoryMocks.user.On("First", mock.Anything, &user.User{Name: "DEMO1"}).Return(s.entities.user, error(nil))
+
+	resp, resBody := s.postJSON("/api/register", "", `{"username": "DEMO1", "password": "demo2"}`)
+	assert.Equal(http.StatusConflict, resp.StatusCode)
+	assert.Equal(apperror.ErrUsernameTaken.Error(), strings.TrimSpace(string(resBody)))
+	s.repositoryMocks.user.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) login(username, password, ip string) (*http.Response, []byte) {
+	require := require.New(s.T())
+
+	reqBody := strings.NewReader(`{
+	"username": "` + username + `",
+	"password": "` + password + `"
+}`)
+	req, _ := http.NewRequest(http.MethodPost, s.server.URL+"/api/login", reqBody)
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Forwarded-For", ip)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+	return resp, resBody
 }
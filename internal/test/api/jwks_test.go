@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	gojwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"redditclone/internal/pkg/jwt"
+)
+
+func (s *ApiTestSuite) TestJWKS() {
+	var result jwt.JWKS
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	uri := "/.well-known/jwks.json"
+	expectedStatus := http.StatusOK
+
+	req, _ := http.NewRequest(http.MethodGet, s.server.URL+uri, nil)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+
+	err = json.Unmarshal(resBody, &result)
+	require.NoErrorf(err, "can not unpack json, error: %v", err)
+
+	//	the HS256 key is a secret, it must not be published
+	require.Len(result.Keys, 2)
+	algs := map[string]string{}
+	for _, key := range result.Keys {
+		algs[key.Algorithm] = key.KeyType
+		_, ok := s.keySet.Get(key.KeyID)
+		assert.Truef(ok, "unknown key %q", key.KeyID)
+	}
+	assert.Equal(map[string]string{jwt.AlgEdDSA: "OKP", jwt.AlgRS256: "RSA"}, algs)
+}
+
+func (s *ApiTestSuite) TestIdentity_TokenOfWrongAlgorithm() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	//	a token signed by HS256 with the ID of the RS256 key must not be accepted
+	var rsaKeyID string
+	for _, key := range s.keySet.Keys {
+		if key.Algorithm == jwt.AlgRS256 {
+			rsaKeyID = key.ID
+		}
+	}
+	token := gojwt.NewWithClaims(gojwt.SigningMethodHS256, gojwt.MapClaims{
+		"sid":    s.entities.session.ID,
+		"UserID": s.entities.user.ID,
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = rsaKeyID
+	stringToken, err := token.SignedString([]byte("public key"))
+	require.NoError(err)
+
+	uri := "/api/sessions"
+	expectedStatus := http.StatusUnauthorized
+
+	req, _ := http.NewRequest(http.MethodGet, s.server.URL+uri, nil)
+	req.Header.Add("Authorization", "Bearer "+stringToken)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	s.repositoryMocks.session.AssertNotCalled(s.T(), "QueryByUserID", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/minipkg/selection_condition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"redditclone/internal/domain/modlog"
+	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/user"
+)
+
+func (s *ApiTestSuite) modLogEntry() modlog.Entry {
+	return modlog.Entry{
+		ID:         "51",
+		ActorID:    s.entities.user.ID,
+		ActorName:  s.entities.user.Name,
+		Action:     modlog.ActionDeletePost,
+		TargetType: modlog.TargetPost,
+		TargetID:   s.entities.post.ID,
+		Category:   s.entities.post.Category,
+		Before:     json.RawMessage(`{"id":"1","title":"What does a good programmer mean?"}`),
+		CreatedAt:  time.Now().Local(),
+	}
+}
+
+func (s *ApiTestSuite) TestModLog_Forbidden() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/mod/log", s.token, "")
+
+	assert.Equal(http.StatusForbidden, resp.StatusCode, string(resBody))
+	s.repositoryMocks.modLog.AssertNotCalled(s.T(), "Query", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestModLog_OtherCategory() {
+	assert := assert.New(s.T())
+	s.setupModeratorSession(post.CategoryProgramming)
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/mod/log?category="+post.CategoryMusic, s.token, "")
+
+	assert.Equal(http.StatusForbidden, resp.StatusCode, string(resBody))
+}
+
+func (s *ApiTestSuite) TestModLog_BadFilter() {
+	assert := assert.New(s.T())
+	s.setupModeratorSession(post.CategoryProgramming)
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/mod/log?action=edit_post", s.token, "")
+	assert.Equal(http.StatusBadRequest, resp.StatusCode, string(resBody))
+
+	resp, resBody = s.sendJSON(http.MethodGet, "/api/mod/log?from=2020-02-01&to=2020-01-01", s.token, "")
+	assert.Equal(http.StatusBadRequest, resp.StatusCode, string(resBody))
+}
+
+func (s *ApiTestSuite) TestModLog_Moderator() {
+	var result []modlog.Entry
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+	s.setupModeratorSession(post.CategoryProgramming)
+
+	entry := s.modLogEntry()
+	s.repositoryMocks.modLog.On("Query", mock.Anything, mock.MatchedBy(func(cond selection_condition.SelectionCondition) bool {
+		f, ok := cond.Where.(*modlog.Filter)
+		return ok && f.Action == modlog.ActionDeletePost && f.ActorID == 2 &&
+			len(f.Categories) == 1 && f.Categories[0] == post.CategoryProgramming &&
+			f.From.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) && f.To.IsZero() && cond.Limit == modlog.DefaultLimit
+	})).Return([]modlog.Entry{entry}, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/mod/log?action=delete_post&actor=2&from=2020-01-01", s.token, "")
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.Len(result, 1)
+	assert.Equal(entry.ID, result[0].ID)
+	assert.JSONEq(string(entry.Before), string(result[0].Before))
+	assert.Nil(result[0].After)
+}
+
+func (s *ApiTestSuite) TestModLog_Admin() {
+	assert := assert.New(s.T())
+	s.setupStateSession(user.RoleAdmin, user.AccountState{Status: user.StatusActive})
+
+	s.repositoryMocks.modLog.On("Query", mock.Anything, mock.MatchedBy(func(cond selection_condition.SelectionCondition) bool {
+		f, ok := cond.Where.(*modlog.Filter)
+		return ok && f.Categories == nil && f.TargetType == modlog.TargetUser && f.TargetID == "2"
+	})).Return([]modlog.Entry{}, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/mod/log?type=user&target=2", s.token, "")
+
+	assert.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	s.repositoryMocks.modLog.AssertExpectations(s.T())
+}
+
+func (s *ApiTestSuite) TestModLog_PostDeletedByModerator() {
+	assert := assert.New(s.T())
+	s.setupModeratorSession(post.CategoryProgramming)
+
+	p := &post.Post{}
+	*p = *s.entities.post
+	p.UserID = s.entities.user.ID + 1
+
+	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
+	s.repositoryMocks.post.On("Delete", mock.Anything, p.ID).Return(error(nil))
+	s.repositoryMocks.modLog.On("Create", mock.Anything, mock.MatchedBy(func(e *modlog.Entry) bool {
+		var before post.Post
+		return e.Action == modlog.ActionDeletePost && e.TargetID == p.ID && e.ActorID == s.entities.user.ID &&
+			json.Unmarshal(e.Before, &before) == nil && before.Title == p.Title && before.Comments == nil && e.After == nil
+	})).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodDelete, "/api/post/"+p.ID, s.token, "")
+
+	assert.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	s.repositoryMocks.modLog.AssertExpectations(s.T())
+}
+
+func (s *ApiTestSuite) TestModLog_PostDeletedByAuthor() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	s.repositoryMocks.post.On("Get", mock.Anything, s.entities.post.ID).Return(s.entities.post, error(nil))
+	s.repositoryMocks.post.On("Delete", mock.Anything, s.entities.post.ID).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodDelete, "/api/post/"+s.entities.post.ID, s.token, "")
+
+	assert.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	s.repositoryMocks.modLog.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
@@ -10,8 +10,12 @@ import (
 	"redditclone/internal/domain/vote"
 	"redditclone/internal/pkg/apperror"
 	"redditclone/internal/pkg/errorshandler"
+	"redditclone/internal/pkg/pagination"
+	"strings"
+	"time"
 
 	"github.com/minipkg/selection_condition"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -23,13 +27,16 @@ func (s *ApiTestSuite) TestPost_Create() {
 	require := require.New(s.T())
 	assert := assert.New(s.T())
 	s.setupSession()
+	s.setupNoBans()
 
 	newPost := &post.Post{}
 	*newPost = *s.entities.post
 	newPost.ID = ""
 	newPost.Comments = nil
 	newPost.Votes = nil
+	newPost.UpdateRanking(newPost.CreatedAt)
 
+	s.repositoryMocks.community.On("Get", mock.Anything, newPost.Category).Return(s.entities.community, error(nil))
 	s.repositoryMocks.post.On("Create", mock.Anything, newPost).Return(error(nil))
 
 	b, err := json.Marshal(newPost)
@@ -60,6 +67,17 @@ func (s *ApiTestSuite) TestPost_Create() {
 	assert.Equalf(expected, result, "results not match\nGot: %#v\nExpected: %#v", result, expectedData)
 }
 
+func (s *ApiTestSuite) TestPost_CreateInUnknownCommunity() {
+	s.setupSession()
+
+	s.repositoryMocks.community.On("Get", mock.Anything, "nothing").Return(nil, apperror.ErrNotFound)
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/posts", s.token, `{"type": "text", "category": "nothing", "title": "Title", "text": "Text"}`)
+
+	s.Equal(http.StatusBadRequest, resp.StatusCode, string(resBody))
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
 func (s *ApiTestSuite) TestPost_Delete() {
 	var result interface{}
 	var expected interface{}
@@ -73,6 +91,7 @@ func (s *ApiTestSuite) TestPost_Delete() {
 	newPost.Comments = nil
 	newPost.Votes = nil
 
+	s.repositoryMocks.post.On("Get", mock.Anything, s.entities.post.ID).Return(s.entities.post, error(nil))
 	s.repositoryMocks.post.On("Delete", mock.Anything, s.entities.post.ID).Return(error(nil))
 
 	uri := "/api/post/" + s.entities.post.ID
@@ -99,6 +118,171 @@ func (s *ApiTestSuite) TestPost_Delete() {
 	assert.Equalf(expected, result, "results not match\nGot: %#v\nExpected: %#v", result, expectedData)
 }
 
+func (s *ApiTestSuite) TestPost_DeleteForbidden() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	p := &post.Post{}
+	*p = *s.entities.post
+	p.UserID = s.entities.user.ID + 1
+
+	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
+
+	uri := "/api/post/" + p.ID
+	expectedData := errorshandler.Forbidden("")
+	expectedStatus := http.StatusForbidden
+
+	req, _ := http.NewRequest(http.MethodDelete, s.server.URL+uri, nil)
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+s.token)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	assert.Equal(expectedData.Error(), strings.TrimSpace(string(resBody)))
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Delete", mock.Anything, p.ID)
+}
+
+func (s *ApiTestSuite) TestPost_Update() {
+	var result post.Post
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	p := &post.Post{}
+	*p = *s.entities.post
+
+	input := &post.Post{
+		Title: "What does a great programmer mean?",
+		Text:  "Who can consider himself a great programmer?",
+	}
+	revision := post.NewRevision(p)
+
+	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
+	s.repositoryMocks.post.On("CreateRevision", mock.Anything, mock.MatchedBy(func(r *post.Revision) bool {
+		return r.PostID == revision.PostID && r.Title == revision.Title && r.Text == revision.Text
+	})).Return(error(nil))
+	s.repositoryMocks.post.On("Update", mock.Anything, mock.Anything).Return(error(nil))
+
+	b, err := json.Marshal(input)
+	require.NoErrorf(err, "can not json.Marshal() a value: %v, error", input, err)
+
+	uri := "/api/post/" + p.ID
+	expectedStatus := http.StatusOK
+
+	req, _ := http.NewRequest(http.MethodPut, s.server.URL+uri, bytes.NewReader(b))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+s.token)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+
+	err = json.Unmarshal(resBody, &result)
+	require.NoErrorf(err, "can not unpack json, error: %v", err)
+
+	assert.Equal(input.Title, result.Title)
+	assert.Equal(input.Text, result.Text)
+	assert.Equal(s.entities.post.Category, result.Category)
+	s.repositoryMocks.post.AssertExpectations(s.T())
+}
+
+func (s *ApiTestSuite) TestPost_Patch() {
+	var result post.Post
+	require := require.New(s.T())
+	s.setupSession()
+
+	p := &post.Post{}
+	*p = *s.entities.post
+	title := p.Title
+
+	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
+	s.repositoryMocks.post.On("CreateRevision", mock.Anything, mock.Anything).Return(error(nil))
+	s.repositoryMocks.post.On("Update", mock.Anything, mock.MatchedBy(func(updated *post.Post) bool {
+		return updated.Title == title && updated.Text == "Only the text is changed"
+	})).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPatch, "/api/post/"+p.ID, s.token, `{"text": "Only the text is changed"}`)
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	s.Equal(title, result.Title)
+	s.repositoryMocks.post.AssertExpectations(s.T())
+}
+
+func (s *ApiTestSuite) TestPost_UpdateForbidden() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	p := &post.Post{}
+	*p = *s.entities.post
+	p.UserID = s.entities.user.ID + 1
+
+	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
+
+	uri := "/api/post/" + p.ID
+	expectedData := errorshandler.Forbidden("")
+	expectedStatus := http.StatusForbidden
+
+	req, _ := http.NewRequest(http.MethodPatch, s.server.URL+uri, strings.NewReader(`{"title": "Changed title"}`))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+s.token)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	assert.Equal(expectedData.Error(), strings.TrimSpace(string(resBody)))
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "CreateRevision", mock.Anything, mock.Anything)
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestPost_Revisions() {
+	var result interface{}
+	var expected interface{}
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	revision := post.NewRevision(s.entities.post)
+	revision.ID = "21"
+	revision.CreatedAt = time.Now().Local()
+	revisions := []post.Revision{*revision}
+
+	s.repositoryMocks.post.On("Get", mock.Anything, s.entities.post.ID).Return(s.entities.post, error(nil))
+	s.repositoryMocks.post.On("QueryRevisions", mock.Anything, s.entities.post.ID).Return(revisions, error(nil))
+
+	uri := "/api/post/" + s.entities.post.ID + "/revisions"
+	expectedData := revisions
+	expectedStatus := http.StatusOK
+
+	req, _ := http.NewRequest(http.MethodGet, s.server.URL+uri, nil)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+
+	err = json.Unmarshal(resBody, &result)
+	require.NoErrorf(err, "can not unpack json, error: %v", err)
+
+	jsonData, err := json.Marshal(expectedData)
+	json.Unmarshal(jsonData, &expected)
+
+	assert.Equalf(expected, result, "results not match\nGot: %#v\nExpected: %#v", result, expectedData)
+}
+
 func (s *ApiTestSuite) TestPost_Get() {
 	var result interface{}
 	var expected interface{}
@@ -111,7 +295,7 @@ func (s *ApiTestSuite) TestPost_Get() {
 	p.Views++
 
 	s.repositoryMocks.post.On("Get", mock.Anything, s.entities.post.ID).Return(s.entities.post, error(nil))
-	s.repositoryMocks.post.On("Update", mock.Anything, p).Return(error(nil))
+	s.repositoryMocks.post.On("IncrViews", mock.Anything, s.entities.post.ID).Return(error(nil))
 
 	uri := "/api/post/" + s.entities.post.ID
 	expectedData := p
@@ -135,6 +319,9 @@ func (s *ApiTestSuite) TestPost_Get() {
 	json.Unmarshal(jsonData, &expected)
 
 	assert.Equalf(expected, result, "results not match\nGot: %#v\nExpected: %#v", result, expectedData)
+	_, ok := result.(map[string]interface{})["author"].(map[string]interface{})["role"]
+	assert.Falsef(ok, "author %v contains the private data", result.(map[string]interface{})["author"])
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything)
 }
 
 func (s *ApiTestSuite) TestPost_List() {
@@ -146,9 +333,12 @@ func (s *ApiTestSuite) TestPost_List() {
 
 	list := []post.Post{*s.entities.post}
 	query := selection_condition.SelectionCondition{
-		Where: &post.Post{},
+		Where: &post.Filter{
+			ExcludeIDs: []string{},
+		},
 	}
 
+	s.repositoryMocks.post.On("MarkedIDs", mock.Anything, s.entities.user.ID, post.MarkHide).Return([]string{}, error(nil))
 	s.repositoryMocks.post.On("Query", mock.Anything, query).Return(list, error(nil))
 
 	uri := "/api/posts"
@@ -175,6 +365,184 @@ func (s *ApiTestSuite) TestPost_List() {
 	assert.Equalf(expected, result, "results not match\nGot: %#v\nExpected: %#v", result, expectedData)
 }
 
+func (s *ApiTestSuite) TestPost_ListSorted() {
+	var result interface{}
+	var expected interface{}
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	list := []post.Post{*s.entities.post}
+	isTopOfWeek := func(cond selection_condition.SelectionCondition) bool {
+		filter, ok := cond.Where.(*post.Filter)
+		if !ok || filter.Category != post.CategoryProgramming {
+			return false
+		}
+		weekAgo := time.Now().Add(-post.Periods[post.PeriodWeek])
+		return filter.CreatedAfter.Sub(weekAgo) < time.Minute &&
+			len(cond.SortOrder) == 1 && cond.SortOrder[0]["score"] == selection_condition.SortOrderDesc
+	}
+
+	s.repositoryMocks.post.On("Query", mock.Anything, mock.MatchedBy(isTopOfWeek)).Return(list, error(nil))
+
+	uri := "/api/posts/" + post.CategoryProgramming + "?sort=top&t=week"
+	expectedData := list
+	expectedStatus := http.StatusOK
+
+	req, _ := http.NewRequest(http.MethodGet, s.server.URL+uri, nil)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+
+	err = json.Unmarshal(resBody, &result)
+	require.NoErrorf(err, "can not unpack json, error: %v", err)
+
+	jsonData, err := json.Marshal(expectedData)
+	json.Unmarshal(jsonData, &expected)
+
+	assert.Equalf(expected, result, "results not match\nGot: %#v\nExpected: %#v", result, expectedData)
+}
+
+func (s *ApiTestSuite) TestPost_ListPage() {
+	var result interface{}
+	var expected interface{}
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	older := *s.entities.post
+	older.ID = "2"
+	older.CreatedAt = s.entities.post.CreatedAt.Add(-time.Hour)
+	list := []post.Post{*s.entities.post, older}
+	isNewestFirst := func(cond selection_condition.SelectionCondition) bool {
+		filter, ok := cond.Where.(*post.Filter)
+		return ok && filter.Cursor == nil && cond.Limit == 2 && len(cond.SortOrder) == 2 &&
+			cond.SortOrder[0]["createdat"] == selection_condition.SortOrderDesc &&
+			cond.SortOrder[1]["id"] == selection_condition.SortOrderDesc
+	}
+
+	s.repositoryMocks.post.On("Query", mock.Anything, mock.MatchedBy(isNewestFirst)).Return(list, error(nil))
+
+	uri := "/api/posts?limit=1"
+	expectedData := pagination.Page{
+		Items: list[:1],
+		Next:  post.NewCursor(*s.entities.post, "createdat").Encode(),
+	}
+	expectedStatus := http.StatusOK
+
+	req, _ := http.NewRequest(http.MethodGet, s.server.URL+uri, nil)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+
+	err = json.Unmarshal(resBody, &result)
+	require.NoErrorf(err, "can not unpack json, error: %v", err)
+
+	jsonData, err := json.Marshal(expectedData)
+	json.Unmarshal(jsonData, &expected)
+
+	assert.Equalf(expected, result, "results not match\nGot: %#v\nExpected: %#v", result, expectedData)
+}
+
+func (s *ApiTestSuite) TestPost_ListPageRising() {
+	var result pagination.Page
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	//	the ranking is the speed of getting score, so an older post getting votes fast rises above a newer one without votes
+	now := time.Now()
+	rising := *s.entities.post
+	rising.Score = 40
+	rising.CreatedAt = now.Add(-6 * time.Hour)
+	rising.UpdateRanking(now)
+	newer := *s.entities.post
+	newer.ID = "2"
+	newer.Score = 0
+	newer.CreatedAt = now.Add(-time.Minute)
+	newer.UpdateRanking(now)
+	require.Greater(rising.Rising, newer.Rising)
+	//	and the score got for a long time rises slower than the same score got recently
+	slower := rising
+	slower.CreatedAt = now.Add(-20 * time.Hour)
+	slower.UpdateRanking(now)
+	require.Greater(rising.Rising, slower.Rising)
+
+	isRising := func(cond selection_condition.SelectionCondition) bool {
+		filter, ok := cond.Where.(*post.Filter)
+		dayAgo := time.Now().Add(-24 * time.Hour)
+		return ok && filter.CreatedAfter.Sub(dayAgo) < time.Minute && len(cond.SortOrder) == 2 &&
+			cond.SortOrder[0]["rising"] == selection_condition.SortOrderDesc &&
+			cond.SortOrder[1]["id"] == selection_condition.SortOrderDesc
+	}
+	s.repositoryMocks.post.On("Query", mock.Anything, mock.MatchedBy(isRising)).Return([]post.Post{rising, newer}, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/posts?sort=rising&limit=1", "", "")
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+
+	require.NoError(json.Unmarshal(resBody, &result))
+	items, ok := result.Items.([]interface{})
+	require.True(ok)
+	require.Len(items, 1)
+	assert.Equal(rising.ID, items[0].(map[string]interface{})["id"])
+	assert.Equal(post.NewCursor(rising, "rising").Encode(), result.Next)
+}
+
+// unsortedRanker is a ranking which sets no sort order
+type unsortedRanker struct{}
+
+func (r unsortedRanker) Prepare(filter *post.Filter, cond *selection_condition.SelectionCondition, now time.Time) {}
+
+func (r unsortedRanker) Rank(items []post.Post, now time.Time) {}
+
+func (s *ApiTestSuite) TestPost_ListPageUnsortedRanker() {
+	post.RegisterRanker("unsorted", func(period string) (post.Ranker, error) {
+		return unsortedRanker{}, nil
+	})
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/posts?sort=unsorted&limit=1", "", "")
+
+	s.Equal(http.StatusInternalServerError, resp.StatusCode, string(resBody))
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Query", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestPost_ListPageInvalidCursor() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	uri := "/api/posts?after=garbage"
+	expectedStatus := http.StatusBadRequest
+
+	req, _ := http.NewRequest(http.MethodGet, s.server.URL+uri, nil)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Query", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestPost_ListUnknownSort() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	uri := "/api/posts?sort=best"
+	expectedStatus := http.StatusBadRequest
+
+	req, _ := http.NewRequest(http.MethodGet, s.server.URL+uri, nil)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Query", mock.Anything, mock.Anything)
+}
+
 func (s *ApiTestSuite) TestPost_ListByCategory() {
 	var result interface{}
 	var expected interface{}
@@ -184,11 +552,15 @@ func (s *ApiTestSuite) TestPost_ListByCategory() {
 
 	list := []post.Post{*s.entities.post}
 	query := selection_condition.SelectionCondition{
-		Where: &post.Post{
-			Category: "category",
+		Where: &post.Filter{
+			Post: post.Post{
+				Category: "category",
+			},
+			ExcludeIDs: []string{},
 		},
 	}
 
+	s.repositoryMocks.post.On("MarkedIDs", mock.Anything, s.entities.user.ID, post.MarkHide).Return([]string{}, error(nil))
 	s.repositoryMocks.post.On("Query", mock.Anything, query).Return(list, error(nil))
 
 	uri := "/api/posts/category"
@@ -227,12 +599,16 @@ func (s *ApiTestSuite) TestPost_ListByUser() {
 		Name: s.entities.user.Name,
 	}
 	query := selection_condition.SelectionCondition{
-		Where: &post.Post{
-			UserID: s.entities.user.ID,
+		Where: &post.Filter{
+			Post: post.Post{
+				UserID: s.entities.user.ID,
+			},
+			ExcludeIDs: []string{},
 		},
 	}
 
 	s.repositoryMocks.user.On("First", mock.Anything, searchedUser).Return(s.entities.user, error(nil))
+	s.repositoryMocks.post.On("MarkedIDs", mock.Anything, s.entities.user.ID, post.MarkHide).Return([]string{}, error(nil))
 	s.repositoryMocks.post.On("Query", mock.Anything, query).Return(list, error(nil))
 
 	uri := "/api/user/" + s.entities.user.Name
@@ -259,12 +635,43 @@ func (s *ApiTestSuite) TestPost_ListByUser() {
 	assert.Equalf(expected, result, "results not match\nGot: %#v\nExpected: %#v", result, expectedData)
 }
 
+func (s *ApiTestSuite) TestPost_ListByDigitUser() {
+	var result []post.Post
+	require := require.New(s.T())
+	s.setupSession()
+
+	u := &user.User{}
+	*u = *s.entities.user
+	u.Name = "12345"
+	list := []post.Post{*s.entities.post}
+	query := selection_condition.SelectionCondition{
+		Where: &post.Filter{
+			Post: post.Post{
+				UserID: u.ID,
+			},
+			ExcludeIDs: []string{},
+		},
+	}
+
+	s.repositoryMocks.user.On("First", mock.Anything, &user.User{Name: u.Name}).Return(u, error(nil))
+	s.repositoryMocks.post.On("MarkedIDs", mock.Anything, u.ID, post.MarkHide).Return([]string{}, error(nil))
+	s.repositoryMocks.post.On("Query", mock.Anything, query).Return(list, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/user/"+u.Name, s.token, "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.Len(result, 1)
+	s.repositoryMocks.user.AssertNotCalled(s.T(), "Get", mock.Anything, mock.Anything)
+}
+
 func (s *ApiTestSuite) TestPost_Upvote() {
 	var result interface{}
 	var expected interface{}
 	require := require.New(s.T())
 	assert := assert.New(s.T())
 	s.setupSession()
+	s.setupNoBans()
 
 	newVote := s.api.Domain.Vote.Service.NewEntity(s.entities.vote.UserID, s.entities.vote.PostID, 1)
 	newVote.User = *s.entities.user
@@ -273,18 +680,19 @@ func (s *ApiTestSuite) TestPost_Upvote() {
 		PostID: s.entities.vote.PostID,
 		UserID: s.entities.vote.UserID,
 	}
-	p := &post.Post{}
-	*p = *s.entities.post
-	p.Score--
+	updated := &post.Post{}
+	*updated = *s.entities.post
+	updated.Ups++
+	updated.UpdateRanking(time.Now())
 
 	s.repositoryMocks.vote.On("First", mock.Anything, searchedVote).Return(nil, apperror.ErrNotFound)
 	s.repositoryMocks.vote.On("Create", mock.Anything, newVote).Return(error(nil))
 
-	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
-	s.repositoryMocks.post.On("Update", mock.Anything, s.entities.post).Return(error(nil))
+	s.repositoryMocks.post.On("ChangeScore", mock.Anything, updated.ID, 1, 0).Return(error(nil))
+	s.repositoryMocks.post.On("Get", mock.Anything, updated.ID).Return(updated, error(nil))
 
 	uri := "/api/post/" + s.entities.post.ID + "/upvote"
-	expectedData := s.entities.post
+	expectedData := updated
 	expectedStatus := http.StatusOK
 
 	req, _ := http.NewRequest(http.MethodGet, s.server.URL+uri, nil)
@@ -307,12 +715,55 @@ func (s *ApiTestSuite) TestPost_Upvote() {
 	assert.Equalf(expected, result, "results not match\nGot: %#v\nExpected: %#v", result, expectedData)
 }
 
+func (s *ApiTestSuite) TestPost_UpvoteConcurrent() {
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+	s.setupNoBans()
+
+	newVote := s.api.Domain.Vote.Service.NewEntity(s.entities.vote.UserID, s.entities.vote.PostID, 1)
+	newVote.User = *s.entities.user
+
+	searchedVote := &vote.Vote{
+		PostID: s.entities.vote.PostID,
+		UserID: s.entities.vote.UserID,
+	}
+	//	the downvote of the same user has been saved concurrently
+	concurrent := &vote.Vote{}
+	*concurrent = *s.entities.vote
+	concurrent.Value = -1
+	isUpvote := func(v *vote.Vote) bool {
+		return v.ID == concurrent.ID && v.Value == 1
+	}
+
+	s.repositoryMocks.vote.On("First", mock.Anything, searchedVote).Return(nil, apperror.ErrNotFound).Once()
+	s.repositoryMocks.vote.On("Create", mock.Anything, newVote).Return(errors.Wrap(apperror.ErrConflict, "duplicate")).Once()
+	s.repositoryMocks.vote.On("First", mock.Anything, searchedVote).Return(concurrent, error(nil)).Once()
+	s.repositoryMocks.vote.On("UpdateValue", mock.Anything, mock.MatchedBy(isUpvote), -1).Return(error(nil))
+
+	s.repositoryMocks.post.On("ChangeScore", mock.Anything, s.entities.post.ID, 1, -1).Return(error(nil))
+	s.repositoryMocks.post.On("Get", mock.Anything, s.entities.post.ID).Return(s.entities.post, error(nil))
+
+	uri := "/api/post/" + s.entities.post.ID + "/upvote"
+	expectedStatus := http.StatusOK
+
+	req, _ := http.NewRequest(http.MethodGet, s.server.URL+uri, nil)
+	req.Header.Add("Authorization", "Bearer "+s.token)
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	assert.Equalf(expectedStatus, resp.StatusCode, "expected http status %v, got %v", expectedStatus, resp.StatusCode)
+	s.repositoryMocks.post.AssertNumberOfCalls(s.T(), "ChangeScore", 1)
+}
+
 func (s *ApiTestSuite) TestPost_Downvote() {
 	var result interface{}
 	var expected interface{}
 	require := require.New(s.T())
 	assert := assert.New(s.T())
 	s.setupSession()
+	s.setupNoBans()
 
 	newVote := s.api.Domain.Vote.Service.NewEntity(s.entities.vote.UserID, s.entities.vote.PostID, -1)
 	newVote.User = *s.entities.user
@@ -321,18 +772,19 @@ func (s *ApiTestSuite) TestPost_Downvote() {
 		PostID: s.entities.vote.PostID,
 		UserID: s.entities.vote.UserID,
 	}
-	p := &post.Post{}
-	*p = *s.entities.post
-	p.Score++
+	updated := &post.Post{}
+	*updated = *s.entities.post
+	updated.Downs++
+	updated.UpdateRanking(time.Now())
 
 	s.repositoryMocks.vote.On("First", mock.Anything, searchedVote).Return(nil, apperror.ErrNotFound)
 	s.repositoryMocks.vote.On("Create", mock.Anything, newVote).Return(error(nil))
 
-	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
-	s.repositoryMocks.post.On("Update", mock.Anything, s.entities.post).Return(error(nil))
+	s.repositoryMocks.post.On("ChangeScore", mock.Anything, updated.ID, 0, 1).Return(error(nil))
+	s.repositoryMocks.post.On("Get", mock.Anything, updated.ID).Return(updated, error(nil))
 
 	uri := "/api/post/" + s.entities.post.ID + "/downvote"
-	expectedData := s.entities.post
+	expectedData := updated
 	expectedStatus := http.StatusOK
 
 	req, _ := http.NewRequest(http.MethodGet, s.server.URL+uri, nil)
@@ -361,6 +813,7 @@ func (s *ApiTestSuite) TestPost_Unvote() {
 	require := require.New(s.T())
 	assert := assert.New(s.T())
 	s.setupSession()
+	s.setupNoBans()
 
 	newVote := s.api.Domain.Vote.Service.NewEntity(s.entities.vote.UserID, s.entities.vote.PostID, 1)
 	newVote.User = *s.entities.user
@@ -376,8 +829,8 @@ func (s *ApiTestSuite) TestPost_Unvote() {
 	s.repositoryMocks.vote.On("First", mock.Anything, searchedVote).Return(s.entities.vote, nil)
 	s.repositoryMocks.vote.On("Delete", mock.Anything, s.entities.vote.ID).Return(error(nil))
 
+	s.repositoryMocks.post.On("ChangeScore", mock.Anything, p.ID, -1, 0).Return(error(nil))
 	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
-	s.repositoryMocks.post.On("Update", mock.Anything, p).Return(error(nil))
 
 	uri := "/api/post/" + s.entities.post.ID + "/unvote"
 	expectedData := p
@@ -402,3 +855,113 @@ func (s *ApiTestSuite) TestPost_Unvote() {
 
 	assert.Equalf(expected, result, "results not match\nGot: %#v\nExpected: %#v", result, expectedData)
 }
+
+func (s *ApiTestSuite) TestPost_Save() {
+	s.setupSession()
+
+	isSave := func(m *post.Mark) bool {
+		return m.UserID == s.entities.user.ID && m.PostID == s.entities.post.ID && m.Kind == post.MarkSave
+	}
+	s.repositoryMocks.post.On("Get", mock.Anything, s.entities.post.ID).Return(s.entities.post, error(nil))
+	s.repositoryMocks.post.On("CreateMark", mock.Anything, mock.MatchedBy(isSave)).Return(errors.Wrap(apperror.ErrConflict, "already saved"))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/post/"+s.entities.post.ID+"/save", s.token, "")
+
+	s.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	s.repositoryMocks.post.AssertCalled(s.T(), "CreateMark", mock.Anything, mock.MatchedBy(isSave))
+}
+
+func (s *ApiTestSuite) TestPost_SaveNotFound() {
+	s.setupSession()
+
+	s.repositoryMocks.post.On("Get", mock.Anything, "nothing").Return(nil, apperror.ErrNotFound)
+
+	resp, _ := s.sendJSON(http.MethodPost, "/api/post/nothing/save", s.token, "")
+
+	s.Equal(http.StatusNotFound, resp.StatusCode)
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "CreateMark", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestPost_SaveUnauthorized() {
+	resp, _ := s.sendJSON(http.MethodPost, "/api/post/"+s.entities.post.ID+"/save", "", "")
+	s.Equal(http.StatusUnauthorized, resp.StatusCode)
+}
+
+func (s *ApiTestSuite) TestPost_Unhide() {
+	s.setupSession()
+
+	mark := &post.Mark{
+		UserID: s.entities.user.ID,
+		PostID: s.entities.post.ID,
+		Kind:   post.MarkHide,
+	}
+	s.repositoryMocks.post.On("DeleteMark", mock.Anything, mark).Return(apperror.ErrNotFound)
+
+	resp, resBody := s.sendJSON(http.MethodDelete, "/api/post/"+s.entities.post.ID+"/hide", s.token, "")
+
+	s.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+}
+
+func (s *ApiTestSuite) TestPost_Saved() {
+	var result feedPage
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	marks := []post.Mark{
+		{ID: "31", UserID: s.entities.user.ID, PostID: "c", Kind: post.MarkSave, CreatedAt: time.Now()},
+		{ID: "30", UserID: s.entities.user.ID, PostID: "deleted", Kind: post.MarkSave, CreatedAt: time.Now().Add(-time.Minute)},
+		{ID: "29", UserID: s.entities.user.ID, PostID: "a", Kind: post.MarkSave, CreatedAt: time.Now().Add(-2 * time.Minute)},
+	}
+	items := s.feedPosts(3)
+	isSavedQuery := func(cond selection_condition.SelectionCondition) bool {
+		filter, ok := cond.Where.(*post.MarkFilter)
+		return ok && filter.UserID == s.entities.user.ID && filter.Kind == post.MarkSave && filter.Cursor == nil && cond.Limit == 3
+	}
+	postsQuery := selection_condition.SelectionCondition{
+		Where: &post.Filter{IDs: []string{"c", "deleted"}},
+	}
+	s.repositoryMocks.post.On("QueryMarks", mock.Anything, mock.MatchedBy(isSavedQuery)).Return(marks, error(nil))
+	s.repositoryMocks.post.On("Query", mock.Anything, postsQuery).Return([]post.Post{items[0], items[2]}, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/me/saved?limit=2", s.token, "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.Len(result.Items, 1)
+	assert.Equal("c", result.Items[0].ID)
+	assert.NotEmpty(result.Next)
+	assert.Empty(result.Prev)
+}
+
+func (s *ApiTestSuite) TestPost_ListHidden() {
+	var result []post.Post
+	require := require.New(s.T())
+	s.setupSession()
+
+	items := s.feedPosts(2)
+	query := selection_condition.SelectionCondition{
+		Where: &post.Filter{
+			ExcludeIDs: []string{"z"},
+		},
+	}
+	s.repositoryMocks.post.On("MarkedIDs", mock.Anything, s.entities.user.ID, post.MarkHide).Return([]string{"z"}, error(nil))
+	s.repositoryMocks.post.On("Query", mock.Anything, query).Return(items, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/posts", s.token, "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	s.Len(result, 2)
+
+	//	the listing of an anonymous user does not depend on the hidden items
+	anonymousQuery := selection_condition.SelectionCondition{
+		Where: &post.Filter{},
+	}
+	s.repositoryMocks.post.On("Query", mock.Anything, anonymousQuery).Return(items, error(nil))
+
+	resp, resBody = s.sendJSON(http.MethodGet, "/api/posts", "", "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	s.repositoryMocks.post.AssertNumberOfCalls(s.T(), "MarkedIDs", 1)
+}
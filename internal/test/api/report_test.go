@@ -0,0 +1,253 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/minipkg/selection_condition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"redditclone/internal/domain/modlog"
+	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/report"
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/session"
+)
+
+// setupModeratorSession sets up the session of the current user as the session of a moderator of the categories.
+func (s *ApiTestSuite) setupModeratorSession(categories ...string) {
+	newSession := &session.Session{}
+	*newSession = *s.entities.session
+	newSession.LastSeenAt = time.Now()
+	newSession.Data = session.Data{
+		UserID:              s.entities.user.ID,
+		UserName:            s.entities.user.Name,
+		Role:                user.RoleModerator,
+		ModeratedCategories: categories,
+		ExpirationTokenTime: time.Now().Local().Add(time.Hour),
+	}
+	s.repositoryMocks.session.On("Get", mock.Anything, s.entities.session.ID).Return(newSession, error(nil))
+}
+
+func (s *ApiTestSuite) queueItem() *report.Item {
+	return &report.Item{
+		ID:         "21",
+		TargetType: report.TargetPost,
+		TargetID:   s.entities.post.ID,
+		PostID:     s.entities.post.ID,
+		Category:   s.entities.post.Category,
+		Status:     report.StatusOpen,
+		Count:      2,
+		Reasons:    map[string]uint{report.ReasonSpam: 2},
+		CreatedAt:  time.Now().Local(),
+		UpdatedAt:  time.Now().Local(),
+	}
+}
+
+func (s *ApiTestSuite) TestReport_Post() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	s.repositoryMocks.post.On("Get", mock.Anything, s.entities.post.ID).Return(s.entities.post, error(nil))
+	s.repositoryMocks.report.On("Create", mock.Anything, mock.MatchedBy(func(r *report.Report) bool {
+		return r.TargetType == report.TargetPost && r.TargetID == s.entities.post.ID && r.UserID == s.entities.user.ID && r.Reason == report.ReasonSpam
+	})).Return(error(nil))
+	s.repositoryMocks.report.On("AddToQueue", mock.Anything, mock.Anything, mock.MatchedBy(func(item *report.Item) bool {
+		return item.PostID == s.entities.post.ID && item.Category == s.entities.post.Category
+	})).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/post/"+s.entities.post.ID+"/report", s.token, `{"reason": "spam"}`)
+
+	assert.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	s.repositoryMocks.report.AssertExpectations(s.T())
+}
+
+func (s *ApiTestSuite) TestReport_PostTwice() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	s.repositoryMocks.post.On("Get", mock.Anything, s.entities.post.ID).Return(s.entities.post, error(nil))
+	s.repositoryMocks.report.On("Create", mock.Anything, mock.Anything).Return(apperror.ErrConflict)
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/post/"+s.entities.post.ID+"/report", s.token, `{"reason": "spam"}`)
+
+	assert.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	s.repositoryMocks.report.AssertNotCalled(s.T(), "AddToQueue", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestReport_PostBadReason() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/post/"+s.entities.post.ID+"/report", s.token, `{"reason": "boring"}`)
+	assert.Equal(http.StatusBadRequest, resp.StatusCode, string(resBody))
+
+	resp, resBody = s.sendJSON(http.MethodPost, "/api/post/"+s.entities.post.ID+"/report", s.token, `{"reason": "other"}`)
+	assert.Equal(http.StatusBadRequest, resp.StatusCode, string(resBody))
+
+	s.repositoryMocks.report.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestReport_Comment() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	s.repositoryMocks.comment.On("Get", mock.Anything, s.entities.comment.ID).Return(s.entities.comment, error(nil))
+	s.repositoryMocks.post.On("Get", mock.Anything, s.entities.post.ID).Return(s.entities.post, error(nil))
+	s.repositoryMocks.report.On("Create", mock.Anything, mock.MatchedBy(func(r *report.Report) bool {
+		return r.TargetType == report.TargetComment && r.TargetID == s.entities.comment.ID && r.Details == "Off topic"
+	})).Return(error(nil))
+	s.repositoryMocks.report.On("AddToQueue", mock.Anything, mock.Anything, mock.MatchedBy(func(item *report.Item) bool {
+		return item.PostID == s.entities.post.ID && item.Category == s.entities.post.Category
+	})).Return(error(nil))
+
+	uri := "/api/post/" + s.entities.post.ID + "/" + s.entities.comment.ID + "/report"
+	resp, resBody := s.sendJSON(http.MethodPost, uri, s.token, `{"reason": "other", "details": "Off topic"}`)
+
+	assert.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	s.repositoryMocks.report.AssertExpectations(s.T())
+}
+
+func (s *ApiTestSuite) TestReport_CommentOfOtherPost() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	s.repositoryMocks.comment.On("Get", mock.Anything, s.entities.comment.ID).Return(s.entities.comment, error(nil))
+
+	uri := "/api/post/2/" + s.entities.comment.ID + "/report"
+	resp, resBody := s.sendJSON(http.MethodPost, uri, s.token, `{"reason": "spam"}`)
+
+	assert.Equal(http.StatusNotFound, resp.StatusCode, string(resBody))
+	s.repositoryMocks.report.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestReport_QueueForbidden() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/mod/queue", s.token, "")
+
+	assert.Equal(http.StatusForbidden, resp.StatusCode, string(resBody))
+	s.repositoryMocks.report.AssertNotCalled(s.T(), "QueryItems", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestReport_QueueOtherCategory() {
+	assert := assert.New(s.T())
+	s.setupModeratorSession(post.CategoryProgramming)
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/mod/queue?category="+post.CategoryMusic, s.token, "")
+
+	assert.Equal(http.StatusForbidden, resp.StatusCode, string(resBody))
+}
+
+func (s *ApiTestSuite) TestReport_Queue() {
+	var result []report.Item
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+	s.setupModeratorSession(post.CategoryProgramming)
+
+	item := s.queueItem()
+	s.repositoryMocks.report.On("QueryItems", mock.Anything, mock.MatchedBy(func(cond selection_condition.SelectionCondition) bool {
+		f, ok := cond.Where.(*report.QueueFilter)
+		return ok && f.Status == report.StatusOpen && f.TargetType == report.TargetPost &&
+			len(f.Categories) == 1 && f.Categories[0] == post.CategoryProgramming && cond.Limit == report.DefaultLimit
+	})).Return([]report.Item{*item}, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/mod/queue?type=post", s.token, "")
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.Len(result, 1)
+	assert.Equal(item.ID, result[0].ID)
+	assert.Equal(item.Count, result[0].Count)
+}
+
+func (s *ApiTestSuite) TestReport_Remove() {
+	var result report.Item
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+	s.setupModeratorSession(post.CategoryProgramming)
+
+	item := s.queueItem()
+	s.repositoryMocks.report.On("GetItem", mock.Anything, item.ID).Return(item, error(nil))
+	s.repositoryMocks.post.On("Get", mock.Anything, s.entities.post.ID).Return(s.entities.post, error(nil))
+	s.repositoryMocks.post.On("Delete", mock.Anything, s.entities.post.ID).Return(error(nil))
+	s.repositoryMocks.report.On("Resolve", mock.Anything, item.ID, report.StatusRemoved, mock.MatchedBy(func(r *report.Resolution) bool {
+		return r.ModeratorID == s.entities.user.ID && r.Reason == "Spam"
+	})).Return(error(nil))
+	s.repositoryMocks.modLog.On("Create", mock.Anything, mock.MatchedBy(func(e *modlog.Entry) bool {
+		return e.Action == modlog.ActionRemoveReport && e.TargetType == modlog.TargetPost && e.TargetID == s.entities.post.ID &&
+			e.Category == s.entities.post.Category && e.Reason == "Spam" && e.ActorID == s.entities.user.ID && len(e.Before) > 0 && len(e.After) > 0
+	})).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/mod/queue/"+item.ID+"/remove", s.token, `{"reason": "Spam"}`)
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+
+	require.NoError(json.Unmarshal(resBody, &result))
+	assert.Equal(report.StatusRemoved, result.Status)
+	s.repositoryMocks.post.AssertExpectations(s.T())
+	s.repositoryMocks.report.AssertExpectations(s.T())
+	s.repositoryMocks.modLog.AssertExpectations(s.T())
+}
+
+func (s *ApiTestSuite) TestReport_RemoveWithoutReason() {
+	assert := assert.New(s.T())
+	s.setupModeratorSession(post.CategoryProgramming)
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/mod/queue/21/remove", s.token, `{}`)
+
+	assert.Equal(http.StatusBadRequest, resp.StatusCode, string(resBody))
+	s.repositoryMocks.report.AssertNotCalled(s.T(), "GetItem", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestReport_ApproveResolved() {
+	assert := assert.New(s.T())
+	s.setupModeratorSession(post.CategoryProgramming)
+
+	item := s.queueItem()
+	item.Status = report.StatusDismissed
+	s.repositoryMocks.report.On("GetItem", mock.Anything, item.ID).Return(item, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/mod/queue/"+item.ID+"/approve", s.token, "")
+
+	assert.Equal(http.StatusConflict, resp.StatusCode, string(resBody))
+	s.repositoryMocks.report.AssertNotCalled(s.T(), "Resolve", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestReport_DismissForbidden() {
+	assert := assert.New(s.T())
+	s.setupModeratorSession(post.CategoryMusic)
+
+	item := s.queueItem()
+	s.repositoryMocks.report.On("GetItem", mock.Anything, item.ID).Return(item, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPost, "/api/mod/queue/"+item.ID+"/dismiss", s.token, "")
+
+	assert.Equal(http.StatusForbidden, resp.StatusCode, string(resBody))
+	s.repositoryMocks.report.AssertNotCalled(s.T(), "Resolve", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestReport_CountsForModerator() {
+	var result post.Post
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+	s.setupModeratorSession(post.CategoryProgramming)
+
+	p := &post.Post{}
+	*p = *s.entities.post
+
+	s.repositoryMocks.post.On("Get", mock.Anything, p.ID).Return(p, error(nil))
+	s.repositoryMocks.post.On("IncrViews", mock.Anything, p.ID).Return(error(nil))
+	s.repositoryMocks.report.On("Counts", mock.Anything, report.TargetPost, []string{p.ID}).Return(map[string]uint{p.ID: 3}, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/post/"+p.ID, s.token, "")
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.NotNil(result.Reports)
+	assert.Equal(uint(3), *result.Reports)
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/minipkg/selection_condition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"redditclone/internal/domain/comment"
+	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/search"
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
+)
+
+// setupSearchIndex sets up the posts of the in-process search index and the query of the found posts
+func (s *ApiTestSuite) setupSearchIndex(posts ...post.Post) {
+	isIndexQuery := func(cond selection_condition.SelectionCondition) bool {
+		filter, ok := cond.Where.(*post.Filter)
+		return ok && filter.IDs == nil && filter.Cursor == nil && cond.Limit > 0
+	}
+	s.repositoryMocks.post.On("Query", mock.Anything, mock.MatchedBy(isIndexQuery)).Return(posts, error(nil))
+}
+
+func (s *ApiTestSuite) TestSearch() {
+	var result []search.Result
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	other := *s.entities.post
+	other.ID = "2"
+	other.Title = "Music of the week"
+	other.Text = "Nothing about programming"
+	other.Comments = nil
+	s.setupSearchIndex(*s.entities.post, other)
+	found := selection_condition.SelectionCondition{
+		Where: &post.Filter{IDs: []string{s.entities.post.ID}},
+		Limit: 1,
+	}
+	s.repositoryMocks.post.On("Query", mock.Anything, found).Return([]post.Post{*s.entities.post}, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/search?q="+url.QueryEscape("Good programmer"), "", "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.Len(result, 1)
+	assert.Equal(search.KindPost, result[0].Kind)
+	assert.Equal(s.entities.post.ID, result[0].Post.ID)
+	assert.Nil(result[0].Comment)
+	assert.Equal("What does a <em>good</em> <em>programmer</em> mean?", result[0].Highlights.Title)
+	assert.Equal("Who can consider himself a <em>good</em> <em>programmer</em>?", result[0].Highlights.Text)
+}
+
+func (s *ApiTestSuite) TestSearch_Comment() {
+	var result []search.Result
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	s.setupSearchIndex(*s.entities.post)
+	found := selection_condition.SelectionCondition{
+		Where: &post.Filter{IDs: []string{s.entities.post.ID}},
+		Limit: 1,
+	}
+	s.repositoryMocks.post.On("Query", mock.Anything, found).Return([]post.Post{*s.entities.post}, error(nil))
+	s.repositoryMocks.comment.On("Get", mock.Anything, s.entities.comment.ID).Return(s.entities.comment, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/search?q=comments&category="+post.CategoryProgramming+"&from=2000-01-01", "", "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.Len(result, 1)
+	assert.Equal(search.KindComment, result[0].Kind)
+	require.NotNil(result[0].Comment)
+	assert.Equal(s.entities.comment.ID, result[0].Comment.ID)
+	assert.Equal(s.entities.post.ID, result[0].Post.ID)
+	assert.Equal("Who care about <em>comments</em>?", result[0].Highlights.Text)
+}
+
+func (s *ApiTestSuite) TestSearch_ShadowedHidden() {
+	var result []search.Result
+	require := require.New(s.T())
+
+	shadowed := *s.entities.post
+	shadowed.UserID = 2
+	shadowed.Shadowed = true
+	s.setupSearchIndex(shadowed)
+	found := selection_condition.SelectionCondition{
+		Where: &post.Filter{IDs: []string{shadowed.ID}},
+		Limit: 1,
+	}
+	s.repositoryMocks.post.On("Query", mock.Anything, found).Return([]post.Post{shadowed}, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/search?q="+url.QueryEscape("Good programmer"), "", "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	s.Empty(result, "the post of a shadowbanned user should not be found")
+}
+
+func (s *ApiTestSuite) TestSearch_ShadowedCommentHidden() {
+	var result []search.Result
+	require := require.New(s.T())
+
+	shadowed := *s.entities.comment
+	shadowed.UserID = 2
+	shadowed.Shadowed = true
+	p := *s.entities.post
+	p.Comments = []comment.Comment{shadowed}
+	s.setupSearchIndex(p)
+	found := selection_condition.SelectionCondition{
+		Where: &post.Filter{IDs: []string{p.ID}},
+		Limit: 1,
+	}
+	s.repositoryMocks.post.On("Query", mock.Anything, found).Return([]post.Post{p}, error(nil))
+	s.repositoryMocks.comment.On("Get", mock.Anything, shadowed.ID).Return(&shadowed, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/search?q=comments", "", "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	s.Empty(result, "the comment of a shadowbanned user should not be found")
+}
+
+func (s *ApiTestSuite) TestSearch_ShadowedSeenByAuthor() {
+	var result []search.Result
+	require := require.New(s.T())
+	s.setupSession()
+
+	shadowed := *s.entities.post
+	shadowed.Shadowed = true
+	s.setupSearchIndex(shadowed)
+	found := selection_condition.SelectionCondition{
+		Where: &post.Filter{IDs: []string{shadowed.ID}},
+		Limit: 1,
+	}
+	s.repositoryMocks.post.On("Query", mock.Anything, found).Return([]post.Post{shadowed}, error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/search?q="+url.QueryEscape("Good programmer"), s.token, "")
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	require.Len(result, 1)
+	s.Equal(shadowed.ID, result[0].Post.ID)
+}
+
+func (s *ApiTestSuite) TestSearch_UnknownAuthor() {
+	s.repositoryMocks.user.On("First", mock.Anything, &user.User{Name: "nobody"}).Return(nil, apperror.ErrNotFound)
+
+	resp, _ := s.sendJSON(http.MethodGet, "/api/search?q=programmer&author=nobody", "", "")
+
+	s.Equal(http.StatusNotFound, resp.StatusCode)
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Query", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestSearch_BadRequest() {
+	for _, uri := range []string{
+		"/api/search",
+		"/api/search?q=programmer&type=video",
+		"/api/search?q=programmer&from=yesterday",
+		"/api/search?q=programmer&from=2020-02-01&to=2020-01-01",
+	} {
+		resp, _ := s.sendJSON(http.MethodGet, uri, "", "")
+		s.Equalf(http.StatusBadRequest, resp.StatusCode, "uri: %v", uri)
+	}
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "Query", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,244 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
+	"redditclone/internal/pkg/session"
+	"redditclone/internal/pkg/totp"
+)
+
+const (
+	totpSecret   = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	recoveryCode = "abcd-efgh"
+)
+
+// twoFactorUser returns the user with the enabled two-factor authentication, the password is "demo1".
+func (s *ApiTestSuite) twoFactorUser() *user.User {
+	passhash, err := bcrypt.GenerateFromPassword([]byte("demo1"), 4)
+	require.NoError(s.T(), err)
+	sum := sha256.Sum256([]byte("abcdefgh"))
+
+	u := &user.User{}
+	*u = *s.entities.user
+	u.Passhash = string(passhash)
+	u.TOTPSecret = totpSecret
+	u.TOTPEnabled = true
+	u.RecoveryCodes = []string{hex.EncodeToString(sum[:])}
+	return u
+}
+
+// currentCode returns the TOTP code of the secret for now.
+func (s *ApiTestSuite) currentCode(secret string) string {
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(s.T(), err)
+	return code
+}
+
+// loginFirstFactor logs in by the password of the user with the two-factor authentication and returns the pre-auth token.
+func (s *ApiTestSuite) loginFirstFactor(u *user.User) string {
+	require := require.New(s.T())
+
+	searchedUser := user.New()
+	searchedUser.Name = u.Name
+	s.repositoryMocks.user.On("First", mock.Anything, searchedUser).Return(u, error(nil))
+
+	resp, resBody := s.login(u.Name, "demo1", "10.0.0.1")
+	require.Equal(http.StatusOK, resp.StatusCode)
+
+	var res map[string]string
+	require.NoError(json.Unmarshal(resBody, &res))
+	_, ok := res["token"]
+	require.Falsef(ok, "result %v contains a token before the second factor", res)
+	require.NotEmpty(res["preAuthToken"])
+	return res["preAuthToken"]
+}
+
+func (s *ApiTestSuite) postJSON(uri, token, body string) (*http.Response, []byte) {
+	return s.sendJSON(http.MethodPost, uri, token, body)
+}
+
+func (s *ApiTestSuite) sendJSON(method, uri, token, body string) (*http.Response, []byte) {
+	require := require.New(s.T())
+
+	req, _ := http.NewRequest(method, s.server.URL+uri, strings.NewReader(body))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Forwarded-For", "10.0.0.1")
+	if token != "" {
+		req.Header.Add("Authorization", "Bearer "+token)
+	}
+	resp, err := s.client.Do(req)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+	return resp, resBody
+}
+
+func (s *ApiTestSuite) TestTwoFactor_Login() {
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+	u := s.twoFactorUser()
+
+	s.setupThrottle()
+	s.repositoryMocks.user.On("Get", mock.Anything, u.ID).Return(u, error(nil))
+	isStepSaved := func(u *user.User) bool {
+		return u.TOTPLastStep == totp.Step(time.Now()) || u.TOTPLastStep == totp.Step(time.Now())-1
+	}
+	s.repositoryMocks.user.On("Update", mock.Anything, mock.MatchedBy(isStepSaved)).Return(error(nil))
+	s.repositoryMocks.session.On("NewEntity", mock.Anything, u.ID).Return(&session.Session{ID: s.entities.session.ID}, error(nil))
+	s.repositoryMocks.session.On("Create", mock.Anything, mock.Anything).Return(error(nil))
+
+	preAuthToken := s.loginFirstFactor(u)
+	s.repositoryMocks.session.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+
+	resp, resBody := s.postJSON("/api/login/2fa", "", `{"preAuthToken": "`+preAuthToken+`", "code": "`+s.currentCode(totpSecret)+`"}`)
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+
+	var res map[string]string
+	require.NoError(json.Unmarshal(resBody, &res))
+	assert.NotEmpty(res["token"])
+	assert.NotEmpty(res["refreshToken"])
+	s.repositoryMocks.user.AssertNumberOfCalls(s.T(), "Update", 1)
+	s.repositoryMocks.loginAttempt.AssertCalled(s.T(), "ResetAttempts", mock.Anything, "login_user_demol")
+}
+
+func (s *ApiTestSuite) TestTwoFactor_LoginByRecoveryCode() {
+	require := require.New(s.T())
+	u := s.twoFactorUser()
+
+	s.setupThrottle()
+	s.repositoryMocks.user.On("Get", mock.Anything, u.ID).Return(u, error(nil))
+	isCodeUsed := func(u *user.User) bool {
+		return len(u.RecoveryCodes) == 0
+	}
+	s.repositoryMocks.user.On("Update", mock.Anything, mock.MatchedBy(isCodeUsed)).Return(error(nil))
+	s.repositoryMocks.session.On("NewEntity", mock.Anything, u.ID).Return(&session.Session{ID: s.entities.session.ID}, error(nil))
+	s.repositoryMocks.session.On("Create", mock.Anything, mock.Anything).Return(error(nil))
+
+	preAuthToken := s.loginFirstFactor(u)
+
+	resp, resBody := s.postJSON("/api/login/2fa", "", `{"preAuthToken": "`+preAuthToken+`", "code": "`+strings.ToUpper(recoveryCode)+`"}`)
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	s.repositoryMocks.user.AssertNumberOfCalls(s.T(), "Update", 1)
+}
+
+func (s *ApiTestSuite) TestTwoFactor_LoginByWrongCode() {
+	assert := assert.New(s.T())
+	u := s.twoFactorUser()
+
+	s.setupThrottle()
+	s.repositoryMocks.user.On("Get", mock.Anything, u.ID).Return(u, error(nil))
+
+	preAuthToken := s.loginFirstFactor(u)
+
+	resp, resBody := s.postJSON("/api/login/2fa", "", `{"preAuthToken": "`+preAuthToken+`", "code": "000000"}`)
+	assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(apperror.ErrInvalidTwoFactorCode.Error(), strings.TrimSpace(string(resBody)))
+	s.repositoryMocks.loginAttempt.AssertCalled(s.T(), "AddAttempt", mock.Anything, "login_user_demol", mock.Anything, mock.Anything)
+	s.repositoryMocks.session.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestTwoFactor_PreAuthTokenIsNotAccessToken() {
+	assert := assert.New(s.T())
+	u := s.twoFactorUser()
+
+	s.setupThrottle()
+	preAuthToken := s.loginFirstFactor(u)
+
+	resp, resBody := s.postJSON("/api/logout", preAuthToken, "")
+	assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(apperror.ErrNotAccessToken.Error(), strings.TrimSpace(string(resBody)))
+}
+
+func (s *ApiTestSuite) TestTwoFactor_Enroll() {
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+	s.setupSession()
+
+	u := &user.User{}
+	*u = *s.entities.user
+	s.repositoryMocks.user.On("Get", mock.Anything, u.ID).Return(u, error(nil))
+	isEnrolled := func(u *user.User) bool {
+		return u.TOTPSecret != "" && !u.TOTPEnabled
+	}
+	s.repositoryMocks.user.On("Update", mock.Anything, mock.MatchedBy(isEnrolled)).Return(error(nil))
+
+	resp, resBody := s.postJSON("/api/me/2fa/enroll", s.token, "")
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+
+	var res map[string]string
+	require.NoError(json.Unmarshal(resBody, &res))
+	assert.Equal(u.TOTPSecret, res["secret"])
+	assert.True(strings.HasPrefix(res["uri"], "otpauth://totp/redditclone:demo1?"), res["uri"])
+	assert.Contains(res["uri"], "secret="+u.TOTPSecret)
+}
+
+func (s *ApiTestSuite) TestTwoFactor_Confirm() {
+	assert := assert.New(s.T())
+	require := require.New(s.T())
+	s.setupSession()
+
+	u := &user.User{}
+	*u = *s.entities.user
+	u.TOTPSecret = totpSecret
+	s.repositoryMocks.user.On("Get", mock.Anything, u.ID).Return(u, error(nil))
+	isEnabled := func(u *user.User) bool {
+		return u.TOTPEnabled && len(u.RecoveryCodes) == 10
+	}
+	s.repositoryMocks.user.On("Update", mock.Anything, mock.MatchedBy(isEnabled)).Return(error(nil))
+
+	resp, resBody := s.postJSON("/api/me/2fa/confirm", s.token, `{"code": "`+s.currentCode(totpSecret)+`"}`)
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+
+	var res map[string][]string
+	require.NoError(json.Unmarshal(resBody, &res))
+	require.Len(res["recoveryCodes"], 10)
+	//	only the hashes are stored
+	for _, code := range res["recoveryCodes"] {
+		assert.NotContains(u.RecoveryCodes, code)
+	}
+}
+
+func (s *ApiTestSuite) TestTwoFactor_Disable() {
+	require := require.New(s.T())
+	s.setupSession()
+	u := s.twoFactorUser()
+
+	s.setupThrottle()
+	s.repositoryMocks.user.On("Get", mock.Anything, u.ID).Return(u, error(nil))
+	s.repositoryMocks.user.On("Update", mock.Anything, mock.Anything).Return(error(nil))
+
+	resp, resBody := s.postJSON("/api/me/2fa/disable", s.token, `{"password": "demo1", "code": "`+s.currentCode(totpSecret)+`"}`)
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.False(u.TOTPEnabled)
+	require.Empty(u.TOTPSecret)
+	require.Empty(u.RecoveryCodes)
+}
+
+func (s *ApiTestSuite) TestTwoFactor_DisableByWrongPassword() {
+	assert := assert.New(s.T())
+	s.setupSession()
+	u := s.twoFactorUser()
+
+	s.setupThrottle()
+	s.repositoryMocks.user.On("Get", mock.Anything, u.ID).Return(u, error(nil))
+
+	resp, _ := s.postJSON("/api/me/2fa/disable", s.token, `{"password": "wrong", "code": "`+s.currentCode(totpSecret)+`"}`)
+	assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+	assert.True(u.TOTPEnabled)
+	s.repositoryMocks.user.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"redditclone/internal/domain/post"
+	"redditclone/internal/domain/user"
+	"redditclone/internal/pkg/apperror"
+)
+
+func (s *ApiTestSuite) TestUser_About() {
+	var result map[string]interface{}
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+
+	u := &user.User{}
+	*u = *s.entities.user
+	u.Bio = "Just a programmer"
+	searchedUser := &user.User{
+		Name: u.Name,
+	}
+	s.repositoryMocks.user.On("First", mock.Anything, searchedUser).Return(u, error(nil))
+	s.repositoryMocks.post.On("AuthorStats", mock.Anything, u.ID).Return(uint(2), 15, error(nil))
+	s.repositoryMocks.comment.On("AuthorStats", mock.Anything, u.ID).Return(uint(3), -1, error(nil))
+
+	uri := "/api/u/" + u.Name + "/about"
+	resp, err := s.client.Get(s.server.URL + uri)
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+	resBody, err := ioutil.ReadAll(resp.Body)
+	require.NoErrorf(err, "read body error: %v", err)
+
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	assert.Equal(u.Name, result["username"])
+	assert.Equal(u.Bio, result["bio"])
+	assert.Equal(float64(2), result["postCount"])
+	assert.Equal(float64(15), result["postKarma"])
+	assert.Equal(float64(3), result["commentCount"])
+	assert.Equal(float64(-1), result["commentKarma"])
+	_, ok := result["role"]
+	assert.Falsef(ok, "result %v contains the private data", result)
+}
+
+func (s *ApiTestSuite) TestUser_AboutUnknown() {
+	require := require.New(s.T())
+
+	searchedUser := &user.User{
+		Name: "nobody",
+	}
+	s.repositoryMocks.user.On("First", mock.Anything, searchedUser).Return(nil, apperror.ErrNotFound)
+
+	resp, err := s.client.Get(s.server.URL + "/api/u/nobody/about")
+	require.NoErrorf(err, "request error: %v", err)
+	defer resp.Body.Close()
+
+	require.Equal(http.StatusNotFound, resp.StatusCode)
+	s.repositoryMocks.post.AssertNotCalled(s.T(), "AuthorStats", mock.Anything, mock.Anything)
+}
+
+func (s *ApiTestSuite) TestUser_NonASCIIName() {
+	var result map[string]interface{}
+	require := require.New(s.T())
+	s.setupSession()
+
+	u := &user.User{ID: 2, Name: "Алиса2"}
+	s.repositoryMocks.user.On("First", mock.Anything, &user.User{Name: u.Name}).Return(u, error(nil))
+	s.repositoryMocks.post.On("AuthorStats", mock.Anything, u.ID).Return(uint(0), 0, error(nil))
+	s.repositoryMocks.comment.On("AuthorStats", mock.Anything, u.ID).Return(uint(0), 0, error(nil))
+	s.repositoryMocks.post.On("MarkedIDs", mock.Anything, s.entities.user.ID, post.MarkHide).Return([]string{}, error(nil))
+	s.repositoryMocks.post.On("Query", mock.Anything, mock.Anything).Return([]post.Post{}, error(nil))
+	s.repositoryMocks.follow.On("Follow", mock.Anything, mock.Anything).Return(error(nil))
+	s.repositoryMocks.follow.On("Unfollow", mock.Anything, s.entities.user.ID, u.ID).Return(error(nil))
+	s.repositoryMocks.timeline.On("Delete", mock.Anything, s.entities.user.ID).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodGet, "/api/u/"+url.PathEscape(u.Name)+"/about", "", "")
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	s.Equal(u.Name, result["username"])
+
+	for _, req := range []struct{ method, uri string }{
+		{http.MethodGet, "/api/user/" + url.PathEscape(u.Name)},
+		{http.MethodPost, "/api/u/" + url.PathEscape(u.Name) + "/follow"},
+		{http.MethodPost, "/api/u/" + url.PathEscape(u.Name) + "/unfollow"},
+	} {
+		resp, resBody := s.sendJSON(req.method, req.uri, s.token, "")
+		s.Equalf(http.StatusOK, resp.StatusCode, "%v %v: %v", req.method, req.uri, string(resBody))
+	}
+}
+
+func (s *ApiTestSuite) TestUser_Patch() {
+	var result map[string]interface{}
+	require := require.New(s.T())
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	u := &user.User{}
+	*u = *s.entities.user
+	u.Bio = "Just a programmer"
+	u.Prefs.NightMode = true
+	s.repositoryMocks.user.On("Get", mock.Anything, u.ID).Return(u, error(nil))
+	isPatched := func(patched *user.User) bool {
+		return patched.Bio == u.Bio && patched.Prefs.NightMode && patched.Prefs.DefaultSort == "new" &&
+			patched.AvatarURL == "https://example.com/avatar.png"
+	}
+	s.repositoryMocks.user.On("Update", mock.Anything, mock.MatchedBy(isPatched)).Return(error(nil))
+
+	resp, resBody := s.sendJSON(http.MethodPatch, "/api/me", s.token, `{"avatarUrl": "https://example.com/avatar.png", "prefs": {"defaultSort": "new"}}`)
+	require.Equal(http.StatusOK, resp.StatusCode, string(resBody))
+	require.NoError(json.Unmarshal(resBody, &result))
+	assert.Equal(map[string]interface{}{"nightMode": true, "defaultSort": "new", "hideScores": false}, result["prefs"])
+	assert.Equal(u.Role, result["role"])
+	s.repositoryMocks.user.AssertNumberOfCalls(s.T(), "Update", 1)
+}
+
+func (s *ApiTestSuite) TestUser_PatchInvalid() {
+	assert := assert.New(s.T())
+	s.setupSession()
+
+	s.repositoryMocks.user.On("Get", mock.Anything, s.entities.user.ID).Return(s.entities.user, error(nil))
+
+	resp, _ := s.sendJSON(http.MethodPatch, "/api/me", s.token, `{"prefs": {"defaultSort": "best"}}`)
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = s.sendJSON(http.MethodPatch, "/api/me", s.token, `{"avatarUrl": "not a url"}`)
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+	s.repositoryMocks.user.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything)
+}